@@ -0,0 +1,156 @@
+package env
+
+import (
+	"errors"
+	// The package is renamed, as flag is the type of a feature flag within flags.go.
+	goflag "flag"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FlagBinding holds the values of the command-line flags registered by BindFlags.
+//
+// It is given to ParseWithOpts, or WatchWithOpts, as Options.BoundFlags to apply the flags that were set.
+type FlagBinding struct {
+	mu sync.Mutex
+	// values are the values of the flags that were set, by key without Options.Prefix.
+	values map[string]string
+}
+
+// flagValue is the flag.Value of a field bound by BindFlags.
+type flagValue struct {
+	binding *FlagBinding
+	// key is the variable of the field, without Options.Prefix.
+	key string
+	// def is the value shown as the default within the usage message.
+	def string
+	// isBool allows the flag to be set without a value, such as -debug.
+	isBool bool
+}
+
+// BindFlags registers a command-line flag for each variable of a struct, which takes priority over the environment.
+//
+// Each flag is named after its variable in kebab-case, such as -db-host for DB_HOST. Once the flag set has been parsed,
+// ParseWithOpts given the binding as Options.BoundFlags reads the flags that were set before Overlay, Env and
+// `envDefault`.
+// The flags hold text, so they are parsed and validated as the environment variables are.
+//
+// Parameters:
+//
+//   - fs: The flag set to register the flags with, such as flag.CommandLine.
+//   - v: A pointer to a struct containing `env` tags, whose values are shown as the defaults within the usage message.
+//
+// Returns: The FlagBinding to give to ParseWithOpts, or an error if v is not a pointer to a struct, or a value cannot
+// be formatted for the usage message.
+//
+// Example:
+//
+//	var cfg Config
+//	flags, err := env.BindFlags(flag.CommandLine, &cfg)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	flag.Parse()
+//
+//	// -port 9000 wins over PORT=8080, which wins over `envDefault:"80"`.
+//	err = env.ParseWithOpts(&cfg, env.Options{Env: env.ToMap(os.Environ()), BoundFlags: flags})
+//
+// Note: Flags are named without Options.Prefix, which is added when they are read. Slices and maps of structs,
+// whose variables depend on the environment, are not bound. As with flag.FlagSet.Var, a flag defined twice panics.
+func BindFlags(fs *goflag.FlagSet, v interface{}) (*FlagBinding, error) {
+	ref := reflect.ValueOf(v)
+	if ref.Kind() != reflect.Ptr || ref.IsNil() || ref.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("expected a pointer to a valid struct")
+	}
+
+	binding := &FlagBinding{values: make(map[string]string)}
+
+	err := envWalker{fn: func(field envField) error {
+		def, err := marshalField(field)
+		if err != nil {
+			return err
+		}
+		if field.Tags.Secret {
+			def = redact(def)
+		}
+
+		value := &flagValue{
+			binding: binding,
+			key:     field.Tags.Key,
+			def:     def,
+			isBool:  elemType(field.StructField.Type).Kind() == reflect.Bool,
+		}
+		fs.Var(value, flagName(field.Tags.Key), "sets "+field.Tags.Key)
+		return nil
+	}}.walk(ref.Elem(), Options{}, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return binding, nil
+}
+
+// flagName converts a variable to the name of its flag, such as "db-host" for DB_HOST.
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// overlay applies the flags that were set over an overlay.
+//
+// Parameters:
+//   - prefix: The Options.Prefix to add before each key.
+//   - overlay: The Options.Overlay, which is not modified.
+//
+// Returns: A copy of the overlay with the flags applied, or the overlay if b is nil or no flags were set.
+func (b *FlagBinding) overlay(prefix string, overlay map[string]string) map[string]string {
+	if b == nil {
+		return overlay
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.values) == 0 {
+		return overlay
+	}
+
+	merged := make(map[string]string, len(overlay)+len(b.values))
+	for key, val := range overlay {
+		merged[key] = val
+	}
+	for key, val := range b.values {
+		merged[prefix+key] = val
+	}
+	return merged
+}
+
+// String returns the value of the flag, or its default, for the usage message.
+func (f *flagValue) String() string {
+	// The flag package calls String on a zero flagValue to find whether the default is the zero value.
+	if f == nil || f.binding == nil {
+		return ""
+	}
+
+	f.binding.mu.Lock()
+	defer f.binding.mu.Unlock()
+
+	if val, ok := f.binding.values[f.key]; ok {
+		return val
+	}
+	return f.def
+}
+
+// Set records the value of the flag, it is parsed with the struct.
+func (f *flagValue) Set(val string) error {
+	f.binding.mu.Lock()
+	defer f.binding.mu.Unlock()
+
+	f.binding.values[f.key] = val
+	return nil
+}
+
+// IsBoolFlag allows a bool field to be set without a value, such as -debug.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,181 @@
+package env
+
+import (
+	"bytes"
+	goflag "flag"
+	"strings"
+	"testing"
+)
+
+type flagsDatabase struct {
+	Host     string `env:"HOST" envDefault:"localhost"`
+	Password string `env:"PASSWORD,secret" envDefault:"correct-horse-battery"`
+}
+
+type flagsConfig struct {
+	Port     int           `env:"PORT" envDefault:"80"`
+	Debug    bool          `env:"DEBUG"`
+	Verbose  *bool         `env:"VERBOSE"`
+	Database flagsDatabase `envPrefix:"DB"`
+	Workers  []struct {
+		Name string `env:"NAME"`
+	} `envPrefix:"WORKER"`
+}
+
+func TestBindFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		opts     Options
+		expected flagsConfig
+		verbose  bool
+	}{
+		{
+			name:     "Defaults",
+			opts:     Options{Env: map[string]string{}},
+			expected: flagsConfig{Port: 80, Database: flagsDatabase{Host: "localhost", Password: "correct-horse-battery"}},
+		},
+		{
+			name:     "Environment over defaults",
+			opts:     Options{Env: map[string]string{"PORT": "8080", "DB_HOST": "db"}},
+			expected: flagsConfig{Port: 8080, Database: flagsDatabase{Host: "db", Password: "correct-horse-battery"}},
+		},
+		{
+			name: "Flags over environment",
+			args: []string{"-port", "9000", "-debug", "-verbose", "-db-host=flag"},
+			opts: Options{Env: map[string]string{"PORT": "8080", "DEBUG": "false", "DB_HOST": "db"}, Overlay: map[string]string{"PORT": "8081"}},
+			expected: flagsConfig{
+				Port:     9000,
+				Debug:    true,
+				Database: flagsDatabase{Host: "flag", Password: "correct-horse-battery"},
+			},
+			verbose: true,
+		},
+		{
+			name:     "Prefix",
+			args:     []string{"-port", "9000"},
+			opts:     Options{Prefix: "APP", Env: map[string]string{"APP_PORT": "8080", "PORT": "1"}},
+			expected: flagsConfig{Port: 9000, Database: flagsDatabase{Host: "localhost", Password: "correct-horse-battery"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg flagsConfig
+			fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
+			binding, err := BindFlags(fs, &cfg)
+			if err != nil {
+				t.Fatalf("BindFlags() error = %v", err)
+			}
+			if err = fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			tt.opts.BoundFlags = binding
+			overlay := tt.opts.Overlay
+			if err := ParseWithOpts(&cfg, tt.opts); err != nil {
+				t.Fatalf("ParseWithOpts() error = %v", err)
+			}
+
+			if cfg.Port != tt.expected.Port || cfg.Debug != tt.expected.Debug || cfg.Database != tt.expected.Database {
+				t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, tt.expected)
+			}
+			if *cfg.Verbose != tt.verbose {
+				t.Errorf("ParseWithOpts() Verbose = %v, expected %v", *cfg.Verbose, tt.verbose)
+			}
+			if overlay != nil && overlay["PORT"] != "8081" {
+				t.Errorf("ParseWithOpts() modified Overlay = %v", overlay)
+			}
+		})
+	}
+}
+
+func TestBindFlags_Usage(t *testing.T) {
+	var cfg flagsConfig
+	fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
+	if _, err := BindFlags(fs, &cfg); err != nil {
+		t.Fatalf("BindFlags() error = %v", err)
+	}
+
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	fs.PrintDefaults()
+
+	for _, expected := range []string{"-db-host value", "sets DB_HOST (default localhost)", "(default ****tery)", "-debug\n"} {
+		if !strings.Contains(usage.String(), expected) {
+			t.Errorf("PrintDefaults() = %s, expected it to contain %q", usage.String(), expected)
+		}
+	}
+	if strings.Contains(usage.String(), "worker") {
+		t.Errorf("PrintDefaults() = %s, expected slices of structs not to be bound", usage.String())
+	}
+
+	if err := fs.Parse([]string{"-port", "9000"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if port := fs.Lookup("port").Value.String(); port != "9000" {
+		t.Errorf("Lookup(port) = %s, expected 9000", port)
+	}
+}
+
+func TestBindFlags_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr string
+	}{
+		{name: "Not a pointer", v: flagsConfig{}, wantErr: "expected a pointer to a valid struct"},
+		{name: "Nil pointer", v: (*flagsConfig)(nil), wantErr: "expected a pointer to a valid struct"},
+		{
+			name: "Unsupported type",
+			v: &struct {
+				Ch chan int `env:"CH"`
+			}{Ch: make(chan int)},
+			wantErr: "unable to format Ch: unsupported type: chan int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BindFlags(goflag.NewFlagSet("test", goflag.ContinueOnError), tt.v)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("BindFlags() error = %v, expected %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBindFlags_Binding(t *testing.T) {
+	var cfg flagsConfig
+	fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
+	binding, err := BindFlags(fs, &cfg)
+	if err != nil {
+		t.Fatalf("BindFlags() error = %v", err)
+	}
+	if err = fs.Parse([]string{"-port", "9000"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// The flags are only applied through the binding, not to every parse of the same pointer.
+	if err = ParseWithOpts(&cfg, Options{Env: map[string]string{"PORT": "8080"}}); err != nil || cfg.Port != 8080 {
+		t.Errorf("ParseWithOpts() Port = %d, error = %v, expected 8080 without BoundFlags", cfg.Port, err)
+	}
+
+	// The binding applies to any struct, such as the new struct of each reload by WatchWithOpts.
+	var other flagsConfig
+	if err = ParseWithOpts(&other, Options{Env: map[string]string{"PORT": "8080"}, BoundFlags: binding}); err != nil || other.Port != 9000 {
+		t.Errorf("ParseWithOpts() Port = %d, error = %v, expected 9000 from the flag", other.Port, err)
+	}
+}
+
+func BenchmarkParseWithOpts_BoundFlags(b *testing.B) {
+	var cfg flagsConfig
+	fs := goflag.NewFlagSet("bench", goflag.ContinueOnError)
+	binding, _ := BindFlags(fs, &cfg)
+	_ = fs.Parse([]string{"-port", "9000", "-db-host", "flag"})
+	opts := Options{Env: map[string]string{"PORT": "8080"}, BoundFlags: binding}
+
+	for i := 0; i < b.N; i++ {
+		_ = ParseWithOpts(&cfg, opts)
+	}
+}
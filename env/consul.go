@@ -0,0 +1,154 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConsulWaitTime is how long each blocking query of ConsulSource.Watch waits for a change.
+const DefaultConsulWaitTime = 5 * time.Minute
+
+// ConsulSource is the Source of the keys beneath a prefix within the Consul KV store, using its HTTP API.
+//
+// Keys are converted into environment variables by removing the prefix and converting each segment to
+// SCREAMING_SNAKE_CASE, so "config/app/database/host" with the prefix "config/app/" is read as DATABASE_HOST.
+//
+// Example:
+//
+//	consul := env.ConsulSource{Address: "http://127.0.0.1:8500", Prefix: "config/app/"}
+//	err := env.ParseWithOpts(&cfg, env.Options{Sources: []env.Source{consul, env.OsEnvSource{}}})
+type ConsulSource struct {
+	// Address is the address of the Consul agent, such as "http://127.0.0.1:8500".
+	Address string
+
+	// Prefix is the prefix of the keys, such as "config/app/".
+	Prefix string
+
+	// Token is the ACL token, sent as the X-Consul-Token header. Optional.
+	Token string
+
+	// WaitTime is how long each blocking query of Watch waits for a change. Defaults to DefaultConsulWaitTime.
+	WaitTime time.Duration
+
+	// Client is the client used for requests, nil for http.DefaultClient.
+	//
+	// Its timeout must be longer than WaitTime, as Watch holds each request open until a change or WaitTime passes.
+	Client *http.Client
+}
+
+// consulKV is a key within the response of the Consul KV API.
+type consulKV struct {
+	Key string `json:"Key"`
+	// Value is base64 within the JSON, decoded by encoding/json. It is null for folders.
+	Value []byte `json:"Value"`
+}
+
+// Load reads the keys beneath the prefix.
+//
+// Returns: The environment variables, or an error if Consul could not be reached or returned an error.
+func (s ConsulSource) Load(ctx context.Context) (map[string]string, error) {
+	vars, _, err := s.fetch(ctx, 0)
+	return vars, err
+}
+
+// Watch calls onChange each time the keys beneath the prefix change, using blocking queries.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - onChange: Called with the environment variables after each change.
+//
+// Returns: The error of the context once it is done, or an error if Consul could not be reached or
+// onChange returns an error.
+func (s ConsulSource) Watch(ctx context.Context, onChange func(vars map[string]string) error) error {
+	vars, index, err := s.fetch(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	for {
+		latest, next, err := s.fetch(ctx, max(index, 1))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			return err
+		}
+
+		// Consul documents that the index should be reset if it goes backwards, such as after a restore.
+		// It is then kept at least 1 above, so the next query blocks rather than returning immediately.
+		if next < index {
+			next = 0
+		}
+		index = next
+
+		// The index changes for any write, including writes that do not change the values.
+		if maps.Equal(vars, latest) {
+			continue
+		}
+
+		if err = onChange(latest); err != nil {
+			return err
+		}
+		vars = latest
+	}
+}
+
+// fetch reads the keys beneath the prefix.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - index: The index of a blocking query, which waits until the keys change after it. 0 returns immediately.
+//
+// Returns: The environment variables, the index of the response, or an error if the request failed.
+func (s ConsulSource) fetch(ctx context.Context, index uint64) (map[string]string, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		wait := s.WaitTime
+		if wait <= 0 {
+			wait = DefaultConsulWaitTime
+		}
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", strconv.FormatInt(wait.Milliseconds(), 10)+"ms")
+	}
+
+	endpoint := strings.TrimSuffix(s.Address, "/") + "/v1/kv/" + (&url.URL{Path: s.Prefix}).EscapedPath() + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	resp, err := doKVRequest(s.Client, req, "consul")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	vars := make(map[string]string)
+	if resp.StatusCode == http.StatusNotFound {
+		return vars, next, nil
+	}
+
+	var kvs []consulKV
+	if err = json.NewDecoder(resp.Body).Decode(&kvs); err != nil {
+		return nil, 0, fmt.Errorf("consul: %w", err)
+	}
+
+	for _, kv := range kvs {
+		if key := kvEnvKey(s.Prefix, kv.Key); key != "" {
+			vars[key] = string(kv.Value)
+		}
+	}
+
+	return vars, next, nil
+}
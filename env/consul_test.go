@@ -0,0 +1,271 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul is a Consul KV store, supporting recursive reads and blocking queries.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	kvs     map[string]string
+	changed chan struct{}
+	status  int
+	// blocked receives when a blocking query starts waiting, so a test can change a key after Watch has started.
+	blocked chan struct{}
+}
+
+func newFakeConsul(kvs map[string]string) *fakeConsul {
+	return &fakeConsul{index: 10, kvs: kvs, changed: make(chan struct{}), blocked: make(chan struct{}, 1)}
+}
+
+// set writes a key, waking any blocking queries.
+func (f *fakeConsul) set(key, val string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.kvs[key] = val
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "secret" {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	if index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); index != 0 && index >= f.index {
+		changed := f.changed
+		f.mu.Unlock()
+
+		select {
+		case f.blocked <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+
+	if f.status != 0 {
+		http.Error(w, "internal error", f.status)
+		return
+	}
+
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	var kvs []consulKV
+	for key, val := range f.kvs {
+		if strings.HasPrefix(key, prefix) {
+			kv := consulKV{Key: key}
+			if !strings.HasSuffix(key, "/") {
+				kv.Value = []byte(val)
+			}
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(kvs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(kvs)
+}
+
+func TestConsulSource_Load(t *testing.T) {
+	fake := newFakeConsul(map[string]string{
+		"config/app/":                  "",
+		"config/app/database/":         "",
+		"config/app/database/host":     "db.internal",
+		"config/app/database/maxConns": "10",
+		"config/app/PORT":              "8080",
+		"config/other/PORT":            "9090",
+	})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		source   ConsulSource
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "Prefix",
+			source:   ConsulSource{Address: server.URL + "/", Prefix: "config/app/", Token: "secret"},
+			expected: map[string]string{"DATABASE_HOST": "db.internal", "DATABASE_MAX_CONNS": "10", "PORT": "8080"},
+		},
+		{
+			name:     "No keys",
+			source:   ConsulSource{Address: server.URL, Prefix: "missing/", Token: "secret"},
+			expected: map[string]string{},
+		},
+		{
+			name:    "Forbidden",
+			source:  ConsulSource{Address: server.URL, Prefix: "config/app/"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid address",
+			source:  ConsulSource{Address: "http://\x7f", Token: "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "Unreachable",
+			source:  ConsulSource{Address: "http://127.0.0.1:0", Token: "secret"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Load(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.expected) {
+				t.Errorf("Load() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{"))
+		}))
+		defer server.Close()
+
+		if _, err := (ConsulSource{Address: server.URL}).Load(context.Background()); err == nil {
+			t.Error("Load() expected an error")
+		}
+	})
+}
+
+func TestConsulSource_Watch(t *testing.T) {
+	t.Run("Change", func(t *testing.T) {
+		fake := newFakeConsul(map[string]string{"config/app/PORT": "8080"})
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan map[string]string, 1)
+		done := make(chan error, 1)
+		source := ConsulSource{Address: server.URL, Prefix: "config/app/", Token: "secret", WaitTime: time.Minute}
+		go func() {
+			done <- source.Watch(ctx, func(vars map[string]string) error {
+				changes <- vars
+				return nil
+			})
+		}()
+
+		// The same value does not call onChange, even though the index changes.
+		waitFor(t, fake.blocked)
+		fake.set("config/app/PORT", "8080")
+		waitFor(t, fake.blocked)
+		fake.set("config/app/PORT", "9090")
+
+		if got := waitFor(t, changes); got["PORT"] != "9090" {
+			t.Errorf("Watch() = %v, expected PORT 9090", got)
+		}
+
+		cancel()
+		if err := waitFor(t, done); !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch() error = %v, expected %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("Index goes backwards", func(t *testing.T) {
+		fake := newFakeConsul(map[string]string{"config/app/PORT": "8080"})
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		changes := make(chan map[string]string, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- ConsulSource{Address: server.URL, Prefix: "config/app/", Token: "secret"}.Watch(context.Background(), func(vars map[string]string) error {
+				changes <- vars
+				return errors.New("stop")
+			})
+		}()
+
+		// A restore resets the index below the one Watch is waiting on.
+		waitFor(t, fake.blocked)
+		fake.mu.Lock()
+		fake.index = 1
+		fake.kvs["config/app/PORT"] = "9090"
+		close(fake.changed)
+		fake.changed = make(chan struct{})
+		fake.mu.Unlock()
+
+		if got := waitFor(t, changes); got["PORT"] != "9090" {
+			t.Errorf("Watch() = %v, expected PORT 9090", got)
+		}
+		if err := waitFor(t, done); err == nil || err.Error() != "stop" {
+			t.Errorf("Watch() error = %v, expected the error of onChange", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		fake := newFakeConsul(map[string]string{"config/app/PORT": "8080"})
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		onChange := func(vars map[string]string) error { return nil }
+
+		if err := (ConsulSource{Address: server.URL}).Watch(context.Background(), onChange); err == nil {
+			t.Error("Watch() expected an error for the first load")
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- ConsulSource{Address: server.URL, Prefix: "config/app/", Token: "secret"}.Watch(context.Background(), onChange)
+		}()
+
+		waitFor(t, fake.blocked)
+		fake.mu.Lock()
+		fake.status = http.StatusInternalServerError
+		fake.mu.Unlock()
+		fake.set("config/app/PORT", "9090")
+
+		if err := waitFor(t, done); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("Watch() error = %v, expected the status", err)
+		}
+	})
+}
+
+func BenchmarkConsulSource_Load(b *testing.B) {
+	server := httptest.NewServer(newFakeConsul(map[string]string{
+		"config/app/database/host": "db.internal",
+		"config/app/PORT":          "8080",
+	}))
+	defer server.Close()
+
+	source := ConsulSource{Address: server.URL, Prefix: "config/app/", Token: "secret"}
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = source.Load(ctx)
+	}
+}
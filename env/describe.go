@@ -0,0 +1,124 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// VarDoc describes an environment variable read by a struct.
+type VarDoc struct {
+	// Key is the environment variable, such as "DB_HOST". Slices of structs use "{n}" for the index.
+	Key string `json:"key"`
+	// Field is the path to the struct field, such as "Database.Host".
+	Field string `json:"field"`
+	// Type is the Go type of the field, such as "time.Duration" or "[]string".
+	Type string `json:"type"`
+	// Default is the `envDefault` of the field, if any.
+	Default string `json:"default,omitempty"`
+	// Required is true if the field has the `required` option.
+	Required bool `json:"required"`
+	// Secret is true if the field has the `secret` option.
+	Secret bool `json:"secret"`
+	// Validate is the `envValidate` of the field, if any, such as "min=1,max=65535".
+	Validate string `json:"validate,omitempty"`
+	// Deprecated is the `envDeprecated` message of the field, if any, such as "use DATABASE_HOST instead".
+	Deprecated string `json:"deprecated,omitempty"`
+	// Aliases are the `envAlias` keys of the field, if any, prefixed as Key is.
+	Aliases []string `json:"aliases,omitempty"`
+	// Prefixes are the prefixes the key is built from, outermost first, such as ["DB_"] or ["WORKER_", "{n}_"].
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Describe lists the environment variables read by a struct containing `env` tags, in field order.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The description of each variable, or an error if v is not a struct.
+//
+// Example:
+//
+//	docs, err := env.Describe(Config{})
+//	fmt.Println(env.Markdown(docs))
+func Describe(v interface{}) ([]VarDoc, error) {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return nil, errors.New("expected a struct or a pointer to a valid struct")
+	}
+
+	var docs []VarDoc
+	err := envWalker{describe: true, fn: func(field envField) error {
+		var aliases []string
+		for _, alias := range field.Tags.Aliases {
+			aliases = append(aliases, strings.TrimSuffix(field.Tags.Key, field.Tags.OwnKey)+alias)
+		}
+
+		docs = append(docs, VarDoc{
+			Key:        field.Tags.Key,
+			Field:      field.Path,
+			Type:       field.StructField.Type.String(),
+			Default:    field.Tags.Default,
+			Required:   field.Tags.Required,
+			Secret:     field.Tags.Secret,
+			Validate:   field.Tags.Validate,
+			Deprecated: field.Tags.Deprecated,
+			Aliases:    aliases,
+			Prefixes:   field.Prefixes,
+		})
+		return nil
+	}}.walk(ref, Options{}, "", "", nil)
+
+	return docs, err
+}
+
+// Markdown renders the descriptions as a Markdown table, for documenting a service's environment variables.
+//
+// Parameters:
+//
+//   - docs: The descriptions from Describe.
+//
+// Returns: A Markdown table with a row for each variable.
+//
+// Example output:
+//
+//	| Variable | Type | Default | Required | Secret |
+//	| --- | --- | --- | --- | --- |
+//	| `PORT` | `int` | `8080` | No | No |
+func Markdown(docs []VarDoc) string {
+	var builder strings.Builder
+
+	builder.WriteString("| Variable | Type | Default | Required | Secret |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- |\n")
+
+	for _, doc := range docs {
+		builder.WriteString("| " + markdownCode(doc.Key))
+		builder.WriteString(" | " + markdownCode(doc.Type))
+		builder.WriteString(" | " + markdownCode(doc.Default))
+		builder.WriteString(" | " + yesNo(doc.Required))
+		builder.WriteString(" | " + yesNo(doc.Secret) + " |\n")
+	}
+
+	return builder.String()
+}
+
+// markdownCode formats a value as inline code, escaping pipes so they do not end the table cell.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+// yesNo formats a bool for a Markdown table.
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
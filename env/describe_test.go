@@ -0,0 +1,156 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	type Database struct {
+		Host     string `env:"HOST" envDefault:"localhost" envDeprecated:"use DB_HOSTNAME instead"`
+		Password string `env:"PASSWORD,required,secret" envAlias:"PASS"`
+	}
+	type Worker struct {
+		Name string `env:"NAME"`
+	}
+	type Config struct {
+		Port     int           `env:"PORT" envDefault:"8080" envValidate:"min=1"`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Hosts    []string      `env:"HOSTS"`
+		Database *Database     `envPrefix:"DB"`
+		Workers  []Worker      `envPrefix:"WORKER"`
+		Ignored  string        `env:"-"`
+	}
+
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected []VarDoc
+		wantErr  bool
+	}{
+		{
+			name: "Struct",
+			v:    Config{},
+			expected: []VarDoc{
+				{Key: "PORT", Field: "Port", Type: "int", Default: "8080", Validate: "min=1"},
+				{Key: "TIMEOUT", Field: "Timeout", Type: "time.Duration"},
+				{Key: "HOSTS", Field: "Hosts", Type: "[]string"},
+				{Key: "DB_HOST", Field: "Database.Host", Type: "string", Default: "localhost", Deprecated: "use DB_HOSTNAME instead", Prefixes: []string{"DB_"}},
+				{Key: "DB_PASSWORD", Field: "Database.Password", Type: "string", Required: true, Secret: true, Aliases: []string{"DB_PASS"}, Prefixes: []string{"DB_"}},
+				{Key: "WORKER_{n}_NAME", Field: "Workers[n].Name", Type: "string", Prefixes: []string{"WORKER_", "{n}_"}},
+			},
+		},
+		{
+			name: "Slice with values",
+			v: &struct {
+				Workers []Worker `envPrefix:"WORKER"`
+			}{Workers: []Worker{{}, {}}},
+			expected: []VarDoc{
+				{Key: "WORKER_0_NAME", Field: "Workers[0].Name", Type: "string", Prefixes: []string{"WORKER_", "0_"}},
+				{Key: "WORKER_1_NAME", Field: "Workers[1].Name", Type: "string", Prefixes: []string{"WORKER_", "1_"}},
+			},
+		},
+		{
+			name: "Nil slice pointer",
+			v: struct {
+				Workers *[]Worker `envPrefix:"WORKER"`
+			}{},
+			expected: []VarDoc{
+				{Key: "WORKER_{n}_NAME", Field: "Workers[n].Name", Type: "string", Prefixes: []string{"WORKER_", "{n}_"}},
+			},
+		},
+		{
+			name: "Slice of pointers",
+			v: struct {
+				Workers []*Worker `envPrefix:"WORKER"`
+			}{Workers: []*Worker{nil, {}}},
+			expected: []VarDoc{
+				{Key: "WORKER_1_NAME", Field: "Workers[1].Name", Type: "string", Prefixes: []string{"WORKER_", "1_"}},
+			},
+		},
+		{
+			name: "Empty slice of pointers",
+			v: struct {
+				Workers []*Worker `envPrefix:"WORKER"`
+			}{},
+			expected: []VarDoc{
+				{Key: "WORKER_{n}_NAME", Field: "Workers[n].Name", Type: "string", Prefixes: []string{"WORKER_", "{n}_"}},
+			},
+		},
+		{
+			name: "Map of structs",
+			v: struct {
+				Workers map[string]*Worker `envPrefix:"WORKER"`
+			}{},
+			expected: []VarDoc{
+				{Key: "WORKER_{key}_NAME", Field: "Workers[key].Name", Type: "string", Prefixes: []string{"WORKER_", "{key}_"}},
+			},
+		},
+		{
+			name: "Map with values",
+			v: struct {
+				Workers *map[string]*Worker `envPrefix:"WORKER"`
+			}{Workers: &map[string]*Worker{"B": {}, "A": {}, "NIL": nil}},
+			expected: []VarDoc{
+				{Key: "WORKER_A_NAME", Field: "Workers[A].Name", Type: "string", Prefixes: []string{"WORKER_", "A_"}},
+				{Key: "WORKER_B_NAME", Field: "Workers[B].Name", Type: "string", Prefixes: []string{"WORKER_", "B_"}},
+			},
+		},
+		{
+			name: "Nested struct without a prefix",
+			v: struct {
+				Worker Worker `envPrefix:""`
+			}{},
+			expected: []VarDoc{
+				{Key: "NAME", Field: "Worker.Name", Type: "string"},
+			},
+		},
+		{
+			name:    "Not a struct",
+			v:       1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Describe(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Describe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Describe() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	docs := []VarDoc{
+		{Key: "PORT", Type: "int", Default: "8080"},
+		{Key: "DB_PASSWORD", Type: "string", Required: true, Secret: true},
+		{Key: "PATTERN", Type: "string", Default: "a|b"},
+	}
+
+	expected := "| Variable | Type | Default | Required | Secret |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `PORT` | `int` | `8080` | No | No |\n" +
+		"| `DB_PASSWORD` | `string` |  | Yes | Yes |\n" +
+		"| `PATTERN` | `string` | `a\\|b` | No | No |\n"
+
+	if got := Markdown(docs); got != expected {
+		t.Errorf("Markdown() = %q, expected %q", got, expected)
+	}
+}
+
+func BenchmarkDescribe(b *testing.B) {
+	type Config struct {
+		Port int    `env:"PORT" envDefault:"8080"`
+		Host string `env:"HOST"`
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = Describe(Config{})
+	}
+}
@@ -0,0 +1,121 @@
+package env
+
+import (
+	"reflect"
+)
+
+// FieldChange is an environment variable whose value differs between two structs, as reported by Diff.
+type FieldChange struct {
+	// Key is the environment variable, such as "DB_HOST".
+	Key string `json:"key"`
+	// Field is the path to the struct field, such as "Database.Host".
+	Field string `json:"field"`
+	// Old is the value within the old struct, formatted as by Marshal. Empty if the variable was added.
+	Old string `json:"old"`
+	// New is the value within the new struct, formatted as by Marshal. Empty if the variable was removed.
+	New string `json:"new"`
+	// Secret is true if the field has the `secret` option, in which case Old and New are masked as by Redacted.
+	Secret bool `json:"secret"`
+}
+
+// Diff compares two structs containing `env` tags, such as the configuration before and after a reload.
+//
+// Each field is formatted as by Marshal, so a field set to its `envDefault` matches a zero field with that default.
+// A slice or map of structs that grows or shrinks reports the variables of its elements as added or removed.
+//
+// Parameters:
+//
+//   - old: A struct, or a pointer to a struct, containing `env` tags.
+//   - new: A struct of the same type, or a pointer to one.
+//
+// Returns: The changed variables in the field order of new, followed by those only within old.
+// It is empty when nothing changed, or when neither is a struct.
+// Fields whose values cannot be formatted, which Marshal would return an error for, are left out.
+//
+// Example:
+//
+//	err := env.Watch(ctx, &cfg, []string{".env"}, func(old, new interface{}) error {
+//		for _, change := range env.Diff(old, new) {
+//			log.Printf("%s changed from %q to %q", change.Key, change.Old, change.New)
+//		}
+//		return nil
+//	})
+//
+// Note: Secrets are compared before they are masked, so a rotated secret is reported even if both look the same.
+func Diff(old, new interface{}) []FieldChange {
+	oldFields := diffFields(old)
+	newFields := diffFields(new)
+
+	oldIndex := make(map[string]int, len(oldFields))
+	for i, field := range oldFields {
+		oldIndex[field.key] = i
+	}
+
+	var changes []FieldChange
+	for _, field := range newFields {
+		change := FieldChange{Key: field.key, Field: field.path, New: field.value, Secret: field.secret}
+
+		if i, ok := oldIndex[field.key]; ok {
+			before := oldFields[i]
+			// Each key is matched once, so those left are only within old.
+			delete(oldIndex, field.key)
+			if before.value == field.value {
+				continue
+			}
+			change.Old = before.value
+			change.Secret = change.Secret || before.secret
+		}
+		changes = append(changes, change)
+	}
+
+	for _, field := range oldFields {
+		if _, ok := oldIndex[field.key]; ok {
+			changes = append(changes, FieldChange{Key: field.key, Field: field.path, Old: field.value, Secret: field.secret})
+		}
+	}
+
+	for i := range changes {
+		if changes[i].Secret {
+			changes[i].Old = redact(changes[i].Old)
+			changes[i].New = redact(changes[i].New)
+		}
+	}
+
+	return changes
+}
+
+// diffField is the formatted value of a field, as compared by Diff.
+type diffField struct {
+	key, path, value string
+	secret           bool
+}
+
+// diffFields formats each field of a struct for Diff.
+//
+// Parameters:
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The fields in field order, or nil if v is not a struct.
+func diffFields(v interface{}) []diffField {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []diffField
+	_ = envWalker{fn: func(field envField) error {
+		val, err := marshalField(field)
+		if err != nil {
+			return nil
+		}
+
+		fields = append(fields, diffField{key: field.Tags.Key, path: field.Path, value: val, secret: field.Tags.Secret})
+		return nil
+	}}.walk(ref, Options{}, "", "", nil)
+
+	return fields
+}
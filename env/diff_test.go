@@ -0,0 +1,115 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	type Worker struct {
+		Name string `env:"NAME"`
+	}
+	type Database struct {
+		Host     string `env:"HOST"`
+		Password string `env:"PASSWORD,secret"`
+	}
+	type Config struct {
+		Port     int           `env:"PORT" envDefault:"8080"`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Database *Database     `envPrefix:"DB"`
+		Workers  []Worker      `envPrefix:"WORKER"`
+		Handler  chan int      `env:"HANDLER"`
+	}
+
+	base := Config{
+		Port:     8080,
+		Timeout:  time.Second,
+		Database: &Database{Host: "db", Password: "old-password-1234"},
+		Workers:  []Worker{{Name: "a"}, {Name: "b"}},
+	}
+
+	tests := []struct {
+		name     string
+		old      interface{}
+		new      interface{}
+		expected []FieldChange
+	}{
+		{name: "Unchanged", old: base, new: &base},
+		{name: "Default matches the zero value", old: base, new: Config{Timeout: time.Second, Database: base.Database, Workers: base.Workers}},
+		{
+			name: "Changed values",
+			old:  base,
+			new:  Config{Port: 9090, Timeout: time.Minute, Database: &Database{Host: "replica", Password: "new-password-5678"}, Workers: base.Workers},
+			expected: []FieldChange{
+				{Key: "PORT", Field: "Port", Old: "8080", New: "9090"},
+				{Key: "TIMEOUT", Field: "Timeout", Old: "1s", New: "1m0s"},
+				{Key: "DB_HOST", Field: "Database.Host", Old: "db", New: "replica"},
+				{Key: "DB_PASSWORD", Field: "Database.Password", Old: "****1234", New: "****5678", Secret: true},
+			},
+		},
+		{
+			name: "Short secret",
+			old:  Config{Database: &Database{Password: "a"}},
+			new:  Config{Database: &Database{Password: "b"}},
+			expected: []FieldChange{
+				{Key: "DB_PASSWORD", Field: "Database.Password", Old: "****", New: "****", Secret: true},
+			},
+		},
+		{
+			name: "Added and removed elements",
+			old:  Config{Workers: []Worker{{Name: "a"}, {Name: "b"}}},
+			new:  Config{Workers: []Worker{{Name: "c"}}},
+			expected: []FieldChange{
+				{Key: "WORKER_0_NAME", Field: "Workers[0].Name", Old: "a", New: "c"},
+				{Key: "WORKER_1_NAME", Field: "Workers[1].Name", Old: "b"},
+			},
+		},
+		{
+			name: "Added elements",
+			old:  Config{},
+			new:  Config{Workers: []Worker{{Name: "a"}}},
+			expected: []FieldChange{
+				{Key: "WORKER_0_NAME", Field: "Workers[0].Name", New: "a"},
+			},
+		},
+		{
+			name: "Not a struct",
+			old:  "old",
+			new:  Config{Port: 8080},
+			expected: []FieldChange{
+				{Key: "PORT", Field: "Port", New: "8080"},
+				{Key: "TIMEOUT", Field: "Timeout", New: "0s"},
+				{Key: "DB_HOST", Field: "Database.Host"},
+				{Key: "DB_PASSWORD", Field: "Database.Password", Secret: true},
+			},
+		},
+		{name: "Neither is a struct", old: nil, new: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Diff() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	type Config struct {
+		Host     string        `env:"HOST"`
+		Port     int           `env:"PORT"`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Password string        `env:"PASSWORD,secret"`
+		Hosts    []string      `env:"HOSTS"`
+	}
+
+	old := Config{Host: "a", Port: 1, Timeout: time.Second, Password: "old-password-1234", Hosts: []string{"a", "b"}}
+	updated := Config{Host: "b", Port: 1, Timeout: time.Minute, Password: "new-password-5678", Hosts: []string{"a", "b"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Diff(old, updated)
+	}
+}
@@ -0,0 +1,262 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// File is a .env file that can be edited, keeping its comments, blank lines and order.
+//
+// Entries that are not changed are written back exactly as they were read.
+// Changed entries keep their indentation, any "export " and any comment after the value, with the value quoted by
+// quoteValue. A line holding several entries, such as A="1" B="2", keeps them on the same line.
+//
+// Example:
+//
+//	f, err := env.OpenFile(".env")
+//	if err != nil {
+//		return err
+//	}
+//
+//	f.Set("API_KEY", newKey)
+//	err = f.Save()
+type File struct {
+	// path is where Save writes the file.
+	path string
+	// lines are the entries, comments and blank lines of the file in order.
+	lines []fileLine
+}
+
+// fileLine is an entry, comment or blank line of a File.
+//
+// An entry may span several lines for a quoted value holding newlines, or share its line with other entries.
+type fileLine struct {
+	// raw is the text as read, without the trailing newline. Empty once the entry is changed.
+	raw string
+	// prefix is the text before the key, such as indentation or "export ".
+	prefix string
+	// key is the key of the entry, empty for comments and blank lines.
+	key string
+	// value is the value of the entry, unquoted and not expanded.
+	value string
+	// comment is the text after the value on the last entry of a line, such as " # local only".
+	comment string
+	// joined is true when the entry follows another on the same line, such as B within A="1" B="2".
+	joined bool
+}
+
+// OpenFile reads a .env file to be edited.
+//
+// Parameters:
+//   - path: The path of the file. If it does not exist, an empty File is returned which Save creates.
+//
+// Returns:
+//   - The File.
+//   - An error if the file could not be read, or a *SyntaxError if an entry is invalid.
+//
+// Note: Windows line endings are read as "\n", and the file is saved with "\n".
+func OpenFile(path string) (*File, error) {
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{path: path}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	lines, err := parseFileLines(bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n")))
+	if err != nil {
+		return nil, withFileName(err, path)
+	}
+
+	return &File{path: path, lines: lines}, nil
+}
+
+// parseFileLines splits the source into entries, comments and blank lines.
+//
+// Parameters:
+//   - src: The source of the file.
+//
+// Returns: The lines of the file, or a *SyntaxError if an entry is invalid.
+func parseFileLines(src []byte) ([]fileLine, error) {
+	var lines []fileLine
+
+	whole := src
+	joined := false
+
+	for len(src) > 0 {
+		lineEnd := indexOfChar(src, '\n')
+		if lineEnd == -1 {
+			lineEnd = len(src)
+		}
+
+		trimmed := bytes.TrimFunc(src[:lineEnd], isSpace)
+		if !joined && (len(trimmed) == 0 || trimmed[0] == CharComment) {
+			lines = append(lines, fileLine{raw: string(src[:lineEnd])})
+			src = skipLine(src, lineEnd)
+			continue
+		}
+
+		key, value, remaining, err := getKeyValue(src, nil)
+		if err != nil {
+			return nil, newSyntaxError(whole, len(whole)-len(src), err.Error())
+		}
+
+		// The rest of the line the value ends on, which may hold a comment or the next entry.
+		end := len(src) - len(remaining)
+		rest := remaining
+		if next := indexOfChar(remaining, '\n'); next != -1 {
+			rest = remaining[:next]
+		}
+
+		line := fileLine{key: key, value: value, joined: joined}
+		if joined = getStart(rest) != nil; joined {
+			// The next entry starts after the value, taking the spaces between them as its prefix.
+			line.raw = string(src[:end])
+			line.prefix = line.raw[:strings.Index(line.raw, key)]
+			lines = append(lines, line)
+			src = remaining
+			continue
+		}
+
+		_, afterKey, _ := getKey(src)
+		if _, quoted := hasQuotePrefix(afterKey); quoted {
+			line.comment = string(rest)
+		} else {
+			// An unquoted value ends at its comment, which is part of the same text.
+			valueLine := afterKey[:findEndOfLine(afterKey)]
+			i := commentIndex(valueLine)
+			line.comment = string(valueLine[len(bytes.TrimRightFunc(valueLine[:i], isSpace)):])
+		}
+		// Only a comment is kept, apart from a changed value which may not be quoted.
+		if strings.TrimFunc(line.comment, isSpace) == "" {
+			line.comment = ""
+		} else if !isSpace(rune(line.comment[0])) {
+			line.comment = " " + line.comment
+		}
+
+		end += len(rest)
+		line.raw = string(src[:end])
+		line.prefix = line.raw[:strings.Index(line.raw, key)]
+		lines = append(lines, line)
+		src = skipLine(src, end)
+	}
+
+	return lines, nil
+}
+
+// skipLine returns the source after the line ending at end, skipping its newline.
+func skipLine(src []byte, end int) []byte {
+	if end < len(src) {
+		return src[end+1:]
+	}
+	return nil
+}
+
+// Get returns the value of a key, as written within the file without expanding references.
+//
+// Parameters:
+//   - key: The key of the entry.
+//
+// Returns:
+//   - The value, of the last entry if the key is repeated.
+//   - True if the key is within the file.
+func (f *File) Get(key string) (string, bool) {
+	if i := f.index(key); i != -1 {
+		return f.lines[i].value, true
+	}
+	return "", false
+}
+
+// Set sets the value of a key, adding it to the end of the file if it is not already within it.
+//
+// Parameters:
+//   - key: The key of the entry.
+//   - value: The value, quoted when saved if needed.
+//
+// Note: If the key is repeated, the last entry is changed as it is the one that is read.
+func (f *File) Set(key, value string) {
+	if i := f.index(key); i != -1 {
+		f.lines[i].value = value
+		f.lines[i].raw = ""
+		return
+	}
+
+	f.lines = append(f.lines, fileLine{key: key, value: value})
+}
+
+// Delete removes every entry of a key from the file.
+//
+// Parameters:
+//   - key: The key of the entries.
+//
+// Returns: True if the key was within the file.
+func (f *File) Delete(key string) bool {
+	kept := f.lines[:0]
+	// startsLine is set once the first entry of a line is deleted, so the next entry on it starts the line instead.
+	startsLine := false
+	for _, line := range f.lines {
+		if line.key == key {
+			startsLine = startsLine || !line.joined
+			continue
+		}
+
+		if startsLine && line.joined {
+			line.joined = false
+		}
+		startsLine = false
+		kept = append(kept, line)
+	}
+
+	deleted := len(kept) != len(f.lines)
+	f.lines = kept
+	return deleted
+}
+
+// Bytes returns the contents of the file, as Save would write it.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, line := range f.lines {
+		if i > 0 && !line.joined {
+			buf.WriteByte('\n')
+		}
+
+		if line.raw != "" || line.key == "" {
+			buf.WriteString(line.raw)
+			continue
+		}
+
+		value := quoteValue(line.value)
+		// An unquoted value runs to the end of its line, so it is quoted when another entry follows on the line.
+		if i+1 < len(f.lines) && f.lines[i+1].joined && value[0] != CharDoubleQuote && value[0] != CharSingleQuote {
+			value = doubleQuote(line.value)
+		}
+		buf.WriteString(line.prefix + line.key + "=" + value + line.comment)
+	}
+
+	if len(f.lines) > 0 {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Save writes the file back to the path it was opened from.
+//
+// Returns: An error if the file could not be written.
+//
+// Note: A new file is created with 0600 permissions, as it may hold secrets. An existing file keeps its permissions.
+func (f *File) Save() error {
+	return os.WriteFile(f.path, f.Bytes(), 0o600)
+}
+
+// index returns the position of the last entry of a key, or -1 if it is not within the file.
+func (f *File) index(key string) int {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if f.lines[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
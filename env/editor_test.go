@@ -0,0 +1,271 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const editorSource = `# Database
+DB_HOST=localhost # local only
+  export DB_PASSWORD="old"
+
+MESSAGE="multi
+line"
+DB_HOST=override
+EMPTY=
+`
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte(editorSource), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := filepath.Join(dir, "broken.env")
+	if err := os.WriteFile(broken, []byte("A=1\r\nB='2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Unchanged", func(t *testing.T) {
+		f, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() error = %v", err)
+		}
+		if got := string(f.Bytes()); got != editorSource {
+			t.Errorf("Bytes() = %q, expected %q", got, editorSource)
+		}
+	})
+
+	t.Run("Without a trailing newline", func(t *testing.T) {
+		for _, src := range []string{"A=1\n# end", "# start\nA=1"} {
+			lines, err := parseFileLines([]byte(src))
+			if err != nil {
+				t.Fatalf("parseFileLines() error = %v", err)
+			}
+
+			f := &File{lines: lines}
+			if got := string(f.Bytes()); got != src+"\n" {
+				t.Errorf("Bytes() = %q, expected %q", got, src+"\n")
+			}
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		f, err := OpenFile(filepath.Join(dir, "missing.env"))
+		if err != nil || len(f.Bytes()) != 0 {
+			t.Errorf("OpenFile() = %q, %v, expected an empty file", f.Bytes(), err)
+		}
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		_, err := OpenFile(broken)
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.File != broken || syntaxErr.Line != 2 {
+			t.Errorf("OpenFile() error = %v, expected a *SyntaxError on line 2", err)
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		if _, err := OpenFile(dir); err == nil {
+			t.Error("OpenFile() expected an error for a directory")
+		}
+	})
+}
+
+func TestFile_Get(t *testing.T) {
+	lines, err := parseFileLines([]byte(editorSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &File{lines: lines}
+
+	tests := []struct {
+		key      string
+		expected string
+		found    bool
+	}{
+		{key: "DB_HOST", expected: "override", found: true},
+		{key: "DB_PASSWORD", expected: "old", found: true},
+		{key: "MESSAGE", expected: "multi\nline", found: true},
+		{key: "EMPTY", expected: "", found: true},
+		{key: "MISSING", expected: "", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, found := f.Get(tt.key)
+			if got != tt.expected || found != tt.found {
+				t.Errorf("Get() = %q, %v, expected %q, %v", got, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestFile_Edit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(editorSource), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Set("DB_PASSWORD", "new $ecret")
+	f.Set("DB_HOST", "db")
+	f.Set("API_KEY", "abc")
+	if !f.Delete("MESSAGE") {
+		t.Error("Delete() = false, expected MESSAGE to be found")
+	}
+	if f.Delete("MISSING") {
+		t.Error("Delete() = true, expected MISSING not to be found")
+	}
+
+	if err = f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	expected := `# Database
+DB_HOST=localhost # local only
+  export DB_PASSWORD='new $ecret'
+
+DB_HOST=db
+EMPTY=
+API_KEY=abc
+`
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Errorf("Save() wrote %q, expected %q", got, expected)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("Save() changed the permissions, got %v", info.Mode().Perm())
+	}
+
+	// The saved file is read back to the same values.
+	envMap, err := parseFile(path, os.Open)
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if envMap["DB_PASSWORD"] != "new $ecret" || envMap["DB_HOST"] != "db" || envMap["API_KEY"] != "abc" {
+		t.Errorf("parseFile() = %v, expected the edited values", envMap)
+	}
+}
+
+func TestFile_SameLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		edit     func(f *File)
+		expected string
+	}{
+		{
+			name:     "Set the second entry",
+			src:      `A="1" B="2" # both`,
+			edit:     func(f *File) { f.Set("B", "3") },
+			expected: `A="1" B=3 # both`,
+		},
+		{
+			name:     "Set the first entry",
+			src:      `A="1" B="2"`,
+			edit:     func(f *File) { f.Set("A", "plain") },
+			expected: `A="plain" B="2"`,
+		},
+		{
+			name:     "Delete the first entry",
+			src:      "X=0\nA='1' B='2'\nC=3",
+			edit:     func(f *File) { f.Delete("A") },
+			expected: "X=0\n B='2'\nC=3",
+		},
+		{
+			name:     "Delete the second entry",
+			src:      "A='1' B='2'\nC=3",
+			edit:     func(f *File) { f.Delete("B") },
+			expected: "A='1'\nC=3",
+		},
+		{
+			name:     "Keep an unquoted comment",
+			src:      "PORT=80   # public\nHOST=a",
+			edit:     func(f *File) { f.Set("PORT", "8080") },
+			expected: "PORT=8080   # public\nHOST=a",
+		},
+		{
+			name:     "Keep a quoted comment",
+			src:      `KEY="old"# note`,
+			edit:     func(f *File) { f.Set("KEY", "new") },
+			expected: `KEY=new # note`,
+		},
+		{
+			name:     "Drop trailing spaces",
+			src:      "KEY=old   ",
+			edit:     func(f *File) { f.Set("KEY", "new") },
+			expected: "KEY=new",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := parseFileLines([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("parseFileLines() error = %v", err)
+			}
+			f := &File{lines: lines}
+			if got := string(f.Bytes()); got != tt.src+"\n" {
+				t.Errorf("Bytes() = %q, expected the file unchanged", got)
+			}
+
+			tt.edit(f)
+			got := f.Bytes()
+			if string(got) != tt.expected+"\n" {
+				t.Errorf("Bytes() = %q, expected %q", got, tt.expected+"\n")
+			}
+
+			// The edited file is read back to the same values.
+			envMap, err := parseEnvFileBytes(got)
+			if err != nil {
+				t.Fatalf("parseEnvFileBytes() error = %v", err)
+			}
+			for _, line := range f.lines {
+				if line.key != "" && envMap[line.key] != line.value {
+					t.Errorf("parseEnvFileBytes() %s = %q, expected %q", line.key, envMap[line.key], line.value)
+				}
+			}
+		})
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		lines, err := parseFileLines([]byte("A=\"1\" B='2'  C=\"3\"\n"))
+		if err != nil {
+			t.Fatalf("parseFileLines() error = %v", err)
+		}
+		f := &File{lines: lines}
+
+		if got, ok := f.Get("B"); got != "2" || !ok {
+			t.Errorf("Get(B) = %q, %v, expected 2", got, ok)
+		}
+		f.Set("C", "4")
+		if len(f.lines) != 3 {
+			t.Errorf("Set() added an entry, expected C to be changed within its line")
+		}
+	})
+}
+
+func BenchmarkOpenFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(editorSource), 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = OpenFile(path)
+	}
+}
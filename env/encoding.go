@@ -0,0 +1,165 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// bomUTF8 is the byte order mark some Windows editors write at the start of a UTF-8 file.
+var bomUTF8 = []byte{0xEF, 0xBB, 0xBF}
+
+// detectEncoding finds the encoding of a .env file from its first bytes.
+//
+// A file starting with a byte order mark is UTF-8 or UTF-16 as the mark says. Without one, a zero byte within the
+// first two bytes means UTF-16, as a UTF-8 .env file never holds one.
+//
+// Parameters:
+//   - head: The first bytes of the file, at least 3 unless the file is shorter.
+//
+// Returns: The byte order of a UTF-16 file or nil for UTF-8, and the length of the byte order mark to skip.
+func detectEncoding(head []byte) (binary.ByteOrder, int) {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return nil, len(bomUTF8)
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return binary.LittleEndian, 2
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return binary.BigEndian, 2
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		return binary.LittleEndian, 0
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		return binary.BigEndian, 0
+	}
+	return nil, 0
+}
+
+// decodeReader returns a reader of br as UTF-8, without a byte order mark.
+//
+// A UTF-8 file is read from br itself, a UTF-16 file is decoded as it is read, so it is still read a line at a time
+// by the tokenizer.
+func decodeReader(br *bufio.Reader) *bufio.Reader {
+	// A short file returns fewer bytes along with an error, which the tokenizer finds again when reading.
+	head, _ := br.Peek(len(bomUTF8))
+	order, bomLen := detectEncoding(head)
+	_, _ = br.Discard(bomLen)
+
+	if order == nil {
+		return br
+	}
+	return bufio.NewReader(&utf16Reader{r: br, order: order})
+}
+
+// decodeBytes returns src as UTF-8, without a byte order mark.
+//
+// UTF-8 is returned as a slice of src, UTF-16 is decoded into a new slice.
+func decodeBytes(src []byte) []byte {
+	order, bomLen := detectEncoding(src)
+	src = src[bomLen:]
+
+	if order == nil {
+		return src
+	}
+
+	units := make([]uint16, len(src)/2)
+	for i := range units {
+		units[i] = order.Uint16(src[i*2:])
+	}
+
+	decoded := make([]byte, 0, len(src))
+	for _, r := range utf16.Decode(units) {
+		decoded = utf8.AppendRune(decoded, r)
+	}
+	// A trailing odd byte is not a whole character.
+	if len(src)%2 != 0 {
+		decoded = utf8.AppendRune(decoded, utf8.RuneError)
+	}
+	return decoded
+}
+
+// utf16Reader decodes UTF-16 from r into UTF-8.
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	// pending is a code unit read after an unpaired surrogate, to be decoded next.
+	pending rune
+	// hasPending is true when pending holds a code unit.
+	hasPending bool
+	// unit holds the bytes of a code unit being read.
+	unit [2]byte
+	// out holds the encoded character that did not fit within the last Read.
+	out []byte
+}
+
+// Read decodes as many whole characters as fit within p.
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+
+	for n+utf8.UTFMax <= len(p) || (n == 0 && len(u.out) == 0) {
+		r, err := u.readRune()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+
+		if n+utf8.RuneLen(r) > len(p) {
+			// Only possible when p is shorter than utf8.UTFMax, the rest is returned by the next Read.
+			u.out = utf8.AppendRune(u.out[:0], r)
+			written := copy(p[n:], u.out)
+			u.out = u.out[written:]
+			return n + written, nil
+		}
+		n += utf8.EncodeRune(p[n:], r)
+	}
+
+	return n, nil
+}
+
+// readRune reads the next character, joining a surrogate pair.
+func (u *utf16Reader) readRune() (rune, error) {
+	r1, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
+	}
+
+	r2, err := u.readUnit()
+	if err == io.EOF {
+		return utf8.RuneError, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if r := utf16.DecodeRune(r1, r2); r != utf8.RuneError {
+		return r, nil
+	}
+
+	// The second unit is not part of a pair, so it is decoded on its own next.
+	u.pending, u.hasPending = r2, true
+	return utf8.RuneError, nil
+}
+
+// readUnit reads the next UTF-16 code unit.
+func (u *utf16Reader) readUnit() (rune, error) {
+	if u.hasPending {
+		u.hasPending = false
+		return u.pending, nil
+	}
+
+	if _, err := io.ReadFull(u.r, u.unit[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			// A trailing odd byte is not a whole character.
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return rune(u.order.Uint16(u.unit[:])), nil
+}
@@ -0,0 +1,167 @@
+package env
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"maps"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes s as UTF-16 in the byte order, with a byte order mark if bom is set.
+func encodeUTF16(s string, order binary.AppendByteOrder, bom bool) []byte {
+	var b []byte
+	if bom {
+		b = order.AppendUint16(b, 0xFEFF)
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, unit)
+	}
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		head   []byte
+		order  binary.ByteOrder
+		bomLen int
+	}{
+		{name: "UTF-8", head: []byte("KEY")},
+		{name: "UTF-8 with a BOM", head: []byte("\xEF\xBB\xBFKEY"), bomLen: 3},
+		{name: "UTF-16LE with a BOM", head: []byte{0xFF, 0xFE, 'K'}, order: binary.LittleEndian, bomLen: 2},
+		{name: "UTF-16BE with a BOM", head: []byte{0xFE, 0xFF, 0}, order: binary.BigEndian, bomLen: 2},
+		{name: "UTF-16LE", head: []byte{'K', 0, 'E'}, order: binary.LittleEndian},
+		{name: "UTF-16BE", head: []byte{0, 'K', 0}, order: binary.BigEndian},
+		{name: "Short", head: []byte("K")},
+		{name: "Empty", head: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, bomLen := detectEncoding(tt.head)
+			if order != tt.order || bomLen != tt.bomLen {
+				t.Errorf("detectEncoding() = %v, %d, expected %v, %d", order, bomLen, tt.order, tt.bomLen)
+			}
+		})
+	}
+}
+
+func TestParse_Encodings(t *testing.T) {
+	const src = "KEY=value\r\nEMOJI=\"😀 ü\"\r\n"
+	expected := map[string]string{"KEY": "value", "EMOJI": "😀 ü"}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected map[string]string
+	}{
+		{name: "UTF-8 with a BOM", input: append([]byte("\xEF\xBB\xBF"), src...), expected: expected},
+		{name: "UTF-16LE with a BOM", input: encodeUTF16(src, binary.LittleEndian, true), expected: expected},
+		{name: "UTF-16BE with a BOM", input: encodeUTF16(src, binary.BigEndian, true), expected: expected},
+		{name: "UTF-16LE", input: encodeUTF16(src, binary.LittleEndian, false), expected: expected},
+		{name: "UTF-16BE", input: encodeUTF16(src, binary.BigEndian, false), expected: expected},
+		{
+			name:     "Unpaired surrogates",
+			input:    binary.LittleEndian.AppendUint16(encodeUTF16("A=x", binary.LittleEndian, true), 0xD800),
+			expected: map[string]string{"A": "x�"},
+		},
+		{
+			name: "Surrogate followed by a character",
+			input: append(binary.LittleEndian.AppendUint16(encodeUTF16("A=x", binary.LittleEndian, true), 0xDC00),
+				encodeUTF16("y", binary.LittleEndian, false)...),
+			expected: map[string]string{"A": "x�y"},
+		},
+		{
+			name:     "Trailing odd byte",
+			input:    append(encodeUTF16("A=x", binary.LittleEndian, true), 'y'),
+			expected: map[string]string{"A": "x�"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readers := map[string]io.Reader{
+				"Reader":     bytes.NewReader(tt.input),
+				"OneByte":    iotest.OneByteReader(bytes.NewReader(tt.input)),
+				"HalfReader": iotest.HalfReader(bytes.NewReader(tt.input)),
+			}
+			for name, r := range readers {
+				got, err := readWithIO(r)
+				if err != nil {
+					t.Fatalf("readWithIO(%s) error = %v", name, err)
+				}
+				if !maps.Equal(got, tt.expected) {
+					t.Errorf("readWithIO(%s) = %q, expected %q", name, got, tt.expected)
+				}
+			}
+
+			got, err := parseEnvFileBytes(tt.input)
+			if err != nil {
+				t.Fatalf("parseEnvFileBytes() error = %v", err)
+			}
+			if !maps.Equal(got, tt.expected) {
+				t.Errorf("parseEnvFileBytes() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUTF16Reader(t *testing.T) {
+	const src = "A=😀ü\nB=2"
+	input := encodeUTF16(src, binary.BigEndian, false)
+
+	t.Run("Small buffer", func(t *testing.T) {
+		r := &utf16Reader{r: bytes.NewReader(input), order: binary.BigEndian}
+
+		var out []byte
+		p := make([]byte, 1)
+		for {
+			n, err := r.Read(p)
+			out = append(out, p[:n]...)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+		}
+		if string(out) != src {
+			t.Errorf("Read() = %q, expected %q", out, src)
+		}
+	})
+
+	t.Run("Read error", func(t *testing.T) {
+		r := &utf16Reader{r: iotest.ErrReader(io.ErrClosedPipe), order: binary.BigEndian}
+		if _, err := r.Read(make([]byte, 16)); err != io.ErrClosedPipe {
+			t.Errorf("Read() error = %v, expected %v", err, io.ErrClosedPipe)
+		}
+
+		// The error of the second unit of a pair is returned as well.
+		r = &utf16Reader{r: io.MultiReader(bytes.NewReader([]byte{0xD8, 0x3D}), iotest.ErrReader(io.ErrClosedPipe)), order: binary.BigEndian}
+		if _, err := r.Read(make([]byte, 16)); err != io.ErrClosedPipe {
+			t.Errorf("Read() error = %v, expected %v", err, io.ErrClosedPipe)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if _, err := readWithIO(strings.NewReader("\xFF\xFE")); err == nil || err.Error() != "empty file" {
+			t.Errorf("readWithIO() error = %v, expected an empty file", err)
+		}
+	})
+}
+
+func BenchmarkReadWithIO_UTF16(b *testing.B) {
+	src := encodeUTF16(string(largeEnvFile()), binary.LittleEndian, true)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := readWithIO(bytes.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
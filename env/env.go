@@ -1,13 +1,39 @@
 package env
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+
+	"github.com/cloudment/utils-go/internal/strcase"
+	"github.com/cloudment/utils-go/internal/structfields"
 )
 
+// ContextUnmarshaler is implemented by types that parse their own value with a context.
+//
+// Useful for values that are fetched from a slow backend, such as a reference to a secret within a vault.
+// It takes priority over encoding.TextUnmarshaler and the built-in parsers.
+//
+// Example:
+//
+//	type VaultSecret string
+//
+//	func (s *VaultSecret) UnmarshalEnv(ctx context.Context, value string) error {
+//		secret, err := vault.Read(ctx, value)
+//		*s = VaultSecret(secret)
+//		return err
+//	}
+type ContextUnmarshaler interface {
+	// UnmarshalEnv parses the value of the environment variable.
+	//
+	// The context is the one passed to ParseWithContext, or context.Background() otherwise.
+	UnmarshalEnv(ctx context.Context, value string) error
+}
+
 // FieldTags contains the tags that can be used to customise the behavior of the parser.
 //
 // Example usages of tags are shown within the struct.
@@ -22,6 +48,16 @@ type FieldTags struct {
 	// There is no ability to set this manually.
 	Key string
 	// Prefix is the prefix to use when looking up the environment variable.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Database Database `envPrefix:"${TENANT:-SHARED}_DB"`
+	//	}
+	//
+	// In this case, TENANT=ACME reads the database from ACME_DB_HOST rather than SHARED_DB_HOST.
+	// Variables within a prefix are not prefixed themselves, and ${VAR:?message} fails when VAR is unset or empty.
+	// Marshal, Describe and the other functions without an environment only expand the defaults.
 	Prefix string `envPrefix:"prefix"`
 	// Default is the default value to use if the environment variable is not set.
 	//
@@ -51,6 +87,8 @@ type FieldTags struct {
 	//
 	// In this case, InnerConfig will be initialised with the value of Host,
 	// only if INNER_HOST is set as an environment variable.
+	//
+	// Nil maps and slices are initialised empty. Options.InitNilPointers does the same for every field.
 	Init bool `env:"init"`
 	// Expand, uses Default as a template to expand environment variables.
 	//
@@ -63,11 +101,131 @@ type FieldTags struct {
 	//	}
 	//
 	// In this case, URL will be expanded to "http://127.0.0.1:8080".
+	//
+	// Fields referenced by the default are parsed first, even when they are declared after it.
 	Expand bool `env:",expand"`
 	// Unset is provided to unset the environment variable after it has been set.
 	//
 	// This is useful when you want to set a value, but not keep it in the environment like a password.
 	Unset bool `env:",unset"`
+	// File reads the value from the file at the path held by the environment variable, trimming a trailing newline.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Password string `env:"DB_PASSWORD,file" envDefault:"/run/secrets/db_password"`
+	//	}
+	//
+	// This is useful for Docker and Kubernetes secrets, which are mounted as files.
+	File bool `env:",file"`
+	// Encoding is how a []byte field is encoded, either Base64Env or HexEnv.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		SigningKey []byte `env:"SIGNING_KEY,base64"`
+	//	}
+	//
+	// Without an encoding, a []byte field is parsed as a slice of numbers, such as "1,2,3".
+	Encoding string `env:",base64"`
+	// AllowEmpty keeps a variable that is set to an empty string, rather than treating it as unset.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Password string `env:"DB_PASSWORD,allowEmpty" envDefault:"postgres"`
+	//	}
+	//
+	// In this case, DB_PASSWORD="" sets an empty password rather than the default, and satisfies required.
+	// Fields other than strings are set to their zero value. Options.AllowEmpty does the same for every field.
+	AllowEmpty bool `env:",allowEmpty"`
+	// ExtendedDuration parses a time.Duration field with the units "d" for days and "w" for weeks, of 24 hours and
+	// 7 days each, along with those of time.ParseDuration.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Retention time.Duration `env:"RETENTION,extendedDuration" envDefault:"2w"`
+	//		Timeout   time.Duration `env:"TIMEOUT,extendedDuration" envDefault:"1d12h"`
+	//	}
+	//
+	// Days ignore daylight saving time, so they suit retention periods better than calendar dates.
+	ExtendedDuration bool `env:",extendedDuration"`
+	// Secret marks the field as holding a secret, such as a password or API key.
+	//
+	// Secret fields are referenced from a Kubernetes Secret by ToK8sEnvVars rather than written as a value,
+	// and masked by Redacted.
+	Secret bool `env:",secret"`
+	// Validate holds the rules the value is checked against once it has been parsed.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Port  int    `env:"PORT" envValidate:"min=1,max=65535"`
+	//		Stage string `env:"STAGE" envValidate:"oneof=dev staging prod"`
+	//		Name  string `env:"NAME" envValidate:"min=3,regex=^[a-z-]+$"`
+	//		Email string `env:"EMAIL" envValidate:"email"`
+	//	}
+	//
+	// The rules are:
+	//   - min, max and len: The value of a number, or the length of a string, slice or map.
+	//     Limits of a time.Duration are durations, such as "min=1s".
+	//   - oneof: The options separated by spaces.
+	//   - regex: A regular expression the value must match. It must be the last rule, as the pattern may hold commas.
+	//   - A format: "email", "url", "uuid", "e164", "hostname" or "semver".
+	//
+	// Other than min, max and len, the rules of a slice apply to each of its elements.
+	// Unset values are not validated, use required to check they are set.
+	Validate string `envValidate:"rules"`
+	// Deprecated is the message passed to Options.WarnFunc when the variable is set, to migrate to another name.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Host string `env:"DB_HOST" envDeprecated:"use DATABASE_HOST instead"`
+	//	}
+	//
+	// The value is still read, so a service keeps working while its deployments are updated.
+	Deprecated string `envDeprecated:"message"`
+	// Aliases are the keys tried in order when the variable is unset or empty, such as the old names of a renamed variable.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Host string `env:"DATABASE_HOST" envAlias:"DB_HOST,POSTGRES_HOST"`
+	//	}
+	//
+	// Aliases are prefixed as the key is. Reading from an alias calls Options.WarnFunc with "use DATABASE_HOST instead".
+	Aliases []string `envAlias:"OLD_NAME,LEGACY_NAME"`
+	// Parser is the name of the parser registered with RegisterParser, used rather than the parser of the field's type.
+	//
+	// Use case:
+	//
+	//	type Config struct {
+	//		Workers []int `env:"WORKERS" envParser:"int-range"`
+	//	}
+	//
+	// Parsing fails if no parser is registered under the name.
+	Parser string `envParser:"int-range"`
+	// Group is the name of the group the field belongs to, from the GroupEnv tag.
+	//
+	// The fields of a struct sharing a group are checked together once the struct has been parsed:
+	//
+	//	type Config struct {
+	//		Cert     string `env:"TLS_CERT" envGroup:"tls,requiredTogether"`
+	//		Key      string `env:"TLS_KEY" envGroup:"tls"`
+	//		Token    string `env:"TOKEN" envGroup:"auth,mutuallyExclusive"`
+	//		Password string `env:"PASSWORD" envGroup:"auth"`
+	//	}
+	//
+	// In this case, TLS_CERT and TLS_KEY must both be set or both unset, and TOKEN and PASSWORD cannot both be set.
+	// A variable counts as set when it is not empty, through its key, an alias or a FileSuffix; defaults do not count.
+	// A *GroupError is returned for a group that is broken.
+	Group string `envGroup:"name"`
+	// GroupMode is the mode of the Group, RequiredTogetherGroup or MutuallyExclusiveGroup.
+	//
+	// Only one field of the group needs the mode, defaulting to RequiredTogetherGroup when none have it.
+	GroupMode string `envGroup:",mode"`
 }
 
 // Parse parses a struct containing `env` tags and loads its values from environment variables.
@@ -94,28 +252,192 @@ func Parse(v interface{}) error {
 //
 // Parameters:
 //
-//   - v: A pointer to a struct containing `env` tags.
+//   - v: A pointer to a struct containing `env` tags, or to a map[string]T collecting every variable with the Prefix.
 //   - opts: The options to use when parsing the struct.
 //
 // Returns: An error if the parsing failed. If successful, it will return nil.
 //
+// Example:
+//
+//	// Port is read from MYAPP_PORT rather than PORT, without adding envPrefix tags.
+//	err := env.ParseWithOpts(&cfg, env.Options{Env: map[string]string{"MYAPP_PORT": "8080"}, Prefix: "MYAPP"})
+//
+//	// LABEL_TEAM=payments and LABEL_TIER=1 are collected as {"TEAM": "payments", "TIER": "1"}.
+//	labels := map[string]string{}
+//	err = env.ParseWithOpts(&labels, env.Options{Prefix: "LABEL", Sources: []env.Source{env.OsEnvSource{}}})
+//
 // Note: When successful, the struct referenced by v will be updated.
+// A map requires a Prefix, its values are parsed as T, such as int or time.Duration, and empty values are skipped
+// unless AllowEmpty is set.
 func ParseWithOpts(v interface{}, opts Options) error {
 	if v == nil || reflect.ValueOf(v).Kind() != reflect.Ptr {
 		return errors.New("expected a pointer to a valid struct")
 	}
 
-	// Currently, there is no prefix as it's the root struct.
-	// After the first loop, any structs within this struct will have a prefix.
-	err := parseInterface(v, opts)
+	// The precedence is Env, then each of Envs, then each of Sources, then Overlay, then BoundFlags.
+	if len(opts.Envs) > 0 {
+		opts.Env = MergeMaps(append([]map[string]string{opts.Env}, opts.Envs...)...)
+	}
+
+	if len(opts.Sources) > 0 {
+		vars, err := loadSources(opts.context(), opts.Env, opts.Sources)
+		if err != nil {
+			return err
+		}
+		opts.Env = vars
+	}
+
+	// The root struct uses the prefix from the options, which may be empty.
+	// After the first loop, any structs within this struct will have their prefix appended.
+	opts.Prefix = opts.normaliseKey(ensureTrailingUnderscore(opts.Prefix))
+
+	// Flags bound by BindFlags take priority over Overlay, without modifying the caller's map.
+	opts.Overlay = opts.BoundFlags.overlay(opts.Prefix, opts.Overlay)
+
+	// Keys are normalised once, so each lookup only normalises the key of the field.
+	if opts.CaseInsensitive {
+		opts.Env = upperKeys(opts.Env)
+		opts.Overlay = upperKeys(opts.Overlay)
+	}
+
+	// Errors hold the path to the field starting from the root type, such as "Config.Database.Host".
+	opts.path = reflect.TypeOf(v).Elem().Name()
+
+	// The used keys are shared by every nested struct, as the map is created before they copy the options.
+	if opts.Strict || opts.UnsetAll {
+		opts.usedKeys = make(map[string]bool)
+	}
+
+	// Options are passed by pointer internally, avoiding a copy for every field.
+	var err error
+	if ref := reflect.ValueOf(v).Elem(); ref.Kind() == reflect.Map {
+		err = parseMap(ref, &opts)
+	} else {
+		err = parseInterface(v, &opts)
+	}
 
 	if err != nil {
 		return err
 	}
 
+	if opts.Strict {
+		if unknown := opts.unknownKeys(); len(unknown) > 0 {
+			return fmt.Errorf("unknown environment variables: %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	if opts.Validator != nil {
+		if err = opts.Validator(v); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if opts.UnsetAll {
+		opts.unsetUsedKeys()
+	}
+
 	return nil
 }
 
+// ParseAs parses the environment variables into a new T, using the default options.
+//
+// Returns: The parsed T, or the zero value of T and an error if the parsing failed.
+//
+// Example:
+//
+//	cfg, err := env.ParseAs[Config]()
+//
+// Note: T must be a struct containing `env` tags. This function is a wrapper around ParseAsWithOpts.
+func ParseAs[T any]() (T, error) {
+	return ParseAsWithOpts[T](defaultOptions())
+}
+
+// ParseAsWithOpts parses the environment variables into a new T, as ParseWithOpts does.
+//
+// Parameters:
+//
+//   - opts: The options to use when parsing the struct.
+//
+// Returns: The parsed T, or the zero value of T and an error if the parsing failed.
+//
+// Example:
+//
+//	cfg, err := env.ParseAsWithOpts[Config](env.Options{Prefix: "MYAPP", Sources: []env.Source{env.OsEnvSource{}}})
+//
+// Note: T must be a struct containing `env` tags, a partially parsed T is never returned.
+func ParseAsWithOpts[T any](opts Options) (T, error) {
+	var t T
+	if err := ParseWithOpts(&t, opts); err != nil {
+		var zero T
+		return zero, err
+	}
+	return t, nil
+}
+
+// ParseWithContext parses a struct containing `env` tags, as ParseWithOpts does, with a context.
+//
+// The context is given to every field implementing ContextUnmarshaler, such as types fetching a secret from a slow
+// backend, so the parse can be cancelled or given a timeout. Parsing stops once the context is done.
+//
+// Parameters:
+//
+//   - ctx: The context of the parse.
+//   - v: A pointer to a struct containing `env` tags.
+//   - opts: The options to use when parsing the struct.
+//
+// Returns: An error if the parsing failed, including the error of the context once it is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//
+//	err := env.ParseWithContext(ctx, &cfg, env.Options{Sources: []env.Source{env.OsEnvSource{}}})
+//
+// Note: When successful, the struct referenced by v will be updated.
+func ParseWithContext(ctx context.Context, v interface{}, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	opts.ctx = ctx
+	return ParseWithOpts(v, opts)
+}
+
+// ParseLayered parses a struct containing `env` tags from a base environment with overrides applied on top.
+//
+// The base map is never modified or copied, so a shared environment can be reused for each request or test.
+//
+// Parameters:
+//
+//   - v: A pointer to a struct containing `env` tags.
+//   - base: The base environment variables, if nil os.Environ() is used.
+//   - overrides: Environment variables applied over base in order, so later overrides take priority.
+//
+// Returns: An error if the parsing failed. If successful, it will return nil.
+//
+// Example:
+//
+//	// Per tenant, without touching os.Environ()
+//	err := env.ParseLayered(&cfg, nil, map[string]string{"DATABASE_NAME": tenant.Database})
+func ParseLayered(v interface{}, base map[string]string, overrides ...map[string]string) error {
+	opts := Options{Env: base, rawEnvVars: make(map[string]string)}
+	if base == nil {
+		opts.Env = ToMap(os.Environ())
+	}
+
+	switch len(overrides) {
+	case 0:
+	case 1:
+		opts.Overlay = overrides[0]
+	default:
+		// Only the overrides are merged, which are expected to be small compared to base.
+		opts.Overlay = MergeMaps(overrides...)
+	}
+
+	return ParseWithOpts(v, opts)
+}
+
 // parseInterface parses an interface and sets the values of the struct.
 //
 // A normal process tree would look like this:
@@ -130,7 +452,7 @@ func ParseWithOpts(v interface{}, opts Options) error {
 //   - opts: The options to use when parsing the struct.
 //
 // Returns: An error if the parsing failed. If successful, it will return nil.
-func parseInterface(i interface{}, opts Options) error {
+func parseInterface(i interface{}, opts *Options) error {
 	v := reflect.ValueOf(i)
 
 	if v.IsNil() || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
@@ -153,7 +475,7 @@ func parseInterface(i interface{}, opts Options) error {
 //   - opts: The options to use when parsing the struct.
 //
 // Returns: An error if the parsing failed. If successful, it will return nil.
-func parseStruct(ref reflect.Value, opts Options) error {
+func parseStruct(ref reflect.Value, opts *Options) error {
 	if ref.Kind() == reflect.Ptr {
 		ref = ref.Elem()
 	}
@@ -162,22 +484,54 @@ func parseStruct(ref reflect.Value, opts Options) error {
 		return fmt.Errorf("expected a struct, but got %v", ref.Kind())
 	}
 
-	refType := ref.Type()
+	if err := opts.checkDepth(ref.Type()); err != nil {
+		return err
+	}
+
+	// The fields and their tags are cached per type, as they are the same every time a type is parsed.
+	cached := cachedStruct(ref.Type(), opts)
+
+	var errs []error
+
+	// Loop through the fields of the struct, in the order their expanded defaults depend on each other.
+	for _, i := range cached.order {
+		field := cached.fields[i]
+
+		// A cancelled parse stops, rather than continuing to fetch values nobody is waiting for.
+		if opts.ctx != nil {
+			if err := opts.ctx.Err(); err != nil {
+				return err
+			}
+		}
 
-	// Loop through the fields of the struct.
-	for i := 0; i < refType.NumField(); i++ {
-		f := ref.Field(i)
-		sf := refType.Field(i)
+		// The cached tags are without a prefix, as it differs for each use of a nested struct.
+		tags := field.tags
+		if !tags.Ignored {
+			tags.Key = opts.Prefix + tags.OwnKey
+		}
 
-		// While aggregating errors could be possible here,
-		// if there is an issue, it should be fixed before continuing,
+		// By default, if there is an issue, it should be fixed before continuing,
 		// minimising wasted processing if there is an issue.
-		if err := parseField(f, sf, opts); err != nil {
-			return err
+		// AggregateErrors instead reports every misconfigured field at once.
+		if err := parseFieldWithTags(ref.Field(i), field.sf, tags, opts); err != nil {
+			if !opts.AggregateErrors {
+				return err
+			}
+			errs = append(errs, withFieldPath(field.sf.Name, err))
 		}
 	}
 
-	return nil
+	// Groups are checked once every field is parsed, so each is reported with all of its variables.
+	if len(cached.groups) > 0 {
+		if err := checkGroups(cached, opts); err != nil {
+			if !opts.AggregateErrors {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // parseField parses a field and sets the value of the field.
@@ -192,11 +546,32 @@ func parseStruct(ref reflect.Value, opts Options) error {
 //   - opts: The options to use when parsing the field.
 //
 // Returns: An error if the parsing failed. If successful, it will return nil.
-func parseField(v reflect.Value, sf reflect.StructField, opts Options) error {
+func parseField(v reflect.Value, sf reflect.StructField, opts *Options) error {
+	// Tags are parsed to determine the behavior of the field.
+	// Such as `env:"key"` or `env:"key,required"` for required fields.
+	return parseFieldWithTags(v, sf, parseFieldTags(sf, opts), opts)
+}
+
+// parseFieldWithTags parses a field whose tags have already been parsed, such as from cachedFields.
+//
+// Parameters:
+//
+//   - v: The reflect.Value of the field to parse.
+//   - sf: The reflect.StructField of the field to parse.
+//   - tags: The FieldTags of the field, including the prefix within Key.
+//   - opts: The options to use when parsing the field.
+//
+// Returns: An error if the parsing failed. If successful, it will return nil.
+func parseFieldWithTags(v reflect.Value, sf reflect.StructField, tags FieldTags, opts *Options) error {
 	if !v.CanSet() {
 		return nil
 	}
 
+	// Nested structs are parsed whether or not they are tagged, so their nil pointers are initialised as well.
+	if opts.InitNilPointers && (!tags.Ignored || (tags.OwnKey != "-" && isNestedStruct(sf.Type))) {
+		initialiseNil(v)
+	}
+
 	var err error
 
 	// Interface checking is done first, as it will require going back to parseInterface
@@ -204,10 +579,6 @@ func parseField(v reflect.Value, sf reflect.StructField, opts Options) error {
 		return err
 	}
 
-	// Tags are parsed to determine the behavior of the field.
-	// Such as `env:"key"` or `env:"key,required"` for required fields.
-	tags := parseFieldTags(sf, opts)
-
 	// If the field does not have a key, it's ignored.
 	// It may also specify to be ignored with `env:"-"`
 	if tags.Ignored {
@@ -216,7 +587,9 @@ func parseField(v reflect.Value, sf reflect.StructField, opts Options) error {
 
 	// set's a value to the field, if it's not empty.
 	if err = setField(v, sf, tags, opts); err != nil {
-		return err
+		if err = opts.handleError(opts.fieldPath(sf.Name), tags.Key, err); err != nil {
+			return err
+		}
 	}
 
 	initialisePointer(v)
@@ -242,26 +615,33 @@ func parseField(v reflect.Value, sf reflect.StructField, opts Options) error {
 //   - opts: The options to use when parsing the field.
 //
 // Returns: An error if the parsing failed. If successful or not applicable, it will return nil.
-func handlePointerStruct(v reflect.Value, sf reflect.StructField, opts Options) error {
+func handlePointerStruct(v reflect.Value, sf reflect.StructField, opts *Options) error {
 	if v.Kind() == reflect.Invalid {
 		return errors.New("expected a valid reflect.Value")
 	}
 
-	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
-		return parseInterface(v.Interface(), opts.withPrefix(sf))
+	isPointer := v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct
+	isAnonymous := v.Kind() == reflect.Struct && v.CanAddr() && v.Type().Name() == ""
+	if !isPointer && !isAnonymous {
+		return nil
 	}
 
-	if v.Kind() == reflect.Struct && v.CanAddr() && v.Type().Name() == "" {
-		return parseInterface(v.Addr().Interface(), opts.withPrefix(sf))
+	nested, err := opts.withPrefix(sf)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if isAnonymous {
+		v = v.Addr()
+	}
+	return parseInterface(v.Interface(), &nested)
 }
 
 // handleStructOrSlice handles a struct or a slice of structs.
 //
 // If the field is a struct, it will call parseStruct to parse the struct.
 // If the field is a slice of structs, it will call parseSliceOfStructs to parse the slice.
+// If the field is a map of structs, it will call parseMapOfStructs to parse the map.
 //
 // Parameters:
 //
@@ -271,31 +651,38 @@ func handlePointerStruct(v reflect.Value, sf reflect.StructField, opts Options)
 //   - tags: The FieldTags of the field to parse.
 //
 // Returns: An error if the parsing failed. If successful or not applicable, it will return nil.
-func handleStructOrSlice(v reflect.Value, sf reflect.StructField, opts Options, tags FieldTags) error {
-	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
-		return parseInterface(v.Interface(), opts.withPrefix(sf))
-	}
+func handleStructOrSlice(v reflect.Value, sf reflect.StructField, opts *Options, tags FieldTags) error {
+	isPointer := v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct
+	isSlice, isMap := isSliceOfStructs(sf), isMapOfStructs(sf)
 
-	if v.Kind() == reflect.Struct {
-		if v.CanAddr() {
-			return parseStruct(v.Addr(), opts.withPrefix(sf))
+	if !isPointer && v.Kind() != reflect.Struct && !isSlice && !isMap {
+		// If the field is nil, it will be initialised.
+		// An example of this might be a map, where the map is nil.
+		if tags.Init {
+			initialiseNil(v)
 		}
-		return fmt.Errorf("cannot address struct field: %s", sf.Name)
+		return nil
 	}
 
-	if isSliceOfStructs(sf) {
-		return parseSliceOfStructs(v, opts.withPrefix(sf))
+	if v.Kind() == reflect.Struct && !v.CanAddr() {
+		return fmt.Errorf("cannot address struct field: %s", sf.Name)
 	}
 
-	// If the field is nil, it will be initialised.
-	// An example of this might be a map, where the map is nil.
-	invalidPtr := v.Kind() == reflect.Ptr && v.IsNil()
-	if tags.Init && invalidPtr {
-		v.Set(reflect.New(v.Type().Elem()))
-		v = v.Elem()
+	nested, err := opts.withPrefix(sf)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	switch {
+	case isPointer:
+		return parseInterface(v.Interface(), &nested)
+	case v.Kind() == reflect.Struct:
+		return parseStruct(v.Addr(), &nested)
+	case isSlice:
+		return parseSliceOfStructs(v, &nested)
+	default:
+		return parseMapOfStructs(v, &nested)
+	}
 }
 
 // setField sets the value of the field.
@@ -312,26 +699,115 @@ func handleStructOrSlice(v reflect.Value, sf reflect.StructField, opts Options,
 //   - opts: The options to use when parsing the field.
 //
 // Returns: An error if the parsing failed. If successful, it will return nil.
-func setField(v reflect.Value, sf reflect.StructField, tags FieldTags, opts Options) error {
-	val, err := resolveValue(tags, opts)
+func setField(v reflect.Value, sf reflect.StructField, tags FieldTags, opts *Options) error {
+	// A value set before parsing is kept, its variables are only marked as used so Strict does not report them.
+	if opts.OnlySetZero && !v.IsZero() {
+		opts.markUsed(tags.Key)
+		if opts.FileSuffix != "" {
+			opts.markUsed(tags.Key + opts.FileSuffix)
+		}
+		for _, alias := range tags.Aliases {
+			opts.markUsed(opts.Prefix + alias)
+		}
+		return nil
+	}
+
+	val, set, err := resolveValue(tags, opts)
 	if err != nil {
+		if notSet, ok := err.(*VarNotSetError); ok {
+			notSet.Field = opts.fieldPath(sf.Name)
+		}
 		return err
 	}
 
-	if val == "" {
+	if !set {
 		return nil
 	}
 
 	handleUnset(tags)
 
-	if tm := asTextUnmarshaler(v); tm != nil {
-		return tm.UnmarshalText([]byte(val))
+	if tags.File && val != "" {
+		if val, err = readValueFile(val); err != nil {
+			return fmt.Errorf("%s: %w", tags.Key, err)
+		}
+	}
+
+	if val == "" {
+		// Only an empty value that is allowed is set, as the zero value, or a pointer to it.
+		initialisePointer(v)
+		vp, _ := resolvePointer(v, sf.Type)
+		vp.Set(reflect.Zero(vp.Type()))
+	} else if tags.Parser != "" {
+		err = parseNamed(v, tags.Parser, val)
+	} else if cu := asContextUnmarshaler(v); cu != nil {
+		err = cu.UnmarshalEnv(opts.context(), val)
+	} else {
+		err = parseValue(v, sf, tags, val)
+	}
+
+	if err != nil {
+		return fieldError(err, tags.Key, opts.fieldPath(sf.Name))
+	}
+
+	if tags.Validate != "" {
+		return validateField(v, tags, opts.fieldPath(sf.Name))
+	}
+
+	return nil
+}
+
+// readValueFile reads the value of a field with the file option.
+//
+// Parameters:
+//
+//   - path: The path to the file.
+//
+// Returns: The contents of the file without a trailing newline, or an error if it could not be read.
+func readValueFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file: %w", err)
+	}
+
+	val := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(val, "\r"), nil
+}
+
+// parseValue parses the value into the field.
+//
+// Parameters:
+//
+//   - v: The reflect.Value of the field to set.
+//   - sf: The reflect.StructField of the field, used for its type and tags.
+//   - tags: The FieldTags of the field.
+//   - val: The value to parse.
+//
+// Returns: An error if the value could not be parsed.
+func parseValue(v reflect.Value, sf reflect.StructField, tags FieldTags, val string) error {
+	if tags.Encoding != "" {
+		return setBytes(v, val, tags.Encoding)
+	}
+	if tags.ExtendedDuration {
+		return setExtendedDuration(v, val)
+	}
+
+	// time.Time is a TextUnmarshaler, but only for RFC 3339, so it's handled first to support other layouts.
+	if isTimeType(sf.Type) {
+		return setTime(v, val, sf)
 	}
 
+	// Type parsers take priority over UnmarshalText, as they validate types such as net.IP with clearer errors.
+	_, hasTypeParser := typeParsers[elemType(sf.Type)]
+	if !hasTypeParser {
+		if tm := asTextUnmarshaler(v); tm != nil {
+			return tm.UnmarshalText([]byte(val))
+		}
+	}
+
+	initialisePointer(v)
 	vp, sfType := resolvePointer(v, sf.Type)
 
-	var ok bool
-	if ok, err = applyParser(vp, sfType, val); ok {
+	if ok, err := applyParser(vp, sfType, val); ok {
 		// If it's successful, return nil otherwise it would run handleSpecialTypes
 		// which would return an error if it could not be found.
 		return nil
@@ -344,37 +820,115 @@ func setField(v reflect.Value, sf reflect.StructField, tags FieldTags, opts Opti
 }
 
 // resolveValue resolves the value of the field.
-// This uses the opts.Env map to get the value of the field.
+// This uses the opts.Overlay and opts.Env maps to get the value of the field.
 //
-// If expanding is set, it will expand the value.
+// If opts.FileSuffix is set, the value may be read from the file at KEY_FILE.
+// If expanding is set, it will expand the value, including ${VAR:-default} and ${VAR:?message}.
 //
 // Parameters:
 //
 //   - tags: The FieldTags of the field to parse.
 //   - opts: The options to use when parsing the field.
 //
-// Returns: The value of the field, or an error if the value could not be resolved.
-func resolveValue(tags FieldTags, opts Options) (string, error) {
-	val, exists := opts.Env[tags.Key]
-	if (tags.Key == "" || !exists || val == "") && tags.Default != "" {
-		val = tags.Default
+// Returns:
+//   - The value of the field.
+//   - Whether the field is set, which is when the value is not empty, or is set to empty and allowed to be.
+//   - An error if the value could not be resolved.
+func resolveValue(tags FieldTags, opts *Options) (string, bool, error) {
+	val, exists := opts.lookup(tags.Key)
+	opts.markUsed(tags.Key)
+
+	if opts.FileSuffix != "" && tags.Key != "" {
+		fileKey := tags.Key + opts.FileSuffix
+		opts.markUsed(fileKey)
+		if path, ok := opts.lookup(fileKey); ok && path != "" {
+			if val != "" {
+				return "", false, fmt.Errorf("%s and %s are both set, only one may be used", tags.Key, fileKey)
+			}
+
+			var err error
+			if val, err = readValueFile(path); err != nil {
+				return "", false, fmt.Errorf("%s: %w", fileKey, err)
+			}
+			exists = true
+		}
+	}
+
+	if exists && tags.Deprecated != "" && opts.WarnFunc != nil {
+		opts.WarnFunc(tags.Key, tags.Deprecated)
+	}
+
+	if len(tags.Aliases) > 0 {
+		val, exists = lookupAlias(val, exists, tags, opts)
 	}
 
-	if opts.rawEnvVars == nil {
-		opts.rawEnvVars = make(map[string]string)
+	// An empty value is kept rather than replaced by the default, when it is set and allowed to be.
+	allowEmpty := exists && tags.Key != "" && (tags.AllowEmpty || opts.AllowEmpty)
+
+	if (tags.Key == "" || !exists || (val == "" && !allowEmpty)) && tags.Default != "" {
+		val = tags.Default
 	}
 
 	if tags.Expand {
-		val = os.Expand(val, opts.getRawEnv)
+		var err error
+		if val, err = opts.expand(val); err != nil {
+			return "", false, fmt.Errorf("%s: %w", tags.Key, err)
+		}
 	}
 
-	opts.rawEnvVars[tags.OwnKey] = val
+	opts.setRawEnv(tags.OwnKey, val)
 
-	if tags.Required && (tags.OwnKey == "" || val == "") {
-		return "", fmt.Errorf("required environment variable not set: %s", tags.Key)
+	if tags.Required && (tags.OwnKey == "" || (val == "" && !allowEmpty)) {
+		return "", false, &VarNotSetError{Key: tags.Key}
 	}
 
-	return val, nil
+	return val, val != "" || allowEmpty, nil
+}
+
+// lookupAlias gets the value from the first alias that is set, when the key of the field is unset or empty.
+//
+// Parameters:
+//
+//   - val: The value of the key.
+//   - exists: Whether the key is set.
+//   - tags: The FieldTags of the field, holding its aliases.
+//   - opts: The options to use, holding the prefix of the aliases.
+//
+// Returns: The value of the key, or of the first alias that is set, and whether either is set.
+func lookupAlias(val string, exists bool, tags FieldTags, opts *Options) (string, bool) {
+	// Every alias is known, so Strict does not report the old names while both are set during a migration.
+	for _, alias := range tags.Aliases {
+		opts.markUsed(opts.Prefix + alias)
+	}
+
+	if val != "" || (exists && (tags.AllowEmpty || opts.AllowEmpty)) {
+		return val, exists
+	}
+
+	for _, alias := range tags.Aliases {
+		key := opts.Prefix + alias
+		if aliasVal, ok := opts.lookup(key); ok && aliasVal != "" {
+			if opts.WarnFunc != nil {
+				opts.WarnFunc(key, "use "+tags.Key+" instead")
+			}
+			return aliasVal, true
+		}
+	}
+
+	return val, exists
+}
+
+// parseAliases splits the keys of an AliasEnv tag, returning nil for an empty tag.
+func parseAliases(tag string) []string {
+	var aliases []string
+	for tag != "" {
+		var alias string
+		alias, tag, _ = strings.Cut(tag, ",")
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
 }
 
 // handleUnset unsets the environment variable if the Unset tag is set.
@@ -415,18 +969,16 @@ func applyParser(v reflect.Value, sfType reflect.Type, val string) (bool, error)
 	if parseFunc, ok := typeParsers[sfType]; ok {
 		parsedVal, err := parseFunc(val)
 		if err != nil {
-			return false, fmt.Errorf("failed to parse value: %v", err)
+			return false, fmt.Errorf("failed to parse value: %w", err)
 		}
 		v.Set(reflect.ValueOf(parsedVal))
 		return true, nil
 	}
 
 	if parseFunc, ok := parsers[sfType.Kind()]; ok {
-		parsedVal, err := parseFunc(val)
-		if err != nil {
-			return false, fmt.Errorf("failed to parse value: %v", err)
+		if err := setValue(v, val, parseFunc); err != nil {
+			return false, fmt.Errorf("failed to parse value: %w", err)
 		}
-		v.Set(reflect.ValueOf(parsedVal).Convert(sfType))
 		return true, nil
 	}
 
@@ -445,16 +997,25 @@ func applyParser(v reflect.Value, sfType reflect.Type, val string) (bool, error)
 // Returns: The FieldTags of the field.
 //
 // Note: This function is called before the value of the field is set.
-func parseFieldTags(sf reflect.StructField, opts Options) FieldTags {
+func parseFieldTags(sf reflect.StructField, opts *Options) FieldTags {
 	// While slightly slower, having all tag lookups grouped looks slightly cleaner
 	// To speed up the code, defaultValue can be moved after the ignore checking.
 	// It would only save ~5 ns/op
-	_, hasPrefix := sf.Tag.Lookup(PrefixEnv)
-	env, hasEnv := sf.Tag.Lookup(Env)
-	defaultValue := sf.Tag.Get(DefaultEnv)
+	_, hasPrefix := sf.Tag.Lookup(opts.prefixTagName())
+	env, hasEnv := sf.Tag.Lookup(opts.tagName())
+	defaultValue, hasDefault := sf.Tag.Lookup(opts.defaultTagName())
+
+	if opts.UseFieldNameByDefault && !hasEnv && !hasPrefix && sf.IsExported() {
+		// Nested structs are only prefixed, as their fields hold the values.
+		if isNestedStruct(sf.Type) || isSliceOfStructs(sf) || isMapOfStructs(sf) {
+			hasPrefix = true
+		} else {
+			env, hasEnv = strcase.ToScreamingSnake(sf.Name), true
+		}
+	}
 
-	o := strings.Split(env, ",")
-	ownKey, tags := o[0], o[1:]
+	// Cutting the options one at a time avoids allocating a slice for every field.
+	ownKey, options, _ := strings.Cut(env, ",")
 
 	if (ownKey == "-" || !hasEnv) && !hasPrefix {
 		return FieldTags{
@@ -464,13 +1025,21 @@ func parseFieldTags(sf reflect.StructField, opts Options) FieldTags {
 	}
 
 	res := FieldTags{
-		OwnKey:   ownKey,
-		Key:      opts.Prefix + ownKey,
-		Default:  defaultValue,
-		Required: false,
+		OwnKey:     ownKey,
+		Key:        opts.Prefix + ownKey,
+		Default:    defaultValue,
+		Required:   opts.RequiredIfNoDefault && !hasDefault && ownKey != "",
+		Validate:   sf.Tag.Get(ValidateEnv),
+		Deprecated: sf.Tag.Get(DeprecatedEnv),
+		Aliases:    parseAliases(sf.Tag.Get(AliasEnv)),
+		Parser:     sf.Tag.Get(ParserEnv),
 	}
+	res.Group, res.GroupMode, _ = strings.Cut(sf.Tag.Get(GroupEnv), ",")
+
+	for options != "" {
+		var tag string
+		tag, options, _ = strings.Cut(options, ",")
 
-	for _, tag := range tags {
 		switch tag {
 		case RequiredEnv:
 			res.Required = true
@@ -480,8 +1049,179 @@ func parseFieldTags(sf reflect.StructField, opts Options) FieldTags {
 			res.Init = true
 		case UnsetEnv:
 			res.Unset = true
+		case SecretEnv:
+			res.Secret = true
+		case AllowEmptyEnv:
+			res.AllowEmpty = true
+		case ExtendedDurationEnv:
+			res.ExtendedDuration = true
+		case FileEnv:
+			res.File = true
+		case Base64Env, HexEnv:
+			res.Encoding = tag
 		}
 	}
 
 	return res
 }
+
+// structField is a field of a struct and its tags, as cached by cachedFields.
+type structField struct {
+	sf   reflect.StructField
+	tags FieldTags
+}
+
+// tagSettings are the options that change how tags are parsed, so a type parsed with other options is cached separately.
+type tagSettings struct {
+	tagName, defaultTagName, prefixTagName     string
+	useFieldNameByDefault, requiredIfNoDefault bool
+}
+
+// fieldsCacheKey is the key of fieldsCache.
+type fieldsCacheKey struct {
+	structType reflect.Type
+	settings   tagSettings
+}
+
+// structFields are the fields of a struct type, as cached by fieldsCache.
+type structFields struct {
+	// fields are the fields in declaration order.
+	fields []structField
+	// order holds the index of each field within fields, in the order they are parsed. See parseOrder.
+	order []int
+	// groups are the groups of the GroupEnv tags, in the order they are first declared. See parseGroups.
+	groups []fieldGroup
+}
+
+// fieldsCache holds the structFields of each struct type that has been parsed.
+//
+// Services that parse the same struct repeatedly, such as per-request options, only pay for the reflection once.
+var fieldsCache sync.Map
+
+// cachedFields returns the fields of a struct type with their tags, parsing them on first use.
+//
+// Parameters:
+//
+//   - structType: The type of the struct.
+//   - opts: The options to use when parsing the tags, only those within tagSettings are used.
+//
+// Returns: The fields in order, with tags parsed without a prefix so the same tags are used for every prefix.
+func cachedFields(structType reflect.Type, opts *Options) []structField {
+	return cachedStruct(structType, opts).fields
+}
+
+// cachedStruct returns the fields of a struct type and the order to parse them in, parsing them on first use.
+//
+// Parameters:
+//
+//   - structType: The type of the struct.
+//   - opts: The options to use when parsing the tags, only those within tagSettings are used.
+//
+// Returns: The cached fields, which must not be modified.
+func cachedStruct(structType reflect.Type, opts *Options) *structFields {
+	key := fieldsCacheKey{structType: structType, settings: tagSettings{
+		tagName:               opts.tagName(),
+		defaultTagName:        opts.defaultTagName(),
+		prefixTagName:         opts.prefixTagName(),
+		useFieldNameByDefault: opts.UseFieldNameByDefault,
+		requiredIfNoDefault:   opts.RequiredIfNoDefault,
+	}}
+
+	if cached, ok := fieldsCache.Load(key); ok {
+		return cached.(*structFields)
+	}
+
+	unprefixed := Options{
+		TagName:               key.settings.tagName,
+		DefaultTagName:        key.settings.defaultTagName,
+		PrefixTagName:         key.settings.prefixTagName,
+		UseFieldNameByDefault: key.settings.useFieldNameByDefault,
+		RequiredIfNoDefault:   key.settings.requiredIfNoDefault,
+	}
+
+	// The struct fields themselves are shared with the utils package, only their tags are parsed here.
+	sfs := structfields.Fields(structType)
+	fields := make([]structField, len(sfs))
+	for i, sf := range sfs {
+		fields[i] = structField{sf: sf, tags: parseFieldTags(sf, &unprefixed)}
+	}
+
+	// Another goroutine may have stored the same fields first, either is correct.
+	cached := &structFields{fields: fields, order: parseOrder(fields), groups: parseGroups(fields)}
+	actual, _ := fieldsCache.LoadOrStore(key, cached)
+	return actual.(*structFields)
+}
+
+// parseOrder returns the order to parse the fields of a struct in, so a default referencing a sibling is expanded
+// with the value of that sibling, even when it is declared later or only set by its own default.
+//
+// Use case:
+//
+//	type Config struct {
+//		URL  string `env:"URL,expand" envDefault:"http://${HOST}:${PORT}"`
+//		Host string `env:"HOST" envDefault:"127.0.0.1"`
+//		Port int    `env:"PORT" envDefault:"8080"`
+//	}
+//
+// In this case, HOST and PORT are parsed before URL, which is expanded to "http://127.0.0.1:8080".
+//
+// Parameters:
+//
+//   - fields: The fields of the struct, in declaration order.
+//
+// Returns: The index of each field, with the siblings referenced by an expanded default before it.
+// Otherwise, fields keep their declaration order, including those which reference each other.
+func parseOrder(fields []structField) []int {
+	// Values are expanded using the keys of the fields within their own struct, see Options.setRawEnv.
+	siblings := make(map[string]int, len(fields))
+	for i, field := range fields {
+		if !field.tags.Ignored && field.tags.OwnKey != "" {
+			siblings[field.tags.OwnKey] = i
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(fields))
+	order := make([]int, 0, len(fields))
+
+	var visit func(i int)
+	visit = func(i int) {
+		// A field still being visited references itself through its siblings, so it keeps its place.
+		if state[i] != unvisited {
+			return
+		}
+		state[i] = visiting
+
+		if tags := fields[i].tags; tags.Expand && tags.Default != "" {
+			for _, name := range referencedVars(tags.Default) {
+				if j, ok := siblings[name]; ok {
+					visit(j)
+				}
+			}
+		}
+
+		state[i] = visited
+		order = append(order, i)
+	}
+
+	for i := range fields {
+		visit(i)
+	}
+
+	return order
+}
+
+// referencedVars returns the names of the variables referenced within s, such as HOST within ${HOST:-localhost}.
+func referencedVars(s string) []string {
+	var names []string
+	os.Expand(s, func(name string) string {
+		name, _, _ = strings.Cut(name, ":")
+		names = append(names, name)
+		return ""
+	})
+	return names
+}
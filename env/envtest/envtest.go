@@ -0,0 +1,141 @@
+// Package envtest provides helpers for tests reading environment variables, saving and restoring the process
+// environment and writing temporary .env files.
+//
+// The process environment is shared by every goroutine, so these helpers must not be used by parallel tests.
+package envtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cloudment/utils-go/env"
+)
+
+// State is a copy of the process environment, taken by Snapshot.
+type State struct {
+	// vars are the variables of the process when the snapshot was taken.
+	vars map[string]string
+}
+
+// Snapshot copies the process environment, so it can be put back with Restore.
+//
+// Returns: The copy of the process environment.
+//
+// Example:
+//
+//	state := envtest.Snapshot()
+//	defer state.Restore()
+//
+//	os.Setenv("PORT", "8080")
+func Snapshot() *State {
+	// env.ToMap keeps the leading '=' of Windows variables such as "=C:", which would otherwise have an empty key.
+	return &State{vars: env.ToMap(os.Environ())}
+}
+
+// Restore puts the process environment back as it was when the snapshot was taken.
+//
+// Variables set since are unset, and variables changed or unset since are set to their previous value.
+//
+// Returns: An error for each variable that could not be set or unset, joined.
+func (s *State) Restore() error {
+	var errs []error
+
+	for key := range env.ToMap(os.Environ()) {
+		if _, saved := s.vars[key]; !saved {
+			if err := os.Unsetenv(key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for key, val := range s.vars {
+		if current, ok := os.LookupEnv(key); ok && current == val {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WithEnv sets environment variables while fn runs, then restores the process environment.
+//
+// Anything fn changes within the process environment is restored as well, even if fn fails the test.
+//
+// Parameters:
+//
+//   - t: The test, failed if a variable cannot be set or the environment cannot be restored.
+//   - vars: The variables to set, an empty value sets the variable to an empty string.
+//   - fn: The function to run with the variables set.
+//
+// Example:
+//
+//	envtest.WithEnv(t, map[string]string{"PORT": "8080"}, func() {
+//		cfg, err := env.ParseAs[Config]()
+//		...
+//	})
+func WithEnv(t testing.TB, vars map[string]string, fn func()) {
+	t.Helper()
+
+	state := Snapshot()
+	defer func() {
+		if err := state.Restore(); err != nil {
+			t.Errorf("envtest: failed to restore the environment: %v", err)
+		}
+	}()
+
+	for key, val := range vars {
+		if err := os.Setenv(key, val); err != nil {
+			t.Fatalf("envtest: failed to set %s: %v", key, err)
+		}
+	}
+
+	fn()
+}
+
+// WriteFile writes variables to a temporary .env file, removed when the test finishes.
+//
+// Values are quoted where needed, so they are read back exactly by env.ParseFromFileIntoStruct, env.Load or
+// env.FileSource.
+//
+// Parameters:
+//
+//   - t: The test, whose temporary directory holds the file.
+//   - vars: The variables to write, in sorted order.
+//
+// Returns: The path of the file.
+//
+// Example:
+//
+//	path := envtest.WriteFile(t, map[string]string{"PORT": "8080"})
+//	err := env.ParseFromFileIntoStruct(&cfg, path)
+func WriteFile(t testing.TB, vars map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	f, err := env.OpenFile(path)
+	if err != nil {
+		t.Fatalf("envtest: failed to create %s: %v", path, err)
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		f.Set(key, vars[key])
+	}
+
+	if err = f.Save(); err != nil {
+		t.Fatalf("envtest: failed to write %s: %v", path, err)
+	}
+	return path
+}
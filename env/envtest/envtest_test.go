@@ -0,0 +1,156 @@
+package envtest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/cloudment/utils-go/env"
+)
+
+// fakeTB records the failures of a test, stopping at Fatalf as a test does.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	panic(f)
+}
+
+// run calls fn with the fake test, recovering from Fatalf.
+func (f *fakeTB) run(fn func()) {
+	defer func() {
+		if r := recover(); r != nil && r != f {
+			panic(r)
+		}
+	}()
+	fn()
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Setenv("ENVTEST_KEPT", "kept")
+	t.Setenv("ENVTEST_CHANGED", "before")
+	t.Setenv("ENVTEST_REMOVED", "removed")
+	t.Setenv("ENVTEST_ADDED", "")
+	os.Unsetenv("ENVTEST_ADDED")
+
+	state := Snapshot()
+
+	os.Setenv("ENVTEST_CHANGED", "after")
+	os.Unsetenv("ENVTEST_REMOVED")
+	os.Setenv("ENVTEST_ADDED", "added")
+
+	if err := state.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	expected := map[string]string{"ENVTEST_KEPT": "kept", "ENVTEST_CHANGED": "before", "ENVTEST_REMOVED": "removed"}
+	for key, val := range expected {
+		if got, ok := os.LookupEnv(key); !ok || got != val {
+			t.Errorf("LookupEnv(%s) = %q, expected %q", key, got, val)
+		}
+	}
+	if _, ok := os.LookupEnv("ENVTEST_ADDED"); ok {
+		t.Error("LookupEnv(ENVTEST_ADDED) expected the variable to be unset")
+	}
+
+	// An invalid key cannot be set, which is reported rather than ignored.
+	if err := (&State{vars: map[string]string{"": "x"}}).Restore(); err == nil {
+		t.Error("Restore() expected an error for an empty key")
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	t.Setenv("ENVTEST_PORT", "80")
+
+	type Config struct {
+		Port int    `env:"ENVTEST_PORT"`
+		Host string `env:"ENVTEST_HOST"`
+	}
+
+	WithEnv(t, map[string]string{"ENVTEST_PORT": "8080", "ENVTEST_HOST": "localhost"}, func() {
+		cfg, err := env.ParseAs[Config]()
+		if err != nil {
+			t.Fatalf("ParseAs() error = %v", err)
+		}
+		if expected := (Config{Port: 8080, Host: "localhost"}); cfg != expected {
+			t.Errorf("ParseAs() = %+v, expected %+v", cfg, expected)
+		}
+
+		os.Setenv("ENVTEST_SET_WITHIN", "x")
+	})
+
+	if val := os.Getenv("ENVTEST_PORT"); val != "80" {
+		t.Errorf("Getenv(ENVTEST_PORT) = %q, expected 80", val)
+	}
+	for _, key := range []string{"ENVTEST_HOST", "ENVTEST_SET_WITHIN"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("LookupEnv(%s) expected the variable to be unset", key)
+		}
+	}
+}
+
+func TestWithEnv_Errors(t *testing.T) {
+	fake := &fakeTB{TB: t}
+	called := false
+
+	fake.run(func() {
+		WithEnv(fake, map[string]string{"": "x"}, func() { called = true })
+	})
+
+	if called {
+		t.Error("WithEnv() expected fn not to be called when a variable cannot be set")
+	}
+	if len(fake.errors) != 1 {
+		t.Errorf("WithEnv() errors = %v, expected a single failure", fake.errors)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	vars := map[string]string{
+		"PLAIN":     "value",
+		"SPACES":    "hello world",
+		"QUOTES":    `say "hi"`,
+		"MULTILINE": "line one\nline two",
+		"COMMENT":   "value # not a comment",
+		"EMPTY":     "",
+	}
+
+	path := WriteFile(t, vars)
+
+	got := make(map[string]string)
+	err := env.ParseFromFile(func(key, value string) error {
+		got[key] = value
+		return nil
+	}, path)
+	if err != nil {
+		t.Fatalf("ParseFromFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, vars) {
+		t.Errorf("WriteFile() read back = %v, expected %v", got, vars)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("WriteFile() permissions = %v, expected 0600", info.Mode().Perm())
+	}
+}
+
+func BenchmarkWithEnv(b *testing.B) {
+	vars := map[string]string{"ENVTEST_BENCH": "1"}
+	for i := 0; i < b.N; i++ {
+		WithEnv(b, vars, func() {})
+	}
+}
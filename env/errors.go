@@ -0,0 +1,253 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// VarNotSetError is returned when a required environment variable is not set, or is empty.
+type VarNotSetError struct {
+	// Key is the environment variable, including any prefix, such as "APP_DB_PASSWORD".
+	Key string
+	// Field is the path to the field, such as "Config.Database.Password".
+	Field string
+}
+
+// Error returns the message, such as "required environment variable not set: APP_DB_PASSWORD".
+func (e *VarNotSetError) Error() string {
+	return "required environment variable not set: " + e.Key
+}
+
+// ParseError is returned when the value of an environment variable cannot be parsed into its field.
+type ParseError struct {
+	// Key is the environment variable, including any prefix, such as "APP_DB_POOL_MAX_CONNS".
+	Key string
+	// Field is the path to the field, such as "Config.Database.Pool.MaxConns".
+	Field string
+	// Err is the error from the parser, such as a *strconv.NumError.
+	Err error
+}
+
+// Error returns the key followed by the error, such as "APP_DB_POOL_MAX_CONNS: invalid syntax".
+func (e *ParseError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error from the parser, for use with errors.Is and errors.As.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned when a parsed value breaks a rule of its envValidate tag.
+type ValidationError struct {
+	// Key is the environment variable, including any prefix, such as "APP_PORT".
+	Key string
+	// Field is the path to the field, such as "Config.Port".
+	Field string
+	// Rule is the rule that was broken, such as "min=1".
+	Rule string
+	// Msg describes why the value breaks the rule, such as "0 is less than the minimum of 1".
+	Msg string
+}
+
+// Error returns the key followed by the message, such as "APP_PORT: 0 is less than the minimum of 1".
+func (e *ValidationError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// UnsupportedTypeError is returned when a field's type, or the element type of its slice or map, has no parser.
+//
+// Implement encoding.TextUnmarshaler, encoding.BinaryUnmarshaler or json.Unmarshaler on the type to support it.
+type UnsupportedTypeError struct {
+	// Key is the environment variable, including any prefix. Empty when formatting a value with Marshal.
+	Key string
+	// Field is the path to the field, such as "Config.Handler".
+	Field string
+	// Type is the type without a parser, such as chan int.
+	Type reflect.Type
+}
+
+// Error returns the key followed by the type, such as "APP_HANDLER: unsupported type: chan int".
+func (e *UnsupportedTypeError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("unsupported type: %v", e.Type)
+	}
+	return fmt.Sprintf("%s: unsupported type: %v", e.Key, e.Type)
+}
+
+// GroupError is returned when the variables of a group set by the GroupEnv tag break its mode.
+type GroupError struct {
+	// Group is the name of the group, such as "tls".
+	Group string
+	// Mode is the mode of the group, RequiredTogetherGroup or MutuallyExclusiveGroup.
+	Mode string
+	// Field is the path to the struct holding the group, such as "Config.Server".
+	Field string
+	// Keys are the environment variables of the group, including any prefix, such as ["TLS_CERT", "TLS_KEY"].
+	Keys []string
+	// Set are the Keys that were set.
+	Set []string
+}
+
+// Error returns the group followed by the problem, such as "envGroup tls: TLS_CERT and TLS_KEY must be set together,
+// only TLS_CERT is set".
+func (e *GroupError) Error() string {
+	verb := " is set"
+	if len(e.Set) > 1 {
+		verb = " are set"
+	}
+
+	if e.Mode == MutuallyExclusiveGroup {
+		return fmt.Sprintf("%s %s: only one of %s may be set, %s%s",
+			GroupEnv, e.Group, joinKeys(e.Keys, "or"), joinKeys(e.Set, "and"), verb)
+	}
+	return fmt.Sprintf("%s %s: %s must be set together, only %s%s",
+		GroupEnv, e.Group, joinKeys(e.Keys, "and"), joinKeys(e.Set, "and"), verb)
+}
+
+// joinKeys joins keys for a message, such as "A, B and C" or "A or B".
+func joinKeys(keys []string, conjunction string) string {
+	if len(keys) < 2 {
+		return strings.Join(keys, "")
+	}
+	return strings.Join(keys[:len(keys)-1], ", ") + " " + conjunction + " " + keys[len(keys)-1]
+}
+
+// MaxDepthError is returned when structs are nested deeper than Options.MaxDepth, such as a struct with a pointer to itself.
+type MaxDepthError struct {
+	// Field is the path to the struct beyond the limit, such as "Node.Next.Next".
+	Field string
+	// Type is the type of the struct beyond the limit, such as Node.
+	Type reflect.Type
+	// MaxDepth is the limit that was exceeded.
+	MaxDepth int
+}
+
+// Error returns the path followed by the limit, such as "Node.Next(.Next)...: exceeded the maximum depth of 32 at env.Node".
+func (e *MaxDepthError) Error() string {
+	return fmt.Sprintf("%s: exceeded the maximum depth of %d at %v", cyclePath(e.Field), e.MaxDepth, e.Type)
+}
+
+// cyclePath shortens a path which repeats the same fields, so a cycle is shown once.
+//
+// Such as "Node.Next.Next.Next" becoming "Node.Next(.Next)...", or "A.B.C.B.C.B.C" becoming "A.B.C(.B.C)...".
+func cyclePath(path string) string {
+	parts := strings.Split(path, ".")
+
+	// The shortest suffix repeated to the end of the path is the cycle.
+	for size := 1; size <= len(parts)/2; size++ {
+		start := len(parts) - size
+		for start-size >= 0 && slices.Equal(parts[start-size:start], parts[start:start+size]) {
+			start -= size
+		}
+		if start < len(parts)-size {
+			return strings.Join(parts[:start+size], ".") + "(." + strings.Join(parts[start:start+size], ".") + ")..."
+		}
+	}
+
+	return path
+}
+
+// FieldError is an error for a specific field, returned when Options.AggregateErrors is set.
+type FieldError struct {
+	// Path is the path to the field, such as "Database.Port" or "Workers[1].Name".
+	Path string
+	// Err is the error from parsing the field.
+	Err error
+}
+
+// Error returns the path followed by the error, such as "Database.Port: failed to parse value".
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error, for use with errors.Is and errors.As.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SyntaxError is returned when a .env file cannot be parsed, holding the position of the invalid entry.
+type SyntaxError struct {
+	// File is the name of the file, empty when parsing from an io.Reader.
+	File string
+	// Line is the line of the entry, starting at 1.
+	Line int
+	// Col is the column of the entry in bytes, starting at 1.
+	Col int
+	// Msg describes the problem, such as "unterminated closing quote".
+	Msg string
+}
+
+// Error returns the position followed by the message, such as ".env:3:1: unterminated closing quote".
+func (e *SyntaxError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+}
+
+// newSyntaxError creates a *SyntaxError from the offset of the invalid entry within src.
+//
+// Parameters:
+//   - src: The whole source being parsed.
+//   - offset: The offset of the invalid entry within src.
+//   - msg: The message describing the problem.
+//
+// Returns: The *SyntaxError, without a File.
+func newSyntaxError(src []byte, offset int, msg string) *SyntaxError {
+	before := src[:offset]
+	return &SyntaxError{
+		Line: bytes.Count(before, []byte("\n")) + 1,
+		Col:  offset - bytes.LastIndexByte(before, '\n'),
+		Msg:  msg,
+	}
+}
+
+// fieldError returns the typed error for a field that could not be parsed.
+//
+// Errors already typed within the parser, such as *UnsupportedTypeError, are given the key and field path.
+// Any other error is wrapped in a *ParseError.
+func fieldError(err error, key, field string) error {
+	var unsupported *UnsupportedTypeError
+	if errors.As(err, &unsupported) {
+		unsupported.Key = key
+		unsupported.Field = field
+		return unsupported
+	}
+
+	return &ParseError{Key: key, Field: field, Err: err}
+}
+
+// withFieldPath prefixes the path of an error with a field name or slice index.
+//
+// Errors joined by a nested struct are prefixed individually, so the result stays a flat list.
+//
+// Parameters:
+//   - name: The field name, or an index such as "[1]".
+//   - err: The error to prefix.
+//
+// Returns: A *FieldError, or the joined *FieldErrors.
+func withFieldPath(name string, err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		prefixed := make([]error, len(errs))
+		for i, e := range errs {
+			prefixed[i] = withFieldPath(name, e)
+		}
+		return errors.Join(prefixed...)
+	}
+
+	if fe, ok := err.(*FieldError); ok {
+		sep := "."
+		if strings.HasPrefix(fe.Path, "[") {
+			sep = ""
+		}
+		return &FieldError{Path: name + sep + fe.Path, Err: fe.Err}
+	}
+
+	return &FieldError{Path: name, Err: err}
+}
@@ -0,0 +1,248 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithFieldPath(t *testing.T) {
+	base := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		field    string
+		err      error
+		expected string
+	}{
+		{
+			name:     "Plain error",
+			field:    "Port",
+			err:      base,
+			expected: "Port: failed",
+		},
+		{
+			name:     "Nested field",
+			field:    "Database",
+			err:      &FieldError{Path: "Port", Err: base},
+			expected: "Database.Port: failed",
+		},
+		{
+			name:     "Slice index",
+			field:    "Workers",
+			err:      &FieldError{Path: "[1].Name", Err: base},
+			expected: "Workers[1].Name: failed",
+		},
+		{
+			name:     "Joined errors",
+			field:    "Database",
+			err:      errors.Join(&FieldError{Path: "Host", Err: base}, &FieldError{Path: "Port", Err: base}),
+			expected: "Database.Host: failed\nDatabase.Port: failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withFieldPath(tt.field, tt.err)
+			if err.Error() != tt.expected {
+				t.Errorf("withFieldPath() = %q, expected %q", err.Error(), tt.expected)
+			}
+			if !errors.Is(err, base) {
+				t.Errorf("withFieldPath() = %v, expected to wrap %v", err, base)
+			}
+		})
+	}
+}
+
+func TestParseWithOpts_AggregateErrors(t *testing.T) {
+	type Worker struct {
+		Port int `env:"PORT"`
+	}
+	type Database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT"`
+	}
+	type Config struct {
+		Name     string   `env:"NAME,required"`
+		Debug    bool     `env:"DEBUG"`
+		Database Database `envPrefix:"DB"`
+		Workers  []Worker `envPrefix:"WORKER"`
+		Valid    string   `env:"VALID"`
+	}
+
+	env := map[string]string{
+		"DEBUG":         "maybe",
+		"DB_PORT":       "abc",
+		"WORKER_0_PORT": "1",
+		"WORKER_1_PORT": "x",
+		"VALID":         "ok",
+	}
+
+	var cfg Config
+	err := ParseWithOpts(&cfg, Options{Env: env, AggregateErrors: true})
+	if err == nil {
+		t.Fatal("ParseWithOpts() expected an error")
+	}
+
+	paths := []string{"Name: ", "Debug: ", "Database.Host: ", "Database.Port: ", "Workers[1].Port: "}
+	for _, path := range paths {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("ParseWithOpts() error = %q, expected it to contain %q", err, path)
+		}
+	}
+	if got := strings.Count(err.Error(), "\n") + 1; got != len(paths) {
+		t.Errorf("ParseWithOpts() returned %d errors, expected %d", got, len(paths))
+	}
+
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "Name" {
+		t.Errorf("errors.As() = %v, expected the Name field error", fe)
+	}
+	if cfg.Valid != "ok" {
+		t.Errorf("ParseWithOpts() Valid = %q, expected fields after an error to be parsed", cfg.Valid)
+	}
+
+	t.Run("Fail fast", func(t *testing.T) {
+		var cfg Config
+		err := ParseWithOpts(&cfg, Options{Env: env})
+		if err == nil || strings.Contains(err.Error(), "\n") {
+			t.Errorf("ParseWithOpts() error = %v, expected a single error", err)
+		}
+	})
+
+	t.Run("No errors", func(t *testing.T) {
+		var cfg Config
+		err := ParseWithOpts(&cfg, Options{Env: map[string]string{"NAME": "a", "DB_HOST": "b"}, AggregateErrors: true})
+		if err != nil {
+			t.Errorf("ParseWithOpts() error = %v", err)
+		}
+	})
+}
+
+func TestTypedErrors(t *testing.T) {
+	type Pool struct {
+		MaxConns int `env:"MAX_CONNS"`
+	}
+	type Database struct {
+		Password string `env:"PASSWORD,required"`
+		Pool     Pool   `envPrefix:"POOL"`
+	}
+	type Worker struct {
+		Handler chan int `env:"HANDLER"`
+	}
+	type Config struct {
+		Database *Database           `envPrefix:"DB"`
+		Workers  []Worker            `envPrefix:"WORKER"`
+		Regions  map[string]Database `envPrefix:"REGION"`
+	}
+
+	t.Run("VarNotSetError", func(t *testing.T) {
+		err := ParseWithOpts(&Config{}, Options{Env: map[string]string{}, Prefix: "APP"})
+
+		var notSet *VarNotSetError
+		if !errors.As(err, &notSet) {
+			t.Fatalf("ParseWithOpts() error = %v, expected a *VarNotSetError", err)
+		}
+		if notSet.Key != "APP_DB_PASSWORD" || notSet.Field != "Config.Database.Password" {
+			t.Errorf("VarNotSetError = %+v, expected APP_DB_PASSWORD and Config.Database.Password", notSet)
+		}
+		if err.Error() != "required environment variable not set: APP_DB_PASSWORD" {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+
+	t.Run("ParseError", func(t *testing.T) {
+		env := map[string]string{"DB_PASSWORD": "a", "DB_POOL_MAX_CONNS": "many"}
+		err := ParseWithOpts(&Config{}, Options{Env: env})
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("ParseWithOpts() error = %v, expected a *ParseError", err)
+		}
+		if parseErr.Key != "DB_POOL_MAX_CONNS" || parseErr.Field != "Config.Database.Pool.MaxConns" {
+			t.Errorf("ParseError = %+v, expected DB_POOL_MAX_CONNS and Config.Database.Pool.MaxConns", parseErr)
+		}
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("ParseWithOpts() error = %v, expected to wrap strconv.ErrSyntax", err)
+		}
+		if !strings.HasPrefix(err.Error(), "DB_POOL_MAX_CONNS: ") {
+			t.Errorf("Error() = %q, expected the key first", err.Error())
+		}
+	})
+
+	t.Run("Map entry", func(t *testing.T) {
+		env := map[string]string{"DB_PASSWORD": "a", "REGION_EU_PASSWORD": "b", "REGION_EU_POOL_MAX_CONNS": "many"}
+		err := ParseWithOpts(&Config{}, Options{Env: env})
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Field != "Config.Regions[EU].Pool.MaxConns" {
+			t.Errorf("ParseWithOpts() error = %v, expected the path Config.Regions[EU].Pool.MaxConns", err)
+		}
+	})
+
+	t.Run("UnsupportedTypeError", func(t *testing.T) {
+		env := map[string]string{"DB_PASSWORD": "a", "WORKER_1_HANDLER": "x"}
+		err := ParseWithOpts(&Config{}, Options{Env: env})
+
+		var unsupported *UnsupportedTypeError
+		if !errors.As(err, &unsupported) {
+			t.Fatalf("ParseWithOpts() error = %v, expected an *UnsupportedTypeError", err)
+		}
+		if unsupported.Key != "WORKER_1_HANDLER" || unsupported.Field != "Config.Workers[1].Handler" || unsupported.Type != reflect.TypeOf(make(chan int)) {
+			t.Errorf("UnsupportedTypeError = %+v", unsupported)
+		}
+		if err.Error() != "WORKER_1_HANDLER: unsupported type: chan int" {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+
+	t.Run("UnsupportedTypeError without a key", func(t *testing.T) {
+		err := &UnsupportedTypeError{Type: reflect.TypeOf(make(chan int))}
+		if err.Error() != "unsupported type: chan int" {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+
+	t.Run("Anonymous root struct", func(t *testing.T) {
+		var cfg struct {
+			Port int `env:"PORT"`
+		}
+		err := ParseWithOpts(&cfg, Options{Env: map[string]string{"PORT": "x"}})
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Field != "Port" {
+			t.Errorf("ParseWithOpts() error = %v, expected the path Port", err)
+		}
+	})
+}
+
+func TestCyclePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "No cycle", path: "Config.Database.Pool", expected: "Config.Database.Pool"},
+		{name: "Single field", path: "Node.Next.Next.Next", expected: "Node.Next(.Next)..."},
+		{name: "Several fields", path: "A.B.C.B.C.B.C", expected: "A.B.C(.B.C)..."},
+		{name: "Without a root", path: "Next.Next", expected: "Next(.Next)..."},
+		{name: "Empty", path: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cyclePath(tt.path); got != tt.expected {
+				t.Errorf("cyclePath() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkWithFieldPath(b *testing.B) {
+	err := errors.Join(&FieldError{Path: "Host", Err: errors.New("a")}, errors.New("b"))
+	for i := 0; i < b.N; i++ {
+		_ = withFieldPath("Database", err)
+	}
+}
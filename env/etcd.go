@@ -0,0 +1,233 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// EtcdSource is the Source of the keys beneath a prefix within etcd, using the JSON gateway of its v3 API.
+//
+// Keys are converted into environment variables by removing the prefix and converting each segment to
+// SCREAMING_SNAKE_CASE, so "/config/app/database/host" with the prefix "/config/app/" is read as DATABASE_HOST.
+//
+// Example:
+//
+//	etcd := env.EtcdSource{Endpoint: "http://127.0.0.1:2379", Prefix: "/config/app/"}
+//	err := env.ParseWithOpts(&cfg, env.Options{Sources: []env.Source{etcd, env.OsEnvSource{}}})
+type EtcdSource struct {
+	// Endpoint is the address of an etcd member, such as "http://127.0.0.1:2379".
+	Endpoint string
+
+	// Prefix is the prefix of the keys, such as "/config/app/". An empty prefix reads every key.
+	Prefix string
+
+	// Token is an auth token from /v3/auth/authenticate, sent as the Authorization header. Optional.
+	Token string
+
+	// Client is the client used for requests, nil for http.DefaultClient.
+	//
+	// It must not have a timeout to use Watch, as the watch is a single request streaming every change.
+	Client *http.Client
+}
+
+// etcdKV is a key within the response of the etcd range API.
+type etcdKV struct {
+	// Key and Value are base64 within the JSON, decoded by encoding/json.
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// etcdRangeResponse is the response of the etcd range API.
+type etcdRangeResponse struct {
+	Header struct {
+		Revision int64 `json:"revision,string"`
+	} `json:"header"`
+	Kvs []etcdKV `json:"kvs"`
+}
+
+// etcdWatchResponse is a message streamed by the etcd watch API.
+type etcdWatchResponse struct {
+	Result struct {
+		Canceled     bool              `json:"canceled"`
+		CancelReason string            `json:"cancel_reason"`
+		Events       []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Load reads the keys beneath the prefix.
+//
+// Returns: The environment variables, or an error if etcd could not be reached or returned an error.
+func (s EtcdSource) Load(ctx context.Context) (map[string]string, error) {
+	vars, _, err := s.fetch(ctx)
+	return vars, err
+}
+
+// Watch calls onChange each time the keys beneath the prefix change, using the watch API.
+//
+// The keys are read again after each change, so onChange is given every key rather than the change.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - onChange: Called with the environment variables after each change.
+//
+// Returns: The error of the context once it is done, or an error if etcd could not be reached, the watch was
+// closed, or onChange returns an error.
+func (s EtcdSource) Watch(ctx context.Context, onChange func(vars map[string]string) error) error {
+	vars, revision, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, rangeEnd := etcdPrefixRange(s.Prefix)
+
+	// The watch starts after the revision that was read, so no change is missed in between.
+	var body struct {
+		CreateRequest struct {
+			Key           []byte `json:"key"`
+			RangeEnd      []byte `json:"range_end"`
+			StartRevision int64  `json:"start_revision,string"`
+		} `json:"create_request"`
+	}
+	body.CreateRequest.Key = key
+	body.CreateRequest.RangeEnd = rangeEnd
+	body.CreateRequest.StartRevision = revision + 1
+
+	resp, err := s.post(ctx, "/v3/watch", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg etcdWatchResponse
+		if err = dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("etcd: watch closed: %w", err)
+		}
+
+		if msg.Error != nil {
+			return errors.New("etcd: " + msg.Error.Message)
+		}
+		if msg.Result.Canceled {
+			return errors.New("etcd: watch cancelled: " + msg.Result.CancelReason)
+		}
+		if len(msg.Result.Events) == 0 {
+			continue
+		}
+
+		latest, _, err := s.fetch(ctx)
+		if err != nil {
+			return err
+		}
+
+		// A put of the same value is still an event.
+		if maps.Equal(vars, latest) {
+			continue
+		}
+
+		if err = onChange(latest); err != nil {
+			return err
+		}
+		vars = latest
+	}
+}
+
+// fetch reads the keys beneath the prefix.
+//
+// Returns: The environment variables, the revision of the store, or an error if the request failed.
+func (s EtcdSource) fetch(ctx context.Context) (map[string]string, int64, error) {
+	key, rangeEnd := etcdPrefixRange(s.Prefix)
+
+	resp, err := s.post(ctx, "/v3/kv/range", struct {
+		Key      []byte `json:"key"`
+		RangeEnd []byte `json:"range_end"`
+	}{key, rangeEnd})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var rangeResp etcdRangeResponse
+	if err = json.NewDecoder(resp.Body).Decode(&rangeResp); err != nil {
+		return nil, 0, fmt.Errorf("etcd: %w", err)
+	}
+
+	vars := make(map[string]string, len(rangeResp.Kvs))
+	for _, kv := range rangeResp.Kvs {
+		if key := kvEnvKey(s.Prefix, string(kv.Key)); key != "" {
+			vars[key] = string(kv.Value)
+		}
+	}
+
+	return vars, rangeResp.Header.Revision, nil
+}
+
+// post sends a JSON request to the etcd API.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - path: The path of the API, such as "/v3/kv/range".
+//   - body: The request, encoded as JSON.
+//
+// Returns: The response, which the caller must close, or an error if the request failed.
+func (s EtcdSource) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.Endpoint, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", s.Token)
+	}
+
+	resp, err := doKVRequest(s.Client, req, "etcd")
+	if err != nil {
+		return nil, err
+	}
+
+	// etcd does not use 404, so it is an error such as a proxy without the gateway.
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("etcd: unexpected status %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+// etcdPrefixRange returns the key and range end of every key beginning with the prefix.
+//
+// The range end is the prefix with its last byte incremented, as etcd reads keys from key up to, but not
+// including, the range end. An empty prefix uses "\x00" for both, which etcd treats as every key.
+func etcdPrefixRange(prefix string) (key, rangeEnd []byte) {
+	if prefix == "" {
+		return []byte{0}, []byte{0}
+	}
+
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return []byte(prefix), end[:i+1]
+		}
+	}
+
+	// Every byte is 0xff, so there is no greater key and the range is every key from the prefix.
+	return []byte(prefix), []byte{0}
+}
@@ -0,0 +1,354 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeEtcd is the JSON gateway of etcd, supporting range reads and watches.
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	kvs      map[string]string
+	changed  chan struct{}
+	// watching receives when a watch is created, so a test can change a key after Watch has started.
+	watching chan struct{}
+	// cancel ends watches with a cancelled or error message, rather than an event.
+	cancel string
+}
+
+func newFakeEtcd(kvs map[string]string) *fakeEtcd {
+	return &fakeEtcd{revision: 5, kvs: kvs, changed: make(chan struct{}), watching: make(chan struct{}, 1)}
+}
+
+// set writes a key, notifying any watches.
+func (f *fakeEtcd) set(key, val string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.kvs[key] = val
+	f.revision++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token" {
+		http.Error(w, `{"error":"etcdserver: user name is empty"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var req struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var resp etcdRangeResponse
+		resp.Header.Revision = f.revision
+		for key, val := range f.kvs {
+			all := bytes.Equal(req.RangeEnd, []byte{0})
+			if bytes.Compare([]byte(key), req.Key) >= 0 && (all || bytes.Compare([]byte(key), req.RangeEnd) < 0) {
+				resp.Kvs = append(resp.Kvs, etcdKV{Key: []byte(key), Value: []byte(val)})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case "/v3/watch":
+		f.mu.Lock()
+		changed, cancel, revision := f.changed, f.cancel, f.revision
+		f.mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"result":{"header":{"revision":"` + strconv.FormatInt(revision, 10) + `"},"created":true}}` + "\n"))
+		w.(http.Flusher).Flush()
+
+		select {
+		case f.watching <- struct{}{}:
+		default:
+		}
+
+		for {
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+
+			f.mu.Lock()
+			changed = f.changed
+			f.mu.Unlock()
+
+			if cancel != "" {
+				_, _ = w.Write([]byte(cancel))
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":{"events":[{"kv":{}}]}}` + "\n"))
+			w.(http.Flusher).Flush()
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestEtcdSource_Load(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{
+		"/config/app/database/host":     "db.internal",
+		"/config/app/database/maxConns": "10",
+		"/config/app/PORT":              "8080",
+		"/config/apq":                   "after the range",
+		"/config/other/PORT":            "9090",
+	})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		source   EtcdSource
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "Prefix",
+			source:   EtcdSource{Endpoint: server.URL + "/", Prefix: "/config/app/", Token: "token"},
+			expected: map[string]string{"DATABASE_HOST": "db.internal", "DATABASE_MAX_CONNS": "10", "PORT": "8080"},
+		},
+		{
+			name:     "No keys",
+			source:   EtcdSource{Endpoint: server.URL, Prefix: "/missing/", Token: "token"},
+			expected: map[string]string{},
+		},
+		{
+			name:   "Every key",
+			source: EtcdSource{Endpoint: server.URL, Token: "token"},
+			expected: map[string]string{
+				"CONFIG_APP_DATABASE_HOST":      "db.internal",
+				"CONFIG_APP_DATABASE_MAX_CONNS": "10",
+				"CONFIG_APP_PORT":               "8080",
+				"CONFIG_APQ":                    "after the range",
+				"CONFIG_OTHER_PORT":             "9090",
+			},
+		},
+		{
+			name:    "Unauthorised",
+			source:  EtcdSource{Endpoint: server.URL, Prefix: "/config/app/"},
+			wantErr: true,
+		},
+		{
+			name:    "Not found",
+			source:  EtcdSource{Endpoint: server.URL + "/proxy", Token: "token"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid endpoint",
+			source:  EtcdSource{Endpoint: "http://\x7f", Token: "token"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Load(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.expected) {
+				t.Errorf("Load() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{"))
+		}))
+		defer server.Close()
+
+		if _, err := (EtcdSource{Endpoint: server.URL}).Load(context.Background()); err == nil {
+			t.Error("Load() expected an error")
+		}
+	})
+}
+
+func TestEtcdSource_Watch(t *testing.T) {
+	t.Run("Change", func(t *testing.T) {
+		fake := newFakeEtcd(map[string]string{"/config/app/PORT": "8080"})
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan map[string]string, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- EtcdSource{Endpoint: server.URL, Prefix: "/config/app/", Token: "token"}.Watch(ctx, func(vars map[string]string) error {
+				changes <- vars
+				return nil
+			})
+		}()
+
+		// The same value does not call onChange, even though it is an event.
+		waitFor(t, fake.watching)
+		fake.set("/config/app/PORT", "8080")
+		fake.set("/config/app/PORT", "9090")
+
+		if got := waitFor(t, changes); got["PORT"] != "9090" {
+			t.Errorf("Watch() = %v, expected PORT 9090", got)
+		}
+
+		cancel()
+		if err := waitFor(t, done); !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch() error = %v, expected %v", err, context.Canceled)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		cancel   string
+		onChange error
+		expected string
+	}{
+		{name: "onChange error", onChange: errors.New("stop"), expected: "stop"},
+		{name: "Cancelled", cancel: `{"result":{"canceled":true,"cancel_reason":"compacted"}}`, expected: "etcd: watch cancelled: compacted"},
+		{name: "Error message", cancel: `{"error":{"message":"etcdserver: permission denied"}}`, expected: "etcd: etcdserver: permission denied"},
+		{name: "Closed", cancel: "\n", expected: "etcd: watch closed: EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeEtcd(map[string]string{"/config/app/PORT": "8080"})
+			fake.cancel = tt.cancel
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- EtcdSource{Endpoint: server.URL, Prefix: "/config/app/", Token: "token"}.Watch(context.Background(), func(vars map[string]string) error {
+					return tt.onChange
+				})
+			}()
+
+			waitFor(t, fake.watching)
+			fake.set("/config/app/PORT", "9090")
+
+			if err := waitFor(t, done); err == nil || err.Error() != tt.expected {
+				t.Errorf("Watch() error = %v, expected %s", err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Load errors", func(t *testing.T) {
+		fake := newFakeEtcd(map[string]string{"/config/app/PORT": "8080"})
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		onChange := func(vars map[string]string) error { return nil }
+		if err := (EtcdSource{Endpoint: server.URL}).Watch(context.Background(), onChange); err == nil {
+			t.Error("Watch() expected an error for the first load")
+		}
+
+		// The watch API is missing, as with a proxy only forwarding reads.
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/watch") {
+				http.NotFound(w, r)
+				return
+			}
+			fake.ServeHTTP(w, r)
+		}))
+		defer proxy.Close()
+
+		if err := (EtcdSource{Endpoint: proxy.URL, Token: "token"}).Watch(context.Background(), onChange); err == nil {
+			t.Error("Watch() expected an error for the watch request")
+		}
+	})
+
+	t.Run("Reload error", func(t *testing.T) {
+		fake := newFakeEtcd(map[string]string{"/config/app/PORT": "8080"})
+
+		// Range requests fail once the watch has started.
+		var watching sync.Once
+		failing := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-failing:
+				if r.URL.Path == "/v3/kv/range" {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+			default:
+			}
+			if r.URL.Path == "/v3/watch" {
+				watching.Do(func() { close(failing) })
+			}
+			fake.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- EtcdSource{Endpoint: server.URL, Prefix: "/config/app/", Token: "token"}.Watch(context.Background(), func(vars map[string]string) error {
+				return nil
+			})
+		}()
+
+		waitFor(t, fake.watching)
+		fake.set("/config/app/PORT", "9090")
+
+		if err := waitFor(t, done); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Watch() error = %v, expected the status", err)
+		}
+	})
+}
+
+func TestEtcdPrefixRange(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		key      []byte
+		rangeEnd []byte
+	}{
+		{prefix: "", key: []byte{0}, rangeEnd: []byte{0}},
+		{prefix: "/config/", key: []byte("/config/"), rangeEnd: []byte("/config0")},
+		{prefix: "a\xff", key: []byte("a\xff"), rangeEnd: []byte("b")},
+		{prefix: "\xff\xff", key: []byte("\xff\xff"), rangeEnd: []byte{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			key, rangeEnd := etcdPrefixRange(tt.prefix)
+			if !bytes.Equal(key, tt.key) || !bytes.Equal(rangeEnd, tt.rangeEnd) {
+				t.Errorf("etcdPrefixRange() = %q, %q, expected %q, %q", key, rangeEnd, tt.key, tt.rangeEnd)
+			}
+		})
+	}
+}
+
+func BenchmarkEtcdSource_Load(b *testing.B) {
+	server := httptest.NewServer(newFakeEtcd(map[string]string{
+		"/config/app/database/host": "db.internal",
+		"/config/app/PORT":          "8080",
+	}))
+	defer server.Close()
+
+	source := EtcdSource{Endpoint: server.URL, Prefix: "/config/app/", Token: "token"}
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = source.Load(ctx)
+	}
+}
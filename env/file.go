@@ -3,20 +3,37 @@ package env
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode"
 )
 
 type FileOpener func(string) (*os.File, error)
 
+// KeyValue is an entry of a .env file, returned in the order they are declared by ParseFileOrdered and ReadOrdered.
+type KeyValue struct {
+	// Key is the key of the entry, such as "DB_HOST".
+	Key string
+	// Value is the value of the entry, unquoted and with any references expanded.
+	Value string
+}
+
+// DisableFileExpansion turns off the expansion of ${KEY} references within .env file values.
+//
+// Useful for files holding values with a literal $, such as generated passwords.
+// A single value can instead be kept literal by using single quotes, such as PASSWORD='pa$$word'.
+var DisableFileExpansion bool
+
 // ParseFromFilesIntoStruct loads environment variables from a file into a struct.
 //
 // Parameters:
 //   - v: A pointer to a struct containing `env` tags.
-//   - filenames: The filenames to load the environment variables from.
+//   - filenames: The filenames to load the environment variables from, later files take priority.
 //
 // Example:
 //
@@ -26,6 +43,7 @@ type FileOpener func(string) (*os.File, error)
 //
 // Note: If no filenames are provided, it will default to ".env".
 // When successful, the struct referenced by v will be updated.
+// The files are read concurrently, then merged in the order they were given.
 //
 // All processing occurs in ParseWithOpts.
 func ParseFromFilesIntoStruct(v interface{}, filenames ...string) error {
@@ -33,25 +51,16 @@ func ParseFromFilesIntoStruct(v interface{}, filenames ...string) error {
 		filenames = []string{".env"}
 	}
 
-	var err error
-	envMap := make(map[string]string)
-
-	for _, filename := range filenames {
-		var tEnvMap map[string]string
-		if tEnvMap, err = parseFile(filename, os.Open); err != nil {
-			return err
-		}
-
-		for key, val := range tEnvMap {
-			envMap[key] = val
-		}
+	envMaps, err := parseFiles(filenames, os.Open)
+	if err != nil {
+		return err
 	}
 
 	// While this could be used with ParseFromFileIntoStruct, it would error every time a required key is missing.
 	// For example, a .database.env file could be used to load database creds,
 	// but the .env file would determine the database of choice.
 	return ParseWithOpts(v, Options{
-		Env: envMap,
+		Envs: envMaps,
 	})
 }
 
@@ -85,6 +94,47 @@ func ParseFromFileIntoStruct(v interface{}, filename string) error {
 	})
 }
 
+// ParseFromFSIntoStruct loads environment variables from files within an fs.FS into a struct.
+//
+// Useful for .env files bundled with embed.FS, such as default configs or test fixtures,
+// as the OS file system is not used.
+//
+// Parameters:
+//   - fsys: The file system holding the files.
+//   - v: A pointer to a struct containing `env` tags.
+//   - filenames: The paths of the files within fsys, later files take priority.
+//
+// Example:
+//
+//	//go:embed defaults.env
+//	var defaults embed.FS
+//
+//	err := env.ParseFromFSIntoStruct(defaults, &config, "defaults.env")
+//
+// Returns: An error if the parsing fails.
+//
+// Note: If no filenames are provided, it will default to ".env".
+// When successful, the struct referenced by v will be updated.
+func ParseFromFSIntoStruct(fsys fs.FS, v interface{}, filenames ...string) error {
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	envMaps := make([]map[string]string, len(filenames))
+
+	for i, filename := range filenames {
+		tEnvMap, err := parseFSFile(fsys, filename)
+		if err != nil {
+			return err
+		}
+		envMaps[i] = tEnvMap
+	}
+
+	return ParseWithOpts(v, Options{
+		Envs: envMaps,
+	})
+}
+
 // ParseFromFiles loads environment variables from multiple file.
 //
 // It allows for a callback function to be called for each key-value pair, to allow for os.Setenv or to return back the key-value pair.
@@ -99,7 +149,7 @@ func ParseFromFileIntoStruct(v interface{}, filename string) error {
 //		return os.Setenv(key, value)
 //	}, ".env")
 //
-// Note: does not support expanding variables.
+// Note: References such as ${KEY} are expanded, see parseEnvFileBytes.
 func ParseFromFiles(callbackFunc func(key, value string) error, filenames ...string) error {
 	if len(filenames) == 0 {
 		filenames = []string{".env"}
@@ -131,7 +181,7 @@ func ParseFromFiles(callbackFunc func(key, value string) error, filenames ...str
 //		return os.Setenv(key, value)
 //	}, ".env")
 //
-// Note: does not support expanding variables.
+// Note: References such as ${KEY} are expanded, see parseEnvFileBytes.
 func ParseFromFile(callbackFunc func(key, value string) error, filename string) error {
 	var err error
 	var envMap map[string]string
@@ -149,6 +199,46 @@ func ParseFromFile(callbackFunc func(key, value string) error, filename string)
 	return nil
 }
 
+// ParseFileOrdered loads the entries of a file in the order they are declared.
+//
+// Unlike ParseFromFile, a key declared more than once is returned for each entry, so the last entry is the one read.
+// Useful for tooling that rewrites or compares files, where the order matters.
+//
+// Parameters:
+//   - filename: The filename to load the environment variables from.
+//
+// Example:
+//
+//	pairs, err := env.ParseFileOrdered(".env")
+//	for _, pair := range pairs {
+//		fmt.Printf("%s=%s\n", pair.Key, pair.Value)
+//	}
+//
+// Returns: The entries, or an error if the file cannot be read or parsed.
+//
+// Note: References such as ${KEY} are expanded, see parseEnvFileBytes.
+func ParseFileOrdered(filename string) ([]KeyValue, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	pairs, err := ReadOrdered(file)
+	return pairs, withFileName(err, filename)
+}
+
+// ReadOrdered reads the entries of a .env file from an io.Reader in the order they are declared, a line at a time.
+//
+// Parameters:
+//   - r: The io.Reader to read the environment variables from.
+//
+// Returns: The entries, including each entry of a repeated key, or an error if reading or parsing fails.
+func ReadOrdered(r io.Reader) ([]KeyValue, error) {
+	return newTokenizer(r).parseOrdered()
+}
+
 // parseFile loads environment variables from a file into a map.
 //
 // Opener is required, as it allows for testing.
@@ -168,32 +258,96 @@ func parseFile(filename string, opener FileOpener) (map[string]string, error) {
 	envMap, err = readWithIO(file)
 
 	if err != nil {
-		return nil, err
+		return nil, withFileName(err, filename)
 	}
 
 	return envMap, nil
 }
 
-// readWithIO reads the environment variables from an io.Reader, calling parseEnvFileBytes.
+// parseFiles loads environment variables from several files concurrently, each into its own map.
+//
+// At most GOMAXPROCS files are read at once, such as for a directory of per-service files.
 //
 // Parameters:
-//   - r: The io.Reader to read the environment variables from.
+//   - filenames: The filenames to load the environment variables from.
+//   - opener: The function opening each file, such as os.Open.
+//
+// Returns: The map of each file in the order of filenames, so they merge the same way every time.
+// Or the error of the first file in that order which could not be loaded.
+func parseFiles(filenames []string, opener FileOpener) ([]map[string]string, error) {
+	envMaps := make([]map[string]string, len(filenames))
+	errs := make([]error, len(filenames))
+
+	workers := min(len(filenames), runtime.GOMAXPROCS(0))
+	if workers <= 1 {
+		for i, filename := range filenames {
+			if envMaps[i], errs[i] = parseFile(filename, opener); errs[i] != nil {
+				return nil, errs[i]
+			}
+		}
+		return envMaps, nil
+	}
+
+	// Each worker takes the next file, so a slow file does not hold up the others.
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1)) - 1; i < len(filenames); i = int(next.Add(1)) - 1 {
+				envMaps[i], errs[i] = parseFile(filenames[i], opener)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return envMaps, nil
+}
+
+// parseFSFile loads environment variables from a file within an fs.FS into a map.
 //
-// Returns: The map of environment variables and an error if the reading fails.
-func readWithIO(r io.Reader) (map[string]string, error) {
-	var buf bytes.Buffer
-	_, err := io.Copy(&buf, r)
+// Parameters:
+//   - fsys: The file system holding the file, such as an embed.FS.
+//   - filename: The path of the file within fsys, such as "config/.env".
+func parseFSFile(fsys fs.FS, filename string) (map[string]string, error) {
+	file, err := fsys.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	var envMap map[string]string
-	envMap, err = parseEnvFileBytes(bytes.Replace(buf.Bytes(), []byte("\r\n"), []byte("\n"), -1))
+	defer file.Close()
+
+	envMap, err := readWithIO(file)
 	if err != nil {
-		return nil, err
+		return nil, withFileName(err, filename)
 	}
 
-	return envMap, err
+	return envMap, nil
+}
+
+// withFileName sets the file of a *SyntaxError, so it can be found when several files are loaded.
+func withFileName(err error, filename string) error {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		syntaxErr.File = filename
+	}
+	return err
+}
+
+// readWithIO reads the environment variables from an io.Reader, a line at a time.
+//
+// Parameters:
+//   - r: The io.Reader to read the environment variables from.
+//
+// Returns: The map of environment variables and an error if the reading fails.
+func readWithIO(r io.Reader) (map[string]string, error) {
+	return newTokenizer(r).parse()
 }
 
 // parseEnvFileBytes parses the environment variables from a byte slice.
@@ -202,31 +356,11 @@ func readWithIO(r io.Reader) (map[string]string, error) {
 //   - src: The byte slice to parse the environment variables from.
 //
 // Returns: The map of environment variables and an error if the parsing fails.
+//
+// Note: Lines are read as slices of src, see tokenizer. A UTF-16 file is decoded into a copy first, see decodeBytes.
 func parseEnvFileBytes(src []byte) (map[string]string, error) {
-	envMap := make(map[string]string)
-
-	if len(src) == 0 {
-		return envMap, errors.New("empty file")
-	}
-
-	for {
-		src = getStart(src)
-		if src == nil {
-			return envMap, nil
-		}
-
-		var key string
-		var value string
-		var err error
-
-		key, value, src, err = getKeyValue(src)
-
-		if err != nil {
-			return nil, err
-		}
-
-		envMap[key] = value
-	}
+	t := tokenizer{src: decodeBytes(src)}
+	return t.parse()
 }
 
 // getStart returns position of the first non-whitespace character
@@ -268,13 +402,14 @@ func getStart(src []byte) []byte {
 //
 // Parameters:
 //   - src: The source to search for the key-value pair.
+//   - expand: The lookup used to expand references within the value, nil to keep it as written.
 //
 // Returns:
 //   - The key.
 //   - The value.
 //   - The remaining bytes after the key-value pair.
 //   - An error if the key-value pair is invalid.
-func getKeyValue(src []byte) (string, string, []byte, error) {
+func getKeyValue(src []byte, expand func(string) string) (string, string, []byte, error) {
 	var key string
 	var value string
 	var err error
@@ -286,15 +421,81 @@ func getKeyValue(src []byte) (string, string, []byte, error) {
 		return "", "", src, err
 	}
 
+	// Single quoted values are literal, as within a shell.
+	literal := len(src) > 0 && src[0] == CharSingleQuote
+	quoted := len(src) > 0 && src[0] == CharDoubleQuote
+	start := src
+
 	value, src, err = getValue(src)
 
 	if err != nil {
 		return "", "", nil, err
 	}
 
+	if expand != nil && quoted {
+		// The contents are expanded as written, so an escaped \$ is kept as a literal $.
+		if value, err = expandQuoted(start[1:len(start)-len(src)-1], expand); err != nil {
+			return "", "", nil, err
+		}
+	} else if expand != nil && !literal {
+		if value, err = expandWith(value, expand); err != nil {
+			return "", "", nil, err
+		}
+	}
+
 	return key, value, src, nil
 }
 
+// expandQuoted unescapes and expands the contents of a double quoted value, keeping each \$ as a literal $.
+//
+// Parameters:
+//   - raw: The contents between the quotes, as written.
+//   - expand: The lookup used to expand references within the value.
+//
+// Returns: The value, or an error if a reference such as ${KEY:?message} is not set.
+func expandQuoted(raw []byte, expand func(string) string) (string, error) {
+	var builder strings.Builder
+
+	for {
+		// Each part before an escaped $ is expanded on its own, so the $ never starts a reference.
+		i := 0
+		for {
+			n := bytes.IndexByte(raw[i:], '$')
+			if n == -1 {
+				i = -1
+				break
+			}
+			i += n
+			if isEscaped(raw[:i]) {
+				break
+			}
+			i++
+		}
+
+		part := raw
+		if i != -1 {
+			// The backslash escaping the $ is dropped.
+			part = raw[:i-1]
+		}
+
+		value := string(part)
+		if bytes.IndexByte(part, '\\') != -1 {
+			value = unescapeQuotes(part)
+		}
+		expanded, err := expandWith(value, expand)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(expanded)
+
+		if i == -1 {
+			return builder.String(), nil
+		}
+		builder.WriteByte('$')
+		raw = raw[i+1:]
+	}
+}
+
 // getValue returns the value and remaining bytes after the value for getKeyValue.
 //
 // Parameters:
@@ -326,31 +527,41 @@ func getValue(src []byte) (string, []byte, error) {
 //   - An error if the value is invalid.
 func getValueWithinQuotes(src []byte, quote byte) (string, []byte, error) {
 	for i := 1; i < len(src); i++ {
-		if src[i] != quote {
-			continue
+		n := bytes.IndexByte(src[i:], quote)
+		if n == -1 {
+			break
 		}
+		i += n
 
-		// If previous char is \, it's an escaped quote
-		// This is for sure as this current loop iteration is not a confirmed matching quote
-		if src[i-1] == '\\' {
-			fmt.Println("escaped quote")
+		// If it's preceded by an odd number of \, it's an escaped quote.
+		// An even number is escaped backslashes, such as "C:\\" ending with a backslash.
+		if isEscaped(src[1:i]) {
 			continue
 		}
 
-		isQuote := func(r rune) bool {
-			return r == rune(quote)
+		// The value is only copied once, unescaping it as it is copied.
+		if quote == CharDoubleQuote && bytes.IndexByte(src[1:i], '\\') != -1 {
+			return unescapeQuotes(src[1:i]), src[i+1:], nil
 		}
 
-		value := string(bytes.TrimLeftFunc(bytes.TrimRightFunc(src[0:i], isQuote), isQuote))
+		return string(src[1:i]), src[i+1:], nil
+	}
 
-		if quote == CharDoubleQuote {
-			value = unescapeQuotes(value)
-		}
+	return "", nil, errUnterminatedQuote
+}
 
-		return value, src[i+1:], nil
+// isEscaped checks if the character after s is escaped, by counting the backslashes at the end of s.
+//
+// Parameters:
+//   - s: The bytes before the character.
+//
+// Returns: True if there is an odd number of trailing backslashes.
+func isEscaped(s []byte) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
 	}
-
-	return "", nil, errors.New("unterminated closing quote")
+	return n%2 == 1
 }
 
 // unescapeQuotes unescapes quotes in a string, such as \n and \r.
@@ -358,10 +569,10 @@ func getValueWithinQuotes(src []byte, quote byte) (string, []byte, error) {
 // This could be done with regex, but it was seen with a 161% performance improvement.
 //
 // Parameters:
-//   - s: The string to unescape quotes from.
+//   - s: The bytes to unescape quotes from.
 //
 // Returns: The string with unescaped quotes.
-func unescapeQuotes(s string) string {
+func unescapeQuotes(s []byte) string {
 	var builder strings.Builder
 
 	// Pre-allocate the builder with the length of the input string
@@ -400,8 +611,8 @@ func unescapeQuotes(s string) string {
 func getValueWithoutQuotes(src []byte) (string, []byte, error) {
 	endOfLine := findEndOfLine(src)
 	if endOfLine == 0 {
-		// Empty line or end of file
-		return "", nil, nil
+		// An empty value, the following lines may still hold entries.
+		return "", src, nil
 	}
 
 	line := src[:endOfLine]
@@ -432,14 +643,22 @@ func findEndOfLine(src []byte) int {
 //
 // Returns: The value.
 func extractValueFromLine(line []byte) string {
-	endOfVar := len(line)
-	for i := 1; i < endOfVar; i++ {
+	return string(bytes.TrimFunc(line[:commentIndex(line)], isSpace))
+}
+
+// commentIndex returns the position of the comment after an unquoted value, a # following a space.
+//
+// Parameters:
+//   - line: The line, starting at the value.
+//
+// Returns: The position of the #, or the length of the line if it has no comment.
+func commentIndex(line []byte) int {
+	for i := 1; i < len(line); i++ {
 		if line[i] == CharComment && isSpace(rune(line[i-1])) {
-			endOfVar = i
-			break
+			return i
 		}
 	}
-	return string(bytes.TrimFunc(line[:endOfVar], isSpace))
+	return len(line)
 }
 
 // getKey returns the key and remaining bytes after the key for getKeyValue.
@@ -453,6 +672,7 @@ func extractValueFromLine(line []byte) string {
 //   - An error if the key is invalid.
 func getKey(src []byte) (string, []byte, error) {
 	src = bytes.TrimLeftFunc(src, isSpace) // Trim leading spaces
+	src = trimExport(src)
 	key, remaining, err := extractKey(src)
 	if err != nil {
 		return "", remaining, err
@@ -464,6 +684,27 @@ func getKey(src []byte) (string, []byte, error) {
 	return key, remaining, nil
 }
 
+// trimExport removes a leading "export " from the line, as used by .env files that are also sourced by shells.
+//
+// Parameters:
+//   - src: The source, starting at the key.
+//
+// Returns: The source starting at the key, after any "export" and the spaces or tabs that follow it.
+func trimExport(src []byte) []byte {
+	const export = "export"
+
+	if len(src) <= len(export) || !bytes.HasPrefix(src, []byte(export)) {
+		return src
+	}
+
+	// It must be followed by a space or tab on the same line, such as "export KEY=value".
+	if c := src[len(export)]; c != ' ' && c != '\t' {
+		return src
+	}
+
+	return bytes.TrimLeft(src[len(export):], " \t")
+}
+
 // extractKey extracts the key and remaining bytes after the separator.
 //
 // Parameters:
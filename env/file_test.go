@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 // TestParseGeneral tests the getKeyValue function with various valid and invalid key-value pairs.
 //
-// There is no "export KEY=VAL" support refer to https://forum.djangoproject.com/t/env-files-and-export/11059
+// A leading "export " is ignored, see https://forum.djangoproject.com/t/env-files-and-export/11059
 func TestParseGeneral(t *testing.T) {
 	validMatches := map[string]map[string]string{
 		"FOO=bar":            {"FOO": "bar"},
@@ -45,6 +50,9 @@ func TestParseGeneral(t *testing.T) {
 		"   KEY=value":                 {"KEY": "value"},
 		"\tKEY=value":                  {"KEY": "value"},
 		"FOO.BAR=foobar":               {"FOO.BAR": "foobar"}, // While dots should not be allowed
+		"export FOO=bar":               {"FOO": "bar"},
+		"EMPTY=\nFOO=bar":              {"EMPTY": "", "FOO": "bar"},
+		`  export FOO="bar baz"`:       {"FOO": "bar baz"},
 	}
 
 	invalidMatches := []string{
@@ -60,7 +68,7 @@ func TestParseGeneral(t *testing.T) {
 
 	for src, expected := range validMatches {
 		t.Run(fmt.Sprintf("Valid: %s", src), func(t *testing.T) {
-			key, val, _, err := getKeyValue([]byte(src))
+			key, val, _, err := getKeyValue([]byte(src), nil)
 			if err != nil {
 				t.Errorf("Expected no error, got %v", err)
 				return
@@ -80,7 +88,7 @@ func TestParseGeneral(t *testing.T) {
 
 	for _, src := range invalidMatches {
 		t.Run(fmt.Sprintf("Invalid: %s", src), func(t *testing.T) {
-			key, val, _, err := getKeyValue([]byte(src))
+			key, val, _, err := getKeyValue([]byte(src), nil)
 			if err == nil && key != "" && val != "" {
 				t.Errorf("Expected error, got %s=%s", key, val)
 			}
@@ -192,6 +200,61 @@ func TestParseFromFiles(t *testing.T) {
 	}
 }
 
+func TestParseFiles(t *testing.T) {
+	contents := make([]string, 50)
+	for i := range contents {
+		contents[i] = fmt.Sprintf("KEY=%d\nFILE_%d=%d", i, i, i)
+	}
+	paths := writeEnvFiles(t, contents...)
+	missing := filepath.Join(t.TempDir(), "missing.env")
+	other := filepath.Join(t.TempDir(), "other.env")
+
+	tests := []struct {
+		name      string
+		filenames []string
+		procs     int
+		wantErr   string
+	}{
+		{name: "Concurrent", filenames: paths, procs: 4},
+		{name: "Sequential", filenames: paths, procs: 1},
+		{name: "Single file", filenames: paths[:1]},
+		{name: "First error in order", filenames: append([]string{paths[0], missing, other}, paths[1:]...), procs: 4, wantErr: missing},
+		{name: "Sequential error", filenames: []string{paths[0], missing, other}, procs: 1, wantErr: missing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.procs > 0 {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(tt.procs))
+			}
+
+			envMaps, err := parseFiles(tt.filenames, os.Open)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFiles() error = %v, expected %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFiles() error = %v", err)
+			}
+
+			if len(envMaps) != len(tt.filenames) {
+				t.Fatalf("parseFiles() = %d maps, expected %d", len(envMaps), len(tt.filenames))
+			}
+			for i, envMap := range envMaps {
+				if expected := strconv.Itoa(i); envMap["KEY"] != expected || envMap["FILE_"+expected] != expected {
+					t.Errorf("parseFiles()[%d] = %v, expected the map of file %d", i, envMap, i)
+				}
+			}
+
+			if merged := MergeMaps(envMaps...); merged["KEY"] != strconv.Itoa(len(tt.filenames)-1) {
+				t.Errorf("MergeMaps() KEY = %s, expected the last file to take priority", merged["KEY"])
+			}
+		})
+	}
+}
+
 func TestParseFromFilesIntoStruct(t *testing.T) {
 	type testStruct struct {
 		String         string  `env:"STRING"`
@@ -253,6 +316,44 @@ OPTIONAL_STRING=optional`,
 	}
 }
 
+func TestParseFromFSIntoStruct(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+
+	fsys := fstest.MapFS{
+		".env":              {Data: []byte("HOST=localhost\nPORT=8080")},
+		"config/prod.env":   {Data: []byte("HOST=db.prod")},
+		"config/broken.env": {Data: []byte("HOST=ok\nPORT='8080")},
+	}
+
+	tests := []struct {
+		name      string
+		filenames []string
+		expected  Config
+		wantErr   string
+	}{
+		{name: "Default file", expected: Config{Host: "localhost", Port: 8080}},
+		{name: "Later files take priority", filenames: []string{".env", "config/prod.env"}, expected: Config{Host: "db.prod", Port: 8080}},
+		{name: "Missing file", filenames: []string{"missing.env"}, wantErr: "open missing.env: file does not exist"},
+		{name: "Syntax error", filenames: []string{".env", "config/broken.env"}, wantErr: "config/broken.env:2:1: unterminated closing quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseFromFSIntoStruct(fsys, &cfg, tt.filenames...)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Fatalf("ParseFromFSIntoStruct() error = %v, expected %q", err, tt.wantErr)
+			}
+			if tt.wantErr == "" && cfg != tt.expected {
+				t.Errorf("ParseFromFSIntoStruct() = %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
 func TestParseFromFileIntoStruct(t *testing.T) {
 	type testStruct struct {
 		String         string  `env:"STRING"`
@@ -540,6 +641,123 @@ func TestParseEnvFileBytes(t *testing.T) {
 	}
 }
 
+func TestParseEnvFileBytes_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected SyntaxError
+	}{
+		{
+			name:     "Unterminated quote",
+			input:    "A=1\n# comment\n\nB='value\nC=3",
+			expected: SyntaxError{Line: 4, Col: 1, Msg: "unterminated closing quote"},
+		},
+		{
+			name:     "Indented entry",
+			input:    "A=1\n   b=2",
+			expected: SyntaxError{Line: 2, Col: 4, Msg: "invalid key: must start with a capital letter"},
+		},
+		{
+			name:     "First line",
+			input:    "KEY value",
+			expected: SyntaxError{Line: 1, Col: 1, Msg: "key-value separator not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEnvFileBytes([]byte(tt.input))
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("parseEnvFileBytes() error = %v, expected a *SyntaxError", err)
+			}
+			if *syntaxErr != tt.expected {
+				t.Errorf("parseEnvFileBytes() error = %+v, expected %+v", *syntaxErr, tt.expected)
+			}
+			if msg := fmt.Sprintf("%d:%d: %s", tt.expected.Line, tt.expected.Col, tt.expected.Msg); err.Error() != msg {
+				t.Errorf("Error() = %q, expected %q", err.Error(), msg)
+			}
+		})
+	}
+
+	t.Run("File name", func(t *testing.T) {
+		path := t.TempDir() + "/.env"
+		if err := os.WriteFile(path, []byte("A=1\r\nB=\"2"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := parseFile(path, os.Open)
+		if err == nil || err.Error() != path+":2:1: unterminated closing quote" {
+			t.Errorf("parseFile() error = %v, expected the file, line and column", err)
+		}
+	})
+}
+
+func TestParseEnvFileBytes_Expansion(t *testing.T) {
+	t.Setenv("FILE_EXPANSION_HOME", "/home/app")
+
+	tests := []struct {
+		name     string
+		input    string
+		disable  bool
+		expected map[string]string
+		wantErr  string
+	}{
+		{
+			name:     "Earlier key",
+			input:    "HOST=db\nURL=postgres://${HOST}:5432/$HOST",
+			expected: map[string]string{"HOST": "db", "URL": "postgres://db:5432/db"},
+		},
+		{
+			name:     "Process environment",
+			input:    `DIR="${FILE_EXPANSION_HOME}/data"`,
+			expected: map[string]string{"DIR": "/home/app/data"},
+		},
+		{
+			name:     "File before process environment",
+			input:    "FILE_EXPANSION_HOME=/srv\nDIR=${FILE_EXPANSION_HOME}/data",
+			expected: map[string]string{"FILE_EXPANSION_HOME": "/srv", "DIR": "/srv/data"},
+		},
+		{
+			name:     "Later key",
+			input:    "URL=${HOST:-localhost}\nHOST=db",
+			expected: map[string]string{"URL": "localhost", "HOST": "db"},
+		},
+		{
+			name:     "Single quotes are literal",
+			input:    "PASSWORD='pa$$word${HOST}'",
+			expected: map[string]string{"PASSWORD": "pa$$word${HOST}"},
+		},
+		{
+			name:     "Disabled",
+			input:    "HOST=db\nURL=${HOST}",
+			disable:  true,
+			expected: map[string]string{"HOST": "db", "URL": "${HOST}"},
+		},
+		{
+			name:    "Required reference",
+			input:   "A=1\nURL=${FILE_EXPANSION_MISSING:?must be set}",
+			wantErr: "2:1: FILE_EXPANSION_MISSING: must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			DisableFileExpansion = tt.disable
+			defer func() { DisableFileExpansion = false }()
+
+			result, err := parseEnvFileBytes([]byte(tt.input))
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Fatalf("parseEnvFileBytes() error = %v, expected %q", err, tt.wantErr)
+			}
+			if tt.wantErr == "" && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseEnvFileBytes() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetStart(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -661,7 +879,7 @@ func TestGetKeyValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			key, value, _, err := getKeyValue(tt.input)
+			key, value, _, err := getKeyValue(tt.input, nil)
 			if tt.expectErr && err == nil {
 				t.Errorf("Expected error, got nil")
 			}
@@ -711,6 +929,22 @@ func TestGetValueWithinQuotes(t *testing.T) {
 			remaining: []byte{},
 			expectErr: false,
 		},
+		{
+			name:      "Escaped double quote at the end",
+			input:     []byte(`"say \"hi\""`),
+			quote:     '"',
+			expected:  `say "hi"`,
+			remaining: []byte{},
+			expectErr: false,
+		},
+		{
+			name:      "Escaped backslash at the end",
+			input:     []byte(`"C:\\"`),
+			quote:     '"',
+			expected:  `C:\`,
+			remaining: []byte{},
+			expectErr: false,
+		},
 		{
 			name:      "Unterminated double quote",
 			input:     []byte(`"value`),
@@ -788,7 +1022,7 @@ func TestUnescapeQuotes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := unescapeQuotes(tt.input)
+			result := unescapeQuotes([]byte(tt.input))
 			if result != tt.expected {
 				t.Errorf("Expected %s, got %s", tt.expected, result)
 			}
@@ -829,7 +1063,7 @@ func TestGetValueWithoutQuotes(t *testing.T) {
 			name:      "Empty value",
 			input:     []byte("\n"),
 			expected:  "",
-			remaining: []byte(""),
+			remaining: []byte("\n"),
 			expectErr: false,
 		},
 		{
@@ -882,6 +1116,34 @@ func TestGetKey(t *testing.T) {
 			expectedRem: []byte("value"),
 			expectErr:   false,
 		},
+		{
+			name:        "Export",
+			input:       []byte("export KEY=value"),
+			expectedKey: "KEY",
+			expectedRem: []byte("value"),
+			expectErr:   false,
+		},
+		{
+			name:        "Export with tabs",
+			input:       []byte("export\t\t KEY=value"),
+			expectedKey: "KEY",
+			expectedRem: []byte("value"),
+			expectErr:   false,
+		},
+		{
+			name:        "Export as a key",
+			input:       []byte("export=value"),
+			expectedKey: "",
+			expectedRem: []byte("value"),
+			expectErr:   true,
+		},
+		{
+			name:        "Key starting with export",
+			input:       []byte("exporter=value"),
+			expectedKey: "",
+			expectedRem: []byte("value"),
+			expectErr:   true,
+		},
 		{
 			name:        "Invalid key",
 			input:       []byte("key=value"),
@@ -926,7 +1188,7 @@ func TestGetKey(t *testing.T) {
 
 func BenchmarkUnescapeQuotes(b *testing.B) {
 	// Input string for the benchmark
-	input := `Line1\nLine2\rLine3\\nLine4\\rEnd`
+	input := []byte(`Line1\nLine2\rLine3\\nLine4\\rEnd`)
 	for i := 0; i < b.N; i++ {
 		unescapeQuotes(input)
 	}
@@ -1037,3 +1299,95 @@ KEY5=value5`
 		}
 	}
 }
+
+// largeEnvFile returns a .env file of several megabytes, mixing comments, quoted, multi-line and expanded values.
+func largeEnvFile() []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < 4<<20; i++ {
+		fmt.Fprintf(&buf, "# Entry %d\n", i)
+		fmt.Fprintf(&buf, "KEY_%d=value_%d # comment\n", i, i)
+		fmt.Fprintf(&buf, "export QUOTED_%d=\"quoted \\\"value\\\" %d\"\n", i, i)
+		fmt.Fprintf(&buf, "LITERAL_%d='pa$$word'\r\n", i)
+		fmt.Fprintf(&buf, "MULTI_%d=\"first line\nsecond line\"\n", i)
+		fmt.Fprintf(&buf, "URL_%d=http://${KEY_%d}:8080\n\n", i, i)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkReadWithIO(b *testing.B) {
+	src := largeEnvFile()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := readWithIO(bytes.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseEnvFileBytes(b *testing.B) {
+	src := largeEnvFile()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parseEnvFileBytes(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseFromFileIntoStruct_Large(b *testing.B) {
+	src := largeEnvFile()
+	filename := createTempFileBenchmark(b, string(src))
+	defer os.Remove(filename)
+
+	type testStruct struct {
+		Key  string `env:"KEY_0"`
+		URL  string `env:"URL_0"`
+		Last string `env:"MULTI_1000"`
+	}
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var test testStruct
+		if err := ParseFromFileIntoStruct(&test, filename); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseFromFilesIntoStruct_ManyFiles(b *testing.B) {
+	type testStruct struct {
+		Service string `env:"SERVICE"`
+		Port    int    `env:"PORT"`
+	}
+
+	for _, count := range []int{1, 16, 128} {
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			dir := b.TempDir()
+			filenames := make([]string, count)
+			for i := range filenames {
+				filenames[i] = filepath.Join(dir, fmt.Sprintf("service%d.env", i))
+				content := fmt.Sprintf("SERVICE=service%d\nPORT=%d\nKEY1=value1\nKEY2=value2\nKEY3=value3", i, 8000+i)
+				if err := os.WriteFile(filenames[i], []byte(content), 0o600); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var test testStruct
+				if err := ParseFromFilesIntoStruct(&test, filenames...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
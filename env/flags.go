@@ -0,0 +1,353 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cloudment/utils-go/internal/strcase"
+)
+
+// DefaultFlagPrefix is the prefix of feature flag environment variables, such as FEATURE_NEW_CHECKOUT.
+const DefaultFlagPrefix = "FEATURE_"
+
+// flagBuckets is the number of buckets keys are hashed into, allowing percentages to two decimal places.
+const flagBuckets = 10000
+
+// flagKind is the type of value a feature flag holds.
+type flagKind int
+
+const (
+	flagBool flagKind = iota
+	flagPercentage
+	flagVariant
+)
+
+// flag is a parsed feature flag.
+type flag struct {
+	kind    flagKind
+	enabled bool
+	// buckets is the number of buckets enabled by a percentage flag.
+	buckets uint32
+	// variants are the variant names, with weights for weighted variants.
+	variants []string
+	weights  []uint32
+	total    uint32
+}
+
+// Flags is a set of feature flags parsed from environment variables.
+//
+// Values may be a bool such as "true" or "off", a percentage such as "25%" for a gradual rollout,
+// a variant such as "blue", or weighted variants such as "control:50,treatment:50".
+// A value is only weighted when every part is a name and a whole number weight, so a variant such as
+// "redis://cache:6379" is kept as it is.
+//
+// Flags is safe for concurrent use, and Load can be called again to reload the flags without a restart,
+// or Watch used to reload them from .env files. The zero value has no flags and uses DefaultFlagPrefix.
+type Flags struct {
+	prefix string
+	flags  atomic.Pointer[map[string]flag]
+}
+
+// NewFlags creates a flag set from the environment variables with the prefix.
+//
+// Parameters:
+//
+//   - prefix: The prefix of flag variables, an empty prefix uses DefaultFlagPrefix.
+//
+// Returns: The Flags, or an error if a flag has an invalid value.
+//
+// Example:
+//
+//	// FEATURE_NEW_CHECKOUT=25%
+//	// FEATURE_BUTTON_COLOUR=control:50,green:50
+//	flags, err := env.NewFlags("")
+//
+//	if flags.IsEnabled("new-checkout", user.ID) {
+//		...
+//	}
+//	colour := flags.Variant("button-colour", user.ID)
+func NewFlags(prefix string) (*Flags, error) {
+	if prefix == "" {
+		prefix = DefaultFlagPrefix
+	}
+
+	f := &Flags{prefix: prefix}
+	if err := f.Load(ToMap(os.Environ())); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Load replaces the flags with those within the environment variables.
+//
+// It is used for hot reloading, such as after re-reading a .env file. Flags being read during a reload
+// see either the old or the new set, never a mix.
+//
+// Parameters:
+//
+//   - env: The environment variables, only those with the prefix are used.
+//
+// Returns: An error if a flag has an invalid value, in which case the previous flags are kept.
+func (f *Flags) Load(env map[string]string) error {
+	prefix := f.prefix
+	if prefix == "" {
+		prefix = DefaultFlagPrefix
+	}
+
+	flags := make(map[string]flag)
+
+	for key, val := range env {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok || name == "" {
+			continue
+		}
+
+		parsed, err := parseFlag(val)
+		if err != nil {
+			return fmt.Errorf("invalid feature flag %s: %w", key, err)
+		}
+		flags[name] = parsed
+	}
+
+	f.flags.Store(&flags)
+	return nil
+}
+
+// Watch reloads the flags from .env files whenever they change, so a rollout can be changed without a restart.
+//
+// The files are polled every WatchInterval, as Watch does for a struct. The flags are those of the process
+// environment, with the files taking priority, so a flag removed from the files falls back to the process.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - filenames: The filenames to load the environment variables from, later files take priority.
+//   - onError: Called with each change that cannot be read or parsed, or has an invalid flag, or nil to ignore them.
+//
+// Returns: The error of the context once it is done, or an error if the files could not be read or parsed, or hold
+// an invalid flag, before watching starts.
+//
+// Example:
+//
+//	flags, _ := env.NewFlags("")
+//	go func() {
+//		_ = flags.Watch(ctx, []string{"/etc/flags/.env"}, func(err error) {
+//			slog.Warn("keeping the last flags", "error", err)
+//		})
+//	}()
+//
+// Note: A change that cannot be used keeps the last good flags, and is reported once until the files change again.
+func (f *Flags) Watch(ctx context.Context, filenames []string, onError func(err error)) error {
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	contents, err := readWatchedFiles(filenames, false)
+	if err != nil {
+		return err
+	}
+	if err = f.loadWatchedFiles(filenames, contents); err != nil {
+		return err
+	}
+
+	return pollWatchedFiles(ctx, filenames, contents, func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}, func(latest [][]byte) error {
+		if err := f.loadWatchedFiles(filenames, latest); err != nil && onError != nil {
+			onError(err)
+		}
+		return nil
+	})
+}
+
+// loadWatchedFiles loads the flags of the process environment and the contents of the files, which take priority.
+func (f *Flags) loadWatchedFiles(filenames []string, contents [][]byte) error {
+	envMaps, err := parseWatchedEnvs(filenames, contents)
+	if err != nil {
+		return err
+	}
+
+	return f.Load(MergeMaps(append([]map[string]string{ToMap(os.Environ())}, envMaps...)...))
+}
+
+// IsEnabled checks if the flag is enabled for the key, such as a user or tenant ID.
+//
+// Percentage flags hash the flag name and key, so a key always gets the same result,
+// and raising the percentage only adds keys. Variant flags are enabled unless the variant is "off".
+//
+// Parameters:
+//
+//   - name: The flag name in any case, "new-checkout" and "newCheckout" both read FEATURE_NEW_CHECKOUT.
+//   - key: The key to roll out by, only used by percentage and weighted variant flags.
+//
+// Returns: True if the flag is enabled, false if it is disabled or not set.
+func (f *Flags) IsEnabled(name, key string) bool {
+	fl, ok := f.lookup(name)
+	if !ok {
+		return false
+	}
+
+	switch fl.kind {
+	case flagPercentage:
+		return flagBucket(name, key) < fl.buckets
+	case flagVariant:
+		return fl.pick(name, key) != "off"
+	default:
+		return fl.enabled
+	}
+}
+
+// Variant returns the variant of the flag for the key.
+//
+// Parameters:
+//
+//   - name: The flag name in any case.
+//   - key: The key to assign a weighted variant by.
+//
+// Returns: The variant, or an empty string if the flag is not set or is not a variant flag.
+func (f *Flags) Variant(name, key string) string {
+	fl, ok := f.lookup(name)
+	if !ok || fl.kind != flagVariant {
+		return ""
+	}
+
+	return fl.pick(name, key)
+}
+
+// Names returns the names of the flags that are set, without the prefix and sorted.
+func (f *Flags) Names() []string {
+	flags := f.loaded()
+
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookup finds a flag by name, converting the name to SCREAMING_SNAKE_CASE.
+func (f *Flags) lookup(name string) (flag, bool) {
+	fl, ok := f.loaded()[strcase.ToScreamingSnake(name)]
+	return fl, ok
+}
+
+// loaded returns the flags of the last Load, or nil if they have never been loaded.
+func (f *Flags) loaded() map[string]flag {
+	if flags := f.flags.Load(); flags != nil {
+		return *flags
+	}
+	return nil
+}
+
+// pick chooses the variant for the key, by weight if the variants are weighted.
+func (fl flag) pick(name, key string) string {
+	if fl.total == 0 {
+		return fl.variants[0]
+	}
+
+	// Scaling the bucket keeps assignments stable when the weights do not change.
+	target := uint32(uint64(flagBucket(name, key)) * uint64(fl.total) / flagBuckets)
+	for i, weight := range fl.weights {
+		if target < weight {
+			return fl.variants[i]
+		}
+		target -= weight
+	}
+
+	return fl.variants[len(fl.variants)-1]
+}
+
+// flagBucket hashes the flag name and key into a bucket between 0 and flagBuckets.
+//
+// The name is included so that the same key is not always in the first percent of every rollout.
+func flagBucket(name, key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strcase.ToScreamingSnake(name)))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum32() % flagBuckets
+}
+
+// parseFlag parses a flag value.
+//
+// Parameters:
+//
+//   - val: The value, such as "true", "25%", "blue" or "control:50,treatment:50".
+//
+// Returns: The parsed flag, or an error if a percentage is invalid, or weighted variants lack a name or any weight,
+// or their total weight does not fit within a uint32.
+func parseFlag(val string) (flag, error) {
+	val = strings.TrimSpace(val)
+
+	switch strings.ToLower(val) {
+	case "true", "on", "yes", "1", "enabled":
+		return flag{kind: flagBool, enabled: true}, nil
+	case "false", "off", "no", "0", "disabled", "":
+		return flag{kind: flagBool}, nil
+	}
+
+	if pct, ok := strings.CutSuffix(val, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || p < 0 || p > 100 {
+			return flag{}, fmt.Errorf("percentage must be between 0%% and 100%%, got %q", val)
+		}
+		return flag{kind: flagPercentage, buckets: uint32(p * flagBuckets / 100)}, nil
+	}
+
+	parts := strings.Split(val, ",")
+	if !isWeighted(parts) {
+		return flag{kind: flagVariant, variants: []string{val}}, nil
+	}
+
+	fl := flag{kind: flagVariant}
+	for _, part := range parts {
+		name, weight, _ := strings.Cut(part, ":")
+		w, err := strconv.ParseUint(strings.TrimSpace(weight), 10, 32)
+		name = strings.TrimSpace(name)
+		if err != nil || name == "" {
+			return flag{}, fmt.Errorf(`weighted variants should be in "name:weight,name:weight" format, got %q`, part)
+		}
+
+		// The total is checked as it grows, so it cannot wrap around and leave later variants unreachable.
+		if uint64(fl.total)+w > math.MaxUint32 {
+			return flag{}, fmt.Errorf("weighted variants must have a total weight of at most %d, got %q", uint32(math.MaxUint32), val)
+		}
+
+		fl.variants = append(fl.variants, name)
+		fl.weights = append(fl.weights, uint32(w))
+		fl.total += uint32(w)
+	}
+
+	if fl.total == 0 {
+		return flag{}, fmt.Errorf("weighted variants must have a total weight above 0, got %q", val)
+	}
+
+	return fl, nil
+}
+
+// isWeighted reports whether every part of a flag value is a name and a weight, such as "control:50".
+//
+// The name ends at the first ':', so a URL such as "redis://cache:6379" is not weighted.
+func isWeighted(parts []string) bool {
+	for _, part := range parts {
+		_, weight, ok := strings.Cut(part, ":")
+		if !ok {
+			return false
+		}
+		if _, err := strconv.ParseUint(strings.TrimSpace(weight), 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
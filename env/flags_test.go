@@ -0,0 +1,250 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	t.Setenv("FEATURE_NEW_CHECKOUT", "true")
+	t.Setenv("FEATURE_DARK_MODE", "off")
+	t.Setenv("FEATURE_HALF", "50%")
+	t.Setenv("FEATURE_NONE", "0%")
+	t.Setenv("FEATURE_ALL", "100%")
+	t.Setenv("FEATURE_COLOUR", "blue")
+	t.Setenv("FEATURE_DISABLED_VARIANT", "off:1")
+	t.Setenv("FEATURE_SPLIT", "control:50,treatment:50")
+	t.Setenv("FEATURE_BACKEND", "redis://cache:6379")
+	t.Setenv("FEATURE_LABEL", "a:x,b:1")
+	t.Setenv("OTHER_FLAG", "true")
+
+	flags, err := NewFlags("")
+	if err != nil {
+		t.Fatalf("NewFlags() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		flag    string
+		key     string
+		enabled bool
+		variant string
+	}{
+		{name: "Bool enabled", flag: "new-checkout", key: "user", enabled: true},
+		{name: "Camel case name", flag: "newCheckout", key: "user", enabled: true},
+		{name: "Bool disabled", flag: "DARK_MODE", key: "user", enabled: false},
+		{name: "Not set", flag: "missing", key: "user", enabled: false},
+		{name: "Other prefix", flag: "OTHER_FLAG", key: "user", enabled: false},
+		{name: "No rollout", flag: "none", key: "user", enabled: false},
+		{name: "Full rollout", flag: "all", key: "user", enabled: true},
+		{name: "Variant", flag: "colour", key: "user", enabled: true, variant: "blue"},
+		{name: "Off variant", flag: "disabled-variant", key: "user", enabled: false, variant: "off"},
+		{name: "Variant with a colon", flag: "backend", key: "user", enabled: true, variant: "redis://cache:6379"},
+		{name: "Variant with a colon in a list", flag: "label", key: "user", enabled: true, variant: "a:x,b:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flags.IsEnabled(tt.flag, tt.key); got != tt.enabled {
+				t.Errorf("IsEnabled() = %v, expected %v", got, tt.enabled)
+			}
+			if got := flags.Variant(tt.flag, tt.key); got != tt.variant {
+				t.Errorf("Variant() = %q, expected %q", got, tt.variant)
+			}
+		})
+	}
+
+	t.Run("Names", func(t *testing.T) {
+		expected := []string{"ALL", "BACKEND", "COLOUR", "DARK_MODE", "DISABLED_VARIANT", "HALF", "LABEL", "NEW_CHECKOUT", "NONE", "SPLIT"}
+		if got := flags.Names(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Names() = %v, expected %v", got, expected)
+		}
+	})
+}
+
+func TestFlags_Rollout(t *testing.T) {
+	flags := &Flags{prefix: DefaultFlagPrefix}
+	if err := flags.Load(map[string]string{"FEATURE_HALF": "50%", "FEATURE_SPLIT": "a:1,b:3"}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	enabled := 0
+	variants := map[string]int{}
+	for i := range 10000 {
+		key := fmt.Sprint("user-", i)
+		if flags.IsEnabled("half", key) {
+			enabled++
+		}
+		if flags.IsEnabled("half", key) != flags.IsEnabled("half", key) {
+			t.Fatalf("IsEnabled() is not consistent for %s", key)
+		}
+		variants[flags.Variant("split", key)]++
+	}
+
+	if enabled < 4500 || enabled > 5500 {
+		t.Errorf("IsEnabled() enabled %d of 10000 keys, expected about 5000", enabled)
+	}
+	if variants["a"] < 2000 || variants["a"] > 3000 || variants["a"]+variants["b"] != 10000 {
+		t.Errorf("Variant() = %v, expected about 2500 a and 7500 b", variants)
+	}
+
+	t.Run("Raising percentage keeps enabled keys", func(t *testing.T) {
+		low := &Flags{prefix: DefaultFlagPrefix}
+		high := &Flags{prefix: DefaultFlagPrefix}
+		_ = low.Load(map[string]string{"FEATURE_X": "10%"})
+		_ = high.Load(map[string]string{"FEATURE_X": "30.5%"})
+
+		for i := range 1000 {
+			key := fmt.Sprint(i)
+			if low.IsEnabled("x", key) && !high.IsEnabled("x", key) {
+				t.Fatalf("IsEnabled() disabled %s after raising the percentage", key)
+			}
+		}
+	})
+}
+
+func TestFlags_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "Bool", value: "yes"},
+		{name: "Empty", value: ""},
+		{name: "Percentage", value: "12.5%"},
+		{name: "Variant", value: "green"},
+		{name: "Weighted", value: "a:1, b:2"},
+		{name: "Percentage too high", value: "101%", wantErr: true},
+		{name: "Percentage not a number", value: "abc%", wantErr: true},
+		{name: "Variant with a colon", value: "a:x,b:1"},
+		{name: "Missing variant name", value: ":1", wantErr: true},
+		{name: "Zero total weight", value: "a:0,b:0", wantErr: true},
+		{name: "Largest total weight", value: "a:4294967294,b:1"},
+		{name: "Total weight overflows", value: "a:4294967295,b:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := &Flags{prefix: DefaultFlagPrefix}
+			err := flags.Load(map[string]string{"FEATURE_TEST": tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("Reload", func(t *testing.T) {
+		flags := &Flags{prefix: DefaultFlagPrefix}
+		_ = flags.Load(map[string]string{"FEATURE_TEST": "on"})
+
+		if err := flags.Load(map[string]string{"FEATURE_TEST": "200%"}); err == nil {
+			t.Fatal("Load() expected an error")
+		}
+		if !flags.IsEnabled("test", "") {
+			t.Error("IsEnabled() = false, expected the previous flags to be kept")
+		}
+
+		_ = flags.Load(map[string]string{"FEATURE_TEST": "off"})
+		if flags.IsEnabled("test", "") {
+			t.Error("IsEnabled() = true, expected the reloaded flags")
+		}
+	})
+
+	t.Run("Invalid environment", func(t *testing.T) {
+		t.Setenv("TEST_BROKEN", "500%")
+		if _, err := NewFlags("TEST_"); err == nil {
+			t.Error("NewFlags() expected an error")
+		}
+	})
+}
+
+func TestFlags_ZeroValue(t *testing.T) {
+	var flags Flags
+	if flags.IsEnabled("test", "user") || flags.Variant("test", "user") != "" || len(flags.Names()) != 0 {
+		t.Error("Flags{} expected no flags")
+	}
+
+	if err := flags.Load(map[string]string{"FEATURE_TEST": "on", "OTHER": "on"}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := flags.Names(); !reflect.DeepEqual(got, []string{"TEST"}) {
+		t.Errorf("Names() = %v, expected only the flags with DefaultFlagPrefix", got)
+	}
+}
+
+func TestFlags_Watch(t *testing.T) {
+	interval := WatchInterval
+	WatchInterval = 5 * time.Millisecond
+	t.Cleanup(func() { WatchInterval = interval })
+	t.Setenv("FEATURE_FROM_PROCESS", "on")
+
+	paths := writeEnvFiles(t, "FEATURE_CHECKOUT=off")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flags := &Flags{prefix: DefaultFlagPrefix}
+	errs := make(chan error, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- flags.Watch(ctx, paths, func(err error) { errs <- err })
+	}()
+
+	// The flags are loaded before watching starts, so changes are only written once they are.
+	waitUntil := func(cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for Watch")
+			}
+		}
+	}
+	waitUntil(func() bool { return flags.IsEnabled("from-process", "user") })
+	time.Sleep(20 * time.Millisecond)
+
+	if err := os.WriteFile(paths[0], []byte("FEATURE_CHECKOUT=on"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitUntil(func() bool { return flags.IsEnabled("checkout", "user") })
+	if !flags.IsEnabled("from-process", "user") {
+		t.Error("IsEnabled() = false, expected the flags of the process to be kept")
+	}
+
+	// An invalid flag is reported, and the last good flags are kept.
+	if err := os.WriteFile(paths[0], []byte("FEATURE_CHECKOUT=500%"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitFor(t, errs); err == nil {
+		t.Error("Watch() expected an error for the invalid flag")
+	}
+	if !flags.IsEnabled("checkout", "user") {
+		t.Error("IsEnabled() = false, expected the last good flags")
+	}
+
+	cancel()
+	if err := waitFor(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, expected context.Canceled", err)
+	}
+
+	t.Run("Invalid before watching", func(t *testing.T) {
+		paths := writeEnvFiles(t, "FEATURE_CHECKOUT=500%")
+		if err := (&Flags{}).Watch(context.Background(), paths, nil); err == nil {
+			t.Error("Watch() expected an error for the invalid flag")
+		}
+	})
+}
+
+func BenchmarkFlags_IsEnabled(b *testing.B) {
+	flags := &Flags{prefix: DefaultFlagPrefix}
+	_ = flags.Load(map[string]string{"FEATURE_NEW_CHECKOUT": "25%"})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		flags.IsEnabled("new-checkout", "user-123")
+	}
+}
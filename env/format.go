@@ -0,0 +1,431 @@
+package env
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envField is a field holding an environment variable, found by envWalker.
+type envField struct {
+	// Value is the value of the field.
+	Value reflect.Value
+	// StructField is the field's reflect.StructField.
+	StructField reflect.StructField
+	// Tags are the parsed tags of the field.
+	Tags FieldTags
+	// SecretName is the `envSecret` tag of the field, or of its closest parent struct field.
+	SecretName string
+	// Path is the path to the field, such as "Database.Host" or "Workers[0].Name".
+	Path string
+	// Prefixes are the prefixes the field's key is built from, outermost first, such as ["APP_", "DB_"].
+	Prefixes []string
+}
+
+// envWalker calls fn for each field of a struct holding an environment variable, following the same prefixes as Parse.
+//
+// Nil pointers to structs are walked using the zero value of the struct, so they still describe their variables.
+type envWalker struct {
+	fn func(field envField) error
+	// describe walks an empty slice of structs as a single element with an "{n}" index,
+	// and an empty map of structs with a "{key}" key, so their variables can be described.
+	describe bool
+}
+
+// walk calls fn for each field of the struct.
+//
+// Parameters:
+//   - ref: The reflect.Value of the struct.
+//   - opts: The options holding the current prefix.
+//   - secretName: The `envSecret` tag inherited from a parent struct field.
+//   - path: The path to the struct, empty for the root.
+//   - prefixes: The prefixes added by each parent struct, outermost first.
+//
+// Returns: The first error returned by fn, or a *MaxDepthError for a struct nested too deeply.
+func (w envWalker) walk(ref reflect.Value, opts Options, secretName, path string, prefixes []string) error {
+	// A struct with a pointer to itself is walked using zero values, which never become nil.
+	if err := opts.checkDepth(ref.Type()); err != nil {
+		return err
+	}
+
+	for i, field := range cachedFields(ref.Type(), &opts) {
+		f := ref.Field(i)
+		sf := field.sf
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		tags := field.tags
+		if tags.Ignored {
+			continue
+		}
+		tags.Key = opts.Prefix + tags.OwnKey
+
+		fieldSecretName := secretName
+		if name := sf.Tag.Get(SecretNameEnv); name != "" {
+			fieldSecretName = name
+		}
+
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		if isNestedStruct(sf.Type) {
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					f = reflect.New(sf.Type.Elem())
+				}
+				f = f.Elem()
+			}
+
+			nested, err := opts.withPrefix(sf)
+			if err != nil {
+				return err
+			}
+			if err = w.walk(f, nested, fieldSecretName, fieldPath, appendPrefix(prefixes, opts, nested)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isSliceOfStructs(sf) {
+			nested, err := opts.withPrefix(sf)
+			if err != nil {
+				return err
+			}
+			if err = w.walkSlice(f, nested, fieldSecretName, fieldPath, appendPrefix(prefixes, opts, nested)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isMapOfStructs(sf) {
+			nested, err := opts.withPrefix(sf)
+			if err != nil {
+				return err
+			}
+			if err = w.walkMap(f, nested, fieldSecretName, fieldPath, appendPrefix(prefixes, opts, nested)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A field with only an envPrefix has no variable of its own.
+		if tags.OwnKey == "" {
+			continue
+		}
+
+		visited := envField{
+			Value:       f,
+			StructField: sf,
+			Tags:        tags,
+			SecretName:  fieldSecretName,
+			Path:        fieldPath,
+			Prefixes:    prefixes,
+		}
+		if err := w.fn(visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkSlice walks each struct within a slice, as PREFIX_0_KEY.
+func (w envWalker) walkSlice(f reflect.Value, opts Options, secretName, path string, prefixes []string) error {
+	sliceType := f.Type()
+	if f.Kind() == reflect.Ptr {
+		sliceType = sliceType.Elem()
+		if f.IsNil() {
+			f = reflect.MakeSlice(sliceType, 0, 0)
+		} else {
+			f = f.Elem()
+		}
+	}
+
+	opts.Prefix = ensureTrailingUnderscore(opts.Prefix)
+
+	if f.Len() == 0 && w.describe {
+		structType := sliceType.Elem()
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+
+		nested := opts.shared()
+		nested.Prefix = opts.Prefix + "{n}_"
+		return w.walk(reflect.New(structType).Elem(), nested, secretName, path+"[n]", appendPrefix(prefixes, opts, nested))
+	}
+
+	for i := 0; i < f.Len(); i++ {
+		elem := f.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+
+		nested := opts.withSliceEnvPrefix(i)
+		if err := w.walk(elem, nested, secretName, path+"["+strconv.Itoa(i)+"]", appendPrefix(prefixes, opts, nested)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkMap walks each struct within a map, as PREFIX_KEY_FIELD, sorted by key so the output is stable.
+func (w envWalker) walkMap(f reflect.Value, opts Options, secretName, path string, prefixes []string) error {
+	mapType := f.Type()
+	if f.Kind() == reflect.Ptr {
+		mapType = mapType.Elem()
+		f = f.Elem()
+	}
+
+	opts.Prefix = ensureTrailingUnderscore(opts.Prefix)
+
+	if (!f.IsValid() || f.Len() == 0) && w.describe {
+		structType := mapType.Elem()
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+
+		nested := opts.withMapEnvPrefix("{key}")
+		return w.walk(reflect.New(structType).Elem(), nested, secretName, path+"[key]", appendPrefix(prefixes, opts, nested))
+	}
+
+	if !f.IsValid() {
+		return nil
+	}
+
+	keys := f.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		elem := f.MapIndex(key)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+
+		nested := opts.withMapEnvPrefix(key.String())
+		if err := w.walk(elem, nested, secretName, path+"["+key.String()+"]", appendPrefix(prefixes, opts, nested)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendPrefix appends the prefix added by a nested struct, if any.
+//
+// A new slice is always returned, so sibling structs do not share the same backing array.
+func appendPrefix(prefixes []string, parent, nested Options) []string {
+	added := strings.TrimPrefix(nested.Prefix, parent.Prefix)
+	if added == "" {
+		return prefixes
+	}
+	return append(prefixes[:len(prefixes):len(prefixes)], added)
+}
+
+// formatField formats a field's value as an environment variable, the inverse of setField.
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - sf: The reflect.StructField of the field, used for the slice and map separators.
+//   - tags: The FieldTags of the field, used for the encoding of []byte fields.
+//
+// Returns: The formatted value, or an error if the type cannot be formatted.
+func formatField(v reflect.Value, sf reflect.StructField, tags FieldTags) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if tags.Encoding != "" && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		if tags.Encoding == HexEnv {
+			return hex.EncodeToString(v.Bytes()), nil
+		}
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	}
+
+	if v.Type() == timeType {
+		return formatTime(v, sf)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if _, ok := asTextMarshaler(v); !ok {
+			return formatSlice(v, sf)
+		}
+	case reflect.Map:
+		return formatMap(v, sf)
+	}
+
+	return formatElement(v)
+}
+
+// formatTime formats a time.Time using the envLayout and envTZ tags of the field, the inverse of setTime.
+//
+// Without an envLayout tag, it uses time.RFC3339Nano so no precision is lost.
+func formatTime(v reflect.Value, sf reflect.StructField) (string, error) {
+	layout, loc, err := timeLayout(sf, time.RFC3339Nano)
+	if err != nil {
+		return "", err
+	}
+
+	t := v.Interface().(time.Time)
+	if sf.Tag.Get(TZEnv) != "" {
+		t = t.In(loc)
+	}
+
+	return t.Format(layout), nil
+}
+
+// formatSlice formats each element of a slice, joined by the envSeparator.
+func formatSlice(v reflect.Value, sf reflect.StructField) (string, error) {
+	return formatElements(v, getSeparator(sf))
+}
+
+// formatElements formats each element of a slice, joined by the separator.
+func formatElements(v reflect.Value, separator string) (string, error) {
+	parts := make([]string, v.Len())
+	for i := range parts {
+		s, err := formatElement(v.Index(i))
+		if err != nil {
+			return "", err
+		}
+		parts[i] = s
+	}
+
+	return strings.Join(parts, separator), nil
+}
+
+// formatMap formats each entry of a map as key and value, sorted by key so the output is stable.
+//
+// Values that are maps or slices are formatted using the envNestedSeparator and envNestedKeyValSeparator.
+func formatMap(v reflect.Value, sf reflect.StructField) (string, error) {
+	separator, keyValSeparator := getSeparators(sf)
+	nestedSeparator, nestedKeyValSeparator := getNestedSeparators(sf)
+
+	return formatPairs(v, separator, keyValSeparator, func(elem reflect.Value) (string, error) {
+		switch elem.Kind() {
+		case reflect.Map:
+			return formatPairs(elem, nestedSeparator, nestedKeyValSeparator, formatElement)
+		case reflect.Slice:
+			return formatElements(elem, nestedSeparator)
+		}
+		return formatElement(elem)
+	})
+}
+
+// formatPairs formats each entry of a map with formatValue, sorted by key so the output is stable.
+func formatPairs(v reflect.Value, separator, keyValSeparator string, formatValue func(reflect.Value) (string, error)) (string, error) {
+	parts := make([]string, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := formatElement(iter.Key())
+		if err != nil {
+			return "", err
+		}
+		val, err := formatValue(iter.Value())
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, key+keyValSeparator+val)
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, separator), nil
+}
+
+// formatElement formats a single value, such as a field or an element of a slice.
+//
+// Parameters:
+//   - v: The reflect.Value to format.
+//
+// Returns: The formatted value, or an error if the type cannot be formatted.
+func formatElement(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if tm, ok := asTextMarshaler(v); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+
+	switch v.Type() {
+	case reflect.TypeOf(time.Nanosecond):
+		return time.Duration(v.Int()).String(), nil
+	case reflect.TypeOf(time.Location{}):
+		loc := v.Interface().(time.Location)
+		return loc.String(), nil
+	case reflect.TypeOf(url.URL{}):
+		u := v.Interface().(url.URL)
+		return u.String(), nil
+	case reflect.TypeOf(net.IPNet{}):
+		ipNet := v.Interface().(net.IPNet)
+		return ipNet.String(), nil
+	case reflect.TypeOf(net.TCPAddr{}):
+		addr := v.Interface().(net.TCPAddr)
+		return addr.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Complex64:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 64), nil
+	case reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 128), nil
+	default:
+		return "", &UnsupportedTypeError{Type: v.Type()}
+	}
+}
+
+// asTextMarshaler gets the encoding.TextMarshaler from the reflect.Value, including from its address.
+//
+// Parameters:
+//   - v: The reflect.Value to get the encoding.TextMarshaler from.
+//
+// Returns: The encoding.TextMarshaler and true, or false if it is not one.
+func asTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return tm, true
+	}
+
+	if v.CanAddr() {
+		if tm, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			return tm, true
+		}
+	}
+
+	return nil, false
+}
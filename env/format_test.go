@@ -0,0 +1,89 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalText() ([]byte, error) {
+	return nil, errors.New("mocked error marshalling")
+}
+
+func TestFormatField(t *testing.T) {
+	str := "value"
+	var nilStr *string
+
+	tests := []struct {
+		name        string
+		value       interface{}
+		tag         reflect.StructTag
+		expected    string
+		expectedErr bool
+	}{
+		{"String", "value", "", "value", false},
+		{"Pointer", &str, "", "value", false},
+		{"Nil pointer", nilStr, "", "", false},
+		{"Bool", true, "", "true", false},
+		{"Int", int16(-5), "", "-5", false},
+		{"Uint", uint8(5), "", "5", false},
+		{"Float32", float32(1.5), "", "1.5", false},
+		{"Float64", 0.25, "", "0.25", false},
+		{"Uintptr", uintptr(0xff), "", "255", false},
+		{"Complex64", complex64(1 + 2i), "", "(1+2i)", false},
+		{"Complex128", -1.5i, "", "(0-1.5i)", false},
+		{"Duration", 90 * time.Second, "", "1m30s", false},
+		{"Location", *time.UTC, "", "UTC", false},
+		{"Slice", []int{1, 2, 3}, "", "1,2,3", false},
+		{"Slice with separator", []string{"a", "b"}, `envSeparator:"|"`, "a|b", false},
+		{"Slice of pointers", []*string{&str, nil}, "", "value,", false},
+		{"Map", map[string]int{"b": 2, "a": 1}, `envKeyValSeparator:"="`, "a=1,b=2", false},
+		{"Nested map", map[string]map[string]int{"b": {"z": 3}, "a": {"y": 2, "x": 1}}, "", "a:x=1;y=2,b:z=3", false},
+		{"Nested slice", map[string][]string{"a": {"x", "y"}}, `envNestedSeparator:"|"`, "a:x|y", false},
+		{"Nested map with separators", map[string]map[string]int{"a": {"x": 1, "y": 2}}, `envNestedSeparator:"&" envNestedKeyValSeparator:"~"`, "a:x~1&y~2", false},
+		{"Unsupported nested value", map[string][]chan int{"a": {nil}}, "", "", true},
+		{"TextMarshaler", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "", "2024-01-02T03:04:05Z", false},
+		{"Time with nanoseconds", time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), "", "2024-01-02T03:04:05.000000006Z", false},
+		{"Time with layout", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), `envLayout:"2006-01-02"`, "2024-01-02", false},
+		{"Time with zone", time.Date(2024, 1, 2, 18, 0, 0, 0, time.UTC), `envLayout:"2006-01-02 15:04" envTZ:"Asia/Tokyo"`, "2024-01-03 03:00", false},
+		{"Time with invalid zone", time.Time{}, `envTZ:"Invalid/Zone"`, "", true},
+		{"URL", url.URL{Scheme: "https", Host: "example.com", Path: "/a"}, "", "https://example.com/a", false},
+		{"IP", net.ParseIP("10.0.0.1"), "", "10.0.0.1", false},
+		{"IPNet", net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)}, "", "10.0.0.0/8", false},
+		{"TCPAddr", net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}, "", "127.0.0.1:8080", false},
+		{"Bytes", []byte{1, 2}, "", "1,2", false},
+		{"Base64 bytes", []byte{0xfb, 0xff}, `env:"KEY,base64"`, "+/8=", false},
+		{"Hex bytes", []byte{0xfb, 0xff}, `env:"KEY,hex"`, "fbff", false},
+		{"Unsupported", make(chan int), "", "", true},
+		{"Unsupported element", []chan int{make(chan int)}, "", "", true},
+		{"Unsupported map key", map[[1]int]int{{1}: 1}, "", "", true},
+		{"Unsupported map value", map[string]chan int{"a": nil}, "", "", true},
+		{"Failing marshaler", failingMarshaler{}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := reflect.StructField{Tag: tt.tag}
+			got, err := formatField(reflect.ValueOf(tt.value), sf, parseFieldTags(sf, &Options{}))
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("formatField() error = %v, expected error %v", err, tt.expectedErr)
+			}
+			if got != tt.expected {
+				t.Errorf("formatField() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkFormatField(b *testing.B) {
+	v := reflect.ValueOf([]int{1, 2, 3})
+	for i := 0; i < b.N; i++ {
+		_, _ = formatField(v, reflect.StructField{}, FieldTags{})
+	}
+}
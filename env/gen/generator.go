@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudment/utils-go/env"
+	"github.com/cloudment/utils-go/internal/strcase"
+)
+
+// envImport is the import path of the env package, used by the generated code for its errors.
+const envImport = "github.com/cloudment/utils-go/env"
+
+// kinds are the parsers of each supported kind, as the generated code.
+//
+// Each is written with src as the value and parsed as the result, the same as the parsers of the env package.
+var kinds = map[string]struct {
+	code    string
+	imports []string
+	// result is the type of parsed, which is converted if the field is another type.
+	result string
+}{
+	"string":     {result: "string"},
+	"bool":       {code: "parsed, err := strconv.ParseBool(src)", imports: []string{"strconv"}, result: "bool"},
+	"int":        {code: "parsed, err := strconv.ParseInt(src, 10, 32)", imports: []string{"strconv"}, result: "int64"},
+	"int8":       {code: "parsed, err := strconv.ParseInt(src, 10, 8)", imports: []string{"strconv"}, result: "int64"},
+	"int16":      {code: "parsed, err := strconv.ParseInt(src, 10, 16)", imports: []string{"strconv"}, result: "int64"},
+	"int32":      {code: "parsed, err := strconv.ParseInt(src, 10, 32)", imports: []string{"strconv"}, result: "int64"},
+	"int64":      {code: "parsed, err := strconv.ParseInt(src, 10, 64)", imports: []string{"strconv"}, result: "int64"},
+	"uint":       {code: "parsed, err := strconv.ParseUint(src, 10, 32)", imports: []string{"strconv"}, result: "uint64"},
+	"uint8":      {code: "parsed, err := strconv.ParseUint(src, 10, 8)", imports: []string{"strconv"}, result: "uint64"},
+	"uint16":     {code: "parsed, err := strconv.ParseUint(src, 10, 16)", imports: []string{"strconv"}, result: "uint64"},
+	"uint32":     {code: "parsed, err := strconv.ParseUint(src, 10, 32)", imports: []string{"strconv"}, result: "uint64"},
+	"uint64":     {code: "parsed, err := strconv.ParseUint(src, 10, 64)", imports: []string{"strconv"}, result: "uint64"},
+	"float32":    {code: "parsed, err := strconv.ParseFloat(src, 32)", imports: []string{"strconv"}, result: "float64"},
+	"float64":    {code: "parsed, err := strconv.ParseFloat(src, 64)", imports: []string{"strconv"}, result: "float64"},
+	"uintptr":    {code: "parsed, err := strconv.ParseUint(src, 10, 64)", imports: []string{"strconv"}, result: "uint64"},
+	"complex64":  {code: "parsed, err := strconv.ParseComplex(src, 64)", imports: []string{"strconv"}, result: "complex128"},
+	"complex128": {code: "parsed, err := strconv.ParseComplex(src, 128)", imports: []string{"strconv"}, result: "complex128"},
+	"duration":   {code: "parsed, err := time.ParseDuration(src)", imports: []string{"time"}, result: "time.Duration"},
+	"time":       {code: "parsed, err := time.ParseInLocation(layout, src, loc)", imports: []string{"time"}, result: "time.Time"},
+}
+
+// aliases are the predeclared types that are another name for a supported kind.
+var aliases = map[string]string{"byte": "uint8", "rune": "int32"}
+
+// unsupportedOptions are the `env` tag options the generated code cannot handle, such as those reading files or the
+// process, or allowEmpty as the generated code does not tell unset and empty variables apart.
+var unsupportedOptions = []string{
+	env.ExpandEnv, env.FileEnv, env.UnsetEnv, env.InitEnv, env.Base64Env, env.HexEnv, env.AllowEmptyEnv, env.ExtendedDurationEnv,
+}
+
+// unsupportedTags are the tags the generated code cannot handle, as they need env.Options or parsers registered at runtime.
+var unsupportedTags = []string{env.ValidateEnv, env.DeprecatedEnv, env.ParserEnv, env.GroupEnv}
+
+// generator writes the parse functions of struct types declared within a package.
+type generator struct {
+	// pkg is the name of the package.
+	pkg string
+	// types are the type declarations of the package, by name.
+	types map[string]ast.Expr
+	// checked is the type-checked package, used to find the methods of its types.
+	checked *types.Package
+	// imports are the packages used by the generated code.
+	imports map[string]bool
+}
+
+// fieldType is a resolved field type.
+type fieldType struct {
+	// kind is a key of kinds, "struct", "slice" or "unmarshal".
+	kind string
+	// goType is the type as written within the package, such as "Level" for `type Level string`.
+	goType string
+	// structType is the declaration of a nested struct.
+	structType *ast.StructType
+	// elem is the element type of a slice.
+	elem *fieldType
+	// method is the method parsing the value of a type declared within the package, such as "UnmarshalText".
+	method string
+}
+
+// unmarshalers are the methods env.Parse calls to parse a value, in order of priority.
+//
+// UnmarshalEnv and UnmarshalText are called by the generated code. UnmarshalBinary and UnmarshalJSON are only used by
+// env.Parse for types without a parser of their kind, such as structs, so those types are reported instead.
+var unmarshalers = []string{"UnmarshalEnv", "UnmarshalText", "UnmarshalBinary", "UnmarshalJSON"}
+
+// run generates the parse functions of the types within the package at dir, writing them to output.
+//
+// Parameters:
+//   - dir: The directory of the package.
+//   - typeNames: The comma-separated names of the struct types.
+//   - output: The file to write, relative to dir. Defaults to <type>_env.go, using the first type.
+//
+// Returns: An error if a type could not be generated or the file could not be written.
+func run(dir, typeNames, output string) error {
+	if typeNames == "" {
+		return errors.New("-type is required")
+	}
+	names := strings.Split(typeNames, ",")
+
+	g, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	src, err := g.generate(names)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strcase.ToSnakeCase(names[0]) + "_env.go"
+	}
+	return os.WriteFile(filepath.Join(dir, output), src, 0o644)
+}
+
+// loadPackage parses the Go files of the package at dir, excluding tests, and type-checks them.
+//
+// Type errors are ignored, as only the methods declared within the package are needed.
+//
+// Parameters:
+//   - dir: The directory of the package.
+//
+// Returns: The generator holding the type declarations, or an error if a file could not be parsed.
+func loadPackage(dir string) (*generator, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{types: make(map[string]ast.Expr), imports: make(map[string]bool)}
+	fset := token.NewFileSet()
+	var files []*ast.File
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		g.pkg = file.Name.Name
+		files = append(files, file)
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					g.types[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+
+	if g.pkg == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+	g.checked, _ = conf.Check(g.pkg, fset, files, nil)
+
+	return g, nil
+}
+
+// unmarshaler finds the method env.Parse would call to parse a type declared within the package.
+//
+// Parameters:
+//   - name: The name of the type.
+//   - elem: Whether the type is the element of a slice, whose elements are only parsed with UnmarshalText.
+//
+// Returns: The name of the method, or "" if the type has none of the unmarshalers.
+func (g *generator) unmarshaler(name string, elem bool) string {
+	if g.checked == nil {
+		return ""
+	}
+	obj, ok := g.checked.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return ""
+	}
+
+	// The methods may be declared on the pointer, as env.Parse calls them on the address of the field.
+	methods := types.NewMethodSet(types.NewPointer(obj.Type()))
+	for _, method := range unmarshalers {
+		if elem && method != "UnmarshalText" {
+			continue
+		}
+		if methods.Lookup(nil, method) != nil {
+			return method
+		}
+	}
+
+	return ""
+}
+
+// generate writes the parse function of each type, returning the formatted file.
+//
+// Parameters:
+//   - names: The names of the struct types.
+//
+// Returns: The source of the file, or an error if a type is not a struct or holds an unsupported field.
+func (g *generator) generate(names []string) ([]byte, error) {
+	var body bytes.Buffer
+
+	for _, name := range names {
+		st, ok := g.types[name].(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type within package %s", name, g.pkg)
+		}
+
+		var fields bytes.Buffer
+		if err := g.writeStruct(&fields, st, "", name, "cfg"); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&body, "\n// Parse%s parses a %s from environment variables, without reflection.\n", name, name)
+		fmt.Fprintf(&body, "//\n// It reads the same variables as env.ParseWithOpts with env.Options{Env: vars}.\n")
+		fmt.Fprintf(&body, "func Parse%s(vars map[string]string) (%s, error) {\n\tvar cfg %s\n", name, name, name)
+		if fields.Len() > 0 {
+			body.WriteString("\tvar v string\n")
+			body.Write(fields.Bytes())
+		}
+		body.WriteString("\n\treturn cfg, nil\n}\n")
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by github.com/cloudment/utils-go/env/gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n", g.pkg)
+
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+
+		// The standard library is grouped first, as goimports would.
+		src.WriteString("\nimport (\n")
+		for _, imp := range imports {
+			if imp != envImport {
+				fmt.Fprintf(&src, "\t%q\n", imp)
+			}
+		}
+		if g.imports[envImport] {
+			fmt.Fprintf(&src, "\n\t%q\n", envImport)
+		}
+		src.WriteString(")\n")
+	}
+
+	src.Write(body.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+// writeStruct writes the code of each field of a struct.
+//
+// Parameters:
+//   - buf: The buffer to write to.
+//   - st: The struct declaration.
+//   - prefix: The prefix of the keys, such as "DATABASE_".
+//   - path: The path to the struct, such as "Config.Database", used within errors.
+//   - target: The expression of the struct, such as "cfg.Database".
+//
+// Returns: An error if a field is unsupported.
+func (g *generator) writeStruct(buf *bytes.Buffer, st *ast.StructType, prefix, path, target string) error {
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			// The literal was checked when parsing the file, so it always unquotes.
+			unquoted, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(unquoted)
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(names) == 0 {
+			// An embedded field is named by its type.
+			names = append(names, types.ExprString(field.Type))
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			if err := g.writeField(buf, field.Type, tag, prefix, path+"."+name, target+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeField writes the code of a field, or of its fields if it is a nested struct.
+//
+// Parameters:
+//   - buf: The buffer to write to.
+//   - expr: The type of the field.
+//   - tag: The tag of the field.
+//   - prefix: The prefix of the keys of the struct holding the field.
+//   - path: The path to the field, such as "Config.Database.Host".
+//   - target: The expression of the field, such as "cfg.Database.Host".
+//
+// Returns: An error if the field is unsupported.
+func (g *generator) writeField(buf *bytes.Buffer, expr ast.Expr, tag reflect.StructTag, prefix, path, target string) error {
+	envTag, hasEnv := tag.Lookup(env.Env)
+	nestedPrefix, hasPrefix := tag.Lookup(env.PrefixEnv)
+	key, options, _ := strings.Cut(envTag, ",")
+
+	// The same fields are ignored as within env.Parse.
+	if (key == "-" || !hasEnv) && !hasPrefix {
+		return nil
+	}
+
+	ft, err := g.resolve(expr, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if ft.kind == "struct" {
+		if !hasPrefix && ft.method != "" {
+			return fmt.Errorf("%s: a struct parsed with %s is not supported by the generator", path, ft.method)
+		}
+		if !hasPrefix {
+			return fmt.Errorf("%s: a nested struct requires an %s tag", path, env.PrefixEnv)
+		}
+
+		nestedPrefix, options, _ := strings.Cut(nestedPrefix, ",")
+		if options == env.SquashEnv {
+			nestedPrefix = ""
+		}
+		if strings.Contains(nestedPrefix, "$") {
+			return fmt.Errorf("%s: an expanded %s is not supported by the generator", path, env.PrefixEnv)
+		}
+
+		nestedPrefix = prefix + nestedPrefix
+		if nestedPrefix != "" && !strings.HasSuffix(nestedPrefix, "_") {
+			nestedPrefix += "_"
+		}
+		return g.writeStruct(buf, ft.structType, nestedPrefix, path, target)
+	}
+
+	// A field with only an envPrefix has no variable of its own.
+	if key == "" {
+		return nil
+	}
+
+	required := false
+	for options != "" {
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+
+		if option == env.RequiredEnv {
+			required = true
+		}
+		for _, unsupported := range unsupportedOptions {
+			if option == unsupported {
+				return fmt.Errorf("%s: the %s option is not supported by the generator", path, option)
+			}
+		}
+	}
+
+	// Skipping them would silently behave differently to env.Parse, so they are reported instead.
+	for _, unsupported := range unsupportedTags {
+		if _, ok := tag.Lookup(unsupported); ok {
+			return fmt.Errorf("%s: the %s tag is not supported by the generator", path, unsupported)
+		}
+	}
+
+	key = prefix + key
+	fmt.Fprintf(buf, "\n\t// %s is read from %s.\n", strings.TrimPrefix(path[strings.Index(path, ".")+1:], "."), key)
+	fmt.Fprintf(buf, "\tv = vars[%q]\n", key)
+
+	for _, alias := range strings.Split(tag.Get(env.AliasEnv), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			fmt.Fprintf(buf, "\tif v == \"\" {\n\t\tv = vars[%q]\n\t}\n", prefix+alias)
+		}
+	}
+
+	if def := tag.Get(env.DefaultEnv); def != "" {
+		fmt.Fprintf(buf, "\tif v == \"\" {\n\t\tv = %q\n\t}\n", def)
+	} else if required {
+		g.imports[envImport] = true
+		fmt.Fprintf(buf, "\tif v == \"\" {\n\t\treturn cfg, &env.VarNotSetError{Key: %q, Field: %q}\n\t}\n", key, path)
+	}
+
+	buf.WriteString("\tif v != \"\" {\n")
+	if err = g.writeValue(buf, ft, tag, key, path, target); err != nil {
+		return err
+	}
+	buf.WriteString("\t}\n")
+
+	return nil
+}
+
+// writeValue writes the code parsing v into the target, within the block of a field.
+//
+// Parameters:
+//   - buf: The buffer to write to.
+//   - ft: The type of the field.
+//   - tag: The tag of the field, for its separator and time layout.
+//   - key: The environment variable, used within errors.
+//   - path: The path to the field, used within errors.
+//   - target: The expression of the field.
+//
+// Returns: An error if the type is unsupported, or the envTZ tag is not a valid location.
+func (g *generator) writeValue(buf *bytes.Buffer, ft *fieldType, tag reflect.StructTag, key, path, target string) error {
+	if ft.kind != "slice" {
+		return g.writeParse(buf, ft, tag, "v", target, key, path)
+	}
+
+	separator := tag.Get(env.SeparatorEnv)
+	if separator == "" {
+		separator = ","
+	}
+
+	g.imports["strings"] = true
+	if ft.elem.goType == "string" {
+		fmt.Fprintf(buf, "\t\t%s = strings.Split(v, %q)\n", target, separator)
+		return nil
+	}
+
+	fmt.Fprintf(buf, "\t\tparts := strings.Split(v, %q)\n", separator)
+	fmt.Fprintf(buf, "\t\tvalues := make(%s, len(parts))\n", ft.goType)
+	buf.WriteString("\t\tfor i, part := range parts {\n")
+	if err := g.writeParse(buf, ft.elem, tag, "part", "values[i]", key, path); err != nil {
+		return err
+	}
+	buf.WriteString("\t\t}\n")
+	fmt.Fprintf(buf, "\t\t%s = values\n", target)
+
+	return nil
+}
+
+// writeParse writes the code parsing a single value into the target.
+//
+// Parameters:
+//   - buf: The buffer to write to.
+//   - ft: The type of the value, which is not a slice.
+//   - tag: The tag of the field, for its time layout.
+//   - src: The variable holding the value.
+//   - target: The expression to set.
+//   - key: The environment variable, used within errors.
+//   - path: The path to the field, used within errors.
+//
+// Returns: An error if the envTZ tag is not a valid location.
+func (g *generator) writeParse(buf *bytes.Buffer, ft *fieldType, tag reflect.StructTag, src, target, key, path string) error {
+	if ft.kind == "unmarshal" {
+		// The error is returned as it is, the same as env.Parse does for its unmarshalers.
+		g.imports[envImport] = true
+		call := fmt.Sprintf("%s.UnmarshalText([]byte(%s))", target, src)
+		if ft.method == "UnmarshalEnv" {
+			// env.ParseWithOpts uses context.Background() when no context is given.
+			g.imports["context"] = true
+			call = fmt.Sprintf("%s.UnmarshalEnv(context.Background(), %s)", target, src)
+		}
+		fmt.Fprintf(buf, "\t\tif err := %s; err != nil {\n\t\t\treturn cfg, &env.ParseError{Key: %q, Field: %q, Err: err}\n\t\t}\n", call, key, path)
+		return nil
+	}
+
+	k := kinds[ft.kind]
+	for _, imp := range k.imports {
+		g.imports[imp] = true
+	}
+
+	// The parsed value is converted if the field is declared as another type, such as int from int64.
+	result := "parsed"
+	if k.code == "" {
+		result = src
+	}
+	if ft.goType != k.result {
+		result = ft.goType + "(" + result + ")"
+	}
+
+	if k.code == "" {
+		fmt.Fprintf(buf, "\t\t%s = %s\n", target, result)
+		return nil
+	}
+
+	g.imports[envImport] = true
+	g.imports["fmt"] = true
+
+	if ft.kind == "time" {
+		layout := tag.Get(env.LayoutEnv)
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		fmt.Fprintf(buf, "\t\tlayout := %q\n", layout)
+
+		if tz := tag.Get(env.TZEnv); tz != "" {
+			// The location is checked now, so an invalid tag is reported when generating.
+			if _, err := time.LoadLocation(tz); err != nil {
+				return fmt.Errorf("%s: invalid %s tag: %w", path, env.TZEnv, err)
+			}
+
+			fmt.Fprintf(buf, "\t\tloc, err := time.LoadLocation(%q)\n", tz)
+			fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn cfg, &env.ParseError{Key: %q, Field: %q, Err: err}\n\t\t}\n", key, path)
+		} else {
+			buf.WriteString("\t\tloc := time.UTC\n")
+		}
+	}
+
+	fmt.Fprintf(buf, "\t\t%s\n", strings.Replace(k.code, "src", src, 1))
+	fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn cfg, &env.ParseError{Key: %q, Field: %q, Err: fmt.Errorf(\"failed to parse value: %%w\", err)}\n\t\t}\n", key, path)
+	fmt.Fprintf(buf, "\t\t%s = %s\n", target, result)
+
+	return nil
+}
+
+// resolve resolves the type of a field.
+//
+// Parameters:
+//   - expr: The type, as written within the struct.
+//   - elem: Whether the type is the element of a slice.
+//
+// Returns: The resolved type, or an error if it is unsupported.
+func (g *generator) resolve(expr ast.Expr, elem bool) (*fieldType, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		name := t.Name
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		if _, ok := kinds[name]; ok && name != "duration" && name != "time" {
+			return &fieldType{kind: name, goType: t.Name}, nil
+		}
+
+		// A type declared within the package, such as a nested struct or `type Level string`.
+		if decl, ok := g.types[t.Name]; ok {
+			method := g.unmarshaler(t.Name, elem)
+			if method == "UnmarshalEnv" || method == "UnmarshalText" {
+				return &fieldType{kind: "unmarshal", goType: t.Name, method: method}, nil
+			}
+
+			if st, ok := decl.(*ast.StructType); ok {
+				return &fieldType{kind: "struct", goType: t.Name, structType: st, method: method}, nil
+			}
+			// The parser of the underlying kind takes priority over UnmarshalBinary and UnmarshalJSON within env.Parse.
+			if underlying, err := g.resolve(decl, elem); err == nil && underlying.kind != "struct" && underlying.kind != "slice" && underlying.kind != "unmarshal" {
+				return &fieldType{kind: underlying.kind, goType: t.Name}, nil
+			}
+		}
+	case *ast.StructType:
+		return &fieldType{kind: "struct", structType: t}, nil
+	case *ast.SelectorExpr:
+		switch types.ExprString(t) {
+		case "time.Duration":
+			return &fieldType{kind: "duration", goType: "time.Duration"}, nil
+		case "time.Time":
+			return &fieldType{kind: "time", goType: "time.Time"}, nil
+		}
+	case *ast.ArrayType:
+		if t.Len == nil {
+			elem, err := g.resolve(t.Elt, true)
+			if err == nil && elem.kind != "struct" && elem.kind != "slice" {
+				return &fieldType{kind: "slice", goType: types.ExprString(t), elem: elem}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", types.ExprString(expr))
+}
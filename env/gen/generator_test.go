@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackage writes a package holding src to a temporary directory.
+func writePackage(t *testing.T, src string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte("package config\n\n"+src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		typeNames string
+		contains  []string
+		err       string
+	}{
+		{
+			name:      "Basic types",
+			src:       "type Config struct {\n\tName string `env:\"NAME\"`\n\tCount uint `env:\"COUNT\"`\n\tB byte `env:\"B\"`\n}\n",
+			typeNames: "Config",
+			contains: []string{
+				"func ParseConfig(vars map[string]string) (Config, error) {",
+				`cfg.Name = v`,
+				`strconv.ParseUint(v, 10, 32)`,
+				`cfg.Count = uint(parsed)`,
+				`cfg.B = byte(parsed)`,
+			},
+		},
+		{
+			name:      "Complex and uintptr",
+			src:       "type Config struct {\n\tC complex64 `env:\"C\"`\n\tD complex128 `env:\"D\"`\n\tP uintptr `env:\"P\"`\n\tR rune `env:\"R\"`\n}\n",
+			typeNames: "Config",
+			contains: []string{
+				`strconv.ParseComplex(v, 64)`,
+				`cfg.C = complex64(parsed)`,
+				`cfg.D = parsed`,
+				`cfg.P = uintptr(parsed)`,
+				`cfg.R = rune(parsed)`,
+			},
+		},
+		{
+			name:      "No fields",
+			src:       "type Config struct {\n\tName string\n\tname string `env:\"NAME\"`\n\tSkipped string `env:\"-\"`\n\tOnlyPrefix string `envPrefix:\"P\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{"var cfg Config\n\n\treturn cfg, nil"},
+		},
+		{
+			name:      "Several types",
+			src:       "type A struct {\n\tX string `env:\"X\"`\n}\n\ntype B struct {\n\tY []string `env:\"Y\"`\n}\n",
+			typeNames: "A,B",
+			contains:  []string{"func ParseA(", "func ParseB(", `strings.Split(v, ",")`},
+		},
+		{
+			name:      "Anonymous struct",
+			src:       "type Config struct {\n\tDB struct {\n\t\tHost string `env:\"HOST\"`\n\t} `envPrefix:\"DB_\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`vars["DB_HOST"]`, `cfg.DB.Host = v`},
+		},
+		{
+			name:      "Embedded struct",
+			src:       "type Base struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tBase `envPrefix:\"\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`vars["HOST"]`, `cfg.Base.Host = v`},
+		},
+		{
+			name:      "Squashed struct",
+			src:       "type Base struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tDB struct {\n\t\tBase Base `envPrefix:\"BASE,squash\"`\n\t} `envPrefix:\"DB\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`vars["DB_HOST"]`, `cfg.DB.Base.Host = v`},
+		},
+		{
+			name:      "Time without a zone",
+			src:       "import \"time\"\n\ntype Config struct {\n\tAt time.Time `env:\"AT\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`layout := "2006-01-02T15:04:05Z07:00"`, `loc := time.UTC`},
+		},
+		{
+			name:      "Context unmarshaler",
+			src:       "import \"context\"\n\ntype Secret string\n\nfunc (s *Secret) UnmarshalEnv(ctx context.Context, v string) error { return nil }\n\ntype Config struct {\n\tKey Secret `env:\"KEY\"`\n\tKeys []Secret `env:\"KEYS\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`cfg.Key.UnmarshalEnv(context.Background(), v)`, `values[i] = Secret(part)`},
+		},
+		{
+			name:      "Text unmarshaler struct",
+			src:       "type Pair struct{ A, B string }\n\nfunc (p *Pair) UnmarshalText(b []byte) error { return nil }\n\ntype Config struct {\n\tPair Pair `env:\"PAIR\"`\n\tPairs []Pair `env:\"PAIRS\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`cfg.Pair.UnmarshalText([]byte(v))`, `values[i].UnmarshalText([]byte(part))`},
+		},
+		{
+			name:      "JSON unmarshaler with a kind",
+			src:       "type Level string\n\nfunc (l *Level) UnmarshalJSON(b []byte) error { return nil }\n\ntype Config struct {\n\tLevel Level `env:\"LEVEL\"`\n}\n",
+			typeNames: "Config",
+			contains:  []string{`cfg.Level = Level(v)`},
+		},
+		{name: "No type", err: "-type is required"},
+		{name: "Missing type", src: "type Config struct{}\n", typeNames: "Other", err: "Other is not a struct type"},
+		{name: "Not a struct", src: "type Config string\n", typeNames: "Config", err: "Config is not a struct type"},
+		{
+			name:      "Unsupported type",
+			src:       "type Config struct {\n\tHandler func() `env:\"HANDLER\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Handler: unsupported type: func()",
+		},
+		{
+			name:      "Unsupported slice",
+			src:       "type Config struct {\n\tMatrix [][]int `env:\"MATRIX\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Matrix: unsupported type: [][]int",
+		},
+		{
+			name:      "Unsupported named type",
+			src:       "type Hosts []string\n\ntype Config struct {\n\tHosts Hosts `env:\"HOSTS\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Hosts: unsupported type: Hosts",
+		},
+		{
+			name:      "Unsupported selector",
+			src:       "import \"net/url\"\n\ntype Config struct {\n\tURL url.URL `env:\"URL\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.URL: unsupported type: url.URL",
+		},
+		{
+			name:      "Unsupported nested field",
+			src:       "type DB struct {\n\tConns map[string]int `env:\"CONNS\"`\n}\n\ntype Config struct {\n\tDB DB `envPrefix:\"DB\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.DB.Conns: unsupported type: map[string]int",
+		},
+		{
+			name:      "Unsupported option",
+			src:       "type Config struct {\n\tKey string `env:\"KEY,file\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Key: the file option is not supported by the generator",
+		},
+		{
+			name:      "Unsupported extended duration",
+			src:       "import \"time\"\n\ntype Config struct {\n\tRetention time.Duration `env:\"RETENTION,extendedDuration\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Retention: the extendedDuration option is not supported by the generator",
+		},
+		{
+			name:      "Unsupported expanded prefix",
+			src:       "type DB struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tDB DB `envPrefix:\"${TENANT}_DB\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.DB: an expanded envPrefix is not supported by the generator",
+		},
+		{
+			name:      "Unsupported validation",
+			src:       "type Config struct {\n\tPort int `env:\"PORT\" envValidate:\"min=1\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Port: the envValidate tag is not supported by the generator",
+		},
+		{
+			name:      "Unsupported deprecation",
+			src:       "type Config struct {\n\tHost string `env:\"HOST\" envDeprecated:\"use ADDR\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Host: the envDeprecated tag is not supported by the generator",
+		},
+		{
+			name:      "Unsupported named parser",
+			src:       "type Config struct {\n\tPorts []int `env:\"PORTS\" envParser:\"int-range\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Ports: the envParser tag is not supported by the generator",
+		},
+		{
+			name:      "Unsupported group",
+			src:       "type Config struct {\n\tCert string `env:\"TLS_CERT\" envGroup:\"tls\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Cert: the envGroup tag is not supported by the generator",
+		},
+		{
+			name:      "Nested struct without a prefix",
+			src:       "type DB struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tDB DB `env:\"DB\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.DB: a nested struct requires an envPrefix tag",
+		},
+		{
+			name:      "Unsupported JSON unmarshaler",
+			src:       "type Limits struct{ Burst int }\n\nfunc (l *Limits) UnmarshalJSON(b []byte) error { return nil }\n\ntype Config struct {\n\tLimits Limits `env:\"LIMITS\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.Limits: a struct parsed with UnmarshalJSON is not supported by the generator",
+		},
+		{
+			name:      "Invalid zone",
+			src:       "import \"time\"\n\ntype Config struct {\n\tAt time.Time `env:\"AT\" envTZ:\"Mars/Olympus\"`\n}\n",
+			typeNames: "Config",
+			err:       "Config.At: invalid envTZ tag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writePackage(t, tt.src)
+
+			err := run(dir, tt.typeNames, "")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("run() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			name := strings.ToLower(strings.Split(tt.typeNames, ",")[0]) + "_env.go"
+			got, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.contains {
+				if !bytes.Contains(got, []byte(s)) {
+					t.Errorf("run() = %s, expected it to contain %s", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadPackage(t *testing.T) {
+	if _, err := loadPackage(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadPackage() expected an error for a missing directory")
+	}
+
+	dir := t.TempDir()
+	if _, err := loadPackage(dir); err == nil || !strings.Contains(err.Error(), "no Go files") {
+		t.Errorf("loadPackage() error = %v, expected no Go files", err)
+	}
+
+	// Tests are not part of the package being generated for.
+	if err := os.WriteFile(filepath.Join(dir, "config_test.go"), []byte("package config_test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "invalid.go"), []byte("package"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPackage(dir); err == nil || strings.Contains(err.Error(), "no Go files") {
+		t.Errorf("loadPackage() error = %v, expected a syntax error", err)
+	}
+}
+
+// TestExample checks the generated file of the example is up to date.
+func TestExample(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	expected, err := os.ReadFile(filepath.Join(dir, "config_env.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	if err = os.WriteFile(filepath.Join(tmp, "config.go"), mustRead(t, filepath.Join(dir, "config.go")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = run(tmp, "Config", "out.go"); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if got := mustRead(t, filepath.Join(tmp, "out.go")); !bytes.Equal(got, expected) {
+		t.Errorf("run() = %s, expected %s; run go generate ./env/gen/...", got, expected)
+	}
+
+	if err = run(filepath.Join(tmp, "missing"), "Config", ""); err == nil {
+		t.Error("run() expected an error for a missing directory")
+	}
+}
+
+func mustRead(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	g, err := loadPackage(filepath.Join("internal", "example"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		g.imports = make(map[string]bool)
+		if _, err = g.generate([]string{"Config"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package example holds a struct with its generated parse function, used to test env/gen against env.Parse.
+package example
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//go:generate go run github.com/cloudment/utils-go/env/gen -type Config
+
+// Level is a log level, declared with string as the underlying type.
+type Level string
+
+// Mode is the mode a service runs in, which implements encoding.TextUnmarshaler.
+type Mode string
+
+// UnmarshalText parses a mode in any case, such as "Production".
+func (m *Mode) UnmarshalText(text []byte) error {
+	switch mode := Mode(strings.ToLower(string(text))); mode {
+	case "development", "production":
+		*m = mode
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q", text)
+}
+
+// Config is the configuration of a service.
+type Config struct {
+	Host     string        `env:"HOST" envDefault:"localhost" envAlias:"SERVER_HOST,LISTEN_HOST"`
+	Port     int           `env:"PORT,required"`
+	Debug    bool          `env:"DEBUG"`
+	Level    Level         `env:"LEVEL" envDefault:"info"`
+	Mode     Mode          `env:"MODE" envDefault:"Development"`
+	Modes    []Mode        `env:"MODES"`
+	Ratio    float64       `env:"RATIO"`
+	Timeout  time.Duration `env:"TIMEOUT" envDefault:"30s"`
+	Started  time.Time     `env:"STARTED" envLayout:"2006-01-02" envTZ:"Europe/London"`
+	Hosts    []string      `env:"HOSTS" envSeparator:";"`
+	Ports    []uint16      `env:"PORTS"`
+	Database Database      `envPrefix:"DB"`
+	Password string        `env:"PASSWORD,secret"`
+
+	internal string
+	Ignored  string `env:"-"`
+}
+
+// Database is the configuration of a database, read with the prefix DB_.
+type Database struct {
+	Name     string `env:"NAME,required"`
+	MaxConns int32  `env:"MAX_CONNS" envDefault:"10"`
+}
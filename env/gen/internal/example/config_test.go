@@ -0,0 +1,89 @@
+package example
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cloudment/utils-go/env"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+	}{
+		{
+			name: "Defaults",
+			vars: map[string]string{"PORT": "8080", "DB_NAME": "app"},
+		},
+		{
+			name: "Every field",
+			vars: map[string]string{
+				"HOST": "example.com", "PORT": "443", "DEBUG": "true", "LEVEL": "debug", "MODE": "Production",
+				"MODES": "development,PRODUCTION", "RATIO": "0.5",
+				"TIMEOUT": "1m", "STARTED": "2024-07-01", "HOSTS": "a;b", "PORTS": "80,443",
+				"DB_NAME": "app", "DB_MAX_CONNS": "20", "PASSWORD": "secret", "IGNORED": "set",
+			},
+		},
+		{
+			name: "Alias",
+			vars: map[string]string{"HOST": "", "LISTEN_HOST": "listen.example.com", "PORT": "8080", "DB_NAME": "app"},
+		},
+		{name: "Not set", vars: map[string]string{"DB_NAME": "app"}},
+		{name: "Nested not set", vars: map[string]string{"PORT": "8080"}},
+		{name: "Invalid int", vars: map[string]string{"PORT": "http", "DB_NAME": "app"}},
+		{name: "Int out of range", vars: map[string]string{"PORT": "4294967296", "DB_NAME": "app"}},
+		{name: "Invalid bool", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "DEBUG": "yes please"}},
+		{name: "Invalid float", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "RATIO": "half"}},
+		{name: "Invalid time", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "STARTED": "01/07/2024"}},
+		{name: "Invalid slice", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "PORTS": "80,65536"}},
+		{name: "Invalid mode", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "MODE": "staging"}},
+		{name: "Invalid modes", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "MODES": "production,staging"}},
+		{name: "Invalid nested", vars: map[string]string{"PORT": "8080", "DB_NAME": "app", "DB_MAX_CONNS": "many"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The generated function must behave as the reflection-based parser does.
+			var expected Config
+			expectedErr := env.ParseWithOpts(&expected, env.Options{Env: tt.vars})
+
+			got, err := ParseConfig(tt.vars)
+			if (err != nil) != (expectedErr != nil) {
+				t.Fatalf("ParseConfig() error = %v, expected %v", err, expectedErr)
+			}
+			if err != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(expectedErr) {
+					t.Errorf("ParseConfig() error = %T, expected %T", err, expectedErr)
+				}
+				var notSet *env.VarNotSetError
+				if errors.As(expectedErr, &notSet) && err.Error() != expectedErr.Error() {
+					t.Errorf("ParseConfig() error = %v, expected %v", err, expectedErr)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("ParseConfig() = %+v, expected %+v", got, expected)
+			}
+		})
+	}
+}
+
+var benchVars = map[string]string{
+	"HOST": "example.com", "PORT": "443", "DEBUG": "true", "TIMEOUT": "1m", "STARTED": "2024-07-01",
+	"HOSTS": "a;b", "PORTS": "80,443", "DB_NAME": "app",
+}
+
+func BenchmarkParseConfig(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseConfig(benchVars)
+	}
+}
+
+func BenchmarkParseWithOpts(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		_ = env.ParseWithOpts(&cfg, env.Options{Env: benchVars})
+	}
+}
@@ -0,0 +1,36 @@
+// Command gen generates functions that parse structs containing `env` tags without reflection.
+//
+// For each type, it writes a Parse<Type>(vars map[string]string) (<Type>, error) function which reads the
+// same variables as env.ParseWithOpts with Options{Env: vars}. Types or tag options it cannot generate code for
+// are reported when generating, rather than when the struct is parsed.
+//
+// Usage, within the file declaring the struct:
+//
+//	//go:generate go run github.com/cloudment/utils-go/env/gen -type Config
+//
+// Then, without reflection:
+//
+//	cfg, err := ParseConfig(vars)
+//
+// Supported fields are strings, bools, ints, uints, floats, time.Duration, time.Time, types declared with
+// one of them as the underlying type, types implementing encoding.TextUnmarshaler or env.ContextUnmarshaler,
+// slices of them, and nested structs with an `envPrefix` tag.
+// The `required` and `secret` options, and the envDefault, envAlias, envSeparator, envLayout and envTZ tags are supported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; required")
+	output := flag.String("output", "", "output file name; default <type>_env.go")
+	flag.Parse()
+
+	if err := run(".", *typeNames, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+package env
+
+import (
+	"os"
+	"reflect"
+)
+
+// Get reads a single environment variable and parses it into T.
+//
+// It supports the same types as Parse, such as int, bool, time.Duration, slices, maps and types implementing
+// encoding.TextUnmarshaler, encoding.BinaryUnmarshaler or json.Unmarshaler.
+//
+// Parameters:
+//
+//   - key: The environment variable to read.
+//
+// Returns: The parsed value, or a *VarNotSetError if the variable is not set, or a *ParseError if it cannot be parsed.
+//
+// Example:
+//
+//	port, err := env.Get[int]("PORT")
+//	hosts, err := env.Get[[]string]("HOSTS")
+//
+// Note: A variable that is set but empty returns the zero value of T.
+func Get[T any](key string) (T, error) {
+	var t T
+
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return t, &VarNotSetError{Key: key, Field: key}
+	}
+
+	// The error already includes the key.
+	if err := setValueOf(reflect.ValueOf(&t).Elem(), key, val); err != nil {
+		return t, err
+	}
+
+	return t, nil
+}
+
+// GetOr reads a single environment variable and parses it into T, returning def if it is not set or invalid.
+//
+// Parameters:
+//
+//   - key: The environment variable to read.
+//   - def: The value to return if the variable is not set or cannot be parsed.
+//
+// Returns: The parsed value, or def.
+//
+// Example:
+//
+//	timeout := env.GetOr("TIMEOUT", 30*time.Second)
+func GetOr[T any](key string, def T) T {
+	t, err := Get[T](key)
+	if err != nil {
+		return def
+	}
+	return t
+}
+
+// setValueOf parses a value into v, the same as a struct field named after the key.
+//
+// Parameters:
+//
+//   - v: The settable reflect.Value to set.
+//   - key: The environment variable the value was read from.
+//   - val: The value to parse.
+//
+// Returns: An error if the value could not be parsed.
+func setValueOf(v reflect.Value, key, val string) error {
+	sf := reflect.StructField{Name: key, Type: v.Type()}
+	tags := FieldTags{OwnKey: key, Key: key}
+	opts := Options{Env: map[string]string{key: val}}
+
+	return setField(v, sf, tags, &opts)
+}
@@ -0,0 +1,88 @@
+package env
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	t.Setenv("GET_INT", "42")
+	t.Setenv("GET_BOOL", "true")
+	t.Setenv("GET_DURATION", "5s")
+	t.Setenv("GET_SLICE", "a,b,c")
+	t.Setenv("GET_MAP", "a:1,b:2")
+	t.Setenv("GET_ADDR", "10.0.0.1")
+	t.Setenv("GET_EMPTY", "")
+	t.Setenv("GET_INVALID", "abc")
+
+	tests := []struct {
+		name     string
+		get      func() (any, error)
+		expected any
+		wantErr  bool
+	}{
+		{name: "Int", get: func() (any, error) { return Get[int]("GET_INT") }, expected: 42},
+		{name: "Bool", get: func() (any, error) { return Get[bool]("GET_BOOL") }, expected: true},
+		{name: "Duration", get: func() (any, error) { return Get[time.Duration]("GET_DURATION") }, expected: 5 * time.Second},
+		{name: "Slice", get: func() (any, error) { return Get[[]string]("GET_SLICE") }, expected: []string{"a", "b", "c"}},
+		{name: "Map", get: func() (any, error) { return Get[map[string]int]("GET_MAP") }, expected: map[string]int{"a": 1, "b": 2}},
+		{name: "TextUnmarshaler", get: func() (any, error) { return Get[netip.Addr]("GET_ADDR") }, expected: netip.MustParseAddr("10.0.0.1")},
+		{name: "Pointer", get: func() (any, error) {
+			p, err := Get[*int]("GET_INT")
+			if p == nil {
+				return nil, err
+			}
+			return *p, err
+		}, expected: 42},
+		{name: "Empty", get: func() (any, error) { return Get[string]("GET_EMPTY") }, expected: ""},
+		{name: "Not set", get: func() (any, error) { return Get[int]("GET_MISSING") }, expected: 0, wantErr: true},
+		{name: "Invalid", get: func() (any, error) { return Get[int]("GET_INVALID") }, expected: 0, wantErr: true},
+		{name: "Unsupported", get: func() (any, error) { return Get[chan int]("GET_INT") }, expected: chan int(nil), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Get() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	t.Setenv("GET_OR_INT", "42")
+	t.Setenv("GET_OR_INVALID", "abc")
+
+	tests := []struct {
+		name     string
+		key      string
+		expected int
+	}{
+		{name: "Set", key: "GET_OR_INT", expected: 42},
+		{name: "Not set", key: "GET_OR_MISSING", expected: 7},
+		{name: "Invalid", key: "GET_OR_INVALID", expected: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetOr(tt.key, 7); got != tt.expected {
+				t.Errorf("GetOr() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	b.Setenv("GET_BENCH", "42")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Get[int]("GET_BENCH")
+	}
+}
@@ -0,0 +1,119 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EnvVarSpec is a Kubernetes container environment variable, as found within a Deployment's `env` list.
+//
+// It marshals to the same JSON, or YAML, as the Kubernetes EnvVar type without depending on the Kubernetes packages.
+type EnvVarSpec struct {
+	Name      string        `json:"name" yaml:"name"`
+	Value     string        `json:"value,omitempty" yaml:"value,omitempty"`
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty" yaml:"valueFrom,omitempty"`
+}
+
+// EnvVarSource is the source of an EnvVarSpec's value.
+type EnvVarSource struct {
+	SecretKeyRef *SecretKeySelector `json:"secretKeyRef,omitempty" yaml:"secretKeyRef,omitempty"`
+}
+
+// SecretKeySelector selects a key of a Kubernetes Secret.
+type SecretKeySelector struct {
+	Name string `json:"name" yaml:"name"`
+	Key  string `json:"key" yaml:"key"`
+}
+
+// ToK8sEnvVars converts a struct containing `env` tags into Kubernetes environment variables.
+//
+// Each field becomes a name and value, using the field's value if it is set, otherwise its `envDefault`.
+// Fields with the `secret` option become a secretKeyRef instead, so the value never appears within the manifest.
+//
+// The Secret is named by the `envSecret` tag on the field, or on a parent struct field, as "name" or "name/key".
+// The key defaults to the environment variable name.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The environment variables in field order, or an error if a value cannot be formatted
+// or a secret field has no Secret name.
+//
+// Example:
+//
+//	type Config struct {
+//		Port     int    `env:"PORT" envDefault:"8080"`
+//		Password string `env:"DB_PASSWORD,secret" envSecret:"db-credentials/password"`
+//	}
+//
+//	vars, err := env.ToK8sEnvVars(Config{})
+//	// [{Name: PORT, Value: 8080}, {Name: DB_PASSWORD, ValueFrom: {SecretKeyRef: {Name: db-credentials, Key: password}}}]
+func ToK8sEnvVars(v interface{}) ([]EnvVarSpec, error) {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return nil, errors.New("expected a struct or a pointer to a valid struct")
+	}
+
+	var vars []EnvVarSpec
+	err := envWalker{fn: func(field envField) error {
+		spec, err := k8sEnvVar(field.Value, field.StructField, field.Tags, field.SecretName)
+		if err != nil {
+			return err
+		}
+		vars = append(vars, spec)
+		return nil
+	}}.walk(ref, Options{}, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// k8sEnvVar creates the environment variable for a single field.
+//
+// Parameters:
+//
+//   - f: The reflect.Value of the field.
+//   - sf: The reflect.StructField of the field.
+//   - tags: The FieldTags of the field.
+//   - secretName: The `envSecret` tag of the field or its parent, as "name" or "name/key".
+//
+// Returns: The EnvVarSpec, or an error if the value cannot be formatted or a secret field has no Secret name.
+func k8sEnvVar(f reflect.Value, sf reflect.StructField, tags FieldTags, secretName string) (EnvVarSpec, error) {
+	spec := EnvVarSpec{Name: tags.Key}
+
+	if tags.Secret {
+		if secretName == "" {
+			return spec, fmt.Errorf("secret field %s requires an %s tag naming the Kubernetes Secret", sf.Name, SecretNameEnv)
+		}
+
+		name, key, _ := strings.Cut(secretName, "/")
+		if key == "" {
+			key = tags.Key
+		}
+
+		spec.ValueFrom = &EnvVarSource{SecretKeyRef: &SecretKeySelector{Name: name, Key: key}}
+		return spec, nil
+	}
+
+	if f.IsZero() {
+		spec.Value = tags.Default
+		return spec, nil
+	}
+
+	val, err := formatField(f, sf, tags)
+	if err != nil {
+		return spec, fmt.Errorf("unable to format %s: %w", sf.Name, err)
+	}
+	spec.Value = val
+
+	return spec, nil
+}
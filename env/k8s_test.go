@@ -0,0 +1,147 @@
+package env
+
+import (
+	"encoding/json"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToK8sEnvVars(t *testing.T) {
+	type Database struct {
+		Host     string `env:"HOST" envDefault:"localhost"`
+		Password string `env:"PASSWORD,secret"`
+	}
+	type Worker struct {
+		Name string `env:"NAME"`
+	}
+	type Config struct {
+		Port     int               `env:"PORT" envDefault:"8080"`
+		Timeout  time.Duration     `env:"TIMEOUT"`
+		Hosts    []string          `env:"HOSTS" envSeparator:";"`
+		Labels   map[string]string `env:"LABELS"`
+		Addr     netip.Addr        `env:"ADDR"`
+		Location time.Location     `env:"LOCATION"`
+		APIKey   string            `env:"API_KEY,secret" envSecret:"api/key"`
+		Database *Database         `envPrefix:"DB" envSecret:"db-credentials"`
+		Workers  []Worker          `envPrefix:"WORKER"`
+		Ignored  string            `env:"-"`
+		NoTag    string
+		private  string `env:"PRIVATE"`
+	}
+
+	cfg := Config{
+		Timeout:  time.Minute,
+		Hosts:    []string{"a", "b"},
+		Labels:   map[string]string{"team": "core", "env": "prod"},
+		Addr:     netip.MustParseAddr("10.0.0.1"),
+		Location: *time.UTC,
+		Workers:  []Worker{{Name: "first"}, {Name: "second"}},
+	}
+
+	got, err := ToK8sEnvVars(&cfg)
+	if err != nil {
+		t.Fatalf("ToK8sEnvVars() error = %v", err)
+	}
+
+	expected := []EnvVarSpec{
+		{Name: "PORT", Value: "8080"},
+		{Name: "TIMEOUT", Value: "1m0s"},
+		{Name: "HOSTS", Value: "a;b"},
+		{Name: "LABELS", Value: "env:prod,team:core"},
+		{Name: "ADDR", Value: "10.0.0.1"},
+		{Name: "LOCATION", Value: "UTC"},
+		{Name: "API_KEY", ValueFrom: &EnvVarSource{SecretKeyRef: &SecretKeySelector{Name: "api", Key: "key"}}},
+		{Name: "DB_HOST", Value: "localhost"},
+		{Name: "DB_PASSWORD", ValueFrom: &EnvVarSource{SecretKeyRef: &SecretKeySelector{Name: "db-credentials", Key: "DB_PASSWORD"}}},
+		{Name: "WORKER_0_NAME", Value: "first"},
+		{Name: "WORKER_1_NAME", Value: "second"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ToK8sEnvVars() = %+v, expected %+v", got, expected)
+	}
+
+	data, _ := json.Marshal(got[len(got)-3])
+	if string(data) != `{"name":"DB_PASSWORD","valueFrom":{"secretKeyRef":{"name":"db-credentials","key":"DB_PASSWORD"}}}` {
+		t.Errorf("json.Marshal() = %s, expected a Kubernetes secretKeyRef", data)
+	}
+}
+
+func TestToK8sEnvVarsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{"Nil", nil},
+		{"Nil pointer", (*struct{})(nil)},
+		{"Not a struct", "string"},
+		{"Secret without a name", struct {
+			Password string `env:"PASSWORD,secret"`
+		}{}},
+		{"Nested secret without a name", struct {
+			Inner struct {
+				Password string `env:"PASSWORD,secret"`
+			} `envPrefix:"INNER"`
+		}{}},
+		{"Slice secret without a name", struct {
+			Items []struct {
+				Password string `env:"PASSWORD,secret"`
+			} `envPrefix:"ITEM"`
+		}{Items: make([]struct {
+			Password string `env:"PASSWORD,secret"`
+		}, 1)}},
+		{"Unsupported type", struct {
+			C chan int `env:"C"`
+		}{C: make(chan int)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToK8sEnvVars(tt.input); err == nil {
+				t.Errorf("ToK8sEnvVars() expected an error")
+			}
+		})
+	}
+}
+
+func TestToK8sEnvVarsNilSlice(t *testing.T) {
+	type Item struct {
+		Name string `env:"NAME"`
+	}
+	type Config struct {
+		Items *[]Item `envPrefix:"ITEM"`
+		Ptr   *string `env:"PTR" envDefault:"fallback"`
+	}
+
+	got, err := ToK8sEnvVars(Config{})
+	if err != nil {
+		t.Fatalf("ToK8sEnvVars() error = %v", err)
+	}
+
+	expected := []EnvVarSpec{{Name: "PTR", Value: "fallback"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ToK8sEnvVars() = %+v, expected %+v", got, expected)
+	}
+
+	items := []Item{{Name: "a"}}
+	value := "set"
+	got, _ = ToK8sEnvVars(Config{Items: &items, Ptr: &value})
+	expected = []EnvVarSpec{{Name: "ITEM_0_NAME", Value: "a"}, {Name: "PTR", Value: "set"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ToK8sEnvVars() = %+v, expected %+v", got, expected)
+	}
+}
+
+func BenchmarkToK8sEnvVars(b *testing.B) {
+	type Config struct {
+		Host     string `env:"HOST" envDefault:"localhost"`
+		Port     int    `env:"PORT" envDefault:"8080"`
+		Password string `env:"PASSWORD,secret" envSecret:"db"`
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = ToK8sEnvVars(Config{})
+	}
+}
@@ -0,0 +1,111 @@
+package env
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Load sets the environment variables from .env files, without overwriting variables that are already set.
+//
+// Variables set by the process, such as within a container, take priority over the files.
+// As earlier files set their variables first, they also take priority over later files.
+//
+// Parameters:
+//   - filenames: The filenames to load the environment variables from.
+//
+// Example:
+//
+//	// PORT=9000 ./app keeps 9000, even if .env holds PORT=8080
+//	err := env.Load(".env")
+//
+// Returns: An error if a file could not be parsed, or a variable could not be set.
+//
+// Note: If no filenames are provided, it will default to ".env".
+func Load(filenames ...string) error {
+	return ParseFromFiles(func(key, value string) error {
+		if _, ok := os.LookupEnv(key); ok {
+			return nil
+		}
+		return os.Setenv(key, value)
+	}, filenames...)
+}
+
+// Overload sets the environment variables from .env files, overwriting variables that are already set.
+//
+// Later files take priority over earlier files, and both take priority over the process.
+//
+// Parameters:
+//   - filenames: The filenames to load the environment variables from.
+//
+// Example:
+//
+//	// Values within .env.test always replace those of the process
+//	err := env.Overload(".env", ".env.test")
+//
+// Returns: An error if a file could not be parsed, or a variable could not be set.
+//
+// Note: If no filenames are provided, it will default to ".env".
+func Overload(filenames ...string) error {
+	return ParseFromFiles(os.Setenv, filenames...)
+}
+
+// LoadEnv loads the .env files for an environment, such as "development" or "production", like Rails and Vite.
+//
+// Files are loaded with Load, so the process takes priority, followed by (highest first):
+//   - .env.<environment>.local
+//   - .env.<environment>
+//   - .env.local
+//   - .env
+//
+// Missing files are skipped. The .local files hold overrides for a machine and should not be committed.
+//
+// Parameters:
+//   - environment: The name of the environment, or empty to only load .env.local and .env.
+//
+// Example:
+//
+//	err := env.LoadEnv(os.Getenv("APP_ENV"))
+//
+// Returns: An error if a file could not be read or parsed, or a variable could not be set.
+//
+// Note: .env.local is skipped for the "test" environment, so tests give the same results on every machine.
+func LoadEnv(environment string) error {
+	return loadEnvIn("", environment)
+}
+
+// loadEnvIn loads the .env files for an environment from a directory, see LoadEnv.
+//
+// Parameters:
+//   - dir: The directory holding the files, empty for the working directory.
+//   - environment: The name of the environment.
+//
+// Returns: An error if a file could not be read or parsed, or a variable could not be set.
+func loadEnvIn(dir, environment string) error {
+	var candidates []string
+	if environment != "" {
+		candidates = append(candidates, ".env."+environment+".local", ".env."+environment)
+	}
+	if environment != "test" {
+		candidates = append(candidates, ".env.local")
+	}
+	candidates = append(candidates, ".env")
+
+	filenames := make([]string, 0, len(candidates))
+	for _, filename := range candidates {
+		filename = filepath.Join(dir, filename)
+		if _, err := os.Stat(filename); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		filenames = append(filenames, filename)
+	}
+
+	if len(filenames) == 0 {
+		return nil
+	}
+
+	return Load(filenames...)
+}
@@ -0,0 +1,165 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeEnvFiles writes each file within a temporary directory, returning their paths in order.
+func writeEnvFiles(t testing.TB, files ...string) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, len(files))
+	for i, content := range files {
+		paths[i] = filepath.Join(dir, ".env"+string(rune('a'+i)))
+		if err := os.WriteFile(paths[i], []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestLoad(t *testing.T) {
+	paths := writeEnvFiles(t, "LOAD_EXISTING=file\nLOAD_FIRST=first\nLOAD_BOTH=first", "LOAD_BOTH=second\nLOAD_SECOND=second")
+
+	t.Setenv("LOAD_EXISTING", "process")
+	for _, key := range []string{"LOAD_FIRST", "LOAD_BOTH", "LOAD_SECOND"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := Load(paths...); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"LOAD_EXISTING": "process",
+		"LOAD_FIRST":    "first",
+		"LOAD_BOTH":     "first",
+		"LOAD_SECOND":   "second",
+	}
+	for key, val := range expected {
+		if got := os.Getenv(key); got != val {
+			t.Errorf("Load() %s = %q, expected %q", key, got, val)
+		}
+	}
+
+	if err := Load(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Load() expected an error for a missing file")
+	}
+}
+
+func TestOverload(t *testing.T) {
+	paths := writeEnvFiles(t, "OVERLOAD_EXISTING=file\nOVERLOAD_BOTH=first", "OVERLOAD_BOTH=second")
+
+	t.Setenv("OVERLOAD_EXISTING", "process")
+	t.Setenv("OVERLOAD_BOTH", "")
+
+	if err := Overload(paths...); err != nil {
+		t.Fatalf("Overload() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"OVERLOAD_EXISTING": "file",
+		"OVERLOAD_BOTH":     "second",
+	}
+	for key, val := range expected {
+		if got := os.Getenv(key); got != val {
+			t.Errorf("Overload() %s = %q, expected %q", key, got, val)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	files := map[string]string{
+		".env":                  "LOADENV_A=env\nLOADENV_B=env\nLOADENV_C=env\nLOADENV_D=env\nLOADENV_E=env",
+		".env.local":            "LOADENV_B=local\nLOADENV_C=local\nLOADENV_D=local",
+		".env.production":       "LOADENV_C=production\nLOADENV_D=production",
+		".env.production.local": "LOADENV_D=production.local",
+		".env.test":             "LOADENV_C=test",
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		expected    map[string]string
+	}{
+		{
+			name:        "Production",
+			environment: "production",
+			expected:    map[string]string{"LOADENV_A": "env", "LOADENV_B": "local", "LOADENV_C": "production", "LOADENV_D": "production.local", "LOADENV_E": "process"},
+		},
+		{
+			name:        "Test skips .env.local",
+			environment: "test",
+			expected:    map[string]string{"LOADENV_A": "env", "LOADENV_B": "env", "LOADENV_C": "test", "LOADENV_D": "env", "LOADENV_E": "process"},
+		},
+		{
+			name:     "No environment",
+			expected: map[string]string{"LOADENV_A": "env", "LOADENV_B": "local", "LOADENV_C": "local", "LOADENV_D": "local", "LOADENV_E": "process"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for key := range tt.expected {
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+			t.Setenv("LOADENV_E", "process")
+
+			if err := loadEnvIn(dir, tt.environment); err != nil {
+				t.Fatalf("LoadEnv() error = %v", err)
+			}
+
+			for key, val := range tt.expected {
+				if got := os.Getenv(key); got != val {
+					t.Errorf("LoadEnv() %s = %q, expected %q", key, got, val)
+				}
+			}
+		})
+	}
+
+	t.Run("No files", func(t *testing.T) {
+		// The package directory has no .env files.
+		if err := LoadEnv("production"); err != nil {
+			t.Errorf("LoadEnv() error = %v", err)
+		}
+	})
+
+	t.Run("Invalid file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("key=value"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadEnvIn(dir, ""); err == nil {
+			t.Error("LoadEnv() expected an error")
+		}
+	})
+
+	t.Run("Invalid environment name", func(t *testing.T) {
+		if err := LoadEnv("bad\x00name"); err == nil {
+			t.Error("LoadEnv() expected an error")
+		}
+	})
+}
+
+func BenchmarkLoad(b *testing.B) {
+	paths := writeEnvFiles(b, "BENCH_LOAD_A=1\nBENCH_LOAD_B=2")
+	b.Cleanup(func() {
+		os.Unsetenv("BENCH_LOAD_A")
+		os.Unsetenv("BENCH_LOAD_B")
+	})
+
+	for i := 0; i < b.N; i++ {
+		_ = Load(paths...)
+	}
+}
@@ -0,0 +1,302 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// envPair is an environment variable, kept in field order when writing a file.
+type envPair struct {
+	Key   string
+	Value string
+}
+
+// Marshal converts a struct containing `env` tags into environment variables, the inverse of Parse.
+//
+// Each field uses its value, or its `envDefault` if the value is the zero value.
+// Slices and maps are joined with their separators, and nested structs follow the same prefixes as Parse.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The environment variables, or an error if a value cannot be formatted.
+//
+// Example:
+//
+//	vars, err := env.Marshal(cfg)
+//	// map[DATABASE_URL:postgres://localhost PORT:8080]
+func Marshal(v interface{}) (map[string]string, error) {
+	pairs, err := marshalPairs(v)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		vars[pair.Key] = pair.Value
+	}
+
+	return vars, nil
+}
+
+// WriteToFile writes a struct containing `env` tags to a .env file, as KEY=VALUE lines in field order.
+//
+// Values are quoted if needed, so the file can be read back with ParseFromFileIntoStruct.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//   - filename: The file to write, it is created or truncated.
+//
+// Returns: An error if a value cannot be formatted or the file cannot be written.
+//
+// Example:
+//
+//	// Generate a sample file from the defaults.
+//	err := env.WriteToFile(Config{}, ".env.example")
+//
+// Note: The file is created with 0600 permissions, as it may hold secrets.
+func WriteToFile(v interface{}, filename string) error {
+	pairs, err := marshalPairs(v)
+	if err != nil {
+		return err
+	}
+
+	// Writing to a bytes.Buffer cannot fail.
+	var buf bytes.Buffer
+	_ = writeEnvPairs(&buf, pairs)
+
+	return os.WriteFile(filename, buf.Bytes(), 0o600)
+}
+
+// SetFromStruct sets an environment variable with os.Setenv for each non-zero field of a struct containing `env` tags.
+//
+// Nested structs follow the same prefixes as Parse. Zero fields are skipped, so existing variables are left unchanged.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: An error if a value cannot be formatted or set.
+//
+// Example:
+//
+//	// Pass computed config to a child process.
+//	err := env.SetFromStruct(cfg)
+//	cmd := exec.Command("worker")
+func SetFromStruct(v interface{}) error {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return errors.New("expected a struct or a pointer to a valid struct")
+	}
+
+	return envWalker{fn: func(field envField) error {
+		if field.Value.IsZero() {
+			return nil
+		}
+
+		val, err := formatField(field.Value, field.StructField, field.Tags)
+		if err != nil {
+			return fmt.Errorf("unable to format %s: %w", field.Path, err)
+		}
+
+		return os.Setenv(field.Tags.Key, val)
+	}}.walk(ref, Options{}, "", "", nil)
+}
+
+// marshalPairs formats each field of a struct as an environment variable.
+//
+// Parameters:
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The environment variables in field order, or an error if a value cannot be formatted.
+func marshalPairs(v interface{}) ([]envPair, error) {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return nil, errors.New("expected a struct or a pointer to a valid struct")
+	}
+
+	var pairs []envPair
+	err := envWalker{fn: func(field envField) error {
+		val, err := marshalField(field)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, envPair{Key: field.Tags.Key, Value: val})
+		return nil
+	}}.walk(ref, Options{}, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// marshalField formats a field as an environment variable, using its `envDefault` if the value is the zero value.
+//
+// Parameters:
+//   - field: The field, as found by envWalker.
+//
+// Returns: The value of the variable, or an error if the value cannot be formatted.
+func marshalField(field envField) (string, error) {
+	if field.Value.IsZero() && field.Tags.Default != "" {
+		return field.Tags.Default, nil
+	}
+
+	val, err := formatField(field.Value, field.StructField, field.Tags)
+	if err != nil {
+		return "", fmt.Errorf("unable to format %s: %w", field.Path, err)
+	}
+	return val, nil
+}
+
+// Redacted renders the effective configuration of a struct containing `env` tags, with secret fields masked.
+//
+// Each field uses the same value as Marshal, so services can log their configuration at startup without leaking
+// passwords or API keys. Secrets of at least 12 characters keep their last 4, such as "****1234", so a rotated
+// secret can be told apart from the old one. Shorter secrets are masked entirely, and empty secrets stay empty.
+//
+// Parameters:
+//
+//   - v: A struct, or a pointer to a struct, containing `env` tags.
+//
+// Returns: The environment variables, or nil if v is not a struct.
+// Fields whose values cannot be formatted, which Marshal would return an error for, are left out.
+//
+// Example:
+//
+//	type Config struct {
+//		Port  int    `env:"PORT" envDefault:"8080"`
+//		Token string `env:"TOKEN,secret"`
+//	}
+//
+//	log.Printf("config: %v", env.Redacted(cfg))
+//	// config: map[PORT:8080 TOKEN:****1234]
+func Redacted(v interface{}) map[string]string {
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return nil
+	}
+
+	vars := make(map[string]string)
+	_ = envWalker{fn: func(field envField) error {
+		val, err := marshalField(field)
+		if err != nil {
+			return nil
+		}
+
+		if field.Tags.Secret {
+			val = redact(val)
+		}
+		vars[field.Tags.Key] = val
+		return nil
+	}}.walk(ref, Options{}, "", "", nil)
+
+	return vars
+}
+
+// redact masks a secret, keeping the last 4 characters of secrets of at least 12 characters.
+func redact(secret string) string {
+	const mask = "****"
+
+	if secret == "" {
+		return ""
+	}
+
+	runes := []rune(secret)
+	if len(runes) < 12 {
+		return mask
+	}
+	return mask + string(runes[len(runes)-4:])
+}
+
+// writeEnvPairs writes each environment variable as a KEY=VALUE line.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - pairs: The environment variables to write.
+//
+// Returns: An error if writing fails.
+func writeEnvPairs(w io.Writer, pairs []envPair) error {
+	for _, pair := range pairs {
+		if _, err := io.WriteString(w, pair.Key+"="+quoteValue(pair.Value)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteValue double quotes a value if it would otherwise be read back differently.
+//
+// Such as empty values, values with surrounding spaces, comments, quotes or newlines.
+// Backslashes, double quotes and newlines are escaped, the inverse of unescapeQuotes.
+// Values holding a $ are single quoted where possible, so they are not expanded. Otherwise, such as a value holding
+// both a $ and a ' or a newline, each $ is escaped as \$, which is read back as a literal $.
+//
+// Parameters:
+//   - s: The value to quote.
+//
+// Returns: The value, quoted if needed.
+func quoteValue(s string) string {
+	needsQuotes := s == "" ||
+		strings.TrimFunc(s, isSpace) != s ||
+		strings.ContainsAny(s, "#\"'\\\n\r")
+
+	if !needsQuotes && !strings.Contains(s, "$") {
+		return s
+	}
+
+	// Single quoted values are not expanded when read back, so a $ is kept as written.
+	if strings.Contains(s, "$") && !strings.ContainsAny(s, "'\n\r") && !strings.HasSuffix(s, `\`) {
+		return "'" + s + "'"
+	}
+
+	return doubleQuote(s)
+}
+
+// doubleQuote double quotes a value, escaping backslashes, double quotes, newlines and each $.
+//
+// Parameters:
+//   - s: The value to quote.
+//
+// Returns: The quoted value, read back exactly as s.
+func doubleQuote(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s) + 2)
+
+	builder.WriteByte(CharDoubleQuote)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', CharDoubleQuote, '$':
+			builder.WriteByte('\\')
+			builder.WriteByte(s[i])
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		default:
+			builder.WriteByte(s[i])
+		}
+	}
+	builder.WriteByte(CharDoubleQuote)
+
+	return builder.String()
+}
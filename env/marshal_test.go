@@ -0,0 +1,349 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type marshalDatabase struct {
+	Host     string `env:"HOST" envDefault:"localhost"`
+	Password string `env:"PASSWORD,secret"`
+}
+
+type marshalWorker struct {
+	Name string `env:"NAME"`
+}
+
+type marshalConfig struct {
+	Port     int               `env:"PORT" envDefault:"8080"`
+	Debug    bool              `env:"DEBUG"`
+	Timeout  time.Duration     `env:"TIMEOUT"`
+	Hosts    []string          `env:"HOSTS" envSeparator:";"`
+	Labels   map[string]string `env:"LABELS"`
+	Message  string            `env:"MESSAGE"`
+	Database *marshalDatabase  `envPrefix:"DB"`
+	Workers  []marshalWorker   `envPrefix:"WORKER"`
+	Ignored  string            `env:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "Values",
+			v: &marshalConfig{
+				Port:     9000,
+				Debug:    true,
+				Timeout:  time.Minute,
+				Hosts:    []string{"a", "b"},
+				Labels:   map[string]string{"team": "core", "env": "prod"},
+				Message:  "hello world",
+				Database: &marshalDatabase{Host: "db", Password: "secret"},
+				Workers:  []marshalWorker{{Name: "first"}},
+			},
+			expected: map[string]string{
+				"PORT":          "9000",
+				"DEBUG":         "true",
+				"TIMEOUT":       "1m0s",
+				"HOSTS":         "a;b",
+				"LABELS":        "env:prod,team:core",
+				"MESSAGE":       "hello world",
+				"DB_HOST":       "db",
+				"DB_PASSWORD":   "secret",
+				"WORKER_0_NAME": "first",
+			},
+		},
+		{
+			name: "Defaults",
+			v:    marshalConfig{},
+			expected: map[string]string{
+				"PORT":        "8080",
+				"DEBUG":       "false",
+				"TIMEOUT":     "0s",
+				"HOSTS":       "",
+				"LABELS":      "",
+				"MESSAGE":     "",
+				"DB_HOST":     "localhost",
+				"DB_PASSWORD": "",
+			},
+		},
+		{
+			name:    "Not a struct",
+			v:       "string",
+			wantErr: true,
+		},
+		{
+			name: "Unsupported type",
+			v: struct {
+				Ch chan int `env:"CH"`
+			}{Ch: make(chan int)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Marshal() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	type Config struct {
+		Token  string   `env:"TOKEN,secret"`
+		APIKey string   `env:"API_KEY,secret" envDefault:"sk_test_0123456789"`
+		Ch     chan int `env:"CH"`
+	}
+
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected map[string]string
+	}{
+		{
+			name: "Values",
+			v: &marshalConfig{
+				Port:     9000,
+				Database: &marshalDatabase{Host: "db", Password: "correct-horse-battery"},
+			},
+			expected: map[string]string{
+				"PORT":        "9000",
+				"DEBUG":       "false",
+				"TIMEOUT":     "0s",
+				"HOSTS":       "",
+				"LABELS":      "",
+				"MESSAGE":     "",
+				"DB_HOST":     "db",
+				"DB_PASSWORD": "****tery",
+			},
+		},
+		{
+			name:     "Short, default and unformattable",
+			v:        Config{Token: "hunter2", Ch: make(chan int)},
+			expected: map[string]string{"TOKEN": "****", "API_KEY": "****6789"},
+		},
+		{
+			name:     "Empty secret",
+			v:        Config{},
+			expected: map[string]string{"TOKEN": "", "API_KEY": "****6789"},
+		},
+		{
+			name: "Not a struct",
+			v:    "string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redacted(tt.v); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Redacted() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		secret   string
+		expected string
+	}{
+		{secret: "", expected: ""},
+		{secret: "a", expected: "****"},
+		{secret: "12345678901", expected: "****"},
+		{secret: "123456789012", expected: "****9012"},
+		{secret: "pässwörd-ünïcødé", expected: "****cødé"},
+	}
+
+	for _, tt := range tests {
+		if got := redact(tt.secret); got != tt.expected {
+			t.Errorf("redact(%q) = %q, expected %q", tt.secret, got, tt.expected)
+		}
+	}
+}
+
+func TestWriteToFile(t *testing.T) {
+	cfg := marshalConfig{
+		Port:     9000,
+		Timeout:  time.Second,
+		Hosts:    []string{"a", "b"},
+		Labels:   map[string]string{"team": "core"},
+		Message:  ` say "hi" # not a comment\n` + "\nC:\\",
+		Database: &marshalDatabase{Host: "db", Password: "p@ss word"},
+		Workers:  []marshalWorker{{Name: "first"}, {Name: "second"}},
+	}
+
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := WriteToFile(cfg, filename); err != nil {
+		t.Fatalf("WriteToFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "PORT=9000\nDEBUG=false\n") {
+		t.Errorf("WriteToFile() = %q, expected variables in field order", data)
+	}
+
+	var got marshalConfig
+	if err = ParseFromFileIntoStruct(&got, filename); err != nil {
+		t.Fatalf("ParseFromFileIntoStruct() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("WriteToFile() round trip = %+v, expected %+v", got, cfg)
+	}
+
+	t.Run("Format error", func(t *testing.T) {
+		if err := WriteToFile(1, filename); err == nil {
+			t.Error("WriteToFile() expected an error")
+		}
+	})
+
+	t.Run("Write error", func(t *testing.T) {
+		if err := WriteToFile(cfg, filepath.Join(t.TempDir(), "missing", ".env")); err == nil {
+			t.Error("WriteToFile() expected an error")
+		}
+	})
+}
+
+func TestSetFromStruct(t *testing.T) {
+	keys := []string{"PORT", "DEBUG", "HOSTS", "MESSAGE", "DB_HOST", "DB_PASSWORD", "WORKER_0_NAME"}
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("MESSAGE", "unchanged")
+
+	cfg := marshalConfig{
+		Port:     9000,
+		Hosts:    []string{"a", "b"},
+		Database: &marshalDatabase{Host: "db"},
+		Workers:  []marshalWorker{{Name: "first"}},
+	}
+	if err := SetFromStruct(&cfg); err != nil {
+		t.Fatalf("SetFromStruct() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"PORT":          "9000",
+		"HOSTS":         "a;b",
+		"MESSAGE":       "unchanged",
+		"DB_HOST":       "db",
+		"WORKER_0_NAME": "first",
+	}
+	for _, key := range keys {
+		val, ok := os.LookupEnv(key)
+		if want, wantOk := expected[key]; val != want || ok != wantOk {
+			t.Errorf("SetFromStruct() %s = %q (set %v), expected %q (set %v)", key, val, ok, want, wantOk)
+		}
+	}
+
+	t.Run("Not a struct", func(t *testing.T) {
+		if err := SetFromStruct(nil); err == nil {
+			t.Error("SetFromStruct() expected an error")
+		}
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		v := struct {
+			Ch chan int `env:"CH"`
+		}{Ch: make(chan int)}
+		if err := SetFromStruct(v); err == nil {
+			t.Error("SetFromStruct() expected an error")
+		}
+	})
+}
+
+func TestWriteEnvPairs(t *testing.T) {
+	err := writeEnvPairs(failingWriter{}, []envPair{{Key: "KEY", Value: "value"}})
+	if err == nil {
+		t.Error("writeEnvPairs() expected an error")
+	}
+}
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "with space", expected: "with space"},
+		{value: "a=b:c", expected: "a=b:c"},
+		{value: "", expected: `""`},
+		{value: " padded ", expected: `" padded "`},
+		{value: "a # b", expected: `"a # b"`},
+		{value: `say "hi"`, expected: `"say \"hi\""`},
+		{value: "it's", expected: `"it's"`},
+		{value: `C:\`, expected: `"C:\\"`},
+		{value: "line\nbreak\r", expected: `"line\nbreak\r"`},
+		{value: "pa$$word", expected: `'pa$$word'`},
+		{value: "$HOME # dir", expected: `'$HOME # dir'`},
+		{value: "it's $5", expected: `"it's \$5"`},
+		{value: `$C:\`, expected: `"\$C:\\"`},
+		{value: "${HOME}\n", expected: `"\${HOME}\n"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := quoteValue(tt.value); got != tt.expected {
+				t.Errorf("quoteValue() = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuoteValue_RoundTrip(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	values := []string{
+		"pa$$word", "it's $5", `$C:\`, "${HOME}\nnext", "$HOME's \\$HOME", `\$`, "$", "it's $HOME\r\n",
+		`say "$HOME"`, "$$", "plain", "",
+	}
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			vars, err := parseEnvFileBytes([]byte("KEY=" + quoteValue(value) + "\n"))
+			if err != nil {
+				t.Fatalf("parseEnvFileBytes() error = %v", err)
+			}
+			if vars["KEY"] != value {
+				t.Errorf("parseEnvFileBytes() = %q, expected %q from %s", vars["KEY"], value, quoteValue(value))
+			}
+		})
+	}
+}
+
+func BenchmarkRedacted(b *testing.B) {
+	cfg := marshalConfig{Port: 9000, Database: &marshalDatabase{Host: "db", Password: "correct-horse-battery"}}
+	for i := 0; i < b.N; i++ {
+		_ = Redacted(cfg)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	cfg := marshalConfig{Port: 9000, Hosts: []string{"a", "b"}, Database: &marshalDatabase{Host: "db"}}
+	for i := 0; i < b.N; i++ {
+		_, _ = Marshal(cfg)
+	}
+}
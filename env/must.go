@@ -0,0 +1,41 @@
+package env
+
+import "fmt"
+
+// Must returns the value if err is nil, otherwise it panics.
+//
+// Parameters:
+//
+//   - t: The value to return.
+//   - err: The error from the function returning t.
+//
+// Returns: t, if err is nil.
+//
+// Example:
+//
+//	var flags = env.Must(env.NewFlags(""))
+//
+// Note: Intended for package-level initialisation, where a misconfiguration should stop the program from starting.
+func Must[T any](t T, err error) T {
+	if err != nil {
+		panic(fmt.Sprintf("env: %T: %v", t, err))
+	}
+	return t
+}
+
+// MustParse parses the environment variables into a new T, panicking if parsing fails.
+//
+// Returns: The parsed T.
+//
+// Example:
+//
+//	var cfg = env.MustParse[Config]()
+//
+// Note: T must be a struct containing `env` tags, the panic message includes the struct type and the error.
+func MustParse[T any]() T {
+	t, err := ParseAs[T]()
+	if err != nil {
+		panic(fmt.Sprintf("env: failed to parse %T: %v", t, err))
+	}
+	return t
+}
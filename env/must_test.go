@@ -0,0 +1,74 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recoverMessage calls fn and returns the message it panicked with, or an empty string.
+func recoverMessage(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestMust(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "No error", err: nil, expected: ""},
+		{name: "Error", err: errors.New("failed"), expected: "env: int: failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			msg := recoverMessage(func() { got = Must(1, tt.err) })
+			if msg != tt.expected {
+				t.Errorf("Must() panic = %q, expected %q", msg, tt.expected)
+			}
+			if tt.err == nil && got != 1 {
+				t.Errorf("Must() = %v, expected 1", got)
+			}
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	type Config struct {
+		Port int `env:"MUST_PARSE_PORT,required"`
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Setenv("MUST_PARSE_PORT", "8080")
+
+		var cfg Config
+		if msg := recoverMessage(func() { cfg = MustParse[Config]() }); msg != "" {
+			t.Fatalf("MustParse() panic = %q", msg)
+		}
+		if cfg.Port != 8080 {
+			t.Errorf("MustParse() = %+v, expected port 8080", cfg)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		msg := recoverMessage(func() { MustParse[Config]() })
+		if !strings.Contains(msg, "env.Config") || !strings.Contains(msg, "MUST_PARSE_PORT") {
+			t.Errorf("MustParse() panic = %q, expected the type and variable", msg)
+		}
+	})
+}
+
+func BenchmarkMust(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = Must(i, nil)
+	}
+}
@@ -1,11 +1,15 @@
 package env
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/cloudment/utils-go/internal/strcase"
 )
 
 // Tags used for the struct tags, some are options within the Env tag.
@@ -23,13 +27,73 @@ const (
 	// InitEnv is the option for specifying that the field should be initialised.
 	InitEnv = "init"
 	// PrefixEnv is the option for specifying the prefix to use when looking up the tag.
+	//
+	// Variables within it are expanded, such as `envPrefix:"${TENANT}_DB"`, so one variable can redirect a nested struct.
 	PrefixEnv = "envPrefix"
+	// SquashEnv is the option of PrefixEnv promoting the fields of a nested struct to the namespace of its parent,
+	// such as `envPrefix:",squash"`. Any prefix before it is ignored, as is the field name for UseFieldNameByDefault.
+	SquashEnv = "squash"
 	// UnsetEnv is the option for specifying that the field should be unset/deleted from os.Environ().
+	// Use Options.UnsetAll to unset every variable that was read.
 	UnsetEnv = "unset"
 	// SeparatorEnv is the option for specifying the separator like , for slices.
 	SeparatorEnv = "envSeparator"
 	// KeyValSeparatorEnv is the option for specifying the key value separator like = for slices.
 	KeyValSeparatorEnv = "envKeyValSeparator"
+	// NestedSeparatorEnv is the tag for the separator within a map's slice or map values, like ; for "a:x=1;y=2". Defaults to ;.
+	NestedSeparatorEnv = "envNestedSeparator"
+	// NestedKeyValSeparatorEnv is the tag for the key value separator within a map's map values, like = for "a:x=1;y=2". Defaults to =.
+	NestedKeyValSeparatorEnv = "envNestedKeyValSeparator"
+	// LayoutEnv is the tag for the layout of a time.Time field, such as "2006-01-02". Defaults to time.RFC3339.
+	LayoutEnv = "envLayout"
+	// TZEnv is the tag for the time zone of a time.Time field, such as "Europe/London".
+	//
+	// It is used for layouts without a zone. Defaults to UTC.
+	TZEnv = "envTZ"
+	// FileEnv is the option for reading the value from the file at the path within the environment variable.
+	FileEnv = "file"
+	// Base64Env is the option for decoding a []byte field from base64, either standard or URL encoding.
+	Base64Env = "base64"
+	// HexEnv is the option for decoding a []byte field from hex.
+	HexEnv = "hex"
+	// AllowEmptyEnv is the option for keeping a variable that is set to an empty string, rather than using the default.
+	AllowEmptyEnv = "allowEmpty"
+	// ExtendedDurationEnv is the option for parsing a time.Duration field with days and weeks, such as "1w2d12h".
+	ExtendedDurationEnv = "extendedDuration"
+	// SecretEnv is the option for specifying that the field holds a secret, such as a password.
+	SecretEnv = "secret"
+	// ValidateEnv is the tag holding the rules a field is validated against after parsing, such as "min=1,max=65535".
+	//
+	// See FieldTags.Validate for the rules.
+	ValidateEnv = "envValidate"
+	// AliasEnv is the tag holding the comma separated keys tried in order when the key of a field is unset, such as "OLD_NAME,LEGACY_NAME".
+	//
+	// Aliases are prefixed as the key is.
+	AliasEnv = "envAlias"
+	// DeprecatedEnv is the tag marking the variable of a field as deprecated, holding a message such as "use NEW_KEY instead".
+	//
+	// Options.WarnFunc is called with the message whenever the variable is set.
+	DeprecatedEnv = "envDeprecated"
+	// ParserEnv is the tag naming the parser registered with RegisterParser that parses a field, such as "int-range".
+	ParserEnv = "envParser"
+	// GroupEnv is the tag naming the group of a field and its mode, such as "tls,requiredTogether".
+	//
+	// See FieldTags.Group for the modes.
+	GroupEnv = "envGroup"
+	// RequiredTogetherGroup is the mode of GroupEnv where every variable of the group is set, or none of them are.
+	RequiredTogetherGroup = "requiredTogether"
+	// MutuallyExclusiveGroup is the mode of GroupEnv where at most one variable of the group is set.
+	MutuallyExclusiveGroup = "mutuallyExclusive"
+	// SecretNameEnv is the tag naming the Kubernetes Secret that holds a secret field, as "name" or "name/key".
+	//
+	// When set on a struct field, it applies to the secret fields within it.
+	SecretNameEnv = "envSecret"
+
+	// DefaultFileSuffix is the suffix used by many Docker images for variables holding a file path, such as POSTGRES_PASSWORD_FILE.
+	DefaultFileSuffix = "_FILE"
+
+	// DefaultMaxDepth is the number of nested structs parsed within each other when Options.MaxDepth is not set.
+	DefaultMaxDepth = 32
 
 	// File specific
 
@@ -49,14 +113,203 @@ type Options struct {
 	// Env keys and values. This is fetched from os.Environ()
 	Env map[string]string
 
-	// Prefix is the prefix to apply before the key. Usually taken from the struct tag.
+	// Envs are merged over Env with MergeMaps before parsing, later maps take priority.
+	//
+	// Such as []map[string]string{defaults, fileVars}, so the file overrides the defaults.
+	// Sources are loaded over the merged maps, and Overlay still takes priority over every map.
+	Envs []map[string]string
+
+	// Sources are loaded over Env before parsing, later sources take priority.
+	//
+	// Such as []Source{FileSource{".env"}, OsEnvSource{}}, so the process overrides the file.
+	// Each source is given the context passed to ParseWithContext. Overlay still takes priority over every source.
+	Sources []Source
+
+	// Overlay is checked before Env, its values take priority without modifying Env.
 	//
-	// Such as "PREFIX_"
+	// Useful for tests and per-request overrides, as the base Env can be shared rather than copied.
+	Overlay map[string]string
+
+	// BoundFlags are the command-line flags registered by BindFlags, those that were set take priority over Overlay.
+	BoundFlags *FlagBinding
+
+	// Prefix is the prefix to apply before every key, including those of the root struct.
+	//
+	// Such as "MYAPP_", so PORT is read from MYAPP_PORT. Nested structs add their `envPrefix` after it.
+	// A trailing underscore is added if it is missing.
 	Prefix string
 
+	// TagName is the tag holding the key and options, such as "json" or "config". Defaults to Env.
+	//
+	// Useful for parsing structs that are already tagged for another purpose, without duplicating tags.
+	TagName string
+
+	// DefaultTagName is the tag holding the default value. Defaults to DefaultEnv.
+	DefaultTagName string
+
+	// PrefixTagName is the tag holding the prefix of nested structs. Defaults to PrefixEnv.
+	PrefixTagName string
+
+	// UseFieldNameByDefault reads fields without an `env` tag from their name in SCREAMING_SNAKE_CASE.
+	//
+	// Such as DatabaseURL from DATABASE_URL. Nested structs without an `envPrefix` are prefixed by their name,
+	// except embedded structs whose fields are read as if they were in the parent.
+	// Fields tagged with `env:"-"` are still ignored.
+	UseFieldNameByDefault bool
+
+	// FileSuffix enables reading a variable from a file, when a variable with the suffix holds its path.
+	//
+	// Such as "_FILE", so PASSWORD is read from the file at PASSWORD_FILE if PASSWORD is not set.
+	// Setting both is an error. An empty suffix disables it.
+	FileSuffix string
+
+	// CaseInsensitive matches keys regardless of case, such as reading PORT from Port or port.
+	//
+	// Useful on Windows, where variable names are case-insensitive. Env and Overlay are normalised to upper case once,
+	// before parsing. When several keys only differ by case, the key already in upper case is used, otherwise the first
+	// in sorted order. The keys of a map of structs are read in upper case, such as PRIMARY from DB_primary_HOST.
+	CaseInsensitive bool
+
+	// AllowEmpty keeps every variable that is set to an empty string, rather than treating it as unset.
+	//
+	// Such as PASSWORD="" for an intentionally blank password, which is otherwise replaced by the default,
+	// or reported as not set if the field is required. See FieldTags.AllowEmpty to allow it for a single field.
+	AllowEmpty bool
+
+	// OnlySetZero skips fields that already hold a non-zero value, so the environment only fills the gaps.
+	//
+	// Such as a struct pre-populated programmatically or from a config file. A skipped field is not read, defaulted,
+	// required or validated, though its variable still counts as used for Strict. Nested structs are still parsed,
+	// so their zero fields are filled. A false bool or 0 int is the zero value, so is always filled.
+	OnlySetZero bool
+
+	// InitNilPointers initialises every nil pointer, map and slice of the fields that are parsed, as `env:",init"` does.
+	//
+	// Useful when code assumes nested config is never nil, such as cfg.Database.TLS.CertFile.
+	// Nested structs are initialised whether or not they are tagged, as they are always parsed, as are the elements
+	// of a slice of pointers to structs. Fields tagged with `env:"-"` are left as they are.
+	InitNilPointers bool
+
+	// RequiredIfNoDefault treats every field without an `envDefault` tag as required.
+	//
+	// Fields that only hold an `envPrefix` for a nested struct are not affected.
+	RequiredIfNoDefault bool
+
+	// AggregateErrors collects the errors of every field rather than returning the first.
+	//
+	// Each error is a *FieldError holding the path to the field, and they are returned together with errors.Join.
+	AggregateErrors bool
+
+	// Strict reports the variables beginning with Prefix that are not read by any field, after parsing.
+	//
+	// Such as a typo like MYAPP_TIMEOUTT, which would otherwise silently fall back to the default.
+	// Without a Prefix every variable is checked, so it should be used with a Prefix or a specific Env.
+	Strict bool
+
+	// UnsetAll removes every variable read by a field from the process with os.Unsetenv, after a successful parse.
+	//
+	// This stops secrets leaking to child processes or crash reports, without tagging each field with
+	// `env:",unset"`. Only variables within Env are removed, such as those loaded by OsEnvSource,
+	// including those read through an alias or a FileSuffix. Nothing is removed if parsing fails.
+	UnsetAll bool
+
+	// MaxDepth is the number of nested structs that may be parsed within each other, defaulting to DefaultMaxDepth.
+	//
+	// A struct with a pointer to its own type, such as a linked list, is initialised at every level and would otherwise
+	// recurse until the stack is exhausted. Going deeper returns a *MaxDepthError holding the path that repeats.
+	MaxDepth int
+
+	// Validator is called with the struct once it has been parsed successfully, such as to use go-playground/validator.
+	//
+	// Its error is wrapped rather than flattened, so errors.As still finds its own type, such as
+	// validator.ValidationErrors, with the path of each field that failed.
+	//
+	// Example:
+	//
+	//	validate := validator.New()
+	//	err := env.ParseWithOpts(&cfg, env.Options{Validator: validate.Struct})
+	Validator func(v interface{}) error
+
+	// WarnFunc is called for problems that do not stop parsing, such as a variable marked with DeprecatedEnv being set,
+	// or a field being read from one of its AliasEnv keys.
+	//
+	// Parameters:
+	//   - key: The environment variable, including any prefix, such as "DB_HOST".
+	//   - message: The warning, such as "use DATABASE_HOST instead".
+	//     For an alias, the key is the alias and the message names the key of the field.
+	//
+	// Example:
+	//
+	//	err := env.ParseWithOpts(&cfg, env.Options{WarnFunc: func(key, message string) {
+	//		slog.Warn("deprecated environment variable", "key", key, "message", message)
+	//	}})
+	//
+	// Warnings are discarded when nil.
+	WarnFunc func(key, message string)
+
+	// OnError is called with the error of each field, such as a value that cannot be parsed or a required variable
+	// that is unset, before it stops parsing or is collected by AggregateErrors.
+	//
+	// Parameters:
+	//   - field: The path to the field, such as "Config.Database.Port".
+	//   - key: The environment variable, including any prefix, such as "DB_PORT".
+	//   - err: The error of the field, such as a *ParseError or *VarNotSetError.
+	//
+	// Returning nil downgrades the error and parsing continues, leaving the field unset unless it was parsed and only
+	// failed its envValidate rules. Returning another error replaces it, such as to add a hint for the operator.
+	//
+	// Example:
+	//
+	//	err := env.ParseWithOpts(&cfg, env.Options{OnError: func(field, key string, err error) error {
+	//		if key == "METRICS_PORT" {
+	//			slog.Warn("metrics disabled", "error", err)
+	//			return nil
+	//		}
+	//		return fmt.Errorf("%w (see https://example.com/docs/config)", err)
+	//	}})
+	//
+	// Errors are returned as they are when nil. Errors of a struct as a whole, such as a *GroupError, are not passed.
+	OnError func(field, key string, err error) error
+
+	// ExpandResolver resolves the references within expanded values before the environment, such as secrets held
+	// in a store rather than a variable.
+	//
+	// It is called with the name of each reference, such as "ssm:/app/db-password" for ${ssm:/app/db-password}.
+	// Returning false falls back to the fields parsed so far and the environment. A resolved value is used as it is,
+	// without expanding any references within it.
+	//
+	// Example:
+	//
+	//	type Config struct {
+	//		Password string `env:"DB_PASSWORD,expand" envDefault:"${vault:secret/db#password}"`
+	//	}
+	//
+	//	err := env.ParseWithOpts(&cfg, env.Options{ExpandResolver: func(name string) (string, bool) {
+	//		if ref, ok := strings.CutPrefix(name, "vault:"); ok {
+	//			return vaultClient.Get(ref)
+	//		}
+	//		return "", false
+	//	}})
+	//
+	// The environment is used alone when nil.
+	ExpandResolver func(name string) (string, bool)
+
+	// ctx is the context passed to ParseWithContext, given to each ContextUnmarshaler. It is nil otherwise.
+	ctx context.Context
+
+	// path is the path to the current struct, such as "Config.Database", used within errors.
+	path string
+
+	// depth is the number of structs the current struct is nested within, limited by MaxDepth.
+	depth int
+
+	// usedKeys holds the keys read by a field, used by Strict and UnsetAll. It is only created when either is set.
+	usedKeys map[string]bool
+
 	// rawEnvVars is the raw environment variables, this is used when expanding variables.
 	//
 	// Appended everytime a new key is found. Otherwise, this could be used for additional configuration.
+	// It is created on first use, see setRawEnv.
 	rawEnvVars map[string]string
 }
 
@@ -68,37 +321,290 @@ type Options struct {
 // Returns:
 //   - The raw environment variable in expanded form.
 //
+// Note: Errors from ${VAR:?message} within the raw value are ignored, only the field being parsed reports them.
+// ExpandResolver is asked first, its values are not expanded.
+//
 // See: https://pkg.go.dev/os#Expand
-func (opts Options) getRawEnv(s string) string {
+func (opts *Options) getRawEnv(s string) string {
+	if opts.ExpandResolver != nil {
+		if val, ok := opts.ExpandResolver(s); ok {
+			return val
+		}
+	}
+
 	// All fields that are scanned are put into the rawEnvVars map.
 	// This added with opts.rawEnvVars[tags.OwnKey] within the cmd.go file.
 	val := opts.rawEnvVars[s]
 	if val == "" {
-		val = opts.Env[s]
+		val, _ = opts.lookup(s)
+	}
+	val, _ = opts.expand(val)
+	return val
+}
+
+// expand expands the variables within s, like a POSIX shell, using the raw environment variables.
+//
+// Parameters:
+//   - s: The string to expand.
+//
+// Returns:
+//   - The expanded string.
+//   - An error from the first ${VAR:?message} whose variable is unset or empty.
+//
+// See: expandWith
+func (opts *Options) expand(s string) (string, error) {
+	return expandWith(s, opts.getRawEnv)
+}
+
+// expandWith expands the variables within s, like a POSIX shell.
+//
+// Along with $VAR and ${VAR}, it supports:
+//   - ${VAR:-default} uses default when VAR is unset or empty.
+//   - ${VAR:?message} fails with the message when VAR is unset or empty.
+//
+// Parameters:
+//   - s: The string to expand.
+//   - lookup: The function returning the value of a variable, empty when it is unset.
+//
+// Returns:
+//   - The expanded string.
+//   - An error from the first ${VAR:?message} whose variable is unset or empty.
+func expandWith(s string, lookup func(string) string) (string, error) {
+	var err error
+	val := os.Expand(s, func(name string) string {
+		val, varErr := expandVar(name, lookup)
+		if varErr != nil && err == nil {
+			err = varErr
+		}
+		return val
+	})
+	return val, err
+}
+
+// expandVar gets the value of a single variable within expandWith, such as "VAR" or "VAR:-default".
+func expandVar(name string, lookup func(string) string) (string, error) {
+	// The operator is found by its first ":-" or ":?", as the name may hold colons, such as "ssm:/app/key:-default".
+	i := strings.Index(name, ":-")
+	if j := strings.Index(name, ":?"); j != -1 && (i == -1 || j < i) {
+		i = j
+	}
+	if i == -1 {
+		return lookup(name), nil
+	}
+	key, operand := name[:i], name[i+1:]
+
+	if val := lookup(key); val != "" {
+		return val, nil
+	}
+
+	if operand[0] == '-' {
+		return operand[1:], nil
+	}
+
+	message := operand[1:]
+	if message == "" {
+		message = "parameter null or not set"
+	}
+	return "", fmt.Errorf("%s: %s", key, message)
+}
+
+// lookup gets the value of an environment variable, checking Overlay before Env.
+//
+// Parameters:
+//   - key: The environment variable to look up.
+//
+// Returns:
+//   - The value, and true if it was found in either map.
+func (opts *Options) lookup(key string) (string, bool) {
+	key = opts.normaliseKey(key)
+	if val, ok := opts.Overlay[key]; ok {
+		return val, true
+	}
+	val, ok := opts.Env[key]
+	return val, ok
+}
+
+// tagName returns the tag holding the key and options, falling back to Env.
+func (opts *Options) tagName() string {
+	if opts.TagName == "" {
+		return Env
 	}
-	return os.Expand(val, opts.getRawEnv)
+	return opts.TagName
+}
+
+// defaultTagName returns the tag holding the default value, falling back to DefaultEnv.
+func (opts *Options) defaultTagName() string {
+	if opts.DefaultTagName == "" {
+		return DefaultEnv
+	}
+	return opts.DefaultTagName
+}
+
+// prefixTagName returns the tag holding the prefix, falling back to PrefixEnv.
+func (opts *Options) prefixTagName() string {
+	if opts.PrefixTagName == "" {
+		return PrefixEnv
+	}
+	return opts.PrefixTagName
+}
+
+// setRawEnv records the value of a field, so it can be referenced when expanding later fields.
+//
+// Parameters:
+//   - key: The key of the field within its own struct.
+//   - val: The resolved value of the field.
+//
+// Note: Empty values are removed rather than stored, as getRawEnv treats them as unset.
+func (opts *Options) setRawEnv(key, val string) {
+	if val == "" {
+		delete(opts.rawEnvVars, key)
+		return
+	}
+
+	if opts.rawEnvVars == nil {
+		opts.rawEnvVars = make(map[string]string)
+	}
+	opts.rawEnvVars[key] = val
+}
+
+// markUsed records that a key is read by a field, when Strict is set.
+func (opts *Options) markUsed(key string) {
+	if opts.usedKeys != nil {
+		opts.usedKeys[opts.normaliseKey(key)] = true
+	}
+}
+
+// normaliseKey returns a key as it is held within Env, which is upper case when CaseInsensitive is set.
+func (opts *Options) normaliseKey(key string) string {
+	if opts.CaseInsensitive {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// unsetUsedKeys removes the variables within Env that were read by a field from the process, for UnsetAll.
+func (opts *Options) unsetUsedKeys() {
+	for key := range opts.usedKeys {
+		if _, ok := opts.Env[key]; ok {
+			// As with the unset option, failing to unset a variable is not critical.
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+// unknownKeys returns the variables beginning with the root Prefix that were not read by any field.
+//
+// Returns: The unknown keys, sorted so the error is stable.
+func (opts *Options) unknownKeys() []string {
+	var unknown []string
+
+	for _, vars := range [...]map[string]string{opts.Env, opts.Overlay} {
+		for key := range vars {
+			if strings.HasPrefix(key, opts.Prefix) && !opts.usedKeys[key] {
+				opts.usedKeys[key] = true // Avoids reporting a key within both Env and Overlay twice.
+				unknown = append(unknown, key)
+			}
+		}
+	}
+
+	sort.Strings(unknown)
+	return unknown
+}
+
+// context returns the context of the parse, or context.Background() if it was not started by ParseWithContext.
+func (opts *Options) context() context.Context {
+	if opts.ctx == nil {
+		return context.Background()
+	}
+	return opts.ctx
+}
+
+// fieldPath returns the path to a field of the current struct, such as "Config.Database.Host".
+func (opts *Options) fieldPath(name string) string {
+	if opts.path == "" {
+		return name
+	}
+	return opts.path + "." + name
 }
 
 // withPrefix returns a new Options struct with the prefix set.
 //
+// A prefix holding variables, such as `envPrefix:"${TENANT}_"`, is expanded like an expand field's default, so a whole
+// nested struct can be redirected by setting one variable.
+//
 // Parameters:
 //   - sf: The struct field to get the prefix from a tag.
 //
 // Returns:
 //   - A new Options struct with the prefix set.
+//   - An error from a ${VAR:?message} within the prefix whose variable is unset or empty.
 //
 // See: https://pkg.go.dev/reflect#StructField
 //
 // Note: If a trailing underscore is not present, it will append one.
-func (opts Options) withPrefix(sf reflect.StructField) Options {
-	opts.Prefix = opts.Prefix + sf.Tag.Get(PrefixEnv)
+func (opts *Options) withPrefix(sf reflect.StructField) (Options, error) {
+	prefix, ok := sf.Tag.Lookup(opts.prefixTagName())
+	if !ok && opts.UseFieldNameByDefault && !sf.Anonymous {
+		prefix = strcase.ToScreamingSnake(sf.Name)
+	}
+
+	// A squashed struct reads its fields as if they were within the parent, such as a mixin shared by several structs.
+	prefix, options, _ := strings.Cut(prefix, ",")
+	if options == SquashEnv {
+		prefix = ""
+	}
+
+	nested := opts.shared()
+	nested.path = opts.fieldPath(sf.Name)
+	nested.depth++
+
+	if strings.Contains(prefix, "$") {
+		var err error
+		if prefix, err = opts.expand(prefix); err != nil {
+			return nested, fmt.Errorf("%s: %w", nested.path, err)
+		}
+	}
+	nested.Prefix = opts.normaliseKey(opts.Prefix + prefix)
 
 	// Append an underscore if it's not already there.
-	if len(opts.Prefix) > 0 && opts.Prefix[len(opts.Prefix)-1] != '_' {
-		opts.Prefix = opts.Prefix + "_"
+	if len(nested.Prefix) > 0 && nested.Prefix[len(nested.Prefix)-1] != '_' {
+		nested.Prefix = nested.Prefix + "_"
+	}
+
+	return nested, nil
+}
+
+// handleError passes the error of a field to OnError, so it can be downgraded or replaced.
+//
+// Parameters:
+//   - field: The path to the field.
+//   - key: The environment variable of the field.
+//   - err: The error of the field, which must not be nil.
+//
+// Returns: The error from OnError, or err if OnError is nil.
+func (opts *Options) handleError(field, key string, err error) error {
+	if opts.OnError == nil {
+		return err
 	}
+	return opts.OnError(field, key, err)
+}
 
-	return opts
+// checkDepth returns a *MaxDepthError when the current struct is nested deeper than MaxDepth.
+//
+// Parameters:
+//   - structType: The type of the current struct, reported within the error.
+//
+// Returns: An error if the depth has been exceeded, otherwise nil.
+func (opts *Options) checkDepth(structType reflect.Type) error {
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	if opts.depth > maxDepth {
+		return &MaxDepthError{Field: opts.path, Type: structType, MaxDepth: maxDepth}
+	}
+	return nil
 }
 
 // withSliceEnvPrefix returns a new Options struct with the prefix set.
@@ -114,9 +620,43 @@ func (opts Options) withPrefix(sf reflect.StructField) Options {
 //
 // Returns:
 //   - A new Options struct with the prefix set.
-func (opts Options) withSliceEnvPrefix(index int) Options {
-	opts.Prefix = fmt.Sprintf("%s%d_", opts.Prefix, index)
-	return opts
+func (opts *Options) withSliceEnvPrefix(index int) Options {
+	nested := opts.shared()
+	nested.Prefix = opts.Prefix + strconv.Itoa(index) + "_"
+	nested.path = opts.path + "[" + strconv.Itoa(index) + "]"
+	return nested
+}
+
+// withMapEnvPrefix returns a new Options struct with the prefix set.
+//
+// Parameters:
+//   - key: The map key to use for the prefix.
+//
+// Usage:
+//
+// Typically used when parsing a map of structs, this will append the key to the prefix.
+//   - prefix is "PREFIX_" and key is "PRIMARY", the new prefix will be "PREFIX_PRIMARY_"
+//
+// Returns:
+//   - A new Options struct with the prefix set.
+func (opts *Options) withMapEnvPrefix(key string) Options {
+	nested := opts.shared()
+	nested.Prefix = opts.Prefix + key + "_"
+	nested.path = opts.path + "[" + key + "]"
+	return nested
+}
+
+// shared returns a copy of the options for a nested struct.
+//
+// The rawEnvVars map is created first if needed, so values set within the nested struct are visible to its parent.
+//
+// Returns:
+//   - A copy of the options sharing the same maps.
+func (opts *Options) shared() Options {
+	if opts.rawEnvVars == nil {
+		opts.rawEnvVars = make(map[string]string)
+	}
+	return *opts
 }
 
 // filterPrefixedEnvVars filters the environment variables that have the current prefix.
@@ -129,32 +669,118 @@ func (opts Options) withSliceEnvPrefix(index int) Options {
 // Returns: A map of the index of the environment variable.
 //
 // Note: mainly used for parseSliceOfStructs.
-func (opts Options) filterPrefixedEnvVars() map[int]bool {
+func (opts *Options) filterPrefixedEnvVars() map[int]bool {
 	prefixedEnvMap := make(map[int]bool)
 
 	// prefixLen is the length of the prefix, it's as a variable to ensure it's only calculated once.
 	prefixLen := len(opts.Prefix)
 
-	for env := range opts.Env {
-		if !strings.HasPrefix(env, opts.Prefix) {
-			continue
+	// Overlay may add indexes that are not within Env.
+	for _, vars := range [...]map[string]string{opts.Env, opts.Overlay} {
+		for env := range vars {
+			if !strings.HasPrefix(env, opts.Prefix) {
+				continue
+			}
+
+			// SplitN expects 2 underscores, if there's 3 it will ignore the last part.
+			// For example PREFIX_2_a_b -> [2 a_b]
+			parts := strings.SplitN(env[prefixLen:], "_", 2)
+			// If there's not 2 parts or both are empty, it's not a valid environment variable.
+			// For example: PREFIX_0_FOO -> [0 FOO]
+			// For example: PREFIX_0_FOO_BAR -> [0 FOO_BAR]
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				continue
+			}
+
+			if idx, err := strconv.Atoi(parts[0]); err == nil {
+				prefixedEnvMap[idx] = true
+			}
 		}
+	}
+	return prefixedEnvMap
+}
+
+// filterPrefixedMapKeys finds the map keys of the environment variables that have the current prefix.
+//
+// If it's currently in the struct of "PREFIX_", "PREFIX_PRIMARY_HOST" has the key "PRIMARY" when the struct has a HOST variable.
+// As keys may hold underscores, the key is found by matching the end of the variable against the variables of the struct.
+// When more than one matches, the longest variable is used, so the shortest key.
+//
+// Parameters:
+//   - structType: The type of the map's elements.
+//
+// Returns: A map of the keys found.
+//
+// Note: mainly used for parseMapOfStructs.
+func (opts *Options) filterPrefixedMapKeys(structType reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+
+	suffixes := structKeys(structType, opts)
+	if len(suffixes) == 0 {
+		return keys
+	}
+
+	// Overlay may add keys that are not within Env.
+	for _, vars := range [...]map[string]string{opts.Env, opts.Overlay} {
+		for env := range vars {
+			if !strings.HasPrefix(env, opts.Prefix) {
+				continue
+			}
+
+			if key := matchMapKey(env[len(opts.Prefix):], suffixes); key != "" {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
+
+// structKeys returns the variables of a struct without a prefix, such as "HOST" or "TLS_CERT".
+//
+// Variables within a slice or map of structs are cut at the index, such as "WORKERS_" for "WORKERS_{n}_NAME".
+func structKeys(structType reflect.Type, opts *Options) []string {
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	unprefixed := *opts
+	unprefixed.Prefix = ""
+
+	var keys []string
+	_ = envWalker{describe: true, fn: func(field envField) error {
+		key, _, _ := strings.Cut(field.Tags.Key, "{")
+		keys = append(keys, opts.normaliseKey(key))
+		return nil
+	}}.walk(reflect.New(structType).Elem(), unprefixed, "", "", nil)
 
-		// SplitN expects 2 underscores, if there's 3 it will ignore the last part.
-		// For example PREFIX_2_a_b -> [2 a_b]
-		parts := strings.SplitN(env[prefixLen:], "_", 2)
-		// If there's not 2 parts or both are empty, it's not a valid environment variable.
-		// For example: PREFIX_0_FOO -> [0 FOO]
-		// For example: PREFIX_0_FOO_BAR -> [0 FOO_BAR]
-		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+	return keys
+}
+
+// matchMapKey returns the map key at the start of rest, such as "PRIMARY" for "PRIMARY_HOST".
+//
+// Parameters:
+//   - rest: The variable with the map's prefix removed.
+//   - keys: The variables of the map's struct, from structKeys.
+//
+// Returns: The shortest key found, or an empty string if none match.
+func matchMapKey(rest string, keys []string) string {
+	match := ""
+	for _, key := range keys {
+		var idx int
+		if strings.HasSuffix(key, "_") {
+			// A slice or map within the struct, its variables continue after the key.
+			idx = strings.Index(rest, "_"+key)
+		} else if strings.HasSuffix(rest, "_"+key) {
+			idx = len(rest) - len(key) - 1
+		} else {
 			continue
 		}
 
-		if idx, err := strconv.Atoi(parts[0]); err == nil {
-			prefixedEnvMap[idx] = true
+		if idx > 0 && (match == "" || idx < len(match)) {
+			match = rest[:idx]
 		}
 	}
-	return prefixedEnvMap
+	return match
 }
 
 // defaultOptions is the initial options to use when parsing the struct.
@@ -162,15 +788,14 @@ func (opts Options) filterPrefixedEnvVars() map[int]bool {
 // This is used to clean up the parameters during parsing.
 //
 // Returns:
-//   - An env map with the environment variables from os.Environ().
+//   - The OsEnvSource, loading the environment variables from os.Environ().
 //   - An empty prefix, as this is the root struct.
-//   - An empty rawEnvVars map, as this is the root struct.
+//   - No rawEnvVars map, it is created when the first value is set.
 //
 // Note:  This cannot be a pointer value, as it's modified within the parseStruct function for additional prefixes
 func defaultOptions() Options {
 	return Options{
-		Env:        toMap(os.Environ()),
-		Prefix:     "",
-		rawEnvVars: make(map[string]string),
+		Sources: []Source{OsEnvSource{}},
+		Prefix:  "",
 	}
 }
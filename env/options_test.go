@@ -49,12 +49,159 @@ func TestGetRawEnv_ReturnsEmptyStringWhenVarNotSet(t *testing.T) {
 	}
 }
 
+func TestExpand(t *testing.T) {
+	opts := Options{
+		Env: map[string]string{"HOST": "db", "EMPTY": "", "PORT": "${HOST:-x}:5432", "BAD": "${MISSING:?inner}"},
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		err      string
+	}{
+		{"Plain", "${HOST}/$HOST", "db/db", ""},
+		{"Default unused", "${HOST:-localhost}", "db", ""},
+		{"Default when unset", "${MISSING:-localhost}", "localhost", ""},
+		{"Default when empty", "${EMPTY:-localhost}", "localhost", ""},
+		{"Empty default", "a${MISSING:-}b", "ab", ""},
+		{"Default within a referenced value", "${PORT}", "db:5432", ""},
+		{"Error unused", "${HOST:?host is required}", "db", ""},
+		{"Error when unset", "${MISSING:?missing is required}", "", "MISSING: missing is required"},
+		{"Error when empty", "${EMPTY:?}", "", "EMPTY: parameter null or not set"},
+		{"First error is kept", "${A:?first}${B:?second}", "", "A: first"},
+		{"Error within a referenced value is ignored", "${BAD}", "", ""},
+		{"Other colon", "${HOST:+x}", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := opts.expand(tt.value)
+			if (err == nil) != (tt.err == "") || (err != nil && err.Error() != tt.err) {
+				t.Fatalf("expand() error = %v, expected %q", err, tt.err)
+			}
+			if tt.err == "" && got != tt.expected {
+				t.Errorf("expand() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	opts := Options{
+		Env:     map[string]string{"A": "env", "B": "env"},
+		Overlay: map[string]string{"B": "overlay", "C": ""},
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+		found    bool
+	}{
+		{key: "A", expected: "env", found: true},
+		{key: "B", expected: "overlay", found: true},
+		{key: "C", expected: "", found: true},
+		{key: "D", expected: "", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			val, found := opts.lookup(tt.key)
+			if val != tt.expected || found != tt.found {
+				t.Errorf("lookup() = %q, %v, expected %q, %v", val, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	defaults := Options{}
+	if defaults.tagName() != Env || defaults.defaultTagName() != DefaultEnv || defaults.prefixTagName() != PrefixEnv {
+		t.Errorf("Expected the default tag names, got %s, %s, %s", defaults.tagName(), defaults.defaultTagName(), defaults.prefixTagName())
+	}
+
+	custom := Options{TagName: "a", DefaultTagName: "b", PrefixTagName: "c"}
+	if custom.tagName() != "a" || custom.defaultTagName() != "b" || custom.prefixTagName() != "c" {
+		t.Errorf("Expected a, b, c, got %s, %s, %s", custom.tagName(), custom.defaultTagName(), custom.prefixTagName())
+	}
+}
+
+func TestSetRawEnv(t *testing.T) {
+	opts := Options{}
+
+	opts.setRawEnv("EMPTY", "")
+	if opts.rawEnvVars != nil {
+		t.Errorf("Expected rawEnvVars to not be created for an empty value")
+	}
+
+	opts.setRawEnv("KEY", "value")
+	if opts.rawEnvVars["KEY"] != "value" {
+		t.Errorf("Expected value, got %s", opts.rawEnvVars["KEY"])
+	}
+
+	opts.setRawEnv("KEY", "")
+	if _, ok := opts.rawEnvVars["KEY"]; ok {
+		t.Errorf("Expected KEY to be removed")
+	}
+}
+
+func TestShared_NestedValuesVisibleToParent(t *testing.T) {
+	type Inner struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Inner Inner  `envPrefix:"INNER"`
+		URL   string `env:"URL,expand" envDefault:"http://${HOST}"`
+	}
+
+	var cfg Config
+	if err := ParseWithOpts(&cfg, Options{Env: map[string]string{"INNER_HOST": "example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.URL != "http://example.com" {
+		t.Errorf("Expected http://example.com, got %s", cfg.URL)
+	}
+}
+
 func TestWithPrefix_AppendsPrefix(t *testing.T) {
 	opts := Options{Prefix: "PREFIX_"}
 	sf := reflect.StructField{Tag: `envPrefix:"NEW_"`}
-	newOpts := opts.withPrefix(sf)
-	if newOpts.Prefix != "PREFIX_NEW_" {
-		t.Errorf("Expected PREFIX_NEW_, got %s", newOpts.Prefix)
+	newOpts, err := opts.withPrefix(sf)
+	if err != nil || newOpts.Prefix != "PREFIX_NEW_" {
+		t.Errorf("Expected PREFIX_NEW_, got %s, %v", newOpts.Prefix, err)
+	}
+}
+
+func TestWithPrefix_Expanded(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      reflect.StructTag
+		env      map[string]string
+		expected string
+		err      string
+	}{
+		{name: "Variable", tag: `envPrefix:"${TENANT}"`, env: map[string]string{"TENANT": "ACME"}, expected: "APP_ACME_"},
+		{name: "Variable within text", tag: `envPrefix:"TENANT_${TENANT}_DB"`, env: map[string]string{"TENANT": "ACME"}, expected: "APP_TENANT_ACME_DB_"},
+		{name: "Default", tag: `envPrefix:"${TENANT:-SHARED}_"`, expected: "APP_SHARED_"},
+		{name: "Unset variable", tag: `envPrefix:"${TENANT}"`, expected: "APP_"},
+		{name: "Squashed", tag: `envPrefix:"${TENANT},squash"`, env: map[string]string{"TENANT": "ACME"}, expected: "APP_"},
+		{name: "Required variable", tag: `envPrefix:"${TENANT:?must be set}"`, err: "Config.DB: TENANT: must be set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Prefix: "APP_", Env: tt.env, path: "Config"}
+			nested, err := opts.withPrefix(reflect.StructField{Name: "DB", Tag: tt.tag})
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("withPrefix() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil || nested.Prefix != tt.expected {
+				t.Errorf("withPrefix() = %q, %v, expected %q", nested.Prefix, err, tt.expected)
+			}
+		})
 	}
 }
 
@@ -82,6 +229,17 @@ func TestFilterPrefixedEnvVars(t *testing.T) {
 		t.Errorf("Expected 2 results, got %d", len(result))
 	}
 
+	overlayOpts := Options{
+		Prefix:  "PREFIX_",
+		Env:     map[string]string{"PREFIX_0_FOO": "foo"},
+		Overlay: map[string]string{"PREFIX_0_FOO": "bar", "PREFIX_3_FOO": "baz"},
+	}
+
+	overlayResult := overlayOpts.filterPrefixedEnvVars()
+	if len(overlayResult) != 2 || !overlayResult[3] {
+		t.Errorf("Expected indexes 0 and 3, got %v", overlayResult)
+	}
+
 	badOpts := Options{
 		Prefix: "PREFIX_0",
 		Env: map[string]string{
@@ -95,13 +253,58 @@ func TestFilterPrefixedEnvVars(t *testing.T) {
 	}
 }
 
+func TestMatchMapKey(t *testing.T) {
+	keys := []string{"HOST", "TLS_CERT", "CERT", "WORKER_"}
+
+	tests := []struct {
+		rest     string
+		expected string
+	}{
+		{"PRIMARY_HOST", "PRIMARY"},
+		{"AUTH_SERVICE_HOST", "AUTH_SERVICE"},
+		{"WEB_TLS_CERT", "WEB"},
+		{"WEB_WORKER_0_NAME", "WEB"},
+		{"HOST", ""},
+		{"PRIMARY_PORT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rest, func(t *testing.T) {
+			if got := matchMapKey(tt.rest, keys); got != tt.expected {
+				t.Errorf("matchMapKey() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterPrefixedMapKeys(t *testing.T) {
+	type Upstream struct {
+		Host string `env:"HOST"`
+	}
+
+	opts := Options{
+		Prefix:  "UPSTREAM_",
+		Env:     map[string]string{"UPSTREAM_A_HOST": "a", "UPSTREAM_B_PORT": "b", "OTHER_C_HOST": "c"},
+		Overlay: map[string]string{"UPSTREAM_D_HOST": "d"},
+	}
+
+	result := opts.filterPrefixedMapKeys(reflect.TypeOf(&Upstream{}))
+	if !reflect.DeepEqual(result, map[string]bool{"A": true, "D": true}) {
+		t.Errorf("Expected keys A and D, got %v", result)
+	}
+
+	if result := opts.filterPrefixedMapKeys(reflect.TypeOf(struct{}{})); len(result) != 0 {
+		t.Errorf("Expected no keys, got %v", result)
+	}
+}
+
 func TestDefaultOptions_ReturnsInitialOptions(t *testing.T) {
 	opts := defaultOptions()
 	if opts.Prefix != "" {
 		t.Errorf("Expected empty prefix, got %s", opts.Prefix)
 	}
-	if len(opts.Env) == 0 {
-		t.Errorf("Expected non-empty Env map")
+	if len(opts.Sources) != 1 || opts.Sources[0] != (OsEnvSource{}) {
+		t.Errorf("Expected the OsEnvSource, got %v", opts.Sources)
 	}
 	if len(opts.rawEnvVars) != 0 {
 		t.Errorf("Expected empty rawEnvVars map")
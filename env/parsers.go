@@ -2,21 +2,99 @@ package env
 
 import (
 	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/cloudment/utils-go/internal/parse"
 )
 
 // ParserFunc defines the signature of a function that can be used within
 // `Options`' `FuncMap`.
 type ParserFunc func(v string) (interface{}, error)
 
+// namedParsers holds the ParserFunc registered under each name by RegisterParser.
+var namedParsers sync.Map
+
+// RegisterParser registers a parser under a name, so fields can select it with the `envParser` tag.
+//
+// It allows two fields of the same type to be parsed differently, such as a []int written as "1,2,3" or as "1-3".
+// Registering a name again replaces its parser. It is safe to call concurrently with parsing.
+//
+// Parameters:
+//
+//   - name: The name used within the tag, such as "int-range".
+//   - fn: The parser, returning a value of the type of the field, or of the type it points to.
+//     A value of another type with the same kind, such as int for a type Level int, is converted.
+//
+// Example:
+//
+//	env.RegisterParser("int-range", func(v string) (interface{}, error) {
+//		from, to, _ := strings.Cut(v, "-")
+//		...
+//	})
+//
+//	type Config struct {
+//		Ports   []int `env:"PORTS"`
+//		Workers []int `env:"WORKERS" envParser:"int-range"`
+//	}
+//
+// Note: It panics if the name is empty or fn is nil, as with registering an invalid http.Handler.
+func RegisterParser(name string, fn ParserFunc) {
+	if name == "" || fn == nil {
+		panic("env: RegisterParser requires a name and a parser")
+	}
+	namedParsers.Store(name, fn)
+}
+
+// parseNamed parses the value of a field with the ParserEnv tag, using the parser registered under its name.
+//
+// Parameters:
+//   - v: The reflect.Value of the field to set.
+//   - name: The name of the parser.
+//   - val: The value to parse.
+//
+// Returns: An error if the parser is not registered, fails, or returns a value of another type.
+func parseNamed(v reflect.Value, name, val string) error {
+	fn, ok := namedParsers.Load(name)
+	if !ok {
+		return fmt.Errorf("unknown parser %q", name)
+	}
+
+	res, err := fn.(ParserFunc)(val)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.ValueOf(res)
+	if v.Kind() == reflect.Ptr && (!result.IsValid() || result.Type() != v.Type()) {
+		initialisePointer(v)
+		v = v.Elem()
+	}
+
+	if !result.IsValid() || result.Kind() != v.Kind() || !result.Type().ConvertibleTo(v.Type()) {
+		return fmt.Errorf("parser %q returned %T, expected %s", name, res, v.Type())
+	}
+
+	v.Set(result.Convert(v.Type()))
+	return nil
+}
+
 var (
 	// parsers is a map of `reflect.Kind` to `ParserFunc` that can be used to
 	// parse a string value into a specific type.
+	// A rune or byte is an alias of int32 or uint8, so it is parsed as a number, such as 97 for 'a'.
+	// Kinds without a parser, such as chan and func, return an *UnsupportedTypeError when a value is set.
 	parsers = map[reflect.Kind]ParserFunc{
 		reflect.Bool: func(v string) (interface{}, error) {
 			return strconv.ParseBool(v)
@@ -60,6 +138,10 @@ var (
 			i, err := strconv.ParseUint(v, 10, 64)
 			return i, err
 		},
+		reflect.Uintptr: func(v string) (interface{}, error) {
+			i, err := strconv.ParseUint(v, 10, 64)
+			return uintptr(i), err
+		},
 		reflect.Float32: func(v string) (interface{}, error) {
 			f, err := strconv.ParseFloat(v, 32)
 			return float32(f), err
@@ -67,6 +149,14 @@ var (
 		reflect.Float64: func(v string) (interface{}, error) {
 			return strconv.ParseFloat(v, 64)
 		},
+		// Complex numbers are written as "1+2i", "3i" or "4", with optional parentheses.
+		reflect.Complex64: func(v string) (interface{}, error) {
+			c, err := strconv.ParseComplex(v, 64)
+			return complex64(c), err
+		},
+		reflect.Complex128: func(v string) (interface{}, error) {
+			return strconv.ParseComplex(v, 128)
+		},
 		reflect.String: func(v string) (interface{}, error) {
 			return v, nil
 		},
@@ -74,30 +164,224 @@ var (
 	// typeParsers is a map of `reflect.Type` to `ParserFunc` that can be used to
 	// parse a string value into a custom type.
 	// Commonly for Duration and Location or other custom types.
-	// Must return a non-pointer type.
-	typeParsers = map[reflect.Type]ParserFunc{
-		reflect.TypeOf(time.Nanosecond): func(v string) (interface{}, error) {
-			d, err := time.ParseDuration(v)
-			// Days are not always 24 hours long
-			// See: https://github.com/golang/go/issues/11473
-			// See: https://bigthink.com/starts-with-a-bang/day-isnt-24-hours/
-			if err != nil && strings.Contains(err.Error(), "unknown unit \"d\"") {
-				err = fmt.Errorf("use '24h' instead of '1d' for 24 hours: %w", err)
-			}
-			return d, err
-		},
-		reflect.TypeOf(time.Location{}): func(v string) (interface{}, error) {
-			loc, err := time.LoadLocation(v)
-			if err != nil {
-				return nil, fmt.Errorf("unable to parse Location: %w", err)
-			}
-			return *loc, nil
-		},
-	}
+	// They are shared with the utils package, so BindRequest parses these types the same way.
+	typeParsers = parse.Types
 )
 
+// timeType is the reflect.Type of time.Time, parsed using the envLayout and envTZ tags.
+var timeType = reflect.TypeOf(time.Time{})
+
+// isTimeType checks if the type is a time.Time or a pointer to one.
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Ptr && t.Elem() == timeType)
+}
+
+// timeLayout gets the layout and location of a time.Time field from its envLayout and envTZ tags.
+//
+// Parameters:
+//   - sf: The reflect.StructField of the field.
+//   - layout: The layout to use if the field has no envLayout tag.
+//
+// Returns:
+//   - The layout.
+//   - The location, UTC if the field has no envTZ tag.
+//   - An error if the envTZ tag is not a valid location.
+func timeLayout(sf reflect.StructField, layout string) (string, *time.Location, error) {
+	if l := sf.Tag.Get(LayoutEnv); l != "" {
+		layout = l
+	}
+
+	loc := time.UTC
+	if tz := sf.Tag.Get(TZEnv); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return "", nil, fmt.Errorf("invalid %s tag: %w", TZEnv, err)
+		}
+	}
+
+	return layout, loc, nil
+}
+
+// setTime parses a time.Time, or pointer to one, using the envLayout and envTZ tags of the field.
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - val: The value to parse.
+//   - sf: The reflect.StructField of the field.
+//
+// Returns: An error if the value does not match the layout.
+//
+// Note: Values without a zone are within the envTZ location, such as "2024-01-02" with `envTZ:"Europe/London"`.
+func setTime(v reflect.Value, val string, sf reflect.StructField) error {
+	layout, loc, err := timeLayout(sf, time.RFC3339)
+	if err != nil {
+		return err
+	}
+
+	t, err := time.ParseInLocation(layout, val, loc)
+	if err != nil {
+		return fmt.Errorf("failed to parse value: %w", err)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(timeType))
+		}
+		v = v.Elem()
+	}
+
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// setBytes decodes a value into a []byte field, or pointer to one.
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - val: The encoded value.
+//   - encoding: Either Base64Env or HexEnv.
+//
+// Returns: An error if the field is not a []byte, or the value cannot be decoded.
+func setBytes(v reflect.Value, val, encoding string) error {
+	initialisePointer(v)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf("the %s option requires a []byte field, got %v", encoding, v.Type())
+	}
+
+	var b []byte
+	var err error
+	if encoding == HexEnv {
+		b, err = hex.DecodeString(val)
+	} else {
+		b, err = decodeBase64(val)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value: %w", encoding, err)
+	}
+
+	v.SetBytes(b)
+	return nil
+}
+
+// setExtendedDuration parses a value with days and weeks into a time.Duration field, or pointer to one.
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - val: The value to parse, such as "1w2d12h".
+//
+// Returns: An error if the field is not a time.Duration, or the value cannot be parsed.
+func setExtendedDuration(v reflect.Value, val string) error {
+	initialisePointer(v)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Type() != reflect.TypeOf(time.Duration(0)) {
+		return fmt.Errorf("the %s option requires a time.Duration field, got %v", ExtendedDurationEnv, v.Type())
+	}
+
+	d, err := parseExtendedDuration(val)
+	if err != nil {
+		return err
+	}
+
+	v.SetInt(int64(d))
+	return nil
+}
+
+// extendedDurationUnits are the units parseExtendedDuration accepts beyond those of time.ParseDuration.
+var extendedDurationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parseExtendedDuration parses a duration like time.ParseDuration, also accepting the units of extendedDurationUnits.
+//
+// Parameters:
+//   - s: The duration, such as "1w2d12h", "1.5d" or "-2w".
+//
+// Returns:
+//   - The duration.
+//   - An error if it is invalid or overflows a time.Duration.
+func parseExtendedDuration(s string) (time.Duration, error) {
+	rest := s
+	if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	// Components with a standard unit are passed to time.ParseDuration together, the rest are summed here.
+	var total time.Duration
+	var std strings.Builder
+	for rest != "" {
+		// Each component is a number followed by its unit, which runs until the next number.
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i == -1 {
+			std.WriteString(rest)
+			break
+		}
+		j := strings.IndexFunc(rest[i:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if j == -1 {
+			j = len(rest)
+		} else {
+			j += i
+		}
+
+		unit, ok := extendedDurationUnits[rest[i:j]]
+		if !ok {
+			std.WriteString(rest[:j])
+			rest = rest[j:]
+			continue
+		}
+
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d := n * float64(unit)
+		if d >= math.MaxInt64 || total > math.MaxInt64-time.Duration(d) {
+			return 0, fmt.Errorf("invalid duration %q: overflows a time.Duration", s)
+		}
+		total += time.Duration(d)
+		rest = rest[j:]
+	}
+
+	if std.Len() > 0 {
+		d, err := time.ParseDuration(std.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		if total > math.MaxInt64-d {
+			return 0, fmt.Errorf("invalid duration %q: overflows a time.Duration", s)
+		}
+		total += d
+	}
+
+	if strings.HasPrefix(s, "-") {
+		total = -total
+	}
+	return total, nil
+}
+
+// decodeBase64 decodes standard or URL base64, with or without padding.
+func decodeBase64(val string) ([]byte, error) {
+	val = strings.TrimRight(val, "=")
+	if strings.ContainsAny(val, "-_") {
+		return base64.RawURLEncoding.DecodeString(val)
+	}
+	return base64.RawStdEncoding.DecodeString(val)
+}
+
 // handleSpecialTypes handles special types like slices and maps.
 //
+// Other types are parsed by unmarshalFallback, if they implement encoding.BinaryUnmarshaler or json.Unmarshaler.
+//
 // Parameters:
 //   - v: The reflect.Value of the field.
 //   - val: The value of the field.
@@ -111,18 +395,49 @@ func handleSpecialTypes(v reflect.Value, val string, sf reflect.StructField) err
 	case reflect.Map:
 		return handleMap(v, val, sf)
 	default:
-		return fmt.Errorf("unsupported type: %v for %v, %s", v.Kind(), sf.Type, sf.Name)
+		return unmarshalFallback(v, val, sf)
 	}
 }
 
-// parseSliceOfStructs parses a slice of structs.
+// unmarshalFallback parses a type without a parser, which implements encoding.BinaryUnmarshaler or json.Unmarshaler.
+//
+// Such as types generated from an API definition, which only implement json.Unmarshaler.
+// A json.Unmarshaler is given the value as it is when it is valid JSON, such as {"burst":10} or 42.
+// Otherwise, it is given the value as a JSON string, so STATUS=active is unmarshalled from "active".
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - val: The value of the field.
+//   - sf: The reflect.StructField of the field.
+//
+// Returns: An error from unmarshalling, or an *UnsupportedTypeError if the type implements neither.
+func unmarshalFallback(v reflect.Value, val string, sf reflect.StructField) error {
+	if bu := asImplementation(v, binaryUnmarshalerType); bu != nil {
+		return bu.(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte(val))
+	}
+
+	if ju := asImplementation(v, jsonUnmarshalerType); ju != nil {
+		data := []byte(val)
+		if !json.Valid(data) {
+			// Marshalling a string cannot fail.
+			data, _ = json.Marshal(val)
+		}
+		return ju.(json.Unmarshaler).UnmarshalJSON(data)
+	}
+
+	return &UnsupportedTypeError{Type: sf.Type}
+}
+
+// parseSliceOfStructs parses a slice of structs, or pointers to structs.
+//
+// For a slice of pointers, indexes without any variables are left nil.
 //
 // Parameters:
 //   - v: The reflect.Value of the field.
 //   - opts: The Options to use when parsing the struct.
 //
 // Returns: An error if there is an issue parsing the slice of structs.
-func parseSliceOfStructs(v reflect.Value, opts Options) error {
+func parseSliceOfStructs(v reflect.Value, opts *Options) error {
 	opts.Prefix = ensureTrailingUnderscore(opts.Prefix)
 
 	prefixedEnvMap := opts.filterPrefixedEnvVars()
@@ -182,12 +497,14 @@ func initialiseSlice(v reflect.Value, maxIndex int) (reflect.Value, int) {
 //   - opts: The Options to use when populating the slice.
 //
 // Returns: An error if there is an issue populating the slice.
-func populateSlice(result, v reflect.Value, prefixedEnvMap map[int]bool, capacity int, opts Options) error {
+func populateSlice(result, v reflect.Value, prefixedEnvMap map[int]bool, capacity int, opts *Options) error {
 	initialised := 0
 	if v.Kind() != reflect.Ptr {
 		initialised = v.Len()
 	}
 
+	var errs []error
+
 	for i := 0; i < capacity; i++ {
 		item := result.Index(i)
 
@@ -195,14 +512,171 @@ func populateSlice(result, v reflect.Value, prefixedEnvMap map[int]bool, capacit
 			item.Set(v.Index(i))
 		}
 
+		// Pointer elements are only allocated for the indexes that are set, the rest stay nil unless InitNilPointers is set.
+		if item.Kind() == reflect.Ptr && item.IsNil() && (prefixedEnvMap[i] || opts.InitNilPointers) {
+			item.Set(reflect.New(item.Type().Elem()))
+		}
+
 		if !prefixedEnvMap[i] {
 			continue
 		}
 
-		if err := parseStruct(item, opts.withSliceEnvPrefix(i)); err != nil {
-			return err
+		nested := opts.withSliceEnvPrefix(i)
+		if err := parseStruct(item, &nested); err != nil {
+			if !opts.AggregateErrors {
+				return err
+			}
+			errs = append(errs, withFieldPath("["+strconv.Itoa(i)+"]", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseMap collects every variable beginning with the prefix into a map[string]T, keyed by the rest of its name.
+//
+// Used when ParseWithOpts is given a pointer to a map rather than a struct, such as for free-form labels.
+// Existing entries are kept, and replaced if a variable is set for their key.
+//
+// Parameters:
+//   - v: The reflect.Value of the map.
+//   - opts: The Options holding the prefix, which must not be empty.
+//
+// Returns: An error if the prefix is empty, the map is not keyed by strings, or a value cannot be parsed.
+func parseMap(v reflect.Value, opts *Options) error {
+	if opts.Prefix == "" {
+		return fmt.Errorf("parsing into a %v requires Options.Prefix", v.Type())
+	}
+	if v.Type().Key().Kind() != reflect.String {
+		return &UnsupportedTypeError{Type: v.Type().Key()}
+	}
+
+	elemType := v.Type().Elem()
+	parserFunc, err := getParserFunc(elemType)
+	if err != nil {
+		return err
+	}
+
+	// Overlay may add keys that are not within Env.
+	var keys []string
+	for _, vars := range [...]map[string]string{opts.Env, opts.Overlay} {
+		for key := range vars {
+			if len(key) > len(opts.Prefix) && strings.HasPrefix(key, opts.Prefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(keys)))
+	}
+
+	var errs []error
+	for _, key := range keys {
+		val, _ := opts.lookup(key)
+		opts.markUsed(key)
+		if val == "" && !opts.AllowEmpty {
+			continue
+		}
+
+		name := key[len(opts.Prefix):]
+		elem := reflect.New(elemType).Elem()
+		if err = setValue(elem, val, parserFunc); err != nil {
+			field := opts.path + "[" + name + "]"
+			if err = opts.handleError(field, key, fieldError(fmt.Errorf("failed to parse value: %w", err), key, field)); err == nil {
+				continue
+			}
+			if !opts.AggregateErrors {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+	}
+	return errors.Join(errs...)
+}
+
+// parseMapOfStructs parses a map of strings to structs, from variables such as PREFIX_PRIMARY_HOST.
+//
+// Existing entries are kept, and updated if variables are set for their key.
+//
+// Parameters:
+//   - v: The reflect.Value of the field.
+//   - opts: The Options to use when parsing the struct.
+//
+// Returns: An error if there is an issue parsing the map of structs.
+func parseMapOfStructs(v reflect.Value, opts *Options) error {
+	opts.Prefix = ensureTrailingUnderscore(opts.Prefix)
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	mapKeys := opts.filterPrefixedMapKeys(v.Type().Elem())
+	if len(mapKeys) == 0 {
+		return nil
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(mapKeys)))
+	}
+
+	keys := make([]string, 0, len(mapKeys))
+	for key := range mapKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+
+	for _, key := range keys {
+		if err := populateMapEntry(v, key, opts); err != nil {
+			if !opts.AggregateErrors {
+				return err
+			}
+			errs = append(errs, withFieldPath("["+key+"]", err))
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// populateMapEntry parses the struct of a single map key, starting from its existing value.
+//
+// Parameters:
+//   - v: The reflect.Value of the map.
+//   - key: The map key, as found within the variables.
+//   - opts: The Options holding the map's prefix.
+//
+// Returns: An error if there is an issue parsing the struct.
+func populateMapEntry(v reflect.Value, key string, opts *Options) error {
+	elemType := v.Type().Elem()
+	mapKey := reflect.ValueOf(key).Convert(v.Type().Key())
+
+	item := reflect.New(elemType).Elem()
+	if existing := v.MapIndex(mapKey); existing.IsValid() {
+		item.Set(existing)
+	}
+
+	target := item
+	if elemType.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			item.Set(reflect.New(elemType.Elem()))
+		}
+		target = item.Elem()
+	}
+
+	nested := opts.withMapEnvPrefix(key)
+	if err := parseStruct(target, &nested); err != nil {
+		return err
+	}
+
+	v.SetMapIndex(mapKey, item)
 	return nil
 }
 
@@ -279,7 +753,7 @@ func handleSlice(v reflect.Value, val string, sf reflect.StructField) error {
 		elemType = elemType.Elem()
 	}
 
-	if _, ok := reflect.New(elemType).Interface().(encoding.TextUnmarshaler); ok {
+	if reflect.PointerTo(elemType).Implements(textUnmarshalerType) {
 		return parseTextUnmarshalers(v, parts)
 	}
 
@@ -328,7 +802,7 @@ func getParserFunc(elemType reflect.Type) (func(string) (interface{}, error), er
 		return parserFunc, nil
 	}
 
-	return nil, errors.New("unsupported type")
+	return nil, &UnsupportedTypeError{Type: elemType}
 }
 
 // parseSliceElements parses the slice elements.
@@ -343,27 +817,51 @@ func getParserFunc(elemType reflect.Type) (func(string) (interface{}, error), er
 //   - The reflect.Value of the slice.
 //   - An error if there is an issue parsing the slice elements.
 func parseSliceElements(parts []string, elemType reflect.Type, parserFunc func(string) (interface{}, error), elemKind reflect.Type) (reflect.Value, error) {
-	result := reflect.MakeSlice(reflect.SliceOf(elemKind), 0, len(parts))
-	for _, part := range parts {
-		res, err := parserFunc(part)
-		if err != nil {
-			return reflect.Value{}, err
-		}
-
-		vp := reflect.ValueOf(res).Convert(elemType)
+	result := reflect.MakeSlice(reflect.SliceOf(elemKind), len(parts), len(parts))
+	for i, part := range parts {
+		target := result.Index(i)
 		if elemKind.Kind() == reflect.Ptr {
-			ptr := reflect.New(elemType)
-			ptr.Elem().Set(vp)
-			vp = ptr
+			target.Set(reflect.New(elemType))
+			target = target.Elem()
 		}
 
-		result = reflect.Append(result, vp)
+		if err := setValue(target, part, parserFunc); err != nil {
+			return reflect.Value{}, err
+		}
 	}
 	return result, nil
 }
 
+// setValue parses the value and sets it to the target.
+//
+// Strings are set directly, as returning them through a ParserFunc allocates for every value.
+//
+// Parameters:
+//   - target: The reflect.Value to set, must be settable.
+//   - val: The value to parse.
+//   - parserFunc: The parser function for the target's type.
+//
+// Returns: An error if the value could not be parsed.
+func setValue(target reflect.Value, val string, parserFunc func(string) (interface{}, error)) error {
+	if target.Kind() == reflect.String && typeParsers[target.Type()] == nil {
+		target.SetString(val)
+		return nil
+	}
+
+	res, err := parserFunc(val)
+	if err != nil {
+		return err
+	}
+
+	target.Set(reflect.ValueOf(res).Convert(target.Type()))
+	return nil
+}
+
 // handleMap handles the map type by parsing the key and value.
 //
+// Values that are maps or slices are split again using the envNestedSeparator and envNestedKeyValSeparator,
+// such as "a:x=1;y=2,b:z=3" for a map[string]map[string]int.
+//
 // Parameters:
 //   - field: The reflect.Value of the field.
 //   - value: The value of the field.
@@ -373,39 +871,146 @@ func parseSliceElements(parts []string, elemType reflect.Type, parserFunc func(s
 //
 // Note: Can be used to parse a map of any supported type.
 func handleMap(field reflect.Value, value string, sf reflect.StructField) error {
-	keyParserFunc, elemParserFunc, err := getKeyAndElemParsers(sf.Type)
+	keyParserFunc, elemParserFunc, err := getMapParsers(sf)
 	if err != nil {
 		return err
 	}
 
 	separator, keyValSeparator := getSeparators(sf)
 
-	result := reflect.MakeMap(sf.Type)
+	result, err := parseMapPairs(sf.Type, value, separator, keyValSeparator, keyParserFunc, elemParserFunc)
+	if err != nil {
+		return err
+	}
+
+	field.Set(result)
+	return nil
+}
+
+// parseMapPairs parses each key and value pair of a map.
+//
+// Parameters:
+//   - mapType: The reflect.Type of the map.
+//   - value: The value to parse, such as "a:1,b:2".
+//   - separator: The separator between pairs.
+//   - keyValSeparator: The separator between a key and its value.
+//   - keyParserFunc: The parser function for the keys.
+//   - elemParserFunc: The parser function for the values.
+//
+// Returns:
+//   - The reflect.Value of the map.
+//   - An error if a pair could not be parsed.
+func parseMapPairs(mapType reflect.Type, value, separator, keyValSeparator string, keyParserFunc, elemParserFunc func(string) (interface{}, error)) (reflect.Value, error) {
+	result := reflect.MakeMap(mapType)
+
+	// The key and element are reused for each pair, as SetMapIndex copies them into the map.
+	key := reflect.New(mapType.Key()).Elem()
+	elem := reflect.New(mapType.Elem()).Elem()
 
 	for _, part := range strings.Split(value, separator) {
-		pairs := strings.SplitN(part, keyValSeparator, 2)
-		if len(pairs) != 2 {
-			return fmt.Errorf(`%q should be in "key%svalue" format`, part, keyValSeparator)
+		rawKey, rawElem, ok := strings.Cut(part, keyValSeparator)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf(`%q should be in "key%svalue" format`, part, keyValSeparator)
 		}
 
-		var key interface{}
-		var elem interface{}
+		if err := setValue(key, rawKey, keyParserFunc); err != nil {
+			return reflect.Value{}, fmt.Errorf(`failed to parse key %q: %w`, rawKey, err)
+		}
 
-		key, err = keyParserFunc(pairs[0])
-		if err != nil {
-			return fmt.Errorf(`failed to parse key %q: %v`, pairs[0], err)
+		if err := setValue(elem, rawElem, elemParserFunc); err != nil {
+			return reflect.Value{}, fmt.Errorf(`failed to parse value %q: %w`, rawElem, err)
 		}
 
-		elem, err = elemParserFunc(pairs[1])
+		result.SetMapIndex(key, elem)
+	}
+
+	return result, nil
+}
+
+// getMapParsers gets the key and element parsers for a map field, including values that are maps or slices.
+//
+// Parameters:
+//   - sf: The reflect.StructField of the map, used for the nested separators.
+//
+// Returns:
+//   - The key parser function.
+//   - The element parser function.
+//   - An error if the key or element type is not supported.
+func getMapParsers(sf reflect.StructField) (keyParser, elemParser func(string) (interface{}, error), err error) {
+	elemType := sf.Type.Elem()
+	if !isNestedElem(elemType) {
+		return getKeyAndElemParsers(sf.Type)
+	}
+
+	keyParser, ok := parsers[sf.Type.Key().Kind()]
+	if !ok {
+		return nil, nil, &UnsupportedTypeError{Type: sf.Type.Key()}
+	}
+
+	separator, keyValSeparator := getNestedSeparators(sf)
+
+	elemParser, err = getNestedParser(elemType, separator, keyValSeparator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keyParser, elemParser, nil
+}
+
+// getNestedParser gets a parser function for a map's value that is itself a map or a slice.
+//
+// Only a single level of nesting is supported, the nested map or slice must hold single values.
+//
+// Parameters:
+//   - elemType: The reflect.Type of the map's value.
+//   - separator: The separator between the nested values.
+//   - keyValSeparator: The separator between a nested key and its value.
+//
+// Returns:
+//   - The parser function, returning a value of elemType.
+//   - An error if the nested type is not supported.
+func getNestedParser(elemType reflect.Type, separator, keyValSeparator string) (func(string) (interface{}, error), error) {
+	if elemType.Kind() == reflect.Map {
+		keyParserFunc, elemParserFunc, err := getKeyAndElemParsers(elemType)
 		if err != nil {
-			return fmt.Errorf(`failed to parse value %q: %v`, pairs[1], err)
+			return nil, err
 		}
 
-		result.SetMapIndex(reflect.ValueOf(key).Convert(sf.Type.Key()), reflect.ValueOf(elem).Convert(sf.Type.Elem()))
+		return func(v string) (interface{}, error) {
+			if v == "" {
+				return reflect.MakeMap(elemType).Interface(), nil
+			}
+
+			result, err := parseMapPairs(elemType, v, separator, keyValSeparator, keyParserFunc, elemParserFunc)
+			if err != nil {
+				return nil, err
+			}
+			return result.Interface(), nil
+		}, nil
 	}
 
-	field.Set(result)
-	return nil
+	itemType := elemType.Elem()
+	baseType := itemType
+	if baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+
+	parserFunc, err := getParserFunc(baseType)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v string) (interface{}, error) {
+		if v == "" {
+			return reflect.MakeSlice(elemType, 0, 0).Interface(), nil
+		}
+
+		result, err := parseSliceElements(strings.Split(v, separator), baseType, parserFunc, itemType)
+		if err != nil {
+			return nil, err
+		}
+		return result.Convert(elemType).Interface(), nil
+	}, nil
 }
 
 // getKeyAndElemParsers gets the key and element parsers for the map type.
@@ -422,12 +1027,12 @@ func handleMap(field reflect.Value, value string, sf reflect.StructField) error
 func getKeyAndElemParsers(mapType reflect.Type) (keyParser, elemParser func(string) (interface{}, error), err error) {
 	keyParserFunc, ok := parsers[mapType.Key().Kind()]
 	if !ok {
-		return nil, nil, errors.New("unsupported key type")
+		return nil, nil, &UnsupportedTypeError{Type: mapType.Key()}
 	}
 
 	elemParserFunc, ok := parsers[mapType.Elem().Kind()]
 	if !ok {
-		return nil, nil, errors.New("unsupported element type")
+		return nil, nil, &UnsupportedTypeError{Type: mapType.Elem()}
 	}
 
 	return keyParserFunc, elemParserFunc, nil
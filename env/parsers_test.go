@@ -2,11 +2,15 @@ package env
 
 import (
 	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/cloudment/utils-go/utils"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -95,6 +99,352 @@ func TestTypeParsers(t *testing.T) {
 	}
 }
 
+func TestNetworkTypeParsers(t *testing.T) {
+	type Config struct {
+		URL     url.URL     `env:"URL"`
+		URLPtr  *url.URL    `env:"URL_PTR"`
+		IP      net.IP      `env:"IP"`
+		IPs     []net.IP    `env:"IPS"`
+		Network net.IPNet   `env:"NETWORK"`
+		Subnets []net.IPNet `env:"SUBNETS"`
+		Addr    net.TCPAddr `env:"ADDR"`
+	}
+
+	valid := map[string]string{
+		"URL":     "postgres://user@db:5432/app?sslmode=disable",
+		"URL_PTR": "https://example.com",
+		"IP":      "::1",
+		"IPS":     "10.0.0.1,10.0.0.2",
+		"NETWORK": "10.1.2.3/8",
+		"SUBNETS": "10.0.0.0/8,192.168.0.0/16",
+		"ADDR":    "127.0.0.1:8080",
+	}
+
+	var cfg Config
+	if err := ParseWithOpts(&cfg, Options{Env: valid}); err != nil {
+		t.Fatalf("ParseWithOpts() error = %v", err)
+	}
+
+	got := []string{
+		cfg.URL.String(), cfg.URLPtr.String(), cfg.IP.String(), fmt.Sprint(cfg.IPs),
+		cfg.Network.String(), fmt.Sprint(cfg.Subnets[0].String(), cfg.Subnets[1].String()), cfg.Addr.String(),
+	}
+	expected := []string{
+		valid["URL"], valid["URL_PTR"], "::1", "[10.0.0.1 10.0.0.2]",
+		"10.0.0.0/8", "10.0.0.0/8192.168.0.0/16", "127.0.0.1:8080",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseWithOpts() = %v, expected %v", got, expected)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected string
+	}{
+		{name: "URL without scheme", key: "URL", value: "example.com/path", expected: `URL: failed to parse value: invalid URL "example.com/path": missing scheme`},
+		{name: "URL with only a host and port", key: "URL", value: "localhost:8080", expected: `URL: failed to parse value: invalid URL "localhost:8080": missing scheme`},
+		{name: "Malformed URL", key: "URL", value: "http://[::1", expected: "URL: failed to parse value: invalid URL"},
+		{name: "Malformed IP", key: "IP", value: "10.0.0.256", expected: `IP: failed to parse value: invalid IP address "10.0.0.256"`},
+		{name: "Malformed IP in slice", key: "IPS", value: "10.0.0.1,abc", expected: "IPS: "},
+		{name: "Malformed CIDR", key: "NETWORK", value: "10.0.0.1", expected: `NETWORK: failed to parse value: invalid CIDR "10.0.0.1"`},
+		{name: "Malformed CIDR in slice", key: "SUBNETS", value: "10.0.0.0/8,abc", expected: `SUBNETS: invalid CIDR "abc"`},
+		{name: "Malformed TCP address", key: "ADDR", value: "127.0.0.1", expected: `ADDR: failed to parse value: invalid TCP address "127.0.0.1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: map[string]string{tt.key: tt.value}})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("ParseWithOpts() error = %v, expected it to start with %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetBytes(t *testing.T) {
+	type Config struct {
+		Std     []byte          `env:"STD,base64"`
+		URL     []byte          `env:"URL,base64"`
+		Hex     []byte          `env:"HEX,hex"`
+		Pointer *[]byte         `env:"POINTER,hex"`
+		Raw     json.RawMessage `env:"RAW,base64"`
+		Invalid string          `env:"INVALID,base64"`
+	}
+
+	var cfg Config
+	err := ParseWithOpts(&cfg, Options{Env: map[string]string{
+		"STD":     "+/8=",
+		"URL":     "-_8",
+		"HEX":     "FBFF",
+		"POINTER": "00",
+		"RAW":     "e30=",
+	}})
+	if err != nil {
+		t.Fatalf("ParseWithOpts() error = %v", err)
+	}
+
+	expected := Config{
+		Std:     []byte{0xfb, 0xff},
+		URL:     []byte{0xfb, 0xff},
+		Hex:     []byte{0xfb, 0xff},
+		Pointer: &[]byte{0},
+		Raw:     json.RawMessage("{}"),
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, expected)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected string
+	}{
+		{name: "Invalid base64", key: "STD", value: "not base64!", expected: "STD: invalid base64 value"},
+		{name: "Invalid hex", key: "HEX", value: "xyz", expected: "HEX: invalid hex value"},
+		{name: "Not bytes", key: "INVALID", value: "e30=", expected: "INVALID: the base64 option requires a []byte field, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: map[string]string{tt.key: tt.value}})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("ParseWithOpts() error = %v, expected it to start with %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseExtendedDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		err      string
+	}{
+		{input: "1d", expected: 24 * time.Hour},
+		{input: "2w", expected: 14 * 24 * time.Hour},
+		{input: "1w2d12h30m", expected: 9*24*time.Hour + 12*time.Hour + 30*time.Minute},
+		{input: "12h1d", expected: 36 * time.Hour},
+		{input: "1.5d", expected: 36 * time.Hour},
+		{input: "-2d", expected: -48 * time.Hour},
+		{input: "+1d1s", expected: 24*time.Hour + time.Second},
+		{input: "90s", expected: 90 * time.Second},
+		{input: "0", expected: 0},
+		{input: "", err: `invalid duration ""`},
+		{input: "-", err: `invalid duration "-"`},
+		{input: "+-1d", err: `invalid duration "+-1d": time: invalid duration "-"`},
+		{input: "d", err: `invalid duration "d"`},
+		{input: "1.2.3d", err: `invalid duration "1.2.3d"`},
+		{input: "1y", err: `invalid duration "1y": time: unknown unit "y" in duration "1y"`},
+		{input: "10", err: `invalid duration "10": time: missing unit in duration "10"`},
+		{input: "20000w", err: `invalid duration "20000w": overflows a time.Duration`},
+		{input: "15000w15000w", err: `invalid duration "15000w15000w": overflows a time.Duration`},
+		{input: "15000w2562047h", err: `invalid duration "15000w2562047h": overflows a time.Duration`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseExtendedDuration(tt.input)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("parseExtendedDuration(%q) error = %v, expected %s", tt.input, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("parseExtendedDuration(%q) = %v, %v, expected %v", tt.input, got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetExtendedDuration(t *testing.T) {
+	type Config struct {
+		Retention time.Duration  `env:"RETENTION,extendedDuration" envDefault:"2w"`
+		Pointer   *time.Duration `env:"POINTER,extendedDuration"`
+		Standard  time.Duration  `env:"STANDARD"`
+		Invalid   int            `env:"INVALID,extendedDuration"`
+	}
+
+	var cfg Config
+	err := ParseWithOpts(&cfg, Options{Env: map[string]string{"POINTER": "1d12h", "STANDARD": "1h"}})
+	if err != nil {
+		t.Fatalf("ParseWithOpts() error = %v", err)
+	}
+
+	pointer := 36 * time.Hour
+	expected := Config{Retention: 14 * 24 * time.Hour, Pointer: &pointer, Standard: time.Hour}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, expected)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected string
+	}{
+		{name: "Invalid duration", key: "RETENTION", value: "1y", expected: `RETENTION: invalid duration "1y"`},
+		{name: "Days without the option", key: "STANDARD", value: "1d", expected: "STANDARD: failed to parse value: use '24h' instead of '1d'"},
+		{name: "Not a duration", key: "INVALID", value: "1d", expected: "INVALID: the extendedDuration option requires a time.Duration field, got int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: map[string]string{tt.key: tt.value}})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("ParseWithOpts() error = %v, expected it to start with %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsers_OtherKinds(t *testing.T) {
+	type Config struct {
+		Complex64  complex64            `env:"COMPLEX64"`
+		Complex128 *complex128          `env:"COMPLEX128"`
+		Uintptr    uintptr              `env:"UINTPTR"`
+		Rune       rune                 `env:"RUNE"`
+		Byte       byte                 `env:"BYTE"`
+		Complexes  []complex128         `env:"COMPLEXES"`
+		Phases     map[string]complex64 `env:"PHASES"`
+		Chan       chan int             `env:"CHAN"`
+		Func       func()               `env:"FUNC"`
+	}
+
+	var cfg Config
+	err := ParseWithOpts(&cfg, Options{Env: map[string]string{
+		"COMPLEX64":  "1+2i",
+		"COMPLEX128": "(-3.5i)",
+		"UINTPTR":    "4096",
+		"RUNE":       "97",
+		"BYTE":       "255",
+		"COMPLEXES":  "1,2i,3+4i",
+		"PHASES":     "a:1i",
+	}})
+	if err != nil {
+		t.Fatalf("ParseWithOpts() error = %v", err)
+	}
+
+	complex128Val := -3.5i
+	expected := Config{
+		Complex64:  1 + 2i,
+		Complex128: &complex128Val,
+		Uintptr:    4096,
+		Rune:       'a',
+		Byte:       255,
+		Complexes:  []complex128{1, 2i, 3 + 4i},
+		Phases:     map[string]complex64{"a": 1i},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, expected)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected string
+		// unsupported is the field of the *UnsupportedTypeError, if one is expected.
+		unsupported string
+	}{
+		{name: "Invalid complex", key: "COMPLEX64", value: "1+", expected: "COMPLEX64: failed to parse value"},
+		{name: "Rune as a character", key: "RUNE", value: "a", expected: "RUNE: failed to parse value"},
+		{name: "Chan", key: "CHAN", value: "1", expected: "CHAN: unsupported type: chan int", unsupported: "Config.Chan"},
+		{name: "Func", key: "FUNC", value: "1", expected: "FUNC: unsupported type: func()", unsupported: "Config.Func"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: map[string]string{tt.key: tt.value}})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("ParseWithOpts() error = %v, expected it to start with %q", err, tt.expected)
+			}
+
+			var unsupported *UnsupportedTypeError
+			if tt.unsupported != "" && (!errors.As(err, &unsupported) || unsupported.Field != tt.unsupported) {
+				t.Errorf("ParseWithOpts() error = %#v, expected an *UnsupportedTypeError for %s", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestSetTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Default  time.Time  `env:"DEFAULT"`
+		Date     time.Time  `env:"DATE" envLayout:"2006-01-02"`
+		Local    time.Time  `env:"LOCAL" envLayout:"2006-01-02 15:04" envTZ:"Asia/Tokyo"`
+		Zoned    time.Time  `env:"ZONED" envTZ:"Asia/Tokyo"`
+		Pointer  *time.Time `env:"POINTER" envLayout:"02/01/2006"`
+		BadTZ    time.Time  `env:"BAD_TZ" envTZ:"Invalid/Zone"`
+		BadValue time.Time  `env:"BAD_VALUE" envLayout:"2006-01-02"`
+	}
+
+	pointer := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Config
+		wantErr  bool
+	}{
+		{
+			name: "Layouts",
+			env: map[string]string{
+				"DEFAULT": "2024-01-02T03:04:05.5+01:00",
+				"DATE":    "2024-01-02",
+				"LOCAL":   "2024-01-02 09:30",
+				"ZONED":   "2024-01-02T03:04:05Z",
+				"POINTER": "01/02/2024",
+			},
+			expected: Config{
+				Default: time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.FixedZone("", 3600)),
+				Date:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Local:   time.Date(2024, 1, 2, 9, 30, 0, 0, tokyo),
+				Zoned:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Pointer: &pointer,
+			},
+		},
+		{name: "Invalid zone", env: map[string]string{"BAD_TZ": "2024-01-02T03:04:05Z"}, wantErr: true},
+		{name: "Invalid value", env: map[string]string{"BAD_VALUE": "02/01/2024"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: tt.env})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWithOpts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := []time.Time{cfg.Default, cfg.Date, cfg.Local, cfg.Zoned, *cfg.Pointer}
+			expected := []time.Time{tt.expected.Default, tt.expected.Date, tt.expected.Local, tt.expected.Zoned, *tt.expected.Pointer}
+			for i := range got {
+				if !got[i].Equal(expected[i]) {
+					t.Errorf("ParseWithOpts() field %d = %v, expected %v", i, got[i], expected[i])
+				}
+			}
+			if cfg.Local.Location().String() != "Asia/Tokyo" {
+				t.Errorf("ParseWithOpts() Local location = %v, expected Asia/Tokyo", cfg.Local.Location())
+			}
+		})
+	}
+}
+
 func TestHandleSpecialTypes(t *testing.T) {
 	tests := []struct {
 		name string
@@ -138,6 +488,92 @@ func TestHandleSpecialTypes(t *testing.T) {
 	}
 }
 
+// binaryID implements encoding.BinaryUnmarshaler, as a UUID variant storing its raw bytes.
+type binaryID [4]byte
+
+func (id *binaryID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(id) {
+		return fmt.Errorf("expected %d bytes, got %d", len(id), len(data))
+	}
+	copy(id[:], data)
+	return nil
+}
+
+// jsonStatus implements json.Unmarshaler, as a type generated from an API definition.
+type jsonStatus struct {
+	Name string
+}
+
+func (s *jsonStatus) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var v struct{ Name string }
+		err := json.Unmarshal(data, &v)
+		s.Name = v.Name
+		return err
+	}
+	return json.Unmarshal(data, &s.Name)
+}
+
+func TestUnmarshalFallback(t *testing.T) {
+	type Config struct {
+		ID       binaryID    `env:"ID"`
+		Status   jsonStatus  `env:"STATUS"`
+		Previous *jsonStatus `env:"PREVIOUS"`
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Config
+		wantErr  string
+	}{
+		{
+			name:     "Binary",
+			env:      map[string]string{"ID": "abcd"},
+			expected: Config{ID: binaryID{'a', 'b', 'c', 'd'}, Previous: &jsonStatus{}},
+		},
+		{
+			name:     "JSON string",
+			env:      map[string]string{"STATUS": "active", "PREVIOUS": `"paused"`},
+			expected: Config{Status: jsonStatus{Name: "active"}, Previous: &jsonStatus{Name: "paused"}},
+		},
+		{
+			name:     "JSON object",
+			env:      map[string]string{"STATUS": `{"Name":"active"}`},
+			expected: Config{Status: jsonStatus{Name: "active"}, Previous: &jsonStatus{}},
+		},
+		{
+			name:    "Binary error",
+			env:     map[string]string{"ID": "abc"},
+			wantErr: "ID: expected 4 bytes, got 3",
+		},
+		{
+			name:    "JSON error",
+			env:     map[string]string{"STATUS": "42"},
+			wantErr: "STATUS: json: cannot unmarshal number into Go value of type string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: tt.env})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseWithOpts() error = %v, expected %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWithOpts() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
 func TestParseSliceOfStructs(t *testing.T) {
 	tests := []struct {
 		name string
@@ -167,7 +603,7 @@ func TestParseSliceOfStructs(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := parseSliceOfStructs(tc.v, tc.opts)
+			err := parseSliceOfStructs(tc.v, &tc.opts)
 			if err != nil && !tc.err {
 				t.Errorf("Expected no error, got %v", err)
 			} else if err == nil && tc.err {
@@ -177,6 +613,153 @@ func TestParseSliceOfStructs(t *testing.T) {
 	}
 }
 
+func TestParseMapOfStructs(t *testing.T) {
+	type TLS struct {
+		Cert string `env:"CERT"`
+	}
+	type Upstream struct {
+		Host    string `env:"HOST"`
+		Port    int    `env:"PORT" envDefault:"80"`
+		TLS     TLS    `envPrefix:"TLS"`
+		Aliases []struct {
+			Name string `env:"NAME"`
+		} `envPrefix:"ALIAS"`
+	}
+	type Config struct {
+		Upstreams map[string]Upstream  `envPrefix:"UPSTREAM"`
+		Optional  map[string]*Upstream `envPrefix:"OPTIONAL"`
+		Pointer   *map[string]Upstream `envPrefix:"POINTER"`
+		Named     map[string]TLS       `envPrefix:"NAMED"`
+		Unused    map[string]Upstream  `envPrefix:"UNUSED"`
+		Invalid   map[string]struct {
+			N int `env:"N"`
+		} `envPrefix:"INVALID"`
+	}
+
+	tests := []struct {
+		name     string
+		initial  Config
+		env      map[string]string
+		aggr     bool
+		expected Config
+		wantErr  bool
+	}{
+		{
+			name: "Keys",
+			env: map[string]string{
+				"UPSTREAM_PRIMARY_HOST":          "a",
+				"UPSTREAM_PRIMARY_PORT":          "8080",
+				"UPSTREAM_AUTH_SERVICE_HOST":     "b",
+				"UPSTREAM_AUTH_SERVICE_TLS_CERT": "cert",
+				"UPSTREAM_CACHE_ALIAS_0_NAME":    "c",
+				"UPSTREAM_HOST":                  "no key",
+				"OPTIONAL_A_PORT":                "1",
+				"POINTER_A_HOST":                 "p",
+				"NAMED_WEB_CERT":                 "web",
+			},
+			expected: Config{
+				Upstreams: map[string]Upstream{
+					"PRIMARY":      {Host: "a", Port: 8080},
+					"AUTH_SERVICE": {Host: "b", Port: 80, TLS: TLS{Cert: "cert"}},
+					"CACHE": {Port: 80, Aliases: []struct {
+						Name string `env:"NAME"`
+					}{{Name: "c"}}},
+				},
+				Optional: map[string]*Upstream{"A": {Port: 1}},
+				Pointer:  &map[string]Upstream{"A": {Host: "p", Port: 80}},
+				Named:    map[string]TLS{"WEB": {Cert: "web"}},
+			},
+		},
+		{
+			name:    "Existing entries",
+			initial: Config{Upstreams: map[string]Upstream{"PRIMARY": {Host: "kept", Port: 1}, "OTHER": {Host: "other"}}},
+			env:     map[string]string{"UPSTREAM_PRIMARY_PORT": "2"},
+			expected: Config{
+				Upstreams: map[string]Upstream{"PRIMARY": {Host: "kept", Port: 2}, "OTHER": {Host: "other"}},
+			},
+		},
+		{
+			name:    "Invalid value",
+			env:     map[string]string{"INVALID_A_N": "x", "INVALID_B_N": "y"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid values aggregated",
+			env:     map[string]string{"INVALID_A_N": "x", "INVALID_B_N": "y"},
+			aggr:    true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.initial
+			err := ParseWithOpts(&cfg, Options{Env: tt.env, AggregateErrors: tt.aggr})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWithOpts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.expected.Pointer == nil {
+				tt.expected.Pointer = cfg.Pointer
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSliceOfStructs_Pointers(t *testing.T) {
+	type Worker struct {
+		Name string `env:"NAME"`
+		Size int    `env:"SIZE" envDefault:"1"`
+	}
+
+	existing := &Worker{Name: "kept", Size: 5}
+
+	tests := []struct {
+		name     string
+		initial  []*Worker
+		env      map[string]string
+		expected []*Worker
+	}{
+		{
+			name:     "Missing indexes are nil",
+			env:      map[string]string{"WORKER_0_NAME": "a", "WORKER_2_NAME": "c"},
+			expected: []*Worker{{Name: "a", Size: 1}, nil, {Name: "c", Size: 1}},
+		},
+		{
+			name:     "Existing pointers are kept",
+			initial:  []*Worker{existing, nil},
+			env:      map[string]string{"WORKER_0_SIZE": "2"},
+			expected: []*Worker{{Name: "kept", Size: 2}, nil},
+		},
+		{
+			name: "No variables",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := struct {
+				Workers []*Worker `envPrefix:"WORKER"`
+			}{Workers: tt.initial}
+
+			if err := ParseWithOpts(&cfg, Options{Env: tt.env}); err != nil {
+				t.Fatalf("ParseWithOpts() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Workers, tt.expected) {
+				t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg.Workers, tt.expected)
+			}
+			if tt.initial != nil && cfg.Workers[0] != existing {
+				t.Errorf("ParseWithOpts() replaced the existing pointer")
+			}
+		})
+	}
+}
+
 func TestInitialiseSlice(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -281,7 +864,7 @@ func TestPopulateSlice(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			slice := reflect.MakeSlice(tc.v.Type(), tc.capacity, tc.capacity)
-			err := populateSlice(slice, tc.v, tc.prefixedEnv, tc.capacity, tc.opts)
+			err := populateSlice(slice, tc.v, tc.prefixedEnv, tc.capacity, &tc.opts)
 			if err != nil && !tc.expectedError {
 				t.Errorf("Expected no error, got %v", err)
 			} else if err == nil && tc.expectedError {
@@ -680,6 +1263,87 @@ func TestHandleMap(t *testing.T) {
 			}{}).Field(0),
 			expectedError: true,
 		},
+		{
+			name: "Nested map",
+			v:    reflect.ValueOf(&map[string]map[string]int{}).Elem(),
+			val:  "a:x=1;y=2,b:z=3,c:",
+			sf: reflect.TypeOf(struct {
+				Field map[string]map[string]int `env:"FIELD"`
+			}{}).Field(0),
+			expected: map[string]map[string]int{"a": {"x": 1, "y": 2}, "b": {"z": 3}, "c": {}},
+		},
+		{
+			name: "Nested map with separators",
+			v:    reflect.ValueOf(&map[string]map[string]string{}).Elem(),
+			val:  "a:x~1&y~2",
+			sf: reflect.TypeOf(struct {
+				Field map[string]map[string]string `env:"FIELD" envNestedSeparator:"&" envNestedKeyValSeparator:"~"`
+			}{}).Field(0),
+			expected: map[string]map[string]string{"a": {"x": "1", "y": "2"}},
+		},
+		{
+			name: "Nested slice",
+			v:    reflect.ValueOf(&map[string][]int{}).Elem(),
+			val:  "a:1;2;3,b:4,c:",
+			sf: reflect.TypeOf(struct {
+				Field map[string][]int `env:"FIELD"`
+			}{}).Field(0),
+			expected: map[string][]int{"a": {1, 2, 3}, "b": {4}, "c": {}},
+		},
+		{
+			name: "Nested slice of pointers",
+			v:    reflect.ValueOf(&map[string][]*int{}).Elem(),
+			val:  "a:1",
+			sf: reflect.TypeOf(struct {
+				Field map[string][]*int `env:"FIELD"`
+			}{}).Field(0),
+			expected: map[string][]*int{"a": {func() *int { i := 1; return &i }()}},
+		},
+		{
+			name: "Invalid nested map value",
+			v:    reflect.ValueOf(&map[string]map[string]int{}).Elem(),
+			val:  "a:x=one",
+			sf: reflect.TypeOf(struct {
+				Field map[string]map[string]int `env:"FIELD"`
+			}{}).Field(0),
+			expectedError: true,
+		},
+		{
+			name: "Invalid nested slice value",
+			v:    reflect.ValueOf(&map[string][]int{}).Elem(),
+			val:  "a:1;two",
+			sf: reflect.TypeOf(struct {
+				Field map[string][]int `env:"FIELD"`
+			}{}).Field(0),
+			expectedError: true,
+		},
+		{
+			name: "Unsupported nested key type",
+			v:    reflect.ValueOf(&map[struct{}][]int{}).Elem(),
+			val:  "a:1",
+			sf: reflect.TypeOf(struct {
+				Field map[struct{}][]int `env:"FIELD"`
+			}{}).Field(0),
+			expectedError: true,
+		},
+		{
+			name: "Unsupported nested map type",
+			v:    reflect.ValueOf(&map[string]map[string]struct{}{}).Elem(),
+			val:  "a:x=1",
+			sf: reflect.TypeOf(struct {
+				Field map[string]map[string]struct{} `env:"FIELD"`
+			}{}).Field(0),
+			expectedError: true,
+		},
+		{
+			name: "Unsupported nested slice type",
+			v:    reflect.ValueOf(&map[string][]struct{}{}).Elem(),
+			val:  "a:1",
+			sf: reflect.TypeOf(struct {
+				Field map[string][]struct{} `env:"FIELD"`
+			}{}).Field(0),
+			expectedError: true,
+		},
 		{
 			name: "Unsupported val type",
 			v:    reflect.ValueOf(&map[string]bool{}).Elem(),
@@ -762,6 +1426,142 @@ func TestGetKeyAndElemParsers(t *testing.T) {
 	}
 }
 
+func TestRegisterParser(t *testing.T) {
+	type Level int
+	type Config struct {
+		Ports   []int  `env:"PORTS"`
+		Workers []int  `env:"WORKERS" envParser:"test-int-range"`
+		Level   Level  `env:"LEVEL" envParser:"test-level"`
+		Limit   *int   `env:"LIMIT" envParser:"test-level"`
+		Name    string `env:"NAME" envParser:"test-upper" envValidate:"len=3"`
+	}
+
+	RegisterParser("test-int-range", func(v string) (interface{}, error) {
+		from, to, _ := strings.Cut(v, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, err
+		}
+		last, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, err
+		}
+
+		var ints []int
+		for i := first; i <= last; i++ {
+			ints = append(ints, i)
+		}
+		return ints, nil
+	})
+	RegisterParser("test-level", func(v string) (interface{}, error) {
+		return map[string]int{"debug": 0, "info": 1, "warn": 2}[v], nil
+	})
+	RegisterParser("test-upper", func(v string) (interface{}, error) {
+		return strings.ToUpper(v), nil
+	})
+
+	limit := 2
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Config
+		wantErr  string
+	}{
+		{
+			name:     "Same type, different parsers",
+			env:      map[string]string{"PORTS": "80,443", "WORKERS": "1-3", "LEVEL": "warn", "LIMIT": "warn", "NAME": "abc"},
+			expected: Config{Ports: []int{80, 443}, Workers: []int{1, 2, 3}, Level: 2, Limit: &limit, Name: "ABC"},
+		},
+		{
+			name:    "Parser error",
+			env:     map[string]string{"WORKERS": "1-x"},
+			wantErr: `WORKERS: strconv.Atoi: parsing "x": invalid syntax`,
+		},
+		{
+			name:    "Validated after parsing",
+			env:     map[string]string{"NAME": "abcd"},
+			wantErr: "NAME: length 4 is not 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: tt.env})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseWithOpts() error = %v, expected %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWithOpts() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid registration", func(t *testing.T) {
+		for _, name := range []string{"", "test-nil"} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("RegisterParser(%q) did not panic", name)
+					}
+				}()
+				RegisterParser(name, nil)
+			}()
+		}
+	})
+}
+
+func TestParseNamed(t *testing.T) {
+	RegisterParser("test-nil-result", func(string) (interface{}, error) { return nil, nil })
+	RegisterParser("test-string", func(v string) (interface{}, error) { return v, nil })
+
+	tests := []struct {
+		name    string
+		parser  string
+		target  interface{}
+		wantErr string
+	}{
+		{name: "Unknown parser", parser: "test-unknown", target: new(int), wantErr: `unknown parser "test-unknown"`},
+		{name: "Nil result", parser: "test-nil-result", target: new(int), wantErr: `parser "test-nil-result" returned <nil>, expected int`},
+		{name: "Other kind", parser: "test-string", target: new(int), wantErr: `parser "test-string" returned string, expected int`},
+		{name: "Nil result for a pointer", parser: "test-nil-result", target: new(*int), wantErr: `parser "test-nil-result" returned <nil>, expected int`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseNamed(reflect.ValueOf(tt.target).Elem(), tt.parser, "1")
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseNamed() error = %v, expected %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func BenchmarkParseExtendedDuration(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseExtendedDuration("1w2d12h30m"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseNamed(b *testing.B) {
+	RegisterParser("bench-int", func(v string) (interface{}, error) { return strconv.Atoi(v) })
+	var i int
+	v := reflect.ValueOf(&i).Elem()
+
+	for n := 0; n < b.N; n++ {
+		_ = parseNamed(v, "bench-int", "42")
+	}
+}
+
 func BenchmarkParseSliceOfStructs(b *testing.B) {
 	type TestStruct struct {
 		Foo string `env:"FOO"`
@@ -775,6 +1575,6 @@ func BenchmarkParseSliceOfStructs(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var ts []TestStruct
 		ref := reflect.ValueOf(&ts).Elem()
-		_ = parseSliceOfStructs(ref, opts)
+		_ = parseSliceOfStructs(ref, &opts)
 	}
 }
@@ -0,0 +1,179 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudment/utils-go/internal/strcase"
+)
+
+// Source provides environment variables from somewhere, such as the process, .env files or a remote store.
+//
+// Sources are set within Options.Sources, so several can be composed with an explicit precedence.
+//
+// Example:
+//
+//	// Remote values are overridden by .env, which is overridden by the process.
+//	err := env.ParseWithOpts(&cfg, env.Options{
+//		Sources: []env.Source{remote, env.FileSource{".env"}, env.OsEnvSource{}},
+//	})
+type Source interface {
+	// Load returns the environment variables of the source.
+	//
+	// The context is the one passed to ParseWithContext, or context.Background() otherwise.
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+// WatchableSource is a Source that can report changes, such as a remote store with watch support.
+//
+// Example:
+//
+//	err := consul.Watch(ctx, func(vars map[string]string) error {
+//		var cfg Config
+//		if err := env.ParseWithOpts(&cfg, env.Options{Env: vars}); err != nil {
+//			return err
+//		}
+//		current.Store(&cfg)
+//		return nil
+//	})
+type WatchableSource interface {
+	Source
+
+	// Watch calls onChange with the environment variables each time they change, after they are first loaded.
+	//
+	// It blocks until the context is done, returning its error, or until loading fails or onChange returns an error.
+	Watch(ctx context.Context, onChange func(vars map[string]string) error) error
+}
+
+// SourceFunc is a function used as a Source, such as a closure fetching values from a remote store.
+type SourceFunc func(ctx context.Context) (map[string]string, error)
+
+// Load calls the function.
+func (f SourceFunc) Load(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+// OsEnvSource is the Source of the environment variables of the process, from os.Environ().
+type OsEnvSource struct{}
+
+// Load returns the environment variables of the process.
+func (OsEnvSource) Load(context.Context) (map[string]string, error) {
+	return ToMap(os.Environ()), nil
+}
+
+// FileSource is the Source of .env files, later files take priority.
+//
+// If no filenames are provided, it will default to ".env". The files are read concurrently.
+type FileSource []string
+
+// Load parses the files, returning an error if a file could not be read or parsed.
+func (s FileSource) Load(context.Context) (map[string]string, error) {
+	filenames := []string(s)
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	envMaps, err := parseFiles(filenames, os.Open)
+	if err != nil {
+		return nil, err
+	}
+
+	return MergeMaps(envMaps...), nil
+}
+
+// MapSource is the Source of a fixed set of environment variables, such as defaults or test values.
+type MapSource map[string]string
+
+// Load returns the map, which is not copied.
+func (s MapSource) Load(context.Context) (map[string]string, error) {
+	return s, nil
+}
+
+// kvEnvKey converts the key of a key-value store into an environment variable, after removing the prefix.
+//
+// Each segment is converted to SCREAMING_SNAKE_CASE, such as "config/app/database/maxConns" with the prefix
+// "config/app/" to DATABASE_MAX_CONNS.
+//
+// Parameters:
+//   - prefix: The prefix of the keys that are read.
+//   - key: The key within the store.
+//
+// Returns: The environment variable, or an empty string for the prefix itself or a folder.
+func kvEnvKey(prefix, key string) string {
+	key = strings.TrimPrefix(key, prefix)
+	if key == "" || strings.HasSuffix(key, "/") {
+		return ""
+	}
+	return strcase.ToScreamingSnake(key)
+}
+
+// doKVRequest sends a request to a key-value store, returning the response if it was successful.
+//
+// Parameters:
+//   - client: The client to send the request with, nil for http.DefaultClient.
+//   - req: The request.
+//   - name: The name of the store, used within errors.
+//
+// Returns: The response, which the caller must close, or an error if it failed or was not successful.
+//
+// Note: A 404 response is returned rather than an error, as Consul uses it when no keys have the prefix.
+func doKVRequest(client *http.Client, req *http.Request, name string) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: unexpected status %s: %s", name, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
+
+// loadSources loads each source in order over the base environment variables.
+//
+// Parameters:
+//   - ctx: The context passed to each source.
+//   - base: The environment variables the sources are loaded over, which is not modified.
+//   - sources: The sources, later sources take priority.
+//
+// Returns: The merged environment variables, or an error if a source could not be loaded.
+//
+// Note: A single source over an empty base is returned without copying, as is the case for Parse.
+func loadSources(ctx context.Context, base map[string]string, sources []Source) (map[string]string, error) {
+	var merged map[string]string
+
+	for i, source := range sources {
+		vars, err := source.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load source %d (%T): %w", i, source, err)
+		}
+
+		if len(base) == 0 && len(sources) == 1 {
+			return vars, nil
+		}
+
+		if merged == nil {
+			merged = make(map[string]string, len(base)+len(vars))
+			for key, val := range base {
+				merged[key] = val
+			}
+		}
+
+		for key, val := range vars {
+			merged[key] = val
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,214 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSources(t *testing.T) {
+	paths := writeEnvFiles(t, "HOST=file\nPORT=8080\nNAME=file", "NAME=second")
+
+	t.Setenv("SOURCE_TEST_HOST", "process")
+
+	tests := []struct {
+		name     string
+		source   Source
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "OsEnvSource",
+			source:   OsEnvSource{},
+			expected: map[string]string{"SOURCE_TEST_HOST": "process"},
+		},
+		{
+			name:     "FileSource",
+			source:   FileSource(paths),
+			expected: map[string]string{"HOST": "file", "PORT": "8080", "NAME": "second"},
+		},
+		{
+			name:    "FileSource missing file",
+			source:  FileSource{filepath.Join(t.TempDir(), ".env")},
+			wantErr: true,
+		},
+		{
+			name:    "FileSource defaults to .env",
+			source:  FileSource{},
+			wantErr: true,
+		},
+		{
+			name:     "MapSource",
+			source:   MapSource{"HOST": "map"},
+			expected: map[string]string{"HOST": "map"},
+		},
+		{
+			name: "SourceFunc",
+			source: SourceFunc(func(ctx context.Context) (map[string]string, error) {
+				return map[string]string{"HOST": "func"}, nil
+			}),
+			expected: map[string]string{"HOST": "func"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := tt.source.Load(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for key, val := range tt.expected {
+				if vars[key] != val {
+					t.Errorf("Load() %s = %q, expected %q", key, vars[key], val)
+				}
+			}
+			if tt.name != "OsEnvSource" && len(tt.expected) != len(vars) {
+				t.Errorf("Load() = %v, expected %v", vars, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	failing := SourceFunc(func(ctx context.Context) (map[string]string, error) {
+		return nil, errors.New("unavailable")
+	})
+
+	tests := []struct {
+		name     string
+		base     map[string]string
+		sources  []Source
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "Single source",
+			sources:  []Source{MapSource{"A": "1"}},
+			expected: map[string]string{"A": "1"},
+		},
+		{
+			name:     "Later sources take priority",
+			sources:  []Source{MapSource{"A": "1", "B": "1"}, MapSource{"B": "2", "C": "2"}},
+			expected: map[string]string{"A": "1", "B": "2", "C": "2"},
+		},
+		{
+			name:     "Sources are loaded over the base",
+			base:     map[string]string{"A": "base", "B": "base"},
+			sources:  []Source{MapSource{"B": "1"}},
+			expected: map[string]string{"A": "base", "B": "1"},
+		},
+		{
+			name:    "Error",
+			sources: []Source{MapSource{"A": "1"}, failing},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadSources(context.Background(), tt.base, tt.sources)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("loadSources() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Base is not modified", func(t *testing.T) {
+		base := map[string]string{"A": "base"}
+		if _, err := loadSources(context.Background(), base, []Source{MapSource{"A": "1"}}); err != nil {
+			t.Fatal(err)
+		}
+		if base["A"] != "base" {
+			t.Errorf("loadSources() modified the base, got %v", base)
+		}
+	})
+}
+
+func TestParseWithOpts_Sources(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+		Name string `env:"NAME"`
+	}
+
+	paths := writeEnvFiles(t, "HOST=file\nPORT=8080\nNAME=file")
+
+	t.Run("Precedence", func(t *testing.T) {
+		var cfg Config
+		err := ParseWithOpts(&cfg, Options{
+			Env:     map[string]string{"NAME": "env"},
+			Sources: []Source{MapSource{"HOST": "defaults", "NAME": "defaults"}, FileSource(paths), MapSource{"PORT": "9090"}},
+			Overlay: map[string]string{"HOST": "overlay"},
+		})
+		if err != nil {
+			t.Fatalf("ParseWithOpts() error = %v", err)
+		}
+
+		expected := Config{Host: "overlay", Port: 9090, Name: "file"}
+		if cfg != expected {
+			t.Errorf("ParseWithOpts() = %+v, expected %+v", cfg, expected)
+		}
+	})
+
+	t.Run("Context is passed to sources", func(t *testing.T) {
+		type hostKey struct{}
+		ctx := context.WithValue(context.Background(), hostKey{}, "remote")
+
+		remote := SourceFunc(func(ctx context.Context) (map[string]string, error) {
+			return map[string]string{"HOST": ctx.Value(hostKey{}).(string)}, nil
+		})
+
+		var cfg Config
+		if err := ParseWithContext(ctx, &cfg, Options{Sources: []Source{remote}}); err != nil || cfg.Host != "remote" {
+			t.Errorf("ParseWithContext() = %+v, %v, expected Host from the context", cfg, err)
+		}
+	})
+
+	t.Run("Source error", func(t *testing.T) {
+		var cfg Config
+		err := ParseWithOpts(&cfg, Options{Sources: []Source{FileSource{paths[0] + ".missing"}}})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ParseWithOpts() error = %v, expected %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestKVEnvKey(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		key      string
+		expected string
+	}{
+		{prefix: "config/app/", key: "config/app/database/host", expected: "DATABASE_HOST"},
+		{prefix: "config/app/", key: "config/app/database/maxConns", expected: "DATABASE_MAX_CONNS"},
+		{prefix: "config/app/", key: "config/app/log-level", expected: "LOG_LEVEL"},
+		{prefix: "config/app/", key: "config/app/PORT", expected: "PORT"},
+		{prefix: "config/app/", key: "config/app/", expected: ""},
+		{prefix: "config/app/", key: "config/app/database/", expected: ""},
+		{prefix: "", key: "/config/PORT", expected: "CONFIG_PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := kvEnvKey(tt.prefix, tt.key); got != tt.expected {
+				t.Errorf("kvEnvKey() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkLoadSources(b *testing.B) {
+	sources := []Source{MapSource{"A": "1", "B": "1"}, MapSource{"B": "2", "C": "2"}}
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = loadSources(ctx, nil, sources)
+	}
+}
@@ -0,0 +1,260 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// errUnterminatedQuote is returned by getValueWithinQuotes when the closing quote is not found.
+var errUnterminatedQuote = errors.New("unterminated closing quote")
+
+// errEmptyFile is returned along with the empty result when a file has no lines.
+var errEmptyFile = errors.New("empty file")
+
+// tokenizer reads the entries of a .env file a line at a time, rather than reading the whole file into memory.
+//
+// Lines are slices of the source, or of the buffer of the reader, so nothing is copied or allocated until the key
+// and value of an entry are converted to strings. Only a quoted value spanning several lines, or a line longer
+// than the buffer, is copied, into buffers that are reused for each entry.
+type tokenizer struct {
+	// r is the reader of the file, nil when the file is already in memory.
+	r *bufio.Reader
+	// pooled is the reader taken from readerPool, returned to it by release.
+	pooled *bufio.Reader
+	// src is the rest of the file when it is already in memory.
+	src []byte
+	// entry holds an entry spanning several lines.
+	entry []byte
+	// long holds a line longer than the buffer of r.
+	long []byte
+	// line is the number of lines read.
+	line int
+	// read is true once a line has been read, so an empty file can be told apart from one with only comments.
+	read bool
+}
+
+// readerPool holds the readers of tokenizers, so reading a small file does not allocate a new buffer every time.
+var readerPool = sync.Pool{New: func() any { return bufio.NewReader(nil) }}
+
+// newTokenizer returns a tokenizer streaming the file from r, with a reader from readerPool.
+//
+// A byte order mark is skipped, and a UTF-16 file is decoded to UTF-8 as it is read, see decodeReader.
+func newTokenizer(r io.Reader) *tokenizer {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return &tokenizer{r: decodeReader(br), pooled: br}
+}
+
+// release returns the reader to readerPool once every entry is read.
+//
+// Lines are slices of its buffer, so it is only released by parse and parseOrdered, once they are strings.
+func (t *tokenizer) release() {
+	if t.pooled == nil {
+		return
+	}
+	t.pooled.Reset(nil)
+	readerPool.Put(t.pooled)
+	t.pooled, t.r = nil, nil
+}
+
+// parse reads every entry of the file.
+//
+// Values may reference other variables, such as ${KEY}, $KEY, ${KEY:-default} or ${KEY:?message}.
+// They are resolved from the keys earlier within the file, then the process environment.
+// Single quoted values are not expanded, nor is a \$ within a double quoted value, and DisableFileExpansion turns it off
+// for every value.
+//
+// Returns: The map of environment variables, or an error if reading fails or the file is empty.
+//
+// Note: Invalid entries return a *SyntaxError, holding the line and column where the entry starts.
+func (t *tokenizer) parse() (map[string]string, error) {
+	defer t.release()
+	envMap := make(map[string]string)
+
+	err := t.each(func(key, value string) {
+		envMap[key] = value
+	}, func(key string) (string, bool) {
+		val, ok := envMap[key]
+		return val, ok
+	})
+	if err != nil && err != errEmptyFile {
+		return nil, err
+	}
+	return envMap, err
+}
+
+// parseOrdered reads every entry of the file in the order they are declared, as parse does.
+//
+// Returns: The entries, including each entry of a repeated key, or an error if reading fails or the file is empty.
+func (t *tokenizer) parseOrdered() ([]KeyValue, error) {
+	defer t.release()
+	var pairs []KeyValue
+	// index holds the position of the last entry of each key, for expanding references.
+	index := make(map[string]int)
+
+	err := t.each(func(key, value string) {
+		index[key] = len(pairs)
+		pairs = append(pairs, KeyValue{Key: key, Value: value})
+	}, func(key string) (string, bool) {
+		if i, ok := index[key]; ok {
+			return pairs[i].Value, true
+		}
+		return "", false
+	})
+	if err != nil && err != errEmptyFile {
+		return nil, err
+	}
+	return pairs, err
+}
+
+// each calls set with the key and value of every entry of the file, in order.
+//
+// Parameters:
+//   - set: Called for each entry.
+//   - lookup: Returns the value of a key set by an earlier entry, for expanding references.
+//
+// Returns: An error if reading fails, errEmptyFile if the file is empty, or a *SyntaxError for an invalid entry.
+func (t *tokenizer) each(set func(key, value string), lookup func(key string) (string, bool)) error {
+	var expand func(string) string
+	if !DisableFileExpansion {
+		expand = func(key string) string {
+			if val, ok := lookup(key); ok {
+				return val
+			}
+			return os.Getenv(key)
+		}
+	}
+
+	for {
+		line, err := t.readLine()
+		if err == io.EOF {
+			if !t.read {
+				return errEmptyFile
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// A line may hold several entries, such as A="1" B="2".
+		for src := getStart(line); src != nil; {
+			lineNum, col := t.line, len(line)-len(src)+1
+
+			key, value, rest, err := getKeyValue(src, expand)
+			if errors.Is(err, errUnterminatedQuote) {
+				var entry []byte
+				if entry, err = t.readQuoted(src); err == nil {
+					key, value, rest, err = getKeyValue(entry, expand)
+					// Any entries after the value are on the last line of the entry.
+					line = entry[bytes.LastIndexByte(entry, '\n')+1:]
+				} else if !errors.Is(err, errUnterminatedQuote) {
+					return err
+				}
+			}
+
+			if err != nil {
+				return &SyntaxError{Line: lineNum, Col: col, Msg: err.Error()}
+			}
+
+			set(key, value)
+			src = getStart(rest)
+		}
+	}
+}
+
+// readQuoted reads the lines following an entry whose quoted value is not closed on its first line.
+//
+// Parameters:
+//   - src: The entry, from its key to the end of its first line.
+//
+// Returns: The entry up to the line holding the closing quote, or errUnterminatedQuote if the file ends first.
+//
+// Note: The entry is held within t.entry, so it is only valid until the next entry spanning several lines.
+func (t *tokenizer) readQuoted(src []byte) ([]byte, error) {
+	// The value starts with its quote, straight after the separator, as found by getKeyValue.
+	start := bytes.IndexAny(src, "=:") + 1
+	quote := src[start]
+
+	t.entry = append(t.entry[:0], src...)
+
+	for {
+		line, err := t.readLine()
+		if err == io.EOF {
+			return nil, errUnterminatedQuote
+		} else if err != nil {
+			return nil, err
+		}
+
+		i := len(t.entry) + 1
+		t.entry = append(t.entry, '\n')
+		t.entry = append(t.entry, line...)
+
+		// Only the new line is searched for the closing quote, so the entry is not parsed until it is found.
+		for {
+			n := bytes.IndexByte(t.entry[i:], quote)
+			if n == -1 {
+				break
+			}
+
+			i += n
+			if !isEscaped(t.entry[start+1 : i]) {
+				return t.entry, nil
+			}
+			i++
+		}
+	}
+}
+
+// readLine returns the next line, without its \n or \r\n.
+//
+// Returns: The line, which is only valid until the next call, or io.EOF once every line has been read.
+func (t *tokenizer) readLine() ([]byte, error) {
+	var line []byte
+
+	if t.r == nil {
+		if len(t.src) == 0 {
+			return nil, io.EOF
+		}
+
+		line, t.src = t.src, nil
+		if i := bytes.IndexByte(line, '\n'); i != -1 {
+			line, t.src = line[:i], line[i+1:]
+		}
+	} else {
+		var err error
+		line, err = t.r.ReadSlice('\n')
+
+		if err == bufio.ErrBufferFull {
+			// The line is longer than the buffer, so it is copied as it is read.
+			t.long = append(t.long[:0], line...)
+			for err == bufio.ErrBufferFull {
+				line, err = t.r.ReadSlice('\n')
+				t.long = append(t.long, line...)
+			}
+			line = t.long
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		} else if len(line) == 0 {
+			return nil, io.EOF
+		}
+
+		if line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+		}
+	}
+
+	t.read = true
+	t.line++
+
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+
+	return line, nil
+}
@@ -0,0 +1,248 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTokenizer_Parse(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "Multi-line value",
+			input:    "A=\"first\nsecond\"\nB=2",
+			expected: map[string]string{"A": "first\nsecond", "B": "2"},
+		},
+		{
+			name:     "Multi-line value with Windows line endings",
+			input:    "A='first\r\nsecond\r\nthird'\r\nB=2\r\n",
+			expected: map[string]string{"A": "first\nsecond\nthird", "B": "2"},
+		},
+		{
+			name:     "Escaped quote on a later line",
+			input:    "A=\"first\n\\\"second\\\"\nthird\" # comment\nB=2",
+			expected: map[string]string{"A": "first\n\"second\"\nthird", "B": "2"},
+		},
+		{
+			name:     "Other quote on a later line",
+			input:    "A='first\n\"second\"\n'\nB=2",
+			expected: map[string]string{"A": "first\n\"second\"\n", "B": "2"},
+		},
+		{
+			name:     "Entries on the same line",
+			input:    "A=\"1\" B='2'\nC=\"3\n\" D=4",
+			expected: map[string]string{"A": "1", "B": "2", "C": "3\n", "D": "4"},
+		},
+		{
+			name:     "Expansion of a multi-line value",
+			input:    "A=1\nB=\"${A}\n${A}\"",
+			expected: map[string]string{"A": "1", "B": "1\n1"},
+		},
+		{
+			name:     "Line longer than the buffer",
+			input:    "A=" + long + "\nB=\"" + long + "\n" + long + "\"\nC=3",
+			expected: map[string]string{"A": long, "B": long + "\n" + long, "C": "3"},
+		},
+		{
+			name:     "Only comments",
+			input:    "# comment\n\n   # another\n",
+			expected: map[string]string{},
+		},
+		{
+			name:  "Unterminated multi-line value",
+			input: "A=1\nB=\"first\nsecond\\\"\nthird",
+			err:   "2:1: unterminated closing quote",
+		},
+		{
+			name:  "Invalid entry after a multi-line value",
+			input: "A=\"first\nsecond\"  b=2",
+			err:   "2:10: invalid key: must start with a capital letter",
+		},
+		{
+			name:  "Key without a separator",
+			input: "KEY\nOTHER=1",
+			err:   "1:1: key-value separator not found",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"Bytes", "Reader"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				var got map[string]string
+				var err error
+				if mode == "Bytes" {
+					got, err = parseEnvFileBytes([]byte(tt.input))
+				} else {
+					// One byte at a time, so lines are split across reads.
+					got, err = readWithIO(iotest.OneByteReader(strings.NewReader(tt.input)))
+				}
+
+				if tt.err != "" {
+					if err == nil || err.Error() != tt.err {
+						t.Fatalf("parse() error = %v, expected %s", err, tt.err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("parse() error = %v", err)
+				}
+				if !maps.Equal(got, tt.expected) {
+					t.Errorf("parse() = %q, expected %q", got, tt.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestTokenizer_ReadError(t *testing.T) {
+	readErr := errors.New("read error")
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Between entries", input: "A=1\n"},
+		{name: "Within a multi-line value", input: "A=\"first\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := io.MultiReader(strings.NewReader(tt.input), iotest.ErrReader(readErr))
+			if _, err := readWithIO(r); !errors.Is(err, readErr) {
+				t.Errorf("readWithIO() error = %v, expected %v", err, readErr)
+			}
+		})
+	}
+}
+
+func TestTokenizer_Modes(t *testing.T) {
+	src := largeEnvFile()
+
+	expected, err := parseEnvFileBytes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readWithIO(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !maps.Equal(got, expected) {
+		t.Errorf("readWithIO() returned %d keys, expected the %d of parseEnvFileBytes()", len(got), len(expected))
+	}
+	if got["MULTI_3"] != "first line\nsecond line" || got["URL_3"] != "http://value_3:8080" || got["LITERAL_3"] != "pa$$word" {
+		t.Errorf("readWithIO() = %q, %q, %q", got["MULTI_3"], got["URL_3"], got["LITERAL_3"])
+	}
+}
+
+func TestReadOrdered(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []KeyValue
+		err      string
+	}{
+		{
+			name:     "Declaration order",
+			input:    "Z=1\nA=2\nM=3",
+			expected: []KeyValue{{Key: "Z", Value: "1"}, {Key: "A", Value: "2"}, {Key: "M", Value: "3"}},
+		},
+		{
+			name:  "Repeated key",
+			input: "HOST=a\nPORT=1\nHOST=b\nURL=${HOST}:${PORT}",
+			expected: []KeyValue{
+				{Key: "HOST", Value: "a"}, {Key: "PORT", Value: "1"}, {Key: "HOST", Value: "b"}, {Key: "URL", Value: "b:1"},
+			},
+		},
+		{
+			name:     "Several entries on a line",
+			input:    "# comment\nA=\"1\" B='2'\n\nC=\"multi\nline\"",
+			expected: []KeyValue{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}, {Key: "C", Value: "multi\nline"}},
+		},
+		{name: "Only comments", input: "# comment\n", expected: nil},
+		{name: "Empty file", input: "", err: "empty file"},
+		{name: "Invalid entry", input: "A=1\nB='2", err: "2:1: unterminated closing quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadOrdered(strings.NewReader(tt.input))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("ReadOrdered() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadOrdered() error = %v", err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("ReadOrdered() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFileOrdered(t *testing.T) {
+	paths := writeEnvFiles(t, "B=1\nA=2", "A=1\nB='2")
+
+	got, err := ParseFileOrdered(paths[0])
+	if err != nil {
+		t.Fatalf("ParseFileOrdered() error = %v", err)
+	}
+	if expected := []KeyValue{{Key: "B", Value: "1"}, {Key: "A", Value: "2"}}; !slices.Equal(got, expected) {
+		t.Errorf("ParseFileOrdered() = %q, expected %q", got, expected)
+	}
+
+	var syntaxErr *SyntaxError
+	if _, err = ParseFileOrdered(paths[1]); !errors.As(err, &syntaxErr) || syntaxErr.File != paths[1] {
+		t.Errorf("ParseFileOrdered() error = %v, expected a *SyntaxError within %s", err, paths[1])
+	}
+
+	if _, err = ParseFileOrdered(paths[0] + ".missing"); err == nil {
+		t.Error("ParseFileOrdered() expected an error for a missing file")
+	}
+}
+
+func BenchmarkReadOrdered(b *testing.B) {
+	src := largeEnvFile()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadOrdered(bytes.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTokenizer_ReadLine(b *testing.B) {
+	src := largeEnvFile()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tok := newTokenizer(bytes.NewReader(src))
+		for {
+			if _, err := tok.readLine(); err != nil {
+				break
+			}
+		}
+		tok.release()
+	}
+}
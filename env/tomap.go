@@ -4,26 +4,105 @@ import (
 	"strings"
 )
 
-// On Windows, environment variables could start with '='?
-// See env_windows.go in the Go source(2023): https://github.com/golang/go/blob/master/src/syscall/env_windows.go#L63
-// See their original reference (2010): https://devblogs.microsoft.com/oldnewthing/20100506-00/?p=14133
-// See current reference (2021): https://learn.microsoft.com/en-us/windows/win32/procthread/environment-variables
-// If an issue arises, please create an issue.
-
-// toMap converts a slice of environment variables into a map.
+// ToMap converts a slice of environment variables, such as from os.Environ, into a map.
+//
+// Each entry is split at its first '=' after the first character. On Windows, the current directory of each drive is
+// held in variables starting with '=', such as "=C:=C:\dir", which are kept with the key "=C:".
 //
 // Parameters:
-//   - env: A slice of environment variables.
+//   - env: A slice of environment variables in the form "KEY=value".
 //
 // Returns:
-//   - A map of environment variables.
-func toMap(env []string) map[string]string {
+//   - A map of environment variables. Entries without a '=' separating a key from its value are skipped.
+//
+// Example:
+//
+//	vars := env.ToMap(os.Environ())
+//
+// See env_windows.go in the Go source: https://github.com/golang/go/blob/master/src/syscall/env_windows.go
+// See: https://devblogs.microsoft.com/oldnewthing/20100506-00/?p=14133
+func ToMap(env []string) map[string]string {
 	r := make(map[string]string, len(env))
 	for _, e := range env {
-		if i := strings.IndexByte(e, '='); i != -1 {
-			// Split at the first '=' character, :i gets the key, i+1: gets the value.
-			r[e[:i]] = e[i+1:]
+		if e == "" {
+			continue
+		}
+		// The search starts after the first character, so a leading '=' is part of the key.
+		if i := strings.IndexByte(e[1:], '='); i != -1 {
+			// :i+1 gets the key, i+2: gets the value.
+			r[e[:i+1]] = e[i+2:]
+		}
+	}
+	return r
+}
+
+// MergeMaps merges maps of environment variables into a new map, later maps take priority.
+//
+// Parameters:
+//   - maps: The environment variables to merge, which are not modified. Nil maps are skipped.
+//
+// Returns:
+//   - A new map of environment variables, which is empty rather than nil when there is nothing to merge.
+//
+// Example:
+//
+//	// The overrides take priority over the file, which takes priority over the defaults.
+//	vars := env.MergeMaps(defaults, fileVars, overrides)
+func MergeMaps(maps ...map[string]string) map[string]string {
+	size := 0
+	for _, vars := range maps {
+		size = max(size, len(vars))
+	}
+
+	merged := make(map[string]string, size)
+	for _, vars := range maps {
+		for key, val := range vars {
+			merged[key] = val
 		}
 	}
+	return merged
+}
+
+// upperKeys copies a map of environment variables with every key in upper case, as used by Options.CaseInsensitive.
+//
+// Parameters:
+//   - vars: The environment variables, which are not modified.
+//
+// Returns:
+//   - A map of environment variables, or nil if vars is nil.
+//
+// Note: When several keys only differ by case, the key already in upper case is used, otherwise the first in sorted
+// order, so the result does not depend on the order of the map.
+func upperKeys(vars map[string]string) map[string]string {
+	if vars == nil {
+		return nil
+	}
+
+	r := make(map[string]string, len(vars))
+
+	// from holds the original key of each value that was not already in upper case.
+	var from map[string]string
+
+	for key, val := range vars {
+		upper := strings.ToUpper(key)
+		if upper == key {
+			r[key] = val
+			continue
+		}
+
+		if _, isUpper := vars[upper]; isUpper {
+			continue
+		}
+		if original, ok := from[upper]; ok && original < key {
+			continue
+		}
+
+		if from == nil {
+			from = make(map[string]string)
+		}
+		from[upper] = key
+		r[upper] = val
+	}
+
 	return r
 }
@@ -42,9 +42,19 @@ func TestToMap(t *testing.T) {
 			expected: map[string]string{"KEY": ""},
 		},
 		{
-			name:     "Empty key with value",
-			input:    []string{"=value"},
-			expected: map[string]string{"": "value"},
+			name:     "Leading equals sign without a value",
+			input:    []string{"=value", "=", ""},
+			expected: map[string]string{},
+		},
+		{
+			name:     "Windows drive directories",
+			input:    []string{"=C:=C:\\Users\\dev", "=D:=D:\\", "=ExitCode=00000000", "PATH=C:\\Windows"},
+			expected: map[string]string{"=C:": "C:\\Users\\dev", "=D:": "D:\\", "=ExitCode": "00000000", "PATH": "C:\\Windows"},
+		},
+		{
+			name:     "Leading equals sign with an empty value",
+			input:    []string{"=C:="},
+			expected: map[string]string{"=C:": ""},
 		},
 		{
 			name:     "Key with multiple equals signs",
@@ -55,17 +65,108 @@ func TestToMap(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := toMap(tt.input)
+			result := ToMap(tt.input)
 			if !reflect.DeepEqual(result, tt.expected) {
-				t.Errorf("toMap(%v) = %v, expected %v", tt.input, result, tt.expected)
+				t.Errorf("ToMap(%v) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "No maps",
+			input:    nil,
+			expected: map[string]string{},
+		},
+		{
+			name:     "Nil maps",
+			input:    []map[string]string{nil, nil},
+			expected: map[string]string{},
+		},
+		{
+			name:     "Later maps take priority",
+			input:    []map[string]string{{"A": "1", "B": "1"}, nil, {"B": "2", "C": "2"}, {"C": "3"}},
+			expected: map[string]string{"A": "1", "B": "2", "C": "3"},
+		},
+		{
+			name:     "Empty values override",
+			input:    []map[string]string{{"A": "1"}, {"A": ""}},
+			expected: map[string]string{"A": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := MergeMaps(tt.input...); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("MergeMaps(%v) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Inputs are not modified", func(t *testing.T) {
+		base := map[string]string{"A": "1"}
+		merged := MergeMaps(base, map[string]string{"A": "2"})
+		merged["B"] = "3"
+		if !reflect.DeepEqual(base, map[string]string{"A": "1"}) {
+			t.Errorf("MergeMaps() modified its input = %v", base)
+		}
+	})
+}
+
+func TestUpperKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "Nil",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "Mixed case",
+			input:    map[string]string{"Path": "a", "home": "b", "USER": "c"},
+			expected: map[string]string{"PATH": "a", "HOME": "b", "USER": "c"},
+		},
+		{
+			name:     "Upper case takes priority",
+			input:    map[string]string{"path": "a", "PATH": "b", "Path": "c"},
+			expected: map[string]string{"PATH": "b"},
+		},
+		{
+			name:     "First in sorted order",
+			input:    map[string]string{"path": "a", "Path": "b", "pAth": "c"},
+			expected: map[string]string{"PATH": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := upperKeys(tt.input); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("upperKeys(%v) = %v, expected %v", tt.input, result, tt.expected)
 			}
 		})
 	}
 }
 
 func BenchmarkToMap(b *testing.B) {
-	envVars := []string{"KEY1=value1", "KEY2=value2", "KEY3=value3"}
+	envVars := []string{"KEY1=value1", "KEY2=value2", "KEY3=value3", "=C:=C:\\dir"}
+	for i := 0; i < b.N; i++ {
+		ToMap(envVars)
+	}
+}
+
+func BenchmarkMergeMaps(b *testing.B) {
+	defaults := map[string]string{"KEY1": "value1", "KEY2": "value2", "KEY3": "value3"}
+	overrides := map[string]string{"KEY2": "override"}
 	for i := 0; i < b.N; i++ {
-		toMap(envVars)
+		MergeMaps(defaults, overrides)
 	}
 }
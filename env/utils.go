@@ -2,12 +2,16 @@ package env
 
 import (
 	"encoding"
+	"encoding/json"
 	"reflect"
 	"strings"
 	"unicode"
 )
 
-// isSliceOfStructs checks if the field is a slice of structs.
+// textUnmarshalerType is used to find structs that are parsed from a single value.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isSliceOfStructs checks if the field is a slice of structs, or pointers to structs.
 //
 // Parameters:
 //   - sf: The reflect.StructField of the field.
@@ -21,7 +25,52 @@ func isSliceOfStructs(sf reflect.StructField) bool {
 		t = t.Elem()
 	}
 
-	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	return elem.Kind() == reflect.Struct
+}
+
+// isMapOfStructs checks if the field is a map of strings to structs, or pointers to structs.
+//
+// Parameters:
+//   - sf: The reflect.StructField of the field.
+//
+// Returns:
+//   - True if the field is a map of structs, false otherwise.
+func isMapOfStructs(sf reflect.StructField) bool {
+	t := sf.Type
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && isNestedStruct(t.Elem())
+}
+
+// isNestedStruct checks if the type is a struct, or pointer to a struct, whose fields are variables of their own.
+//
+// Structs that are parsed from a single value, such as time.Location or encoding.TextUnmarshaler types, are not.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	if _, ok := typeParsers[t]; ok {
+		return false
+	}
+
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
 }
 
 // asTextUnmarshaler gets the encoding.TextUnmarshaler from the reflect.Value.
@@ -49,6 +98,54 @@ func asTextUnmarshaler(v reflect.Value) encoding.TextUnmarshaler {
 	return tm
 }
 
+var (
+	// contextUnmarshalerType is the reflect.Type of ContextUnmarshaler.
+	contextUnmarshalerType = reflect.TypeOf((*ContextUnmarshaler)(nil)).Elem()
+	// binaryUnmarshalerType is the reflect.Type of encoding.BinaryUnmarshaler.
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	// jsonUnmarshalerType is the reflect.Type of json.Unmarshaler.
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// asContextUnmarshaler gets the ContextUnmarshaler from the reflect.Value.
+//
+// Unlike asTextUnmarshaler, a nil pointer is only initialised if its type implements ContextUnmarshaler.
+//
+// Parameters:
+//   - v: The reflect.Value to get the ContextUnmarshaler from.
+//
+// Returns:
+//   - The ContextUnmarshaler or nil if it doesn't exist.
+func asContextUnmarshaler(v reflect.Value) ContextUnmarshaler {
+	if i := asImplementation(v, contextUnmarshalerType); i != nil {
+		return i.(ContextUnmarshaler)
+	}
+	return nil
+}
+
+// asImplementation gets the pointer to the reflect.Value, if it implements an interface such as json.Unmarshaler.
+//
+// A nil pointer is only initialised if its type implements the interface.
+//
+// Parameters:
+//   - v: The reflect.Value, which must be addressable or a pointer.
+//   - iface: The reflect.Type of the interface.
+//
+// Returns:
+//   - The pointer, to be asserted to the interface, or nil if it does not implement it.
+func asImplementation(v reflect.Value, iface reflect.Type) interface{} {
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+
+	if v.Kind() != reflect.Ptr || !v.Type().Implements(iface) {
+		return nil
+	}
+
+	initialisePointer(v)
+	return v.Interface()
+}
+
 // initialisePointer initialises the pointer if it's nil.
 //
 // Parameters:
@@ -62,6 +159,25 @@ func initialisePointer(v reflect.Value) {
 	v = v.Elem()
 }
 
+// initialiseNil sets a nil pointer to a new value, and a nil map or slice to an empty one.
+//
+// Parameters:
+//   - v: The settable reflect.Value to initialise.
+func initialiseNil(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		initialisePointer(v)
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+		}
+	}
+}
+
 // updateReference updates the reference with the result.
 //
 // Parameters:
@@ -77,6 +193,14 @@ func updateReference(ref, result reflect.Value) {
 	}
 }
 
+// elemType returns the type a pointer points to, or the type itself if it's not a pointer.
+func elemType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
 // resolvePointer resolves the pointer to the value and type.
 //
 // Parameters:
@@ -142,6 +266,33 @@ func getSeparators(sf reflect.StructField) (separator, keyValSeparator string) {
 	return separator, keyValSeparator
 }
 
+// getNestedSeparators gets the separators for the slice or map values of a map, from the struct field.
+//
+// Parameters:
+//   - sf: The reflect.StructField of the field.
+//
+// Returns:
+//   - The nested separator, defaults to ;.
+//   - The nested key value separator, defaults to =.
+func getNestedSeparators(sf reflect.StructField) (separator, keyValSeparator string) {
+	separator = sf.Tag.Get(NestedSeparatorEnv)
+	if separator == "" {
+		separator = ";"
+	}
+
+	keyValSeparator = sf.Tag.Get(NestedKeyValSeparatorEnv)
+	if keyValSeparator == "" {
+		keyValSeparator = "="
+	}
+
+	return separator, keyValSeparator
+}
+
+// isNestedElem checks if the map element type holds several values of its own, a map or a slice.
+func isNestedElem(t reflect.Type) bool {
+	return t.Kind() == reflect.Map || t.Kind() == reflect.Slice
+}
+
 // hasQuotePrefix checks if the source has a quote prefix.
 // Such as a double quote (") or a single quote(').
 //
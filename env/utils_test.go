@@ -1,6 +1,7 @@
 package env
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -40,6 +41,20 @@ func TestIsSliceOfStructs(t *testing.T) {
 			}{}).Field(0),
 			expected: true,
 		},
+		{
+			name: "Slice of struct pointers",
+			field: reflect.TypeOf(struct {
+				Field []*struct{}
+			}{}).Field(0),
+			expected: true,
+		},
+		{
+			name: "Slice of non-struct pointers",
+			field: reflect.TypeOf(struct {
+				Field []*int
+			}{}).Field(0),
+			expected: false,
+		},
 		{
 			name: "Pointer to non-slice type",
 			field: reflect.TypeOf(struct {
@@ -132,6 +147,71 @@ func TestAsTextUnmarshaler(t *testing.T) {
 	}
 }
 
+// contextSecret reads its value from the context, as a type fetching it from a remote store would.
+type contextSecret string
+
+type contextSecretKey struct{}
+
+func (s *contextSecret) UnmarshalEnv(ctx context.Context, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	secrets, _ := ctx.Value(contextSecretKey{}).(map[string]string)
+	*s = contextSecret(secrets[value])
+	return nil
+}
+
+func TestAsContextUnmarshaler(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        reflect.Value
+		expected bool
+	}{
+		{
+			name:     "Pointer",
+			v:        reflect.ValueOf(new(contextSecret)),
+			expected: true,
+		},
+		{
+			name:     "Addressable",
+			v:        reflect.ValueOf(new(contextSecret)).Elem(),
+			expected: true,
+		},
+		{
+			name:     "Nil pointer is initialised",
+			v:        reflect.New(reflect.TypeOf((*contextSecret)(nil))).Elem(),
+			expected: true,
+		},
+		{
+			name:     "Nil pointer of another type is left nil",
+			v:        reflect.New(reflect.TypeOf((*int)(nil))).Elem(),
+			expected: false,
+		},
+		{
+			name:     "Not addressable",
+			v:        reflect.ValueOf(contextSecret("")),
+			expected: false,
+		},
+		{
+			name:     "Nil value",
+			v:        reflect.ValueOf(nil),
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := asContextUnmarshaler(tc.v)
+			if (result != nil) != tc.expected {
+				t.Errorf("asContextUnmarshaler() = %v, expected %v", result != nil, tc.expected)
+			}
+			if !tc.expected && tc.v.Kind() == reflect.Ptr && !tc.v.IsNil() {
+				t.Errorf("asContextUnmarshaler() initialised a pointer of another type")
+			}
+		})
+	}
+}
+
 func TestInitialisePointer(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,155 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/cloudment/utils-go/internal/validate"
+)
+
+// validateField checks a parsed field against the rules of its envValidate tag.
+//
+// Parameters:
+//
+//   - v: The reflect.Value of the field, once it has been set.
+//   - tags: The FieldTags of the field, holding its rules.
+//   - field: The path to the field, such as "Config.Port".
+//
+// Returns: A *ValidationError for the first rule that is broken, or an error if the tag is invalid.
+func validateField(v reflect.Value, tags FieldTags, field string) error {
+	rules, err := validate.ParseRules(tags.Validate, v.Type())
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s tag: %w", tags.Key, ValidateEnv, err)
+	}
+
+	for _, rule := range rules {
+		if msg := rule.Check(v); msg != "" {
+			return &ValidationError{Key: tags.Key, Field: field, Rule: rule.String(), Msg: msg}
+		}
+	}
+
+	return nil
+}
+
+// fieldGroup is a group of fields sharing a GroupEnv tag within a struct.
+type fieldGroup struct {
+	// name is the name of the group, such as "tls".
+	name string
+	// mode is RequiredTogetherGroup or MutuallyExclusiveGroup.
+	mode string
+	// fields holds the index of each field of the group, in declaration order.
+	fields []int
+	// err is the problem with the tags of the group, such as an unknown mode, so it is only found once.
+	err error
+	// invalid is the key of the field whose tag is invalid, without a prefix.
+	invalid string
+}
+
+// parseGroups collects the groups of the GroupEnv tags of a struct's fields.
+//
+// Parameters:
+//
+//   - fields: The fields of the struct, in declaration order.
+//
+// Returns: The groups in the order they are first declared, or nil if no field has a group.
+func parseGroups(fields []structField) []fieldGroup {
+	var groups []fieldGroup
+
+	for i, field := range fields {
+		tags := field.tags
+		if tags.Group == "" || tags.OwnKey == "" {
+			continue
+		}
+
+		g := slices.IndexFunc(groups, func(g fieldGroup) bool { return g.name == tags.Group })
+		if g == -1 {
+			groups = append(groups, fieldGroup{name: tags.Group})
+			g = len(groups) - 1
+		}
+		group := &groups[g]
+		group.fields = append(group.fields, i)
+
+		switch {
+		case group.err != nil || tags.GroupMode == "" || tags.GroupMode == group.mode:
+		case tags.GroupMode != RequiredTogetherGroup && tags.GroupMode != MutuallyExclusiveGroup:
+			group.err, group.invalid = fmt.Errorf("unknown mode %q", tags.GroupMode), tags.OwnKey
+		case group.mode != "":
+			group.err, group.invalid = fmt.Errorf("mode %s conflicts with %s", tags.GroupMode, group.mode), tags.OwnKey
+		default:
+			group.mode = tags.GroupMode
+		}
+	}
+
+	for i := range groups {
+		if groups[i].mode == "" {
+			groups[i].mode = RequiredTogetherGroup
+		}
+	}
+
+	return groups
+}
+
+// checkGroups checks the variables of each group of a parsed struct are set as its mode requires.
+//
+// Parameters:
+//
+//   - cached: The fields and groups of the struct.
+//   - opts: The options of the struct, holding its prefix and path.
+//
+// Returns: A *GroupError for each group that is broken, joined, or an error if the tags of a group are invalid.
+func checkGroups(cached *structFields, opts *Options) error {
+	var errs []error
+
+	for _, group := range cached.groups {
+		if group.err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid %s tag: %w", opts.Prefix+group.invalid, GroupEnv, group.err))
+			continue
+		}
+
+		keys := make([]string, len(group.fields))
+		var set []string
+		for i, field := range group.fields {
+			tags := cached.fields[field].tags
+			tags.Key = opts.Prefix + tags.OwnKey
+			keys[i] = tags.Key
+			if isVarSet(tags, opts) {
+				set = append(set, tags.Key)
+			}
+		}
+
+		broken := len(set) > 1
+		if group.mode == RequiredTogetherGroup {
+			broken = len(set) > 0 && len(set) < len(keys)
+		}
+		if broken {
+			errs = append(errs, &GroupError{Group: group.name, Mode: group.mode, Field: opts.path, Keys: keys, Set: set})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isVarSet reports whether the variable of a field is set, through its key, an alias or a FileSuffix.
+//
+// An empty variable only counts when the field allows empty values, and defaults never count.
+func isVarSet(tags FieldTags, opts *Options) bool {
+	if val, ok := opts.lookup(tags.Key); val != "" || (ok && (tags.AllowEmpty || opts.AllowEmpty)) {
+		return true
+	}
+
+	if opts.FileSuffix != "" {
+		if path, _ := opts.lookup(tags.Key + opts.FileSuffix); path != "" {
+			return true
+		}
+	}
+
+	for _, alias := range tags.Aliases {
+		if val, _ := opts.lookup(opts.Prefix + alias); val != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,406 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Validate(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST" envValidate:"hostname"`
+	}
+	type Config struct {
+		Port     int           `env:"PORT" envValidate:"min=1,max=65535"`
+		Stage    string        `env:"STAGE" envDefault:"dev" envValidate:"oneof=dev staging prod"`
+		Name     string        `env:"NAME" envValidate:"min=3,regex=^[a-z]{1,10}$"`
+		Timeout  time.Duration `env:"TIMEOUT" envValidate:"min=1s"`
+		Hosts    []string      `env:"HOSTS" envValidate:"max=2,hostname"`
+		Ratio    *float64      `env:"RATIO" envValidate:"max=1"`
+		Database Database      `envPrefix:"DB"`
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *ValidationError
+	}{
+		{
+			name: "Valid",
+			env:  map[string]string{"PORT": "8080", "STAGE": "prod", "NAME": "api", "TIMEOUT": "5s", "HOSTS": "a.internal,b.internal", "RATIO": "0.5", "DB_HOST": "db"},
+		},
+		{
+			name: "Unset values are not validated",
+			env:  map[string]string{},
+		},
+		{
+			name:     "Minimum",
+			env:      map[string]string{"PORT": "0"},
+			expected: &ValidationError{Key: "PORT", Field: "Config.Port", Rule: "min=1", Msg: "0 is less than the minimum of 1"},
+		},
+		{
+			name:     "Maximum",
+			env:      map[string]string{"PORT": "70000"},
+			expected: &ValidationError{Key: "PORT", Field: "Config.Port", Rule: "max=65535", Msg: "70000 is greater than the maximum of 65535"},
+		},
+		{
+			name:     "Default",
+			env:      map[string]string{"STAGE": "qa"},
+			expected: &ValidationError{Key: "STAGE", Field: "Config.Stage", Rule: "oneof=dev staging prod", Msg: `"qa" is not one of dev, staging, prod`},
+		},
+		{
+			name:     "Length",
+			env:      map[string]string{"NAME": "ab"},
+			expected: &ValidationError{Key: "NAME", Field: "Config.Name", Rule: "min=3", Msg: "length 2 is less than the minimum of 3"},
+		},
+		{
+			name:     "Regex",
+			env:      map[string]string{"NAME": "API"},
+			expected: &ValidationError{Key: "NAME", Field: "Config.Name", Rule: "regex=^[a-z]{1,10}$", Msg: `"API" does not match ^[a-z]{1,10}$`},
+		},
+		{
+			name:     "Duration",
+			env:      map[string]string{"TIMEOUT": "500ms"},
+			expected: &ValidationError{Key: "TIMEOUT", Field: "Config.Timeout", Rule: "min=1s", Msg: "500ms is less than the minimum of 1s"},
+		},
+		{
+			name:     "Slice length",
+			env:      map[string]string{"HOSTS": "a,b,c"},
+			expected: &ValidationError{Key: "HOSTS", Field: "Config.Hosts", Rule: "max=2", Msg: "length 3 is greater than the maximum of 2"},
+		},
+		{
+			name:     "Slice element",
+			env:      map[string]string{"HOSTS": "a,-b"},
+			expected: &ValidationError{Key: "HOSTS", Field: "Config.Hosts", Rule: "hostname", Msg: `[1]: "-b" is not a valid hostname`},
+		},
+		{
+			name:     "Pointer",
+			env:      map[string]string{"RATIO": "1.5"},
+			expected: &ValidationError{Key: "RATIO", Field: "Config.Ratio", Rule: "max=1", Msg: "1.5 is greater than the maximum of 1"},
+		},
+		{
+			name:     "Nested",
+			env:      map[string]string{"DB_HOST": "db_1"},
+			expected: &ValidationError{Key: "DB_HOST", Field: "Config.Database.Host", Rule: "hostname", Msg: `"db_1" is not a valid hostname`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, Options{Env: tt.env})
+
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("ParseWithOpts() error = %v, expected nil", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ParseWithOpts() error = %v, expected a *ValidationError", err)
+			}
+			if *validationErr != *tt.expected {
+				t.Errorf("ParseWithOpts() error = %+v, expected %+v", *validationErr, *tt.expected)
+			}
+			if expected := tt.expected.Key + ": " + tt.expected.Msg; err.Error() != expected {
+				t.Errorf("Error() = %q, expected %q", err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestParse_ValidateInvalidTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected string
+	}{
+		{
+			name: "Unknown rule",
+			v: &struct {
+				Port int `env:"PORT" envValidate:"positive"`
+			}{},
+			expected: "PORT: invalid envValidate tag: positive: unknown rule",
+		},
+		{
+			name: "Invalid limit",
+			v: &struct {
+				Port int `env:"PORT" envValidate:"min=one"`
+			}{},
+			expected: `PORT: invalid envValidate tag: min=one: invalid limit "one"`,
+		},
+		{
+			name: "Rule of another type",
+			v: &struct {
+				Port int `env:"PORT" envValidate:"email"`
+			}{},
+			expected: "PORT: invalid envValidate tag: email: does not apply to int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The error is cached, so it is the same when parsing again.
+			for i := 0; i < 2; i++ {
+				err := ParseWithOpts(tt.v, Options{Env: map[string]string{"PORT": "1"}})
+				if err == nil || err.Error() != tt.expected {
+					t.Errorf("ParseWithOpts() error = %v, expected %s", err, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Groups(t *testing.T) {
+	type TLS struct {
+		Cert string `env:"CERT" envGroup:"tls"`
+		Key  string `env:"KEY" envGroup:"tls,requiredTogether"`
+	}
+	type Config struct {
+		TLS      TLS    `envPrefix:"TLS"`
+		Token    string `env:"TOKEN" envGroup:"auth,mutuallyExclusive" envAlias:"API_TOKEN"`
+		Password string `env:"PASSWORD" envGroup:"auth" envDefault:"postgres"`
+		Username string `env:"USERNAME" envGroup:"auth"`
+	}
+
+	cert := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(cert, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{name: "Nothing set", opts: Options{Env: map[string]string{}}},
+		{name: "Set together", opts: Options{Env: map[string]string{"TLS_CERT": "a", "TLS_KEY": "b", "TOKEN": "t"}}},
+		{
+			name:     "Only one set",
+			opts:     Options{Env: map[string]string{"TLS_KEY": "b"}},
+			expected: "envGroup tls: TLS_CERT and TLS_KEY must be set together, only TLS_KEY is set",
+		},
+		{
+			name:     "Empty is unset",
+			opts:     Options{Env: map[string]string{"TLS_CERT": "a", "TLS_KEY": ""}},
+			expected: "envGroup tls: TLS_CERT and TLS_KEY must be set together, only TLS_CERT is set",
+		},
+		{name: "Empty allowed", opts: Options{AllowEmpty: true, Env: map[string]string{"TLS_CERT": "a", "TLS_KEY": ""}}},
+		{
+			name:     "File suffix",
+			opts:     Options{FileSuffix: "_FILE", Env: map[string]string{"TLS_CERT_FILE": cert}},
+			expected: "envGroup tls: TLS_CERT and TLS_KEY must be set together, only TLS_CERT is set",
+		},
+		{
+			name:     "Exclusive",
+			opts:     Options{Env: map[string]string{"TOKEN": "t", "PASSWORD": "p", "USERNAME": "u"}},
+			expected: "envGroup auth: only one of TOKEN, PASSWORD or USERNAME may be set, TOKEN, PASSWORD and USERNAME are set",
+		},
+		{
+			name:     "Alias",
+			opts:     Options{Env: map[string]string{"API_TOKEN": "t", "PASSWORD": "p"}},
+			expected: "envGroup auth: only one of TOKEN, PASSWORD or USERNAME may be set, TOKEN and PASSWORD are set",
+		},
+		{
+			name:     "Prefix",
+			opts:     Options{Prefix: "APP", Env: map[string]string{"APP_TLS_CERT": "a"}},
+			expected: "envGroup tls: APP_TLS_CERT and APP_TLS_KEY must be set together, only APP_TLS_CERT is set",
+		},
+		{
+			name: "Aggregated",
+			opts: Options{AggregateErrors: true, Env: map[string]string{"TLS_CERT": "a", "TOKEN": "t", "USERNAME": "u"}},
+			expected: "TLS: envGroup tls: TLS_CERT and TLS_KEY must be set together, only TLS_CERT is set\n" +
+				"envGroup auth: only one of TOKEN, PASSWORD or USERNAME may be set, TOKEN and USERNAME are set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := ParseWithOpts(&cfg, tt.opts)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("ParseWithOpts() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("ParseWithOpts() error = %v, expected %s", err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("GroupError", func(t *testing.T) {
+		err := ParseWithOpts(&Config{}, Options{Env: map[string]string{"TLS_CERT": "a"}})
+
+		var groupErr *GroupError
+		if !errors.As(err, &groupErr) {
+			t.Fatalf("ParseWithOpts() error = %v, expected a *GroupError", err)
+		}
+		expected := &GroupError{
+			Group: "tls",
+			Mode:  RequiredTogetherGroup,
+			Field: "Config.TLS",
+			Keys:  []string{"TLS_CERT", "TLS_KEY"},
+			Set:   []string{"TLS_CERT"},
+		}
+		if !reflect.DeepEqual(groupErr, expected) {
+			t.Errorf("GroupError = %+v, expected %+v", groupErr, expected)
+		}
+	})
+}
+
+func TestParse_GroupsInvalidTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected string
+	}{
+		{
+			name: "Unknown mode",
+			v: &struct {
+				A string `env:"A" envGroup:"g,oneOf"`
+				B string `env:"B" envGroup:"g"`
+			}{},
+			expected: `A: invalid envGroup tag: unknown mode "oneOf"`,
+		},
+		{
+			name: "Conflicting modes",
+			v: &struct {
+				A string `env:"A" envGroup:"g,requiredTogether"`
+				B string `env:"B" envGroup:"g,mutuallyExclusive"`
+			}{},
+			expected: "B: invalid envGroup tag: mode mutuallyExclusive conflicts with requiredTogether",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseWithOpts(tt.v, Options{Env: map[string]string{}})
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("ParseWithOpts() error = %v, expected %s", err, tt.expected)
+			}
+		})
+	}
+}
+
+// namespaceErrors is an error holding the path of each invalid field, as returned by go-playground/validator.
+type namespaceErrors []string
+
+func (e namespaceErrors) Error() string {
+	return "invalid fields: " + strings.Join(e, ", ")
+}
+
+func TestParseWithOpts_Validator(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type Config struct {
+		Database Database `envPrefix:"DB"`
+	}
+
+	// The validator checks the struct as parsed, reporting the path of each invalid field.
+	validator := func(v interface{}) error {
+		cfg := v.(*Config)
+
+		var invalid namespaceErrors
+		if cfg.Database.Host == "" {
+			invalid = append(invalid, "Config.Database.Host")
+		}
+		if cfg.Database.Port == 0 {
+			invalid = append(invalid, "Config.Database.Port")
+		}
+		if len(invalid) > 0 {
+			return invalid
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected namespaceErrors
+		wantErr  bool
+	}{
+		{name: "Valid", env: map[string]string{"DB_HOST": "db", "DB_PORT": "5432"}},
+		{name: "Invalid", env: map[string]string{"DB_HOST": "db"}, expected: namespaceErrors{"Config.Database.Port"}},
+		{name: "Every field", env: map[string]string{}, expected: namespaceErrors{"Config.Database.Host", "Config.Database.Port"}},
+		{name: "Parse error", env: map[string]string{"DB_PORT": "many"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			opts := Options{Env: tt.env, Validator: func(v interface{}) error {
+				called = true
+				return validator(v)
+			}}
+
+			var cfg Config
+			err := ParseWithOpts(&cfg, opts)
+
+			// The validator is only called once the struct is parsed.
+			if tt.wantErr {
+				if err == nil || called {
+					t.Errorf("ParseWithOpts() error = %v, called = %v, expected a parse error without validating", err, called)
+				}
+				return
+			}
+
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("ParseWithOpts() error = %v, expected nil", err)
+				}
+				return
+			}
+
+			var invalid namespaceErrors
+			if !errors.As(err, &invalid) || !reflect.DeepEqual(invalid, tt.expected) {
+				t.Fatalf("ParseWithOpts() error = %v, expected the paths %v", err, tt.expected)
+			}
+			if expected := "validation failed: " + tt.expected.Error(); err.Error() != expected {
+				t.Errorf("Error() = %q, expected %q", err.Error(), expected)
+			}
+		})
+	}
+}
+
+func BenchmarkParse_Validate(b *testing.B) {
+	type Config struct {
+		Port  int    `env:"PORT" envValidate:"min=1,max=65535"`
+		Stage string `env:"STAGE" envValidate:"oneof=dev staging prod"`
+		Name  string `env:"NAME" envValidate:"regex=^[a-z]+$"`
+	}
+	opts := Options{Env: map[string]string{"PORT": "8080", "STAGE": "prod", "NAME": "api"}}
+
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		if err := ParseWithOpts(&cfg, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse_Groups(b *testing.B) {
+	type Config struct {
+		Cert  string `env:"TLS_CERT" envGroup:"tls,requiredTogether"`
+		Key   string `env:"TLS_KEY" envGroup:"tls"`
+		Token string `env:"TOKEN" envGroup:"auth,mutuallyExclusive"`
+		User  string `env:"USER" envGroup:"auth"`
+	}
+	opts := Options{Env: map[string]string{"TLS_CERT": "a", "TLS_KEY": "b", "TOKEN": "t"}}
+
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		if err := ParseWithOpts(&cfg, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// WatchInterval is how often Watch checks the files for changes.
+var WatchInterval = time.Second
+
+// Watch reloads a struct from .env files whenever they change, so a service can pick up new config without a restart.
+//
+// The files are polled every WatchInterval and compared to their previous contents, so it works on any file system,
+// including Kubernetes ConfigMap volumes which are replaced through symlinks.
+// Each change is parsed into a new struct of the same type, as ParseFromFilesIntoStruct would.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - v: A pointer to a struct containing `env` tags.
+//   - filenames: The filenames to load the environment variables from, later files take priority.
+//   - onChange: Called with pointers to the old and new structs, before v is updated.
+//
+// Example:
+//
+//	var current atomic.Pointer[Config]
+//
+//	err := env.Watch(ctx, &Config{}, []string{".env"}, func(old, new interface{}) error {
+//		current.Store(new.(*Config))
+//		return nil
+//	})
+//
+// Returns: The error of the context once it is done, the error of onChange, or an error if the files could not be
+// read or parsed before watching starts.
+//
+// Note: Watch blocks, so it is usually run within a goroutine. v is parsed before watching starts and is then updated
+// by that goroutine, so other goroutines should use the structs passed to onChange rather than reading v.
+// A file that is missing is skipped until it exists again, as editors often replace files rather than writing them.
+// A change that cannot be read or parsed, such as a half-saved file, is ignored and v keeps its last good value,
+// use WatchWithErrors to report it.
+func Watch(ctx context.Context, v interface{}, filenames []string, onChange func(old, new interface{}) error) error {
+	return WatchWithErrors(ctx, v, filenames, onChange, nil)
+}
+
+// WatchWithErrors reloads a struct from .env files whenever they change, as Watch does,
+// reporting each change that cannot be read or parsed to onError.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - v: A pointer to a struct containing `env` tags.
+//   - filenames: The filenames to load the environment variables from, later files take priority.
+//   - onChange: Called with pointers to the old and new structs, before v is updated.
+//   - onError: Called with each error once watching has started, such as a *SyntaxError, or nil to ignore them.
+//
+// Example:
+//
+//	err := env.WatchWithErrors(ctx, &Config{}, []string{".env"}, onChange, func(err error) {
+//		slog.Warn("keeping the last config", "error", err)
+//	})
+//
+// Returns: The error of the context once it is done, the error of onChange, or an error if the files could not be
+// read or parsed before watching starts.
+//
+// Note: Watching continues after an error, with v keeping its last good value. An error is reported once,
+// rather than on every poll, until the files change again or can be read again.
+func WatchWithErrors(ctx context.Context, v interface{}, filenames []string, onChange func(old, new interface{}) error,
+	onError func(err error)) error {
+	return WatchWithOpts(ctx, v, filenames, Options{}, onChange, onError)
+}
+
+// WatchWithOpts reloads a struct from .env files whenever they change, as WatchWithErrors does,
+// parsing each change with the options.
+//
+// Parameters:
+//   - ctx: Watching stops once the context is done.
+//   - v: A pointer to a struct containing `env` tags.
+//   - filenames: The filenames to load the environment variables from, later files take priority.
+//   - opts: The options to parse with. The files are merged after Envs, so they take priority over Env and Envs.
+//   - onChange: Called with pointers to the old and new structs, before v is updated.
+//   - onError: Called with each error once watching has started, such as a *SyntaxError, or nil to ignore them.
+//
+// Example:
+//
+//	flags, _ := env.BindFlags(flag.CommandLine, &cfg)
+//	flag.Parse()
+//
+//	// -port 9000 is kept on every reload, rather than being replaced by PORT within .env.
+//	err := env.WatchWithOpts(ctx, &cfg, []string{".env"}, env.Options{BoundFlags: flags}, onChange, nil)
+//
+// Returns: The error of the context once it is done, the error of onChange, or an error if the files could not be
+// read or parsed before watching starts.
+func WatchWithOpts(ctx context.Context, v interface{}, filenames []string, opts Options,
+	onChange func(old, new interface{}) error, onError func(err error)) error {
+	if v == nil || reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return errors.New("expected a pointer to a valid struct")
+	}
+
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+
+	contents, err := readWatchedFiles(filenames, false)
+	if err != nil {
+		return err
+	}
+
+	if err = parseWatchedFiles(v, filenames, contents, opts); err != nil {
+		return err
+	}
+
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+
+	return pollWatchedFiles(ctx, filenames, contents, report, func(latest [][]byte) error {
+		// The old struct is a copy, so onChange can keep it while v is updated.
+		old := reflect.New(reflect.TypeOf(v).Elem())
+		old.Elem().Set(reflect.ValueOf(v).Elem())
+
+		updated := reflect.New(old.Elem().Type())
+		if err := parseWatchedFiles(updated.Interface(), filenames, latest, opts); err != nil {
+			report(err)
+			return nil
+		}
+
+		if err := onChange(old.Interface(), updated.Interface()); err != nil {
+			return err
+		}
+
+		reflect.ValueOf(v).Elem().Set(updated.Elem())
+		return nil
+	})
+}
+
+// pollWatchedFiles reads the files every WatchInterval, calling changed with their contents whenever they change.
+//
+// Parameters:
+//   - ctx: Polling stops once the context is done.
+//   - filenames: The filenames to read.
+//   - contents: The contents the files were first read with.
+//   - report: Called with an error reading the files, once until they can be read again.
+//   - changed: Called with the contents of the files after each change, its error stops polling.
+//
+// Returns: The error of the context once it is done, or the error of changed.
+func pollWatchedFiles(ctx context.Context, filenames []string, contents [][]byte, report func(err error),
+	changed func(latest [][]byte) error) error {
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	// contents are those last compared, including those that failed to parse, so each failure is reported once.
+	readFailed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		latest, err := readWatchedFiles(filenames, true)
+		if err != nil {
+			if !readFailed {
+				report(err)
+			}
+			readFailed = true
+			continue
+		}
+		readFailed = false
+
+		if latest == nil || !watchedFilesChanged(contents, latest) {
+			continue
+		}
+		contents = latest
+
+		if err = changed(latest); err != nil {
+			return err
+		}
+	}
+}
+
+// readWatchedFiles reads the contents of each file.
+//
+// Parameters:
+//   - filenames: The filenames to read.
+//   - skipMissing: Whether a missing file returns nil rather than an error.
+//
+// Returns: The contents in the same order, or an error if a file could not be read.
+func readWatchedFiles(filenames []string, skipMissing bool) ([][]byte, error) {
+	contents := make([][]byte, len(filenames))
+	for i, filename := range filenames {
+		src, err := os.ReadFile(filename)
+		if skipMissing && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		contents[i] = src
+	}
+	return contents, nil
+}
+
+// watchedFilesChanged reports whether the contents of any file differ.
+func watchedFilesChanged(old, latest [][]byte) bool {
+	for i := range latest {
+		if !bytes.Equal(old[i], latest[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWatchedFiles parses the contents of the files into a struct, as ParseFromFilesIntoStruct would.
+//
+// The contents that were compared are parsed, rather than reading the files again, so no change is missed.
+//
+// Parameters:
+//   - v: A pointer to a struct containing `env` tags.
+//   - filenames: The filenames, used within errors.
+//   - contents: The contents of each file.
+//   - opts: The options to parse with, whose Envs are followed by the files.
+//
+// Returns: An error if the parsing fails.
+func parseWatchedFiles(v interface{}, filenames []string, contents [][]byte, opts Options) error {
+	envMaps, err := parseWatchedEnvs(filenames, contents)
+	if err != nil {
+		return err
+	}
+
+	// The caller's Envs are clipped, so appending never writes into their backing array.
+	opts.Envs = append(slices.Clip(opts.Envs), envMaps...)
+	return ParseWithOpts(v, opts)
+}
+
+// parseWatchedEnvs parses the contents of each file into its environment variables.
+//
+// Parameters:
+//   - filenames: The filenames, used within errors.
+//   - contents: The contents of each file.
+//
+// Returns: The environment variables of each file in the same order, or an error if a file could not be parsed.
+func parseWatchedEnvs(filenames []string, contents [][]byte) ([]map[string]string, error) {
+	envMaps := make([]map[string]string, len(contents))
+	for i, src := range contents {
+		tEnvMap, err := parseEnvFileBytes(src)
+		if err != nil {
+			return nil, withFileName(err, filenames[i])
+		}
+		envMaps[i] = tEnvMap
+	}
+	return envMaps, nil
+}
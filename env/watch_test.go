@@ -0,0 +1,312 @@
+package env
+
+import (
+	"context"
+	"errors"
+	goflag "flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type watchConfig struct {
+	Port int    `env:"PORT"`
+	Host string `env:"HOST"`
+}
+
+// startWatch runs WatchWithErrors with a short interval, returning a channel for its error once it has read the files.
+func startWatch(t *testing.T, ctx context.Context, v interface{}, filenames []string, onChange func(old, new interface{}) error,
+	onError func(err error)) <-chan error {
+	t.Helper()
+
+	interval := WatchInterval
+	WatchInterval = 5 * time.Millisecond
+	t.Cleanup(func() { WatchInterval = interval })
+
+	done := make(chan error, 1)
+	go func() { done <- WatchWithErrors(ctx, v, filenames, onChange, onError) }()
+
+	// Changes written before Watch reads the files would not be seen as changes.
+	time.Sleep(50 * time.Millisecond)
+	return done
+}
+
+// waitFor returns the next value from the channel, failing the test if it takes too long.
+func waitFor[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Watch")
+	}
+	var zero T
+	return zero
+}
+
+func TestWatch(t *testing.T) {
+	t.Run("Reload", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080\nHOST=localhost", "HOST=example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		type change struct{ old, new *watchConfig }
+		changes := make(chan change, 1)
+
+		cfg := &watchConfig{}
+		done := startWatch(t, ctx, cfg, paths, func(old, new interface{}) error {
+			changes <- change{old.(*watchConfig), new.(*watchConfig)}
+			return nil
+		}, nil)
+
+		if err := os.WriteFile(paths[0], []byte("PORT=9090\nHOST=localhost"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		got := waitFor(t, changes)
+		if *got.old != (watchConfig{Port: 8080, Host: "example.com"}) {
+			t.Errorf("Watch() old = %+v, expected the first config", *got.old)
+		}
+		if *got.new != (watchConfig{Port: 9090, Host: "example.com"}) {
+			t.Errorf("Watch() new = %+v, expected the changed config", *got.new)
+		}
+
+		cancel()
+		if err := waitFor(t, done); !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch() error = %v, expected %v", err, context.Canceled)
+		}
+		if *cfg != *got.new {
+			t.Errorf("Watch() v = %+v, expected %+v", *cfg, *got.new)
+		}
+	})
+
+	t.Run("Bound flags", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080\nHOST=localhost")
+
+		cfg := &watchConfig{}
+		fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
+		binding, err := BindFlags(fs, cfg)
+		if err != nil {
+			t.Fatalf("BindFlags() error = %v", err)
+		}
+		if err = fs.Parse([]string{"-port", "9000"}); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+
+		interval := WatchInterval
+		WatchInterval = 5 * time.Millisecond
+		t.Cleanup(func() { WatchInterval = interval })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan *watchConfig, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- WatchWithOpts(ctx, cfg, paths, Options{BoundFlags: binding}, func(old, new interface{}) error {
+				changes <- new.(*watchConfig)
+				return nil
+			}, nil)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		if err = os.WriteFile(paths[0], []byte("PORT=8081\nHOST=example.com"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		// The flag keeps taking priority over the file on each reload.
+		if got := waitFor(t, changes); *got != (watchConfig{Port: 9000, Host: "example.com"}) {
+			t.Errorf("WatchWithOpts() new = %+v, expected the flag to be kept", *got)
+		}
+
+		cancel()
+		waitFor(t, done)
+	})
+
+	t.Run("Missing file is skipped", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan interface{}, 1)
+		done := startWatch(t, ctx, &watchConfig{}, paths, func(old, new interface{}) error {
+			changes <- new
+			return nil
+		}, nil)
+
+		// Replaced as an editor would, with the file missing in between.
+		if err := os.Remove(paths[0]); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if err := os.WriteFile(paths[0], []byte("PORT=9090"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := waitFor(t, changes).(*watchConfig); got.Port != 9090 {
+			t.Errorf("Watch() new = %+v, expected Port 9090", *got)
+		}
+
+		cancel()
+		waitFor(t, done)
+	})
+
+	t.Run("onChange error", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080")
+		expected := errors.New("rejected")
+
+		cfg := &watchConfig{}
+		done := startWatch(t, context.Background(), cfg, paths, func(old, new interface{}) error {
+			return expected
+		}, nil)
+
+		if err := os.WriteFile(paths[0], []byte("PORT=9090"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := waitFor(t, done); !errors.Is(err, expected) {
+			t.Errorf("Watch() error = %v, expected %v", err, expected)
+		}
+		if cfg.Port != 8080 {
+			t.Errorf("Watch() v.Port = %d, expected it to be unchanged", cfg.Port)
+		}
+	})
+
+	t.Run("Invalid change", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan *watchConfig, 1)
+		errs := make(chan error, 4)
+		cfg := &watchConfig{}
+		done := startWatch(t, ctx, cfg, paths, func(old, new interface{}) error {
+			changes <- new.(*watchConfig)
+			return nil
+		}, func(err error) {
+			errs <- err
+		})
+
+		// A half-saved file is reported, and watching continues with the last good config.
+		if err := os.WriteFile(paths[0], []byte("PORT=abc"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var parseErr *ParseError
+		if err := waitFor(t, errs); !errors.As(err, &parseErr) {
+			t.Errorf("WatchWithErrors() error = %v, expected a *ParseError", err)
+		}
+
+		if err := os.WriteFile(paths[0], []byte("PORT=9090"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := waitFor(t, changes); got.Port != 9090 {
+			t.Errorf("WatchWithErrors() new = %+v, expected Port 9090", *got)
+		}
+
+		cancel()
+		waitFor(t, done)
+		if len(errs) != 0 {
+			t.Errorf("WatchWithErrors() reported %d more errors, expected the invalid file to be reported once", len(errs))
+		}
+	})
+
+	t.Run("Unreadable file", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := make(chan *watchConfig, 1)
+		errs := make(chan error, 4)
+		done := startWatch(t, ctx, &watchConfig{}, paths, func(old, new interface{}) error {
+			changes <- new.(*watchConfig)
+			return nil
+		}, func(err error) {
+			errs <- err
+		})
+
+		// A directory cannot be read as a file.
+		if err := os.Remove(paths[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(paths[0], 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := waitFor(t, errs); err == nil {
+			t.Error("WatchWithErrors() expected an error")
+		}
+
+		if err := os.Remove(paths[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(paths[0], []byte("PORT=9090"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := waitFor(t, changes); got.Port != 9090 {
+			t.Errorf("WatchWithErrors() new = %+v, expected Port 9090", *got)
+		}
+
+		cancel()
+		waitFor(t, done)
+	})
+
+	t.Run("Errors ignored by Watch", func(t *testing.T) {
+		paths := writeEnvFiles(t, "PORT=8080")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		interval := WatchInterval
+		WatchInterval = 5 * time.Millisecond
+		t.Cleanup(func() { WatchInterval = interval })
+
+		done := make(chan error, 1)
+		go func() { done <- Watch(ctx, &watchConfig{}, paths, func(old, new interface{}) error { return nil }) }()
+		time.Sleep(50 * time.Millisecond)
+
+		if err := os.WriteFile(paths[0], []byte("PORT=abc"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		cancel()
+		if err := waitFor(t, done); !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch() error = %v, expected watching to continue until %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("Invalid start", func(t *testing.T) {
+		ctx := context.Background()
+		onChange := func(old, new interface{}) error { return nil }
+
+		if err := Watch(ctx, watchConfig{}, nil, onChange); err == nil {
+			t.Error("Watch() expected an error for a non-pointer")
+		}
+		if err := Watch(ctx, &watchConfig{}, []string{filepath.Join(t.TempDir(), ".env")}, onChange); err == nil {
+			t.Error("Watch() expected an error for a missing file")
+		}
+		if err := Watch(ctx, &watchConfig{}, nil, onChange); err == nil {
+			t.Error("Watch() expected an error for a missing .env")
+		}
+
+		var syntaxErr *SyntaxError
+		paths := writeEnvFiles(t, "PORT=8080\nkey=value")
+		if err := Watch(ctx, &watchConfig{}, paths, onChange); !errors.As(err, &syntaxErr) || syntaxErr.File != paths[0] {
+			t.Errorf("Watch() error = %v, expected a *SyntaxError within %s", err, paths[0])
+		}
+	})
+}
+
+func BenchmarkWatchedFilesChanged(b *testing.B) {
+	old := [][]byte{[]byte("PORT=8080\nHOST=localhost"), []byte("HOST=example.com")}
+	latest := [][]byte{[]byte("PORT=8080\nHOST=localhost"), []byte("HOST=example.com")}
+
+	for i := 0; i < b.N; i++ {
+		watchedFilesChanged(old, latest)
+	}
+}
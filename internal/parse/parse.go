@@ -0,0 +1,70 @@
+// Package parse parses string values into types that are not parsed by their kind, such as time.Duration.
+//
+// It is internal so that both the env and utils packages can share it.
+package parse
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types is a map of `reflect.Type` to the function parsing a string value into it.
+// Each returns a non-pointer value of the type.
+var Types = map[reflect.Type]func(v string) (interface{}, error){
+	reflect.TypeOf(time.Nanosecond): func(v string) (interface{}, error) {
+		d, err := time.ParseDuration(v)
+		// Days are not always 24 hours long
+		// See: https://github.com/golang/go/issues/11473
+		// See: https://bigthink.com/starts-with-a-bang/day-isnt-24-hours/
+		if err != nil && strings.Contains(err.Error(), "unknown unit \"d\"") {
+			err = fmt.Errorf("use '24h' instead of '1d' for 24 hours: %w", err)
+		}
+		return d, err
+	},
+	reflect.TypeOf(time.Location{}): func(v string) (interface{}, error) {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse Location: %w", err)
+		}
+		return *loc, nil
+	},
+	reflect.TypeOf(url.URL{}): func(v string) (interface{}, error) {
+		u, err := url.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL: %w", err)
+		}
+		// Values without a scheme are parsed as a path rather than failing,
+		// and "localhost:8080" is parsed as the scheme "localhost", so both are rejected.
+		if _, err = strconv.Atoi(u.Opaque); u.Scheme == "" || err == nil {
+			return nil, fmt.Errorf("invalid URL %q: missing scheme, such as https://", v)
+		}
+		return *u, nil
+	},
+	reflect.TypeOf(net.IP{}): func(v string) (interface{}, error) {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", v)
+		}
+		return ip, nil
+	},
+	reflect.TypeOf(net.IPNet{}): func(v string) (interface{}, error) {
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q, expected a network such as 10.0.0.0/8", v)
+		}
+		return *ipNet, nil
+	},
+	// Host names are resolved, such as "localhost:8080".
+	reflect.TypeOf(net.TCPAddr{}): func(v string) (interface{}, error) {
+		addr, err := net.ResolveTCPAddr("tcp", v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TCP address %q, expected host:port: %w", v, err)
+		}
+		return *addr, nil
+	},
+}
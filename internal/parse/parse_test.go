@@ -0,0 +1,62 @@
+package parse
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		input    string
+		expected interface{}
+		err      string
+	}{
+		{name: "Duration", typ: reflect.TypeOf(time.Nanosecond), input: "1m30s", expected: 90 * time.Second},
+		{name: "Duration in days", typ: reflect.TypeOf(time.Nanosecond), input: "1d", err: "use '24h' instead of '1d'"},
+		{name: "Location", typ: reflect.TypeOf(time.Location{}), input: "UTC", expected: *time.UTC},
+		{name: "Invalid location", typ: reflect.TypeOf(time.Location{}), input: "Mars/Olympus", err: "unable to parse Location"},
+		{name: "URL", typ: reflect.TypeOf(url.URL{}), input: "https://example.com/a", expected: url.URL{Scheme: "https", Host: "example.com", Path: "/a"}},
+		{name: "Invalid URL", typ: reflect.TypeOf(url.URL{}), input: "%zz", err: "invalid URL"},
+		{name: "URL without a scheme", typ: reflect.TypeOf(url.URL{}), input: "localhost:8080", err: "missing scheme"},
+		{name: "IP", typ: reflect.TypeOf(net.IP{}), input: "10.0.0.1", expected: net.ParseIP("10.0.0.1")},
+		{name: "Invalid IP", typ: reflect.TypeOf(net.IP{}), input: "10.0.0", err: "invalid IP address"},
+		{name: "IPNet", typ: reflect.TypeOf(net.IPNet{}), input: "10.0.0.0/8", expected: net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}},
+		{name: "Invalid IPNet", typ: reflect.TypeOf(net.IPNet{}), input: "10.0.0.0", err: "invalid CIDR"},
+		{name: "TCPAddr", typ: reflect.TypeOf(net.TCPAddr{}), input: "127.0.0.1:8080", expected: net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}},
+		{name: "Invalid TCPAddr", typ: reflect.TypeOf(net.TCPAddr{}), input: "127.0.0.1", err: "invalid TCP address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Types[tt.typ](tt.input)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Types[%v]() error = %v, expected %s", tt.typ, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Types[%v]() error = %v", tt.typ, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Types[%v]() = %v, expected %v", tt.typ, got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkTypes(b *testing.B) {
+	parse := Types[reflect.TypeOf(time.Nanosecond)]
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parse("1h30m"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package strcase converts identifiers between naming conventions, such as "UserID" to "user_id", "USER_ID",
+// "user-id", "userID" or "UserID".
+//
+// Identifiers are split into words by Words, and common acronyms such as ID and URL are kept whole when converting to
+// camel or pascal case. The env package derives variable names from field names with ToScreamingSnake, and the case
+// functions of the utils package wrap the others.
+package strcase
+
+import (
+	"strings"
+	"unicode"
+)
+
+// acronyms are upper-cased as a whole when converting to camel or pascal case, such as ID rather than Id.
+var acronyms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"JWT": true, "QPS": true, "RAM": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true,
+	"URI": true, "URL": true, "UTF8": true, "UUID": true, "VM": true, "XML": true, "XSRF": true,
+	"XSS": true,
+}
+
+// Words splits an identifier into its words.
+//
+// Words are separated by any non-alphanumeric character, a lower-case letter or digit followed by an upper-case
+// letter, or the last letter of an acronym followed by a lower-case letter, such as "HTTPServer" -> HTTP, Server.
+//
+// Parameters:
+//   - s: The identifier to split.
+//
+// Returns: The words, keeping their original case.
+func Words(s string) []string {
+	runes := []rune(s)
+	words := make([]string, 0, 4)
+
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start != -1 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+
+		if start == -1 {
+			start = i
+			continue
+		}
+
+		prev := runes[i-1]
+		boundary := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		// The end of an acronym, such as the S in "HTTPServer".
+		if !boundary && unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			boundary = true
+		}
+
+		if boundary {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start != -1 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// ToSnakeCase converts an identifier to snake_case, such as "UserID" -> "user_id".
+func ToSnakeCase(s string) string {
+	return join(Words(s), "_", strings.ToLower)
+}
+
+// ToKebabCase converts an identifier to kebab-case, such as "UserID" -> "user-id".
+func ToKebabCase(s string) string {
+	return join(Words(s), "-", strings.ToLower)
+}
+
+// ToScreamingSnake converts an identifier to SCREAMING_SNAKE_CASE, such as "UserID" -> "USER_ID".
+func ToScreamingSnake(s string) string {
+	return join(Words(s), "_", strings.ToUpper)
+}
+
+// ToCamelCase converts an identifier to camelCase, such as "user_id" -> "userID".
+func ToCamelCase(s string) string {
+	words := Words(s)
+	if len(words) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	b.WriteString(strings.ToLower(words[0]))
+	for _, w := range words[1:] {
+		b.WriteString(capitalise(w))
+	}
+
+	return b.String()
+}
+
+// ToPascalCase converts an identifier to PascalCase, such as "user_id" -> "UserID".
+func ToPascalCase(s string) string {
+	return join(Words(s), "", capitalise)
+}
+
+// join transforms each word and joins them with the separator.
+func join(words []string, sep string, transform func(string) string) string {
+	for i, w := range words {
+		words[i] = transform(w)
+	}
+	return strings.Join(words, sep)
+}
+
+// capitalise upper-cases a known acronym, otherwise it upper-cases the first letter and lower-cases the rest.
+func capitalise(w string) string {
+	upper := strings.ToUpper(w)
+	if acronyms[upper] {
+		return upper
+	}
+
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
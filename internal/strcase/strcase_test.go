@@ -0,0 +1,75 @@
+package strcase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"simple", []string{"simple"}},
+		{"userID", []string{"user", "ID"}},
+		{"HTTPServerURL", []string{"HTTP", "Server", "URL"}},
+		{"snake_case_value", []string{"snake", "case", "value"}},
+		{"kebab-case--value", []string{"kebab", "case", "value"}},
+		{"Base64Encode", []string{"Base64", "Encode"}},
+		{"  spaced  out ", []string{"spaced", "out"}},
+		{"ÉcoleNormale", []string{"École", "Normale"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Words(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Words(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		input     string
+		snake     string
+		kebab     string
+		screaming string
+		camel     string
+		pascal    string
+	}{
+		{"userID", "user_id", "user-id", "USER_ID", "userID", "UserID"},
+		{"HTTPServerURL", "http_server_url", "http-server-url", "HTTP_SERVER_URL", "httpServerURL", "HTTPServerURL"},
+		{"database_url", "database_url", "database-url", "DATABASE_URL", "databaseURL", "DatabaseURL"},
+		{"MaxConns", "max_conns", "max-conns", "MAX_CONNS", "maxConns", "MaxConns"},
+		{"api-key", "api_key", "api-key", "API_KEY", "apiKey", "APIKey"},
+		{"", "", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ToSnakeCase(tt.input); got != tt.snake {
+				t.Errorf("ToSnakeCase(%q) = %q, expected %q", tt.input, got, tt.snake)
+			}
+			if got := ToKebabCase(tt.input); got != tt.kebab {
+				t.Errorf("ToKebabCase(%q) = %q, expected %q", tt.input, got, tt.kebab)
+			}
+			if got := ToScreamingSnake(tt.input); got != tt.screaming {
+				t.Errorf("ToScreamingSnake(%q) = %q, expected %q", tt.input, got, tt.screaming)
+			}
+			if got := ToCamelCase(tt.input); got != tt.camel {
+				t.Errorf("ToCamelCase(%q) = %q, expected %q", tt.input, got, tt.camel)
+			}
+			if got := ToPascalCase(tt.input); got != tt.pascal {
+				t.Errorf("ToPascalCase(%q) = %q, expected %q", tt.input, got, tt.pascal)
+			}
+		})
+	}
+}
+
+func BenchmarkToScreamingSnake(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ToScreamingSnake("HTTPServerURL")
+	}
+}
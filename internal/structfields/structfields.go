@@ -0,0 +1,33 @@
+// Package structfields caches the fields of struct types.
+//
+// reflect.Type.Field copies the field on every call, so the env package, BindRequest, GormSearchQuery and WalkStruct
+// read the fields of each type from one shared cache instead.
+package structfields
+
+import (
+	"reflect"
+	"sync"
+)
+
+// fieldCache caches the fields of each struct type.
+var fieldCache sync.Map // map[reflect.Type][]reflect.StructField
+
+// Fields returns the fields of a struct type, cached after the first call.
+//
+// Parameters:
+//   - t: The struct type.
+//
+// Returns: The fields in declaration order, the slice must not be modified.
+func Fields(t reflect.Type) []reflect.StructField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]reflect.StructField)
+	}
+
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i)
+	}
+
+	actual, _ := fieldCache.LoadOrStore(t, fields)
+	return actual.([]reflect.StructField)
+}
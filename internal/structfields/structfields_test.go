@@ -0,0 +1,22 @@
+package structfields
+
+import (
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	Host string
+	Port int
+}
+
+func TestFieldsCached(t *testing.T) {
+	first := Fields(reflect.TypeOf(address{}))
+	second := Fields(reflect.TypeOf(address{}))
+	if &first[0] != &second[0] {
+		t.Errorf("Fields() returned a new slice, expected the cached slice")
+	}
+	if len(first) != 2 || first[1].Name != "Port" {
+		t.Errorf("Fields() = %v, expected Host and Port", first)
+	}
+}
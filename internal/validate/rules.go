@@ -0,0 +1,305 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// durationType is the type of time.Duration, whose limits are written as durations such as "1s".
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// rulesKey is the key of rulesCache, as the same rules are parsed differently for each type.
+type rulesKey struct {
+	tag string
+	typ reflect.Type
+}
+
+// rulesEntry is the result of parseRules, including its error so an invalid tag is only parsed once.
+type rulesEntry struct {
+	rules []Rule
+	err   error
+}
+
+// rulesCache holds the parsed rules of each tag and type, shared by the env and utils packages.
+var rulesCache sync.Map
+
+// Rule is a single rule of a validation tag, such as "min=1", "oneof=dev prod" or "email".
+type Rule struct {
+	// Name is the name of the rule, such as "min".
+	Name string
+	// Arg is the argument of the rule, such as "1". Empty for formats.
+	Arg string
+
+	// each is true when the rule applies to each element of a slice or array, rather than to its length.
+	each bool
+	// check returns why the value breaks the rule, or "" if it is valid.
+	check func(v reflect.Value) string
+}
+
+// String returns the rule as written within the tag, such as "min=1".
+func (r Rule) String() string {
+	if r.Arg == "" {
+		return r.Name
+	}
+	return r.Name + "=" + r.Arg
+}
+
+// Check validates a value against the rule.
+//
+// Pointers are followed, and a nil pointer is always valid.
+//
+// Parameters:
+//   - v: The value, of the type given to ParseRules.
+//
+// Returns: Why the value is invalid, such as "0 is less than the minimum of 1", or "" if it is valid.
+func (r Rule) Check(v reflect.Value) string {
+	if v = indirect(v); !v.IsValid() {
+		return ""
+	}
+
+	if !r.each {
+		return r.check(v)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if elem := indirect(v.Index(i)); elem.IsValid() {
+			if msg := r.check(elem); msg != "" {
+				return fmt.Sprintf("[%d]: %s", i, msg)
+			}
+		}
+	}
+
+	return ""
+}
+
+// ParseRules parses the comma separated rules of a validation tag, checking each applies to the type.
+//
+// The rules are:
+//   - min, max and len: The value of a number, or the length of a string, slice, array or map.
+//     Limits of a time.Duration are durations, such as "min=1s".
+//   - oneof: The options separated by spaces, such as "oneof=dev staging prod".
+//   - regex: A regular expression the string must match. It must be the last rule, as the pattern may hold commas.
+//   - A format, such as "email", "url", "uuid", "e164", "hostname" or "semver".
+//
+// Other than min, max and len, the rules of a slice or array apply to each of its elements.
+//
+// Parameters:
+//   - tag: The rules, such as "min=1,max=65535".
+//   - t: The type of the value the rules are checked against.
+//
+// Returns: The rules, or an error if a rule is unknown, its argument is invalid, or it does not apply to the type.
+// The result is cached, so each tag is only parsed once for each type.
+func ParseRules(tag string, t reflect.Type) ([]Rule, error) {
+	key := rulesKey{tag: tag, typ: t}
+	if cached, ok := rulesCache.Load(key); ok {
+		entry := cached.(rulesEntry)
+		return entry.rules, entry.err
+	}
+
+	rules, err := parseRules(tag, indirectType(t))
+	rulesCache.Store(key, rulesEntry{rules: rules, err: err})
+
+	return rules, err
+}
+
+// parseRules parses the rules of a tag for a type without pointers, as ParseRules does without its cache.
+func parseRules(tag string, t reflect.Type) ([]Rule, error) {
+
+	var rules []Rule
+	for tag != "" {
+		var part string
+		var more bool
+		part, tag, more = strings.Cut(tag, ",")
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "regex" && more {
+			arg, tag = arg+","+tag, ""
+		}
+
+		rule, err := newRule(name, arg, t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rule, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// newRule creates a rule for values of a type.
+//
+// Parameters:
+//   - name: The name of the rule, such as "min".
+//   - arg: The argument of the rule, such as "1".
+//   - t: The type of the value, without pointers.
+//
+// Returns: The rule, or an error if it is unknown, its argument is invalid, or it does not apply to the type.
+func newRule(name, arg string, t reflect.Type) (Rule, error) {
+	r := Rule{Name: name, Arg: arg}
+
+	// Other than limits, the rules of a slice or array apply to its elements.
+	elem := t
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		elem = indirectType(t.Elem())
+		r.each = name != "min" && name != "max" && name != "len"
+	}
+
+	switch name {
+	case "min", "max", "len":
+		return r, r.limit(t)
+	case "oneof":
+		options := strings.Fields(arg)
+		if len(options) == 0 {
+			return r, errors.New("requires at least one option")
+		}
+		if !isBasic(elem.Kind()) {
+			return r, fmt.Errorf("does not apply to %s", elem)
+		}
+
+		r.check = func(v reflect.Value) string {
+			if s := text(v); !slices.Contains(options, s) {
+				return fmt.Sprintf("%q is not one of %s", s, strings.Join(options, ", "))
+			}
+			return ""
+		}
+		return r, nil
+	}
+
+	f, isFormat := Lookup(name)
+	if (!isFormat && name != "regex") || (isFormat && arg != "") {
+		return r, errors.New("unknown rule")
+	}
+	if elem.Kind() != reflect.String {
+		return r, fmt.Errorf("does not apply to %s", elem)
+	}
+
+	if name == "regex" {
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return r, err
+		}
+
+		r.check = func(v reflect.Value) string {
+			if !re.MatchString(v.String()) {
+				return fmt.Sprintf("%q does not match %s", v.String(), arg)
+			}
+			return ""
+		}
+		return r, nil
+	}
+
+	r.check = func(v reflect.Value) string {
+		if !f.Check(v.String()) {
+			return Message(f, v.String())
+		}
+		return ""
+	}
+	return r, nil
+}
+
+// limit sets the check of a min, max or len rule.
+//
+// Parameters:
+//   - t: The type of the value, without pointers.
+//
+// Returns: An error if the limit is not a number, or the type has no value or length to compare.
+func (r *Rule) limit(t reflect.Type) error {
+	var limit float64
+	var err error
+	if t == durationType {
+		var d time.Duration
+		d, err = time.ParseDuration(r.Arg)
+		limit = float64(d)
+	} else {
+		limit, err = strconv.ParseFloat(r.Arg, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid limit %q", r.Arg)
+	}
+
+	var measure func(v reflect.Value) float64
+	var describe func(v reflect.Value) string
+
+	switch t.Kind() {
+	case reflect.String:
+		measure = func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }
+		describe = func(v reflect.Value) string { return "length " + strconv.Itoa(utf8.RuneCountInString(v.String())) }
+	case reflect.Slice, reflect.Array, reflect.Map:
+		measure = func(v reflect.Value) float64 { return float64(v.Len()) }
+		describe = func(v reflect.Value) string { return "length " + strconv.Itoa(v.Len()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		measure = func(v reflect.Value) float64 { return float64(v.Int()) }
+		describe = text
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		measure = func(v reflect.Value) float64 { return float64(v.Uint()) }
+		describe = text
+	case reflect.Float32, reflect.Float64:
+		measure = func(v reflect.Value) float64 { return v.Float() }
+		describe = text
+	default:
+		return fmt.Errorf("does not apply to %s", t)
+	}
+
+	name, arg := r.Name, r.Arg
+	r.check = func(v reflect.Value) string {
+		n := measure(v)
+		switch {
+		case name == "min" && n < limit:
+			return fmt.Sprintf("%s is less than the minimum of %s", describe(v), arg)
+		case name == "max" && n > limit:
+			return fmt.Sprintf("%s is greater than the maximum of %s", describe(v), arg)
+		case name == "len" && n != limit:
+			return fmt.Sprintf("%s is not %s", describe(v), arg)
+		}
+		return ""
+	}
+
+	return nil
+}
+
+// text returns the value as it would be written, such as "8080" or "1m0s".
+func text(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// isBasic reports whether the kind is a string, bool or number, which oneof compares as text.
+func isBasic(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// indirect follows pointers, returning the invalid reflect.Value for a nil pointer.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// indirectType follows pointer types.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,142 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		value    interface{}
+		expected []string
+		err      string
+	}{
+		{name: "Limits", tag: "min=1, max=65535", value: 0, expected: []string{"min=1", "max=65535"}},
+		{name: "Regex with commas", tag: "len=3,regex=^[a-z]{1,3}$", value: "", expected: []string{"len=3", "regex=^[a-z]{1,3}$"}},
+		{name: "Formats of a slice", tag: "max=2,email", value: []string{}, expected: []string{"max=2", "email"}},
+		{name: "Pointer", tag: "oneof=1 2", value: new(int), expected: []string{"oneof=1 2"}},
+		{name: "Empty", tag: "", value: "", expected: nil},
+		{name: "Unknown rule", tag: "min=1,positive", value: 0, err: "positive: unknown rule"},
+		{name: "Format with an argument", tag: "email=true", value: "", err: "email=true: unknown rule"},
+		{name: "Invalid limit", tag: "max=lots", value: 0, err: `max=lots: invalid limit "lots"`},
+		{name: "Invalid duration", tag: "min=1", value: time.Duration(0), err: `min=1: invalid limit "1"`},
+		{name: "Limit of a bool", tag: "min=1", value: false, err: "min=1: does not apply to bool"},
+		{name: "Oneof without options", tag: "oneof=", value: "", err: "oneof: requires at least one option"},
+		{name: "Oneof of a map", tag: "oneof=a", value: map[string]string{}, err: "oneof=a: does not apply to map[string]string"},
+		{name: "Regex of an int", tag: "regex=^1$", value: 0, err: "regex=^1$: does not apply to int"},
+		{name: "Invalid regex", tag: "regex=[", value: "", err: "regex=[: error parsing regexp: missing closing ]: `[`"},
+		{name: "Format of an int slice", tag: "uuid", value: []int{}, err: "uuid: does not apply to int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.tag, reflect.TypeOf(tt.value))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("ParseRules() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRules() error = %v", err)
+			}
+
+			var got []string
+			for _, rule := range rules {
+				got = append(got, rule.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseRules() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRule_Check(t *testing.T) {
+	type Stage string
+
+	two := 2
+
+	tests := []struct {
+		name     string
+		tag      string
+		value    interface{}
+		expected string
+	}{
+		{name: "Int within range", tag: "min=1,max=10", value: 5},
+		{name: "Int below minimum", tag: "min=1", value: 0, expected: "0 is less than the minimum of 1"},
+		{name: "Uint above maximum", tag: "max=10", value: uint(11), expected: "11 is greater than the maximum of 10"},
+		{name: "Float above maximum", tag: "max=0.5", value: 0.75, expected: "0.75 is greater than the maximum of 0.5"},
+		{name: "Duration below minimum", tag: "min=1s", value: 500 * time.Millisecond, expected: "500ms is less than the minimum of 1s"},
+		{name: "String length in characters", tag: "len=4", value: "café"},
+		{name: "String length", tag: "len=4", value: "cafe!", expected: "length 5 is not 4"},
+		{name: "Map length", tag: "min=1", value: map[string]int{}, expected: "length 0 is less than the minimum of 1"},
+		{name: "Array length", tag: "max=1", value: [2]int{}, expected: "length 2 is greater than the maximum of 1"},
+		{name: "Oneof", tag: "oneof=dev prod", value: Stage("prod")},
+		{name: "Oneof invalid", tag: "oneof=dev prod", value: Stage("qa"), expected: `"qa" is not one of dev, prod`},
+		{name: "Oneof of an int", tag: "oneof=1 3", value: 2, expected: `"2" is not one of 1, 3`},
+		{name: "Regex", tag: "regex=^v[0-9]+$", value: "v1"},
+		{name: "Regex invalid", tag: "regex=^v[0-9]+$", value: "1", expected: `"1" does not match ^v[0-9]+$`},
+		{name: "Format", tag: "semver", value: "1.2.3"},
+		{name: "Format invalid", tag: "semver", value: "v1", expected: `"v1" is not a valid semantic version`},
+		{name: "Slice elements", tag: "oneof=a b", value: []string{"a", "c"}, expected: `[1]: "c" is not one of a, b`},
+		{name: "Slice of pointers", tag: "oneof=2", value: []*int{nil, &two}},
+		{name: "Pointer", tag: "min=3", value: &two, expected: "2 is less than the minimum of 3"},
+		{name: "Nil pointer", tag: "min=3", value: (*int)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.tag, reflect.TypeOf(tt.value))
+			if err != nil {
+				t.Fatalf("ParseRules() error = %v", err)
+			}
+
+			got := ""
+			for _, rule := range rules {
+				if got = rule.Check(reflect.ValueOf(tt.value)); got != "" {
+					break
+				}
+			}
+			if got != tt.expected {
+				t.Errorf("Check() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRules_Cached(t *testing.T) {
+	first, err := ParseRules("min=1,max=10", reflect.TypeOf(0))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	second, _ := ParseRules("min=1,max=10", reflect.TypeOf(0))
+	if &first[0] != &second[0] {
+		t.Error("ParseRules() expected the cached rules to be returned")
+	}
+
+	// The same tag is parsed again for another type, as its rules may not apply.
+	if _, err = ParseRules("min=1,max=10", reflect.TypeOf(true)); err == nil {
+		t.Error("ParseRules() expected an error for a bool")
+	}
+	if _, err = ParseRules("min=1,max=10", reflect.TypeOf(true)); err == nil {
+		t.Error("ParseRules() expected the cached error")
+	}
+}
+
+func BenchmarkRule_Check(b *testing.B) {
+	rules, err := ParseRules("min=1,max=65535,oneof=80 443 8080", reflect.TypeOf(0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	v := reflect.ValueOf(8080)
+
+	for i := 0; i < b.N; i++ {
+		for _, rule := range rules {
+			rule.Check(v)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package validate checks values against common formats and the rules of validation tags, for env and BindRequest.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// Format is a named format that a value can be validated against, such as within a validation tag.
+type Format struct {
+	// Name is the name used within tags, such as "email".
+	Name string
+	// Description is used within error messages, such as "email address".
+	Description string
+	// Check reports whether the value is valid.
+	Check func(s string) bool
+}
+
+// formats are the known formats by name.
+var formats = map[string]Format{
+	"email":    {Name: "email", Description: "email address", Check: IsEmail},
+	"url":      {Name: "url", Description: "URL", Check: IsURL},
+	"uuid":     {Name: "uuid", Description: "UUID", Check: IsUUID},
+	"e164":     {Name: "e164", Description: "E.164 phone number", Check: IsE164Phone},
+	"hostname": {Name: "hostname", Description: "hostname", Check: IsHostname},
+	"semver":   {Name: "semver", Description: "semantic version", Check: IsSemver},
+}
+
+// Lookup finds a format by name.
+//
+// Parameters:
+//   - name: The name of the format, such as "email".
+//
+// Returns: The Format, and false if no format has the name.
+func Lookup(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Message is the error message for a value that does not match the format, shared so every caller reads the same.
+//
+// Parameters:
+//   - f: The format the value was checked against.
+//   - value: The invalid value.
+//
+// Returns: A message such as `"abc" is not a valid email address`.
+func Message(f Format, value string) string {
+	return fmt.Sprintf("%q is not a valid %s", value, f.Description)
+}
+
+// IsEmail checks for a bare email address such as "user@example.com", without a display name.
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return false
+	}
+
+	at := strings.LastIndexByte(s, '@')
+	return IsHostname(s[at+1:])
+}
+
+// IsURL checks for an absolute URL with a scheme and host, such as "https://example.com/path".
+func IsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// IsUUID checks for a UUID in its canonical 8-4-4-4-12 hexadecimal form, in either case.
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// IsE164Phone checks for an E.164 phone number, a '+' followed by up to 15 digits without a leading zero.
+func IsE164Phone(s string) bool {
+	if len(s) < 3 || len(s) > 16 || s[0] != '+' || s[1] == '0' {
+		return false
+	}
+
+	return isDigits(s[1:])
+}
+
+// IsHostname checks for an RFC 1123 hostname, such as "api.example.com".
+//
+// Labels contain letters, digits and hyphens, must not start or end with a hyphen and are at most 63 characters.
+// A single trailing dot is allowed.
+func IsHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !isAlphanumeric(c) && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// IsSemver checks for a Semantic Versioning 2.0.0 version, such as "1.2.3-rc.1+build.5".
+//
+// A leading "v" is not part of the specification and is rejected.
+func IsSemver(s string) bool {
+	s, build, hasBuild := strings.Cut(s, "+")
+	if hasBuild && !isIdentifiers(build, false) {
+		return false
+	}
+
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre && !isIdentifiers(pre, true) {
+		return false
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	for _, p := range parts {
+		if !isNumericIdentifier(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isIdentifiers checks the dot separated pre-release or build identifiers of a semantic version.
+//
+// Parameters:
+//   - s: The identifiers.
+//   - noLeadingZeros: Whether numeric identifiers must not have leading zeros, as required for pre-releases.
+//
+// Returns: True if all identifiers are valid, false otherwise.
+func isIdentifiers(s string, noLeadingZeros bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+
+		for i := 0; i < len(id); i++ {
+			if !isAlphanumeric(id[i]) && id[i] != '-' {
+				return false
+			}
+		}
+
+		if noLeadingZeros && isDigits(id) && !isNumericIdentifier(id) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isNumericIdentifier checks for a number without leading zeros.
+func isNumericIdentifier(s string) bool {
+	return isDigits(s) && (s == "0" || s[0] != '0')
+}
+
+// isDigits checks that the string is non-empty and only contains ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAlphanumeric checks if the byte is an ASCII letter or digit.
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isHex checks if the byte is a hexadecimal digit.
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
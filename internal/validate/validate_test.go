@@ -0,0 +1,90 @@
+package validate
+
+import "testing"
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(string) bool
+		input    string
+		expected bool
+	}{
+		{"Email", IsEmail, "user@example.com", true},
+		{"Email with plus", IsEmail, "first.last+tag@sub.example.co.uk", true},
+		{"Email with name", IsEmail, "User <user@example.com>", false},
+		{"Email without domain", IsEmail, "user@", false},
+		{"Email with invalid domain", IsEmail, "user@-example.com", false},
+		{"Email without at", IsEmail, "user.example.com", false},
+
+		{"URL", IsURL, "https://example.com/path?q=1", true},
+		{"URL with port", IsURL, "http://localhost:8080", true},
+		{"URL relative", IsURL, "/path", false},
+		{"URL without host", IsURL, "mailto:user@example.com", false},
+		{"URL invalid", IsURL, "http://[::1", false},
+
+		{"UUID", IsUUID, "123e4567-e89b-12d3-a456-426614174000", true},
+		{"UUID upper", IsUUID, "123E4567-E89B-12D3-A456-426614174000", true},
+		{"UUID no hyphens", IsUUID, "123e4567e89b12d3a456426614174000", false},
+		{"UUID misplaced hyphen", IsUUID, "123e4567-e89b-12d3-a4564-26614174000", false},
+		{"UUID non hex", IsUUID, "123e4567-e89b-12d3-a456-42661417400g", false},
+
+		{"E164", IsE164Phone, "+447700900123", true},
+		{"E164 shortest", IsE164Phone, "+12", true},
+		{"E164 without plus", IsE164Phone, "447700900123", false},
+		{"E164 leading zero", IsE164Phone, "+07700900123", false},
+		{"E164 too long", IsE164Phone, "+1234567890123456", false},
+		{"E164 with spaces", IsE164Phone, "+44 7700 900123", false},
+
+		{"Hostname", IsHostname, "api.example.com", true},
+		{"Hostname single label", IsHostname, "localhost", true},
+		{"Hostname trailing dot", IsHostname, "example.com.", true},
+		{"Hostname empty label", IsHostname, "example..com", false},
+		{"Hostname leading hyphen", IsHostname, "-example.com", false},
+		{"Hostname trailing hyphen", IsHostname, "example-.com", false},
+		{"Hostname underscore", IsHostname, "ex_ample.com", false},
+		{"Hostname long label", IsHostname, string(make([]byte, 64)) + ".com", false},
+		{"Hostname empty", IsHostname, "", false},
+
+		{"Semver", IsSemver, "1.2.3", true},
+		{"Semver pre-release and build", IsSemver, "1.0.0-rc.1+build.5", true},
+		{"Semver hyphenated pre-release", IsSemver, "1.0.0-alpha-beta", true},
+		{"Semver build with leading zero", IsSemver, "1.0.0+001", true},
+		{"Semver v prefix", IsSemver, "v1.2.3", false},
+		{"Semver missing patch", IsSemver, "1.2", false},
+		{"Semver leading zero", IsSemver, "01.2.3", false},
+		{"Semver pre-release leading zero", IsSemver, "1.2.3-01", false},
+		{"Semver empty pre-release", IsSemver, "1.2.3-", false},
+		{"Semver invalid build", IsSemver, "1.2.3+a_b", false},
+		{"Semver invalid pre-release", IsSemver, "1.2.3-a..b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.input); got != tt.expected {
+				t.Errorf("%s(%q) = %v, expected %v", tt.name, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLookupAndMessage(t *testing.T) {
+	f, ok := Lookup("email")
+	if !ok {
+		t.Fatal("Lookup(email) not found")
+	}
+
+	expected := `"abc" is not a valid email address`
+	if got := Message(f, "abc"); got != expected {
+		t.Errorf("Message() = %q, expected %q", got, expected)
+	}
+
+	if _, ok := Lookup("unknown"); ok {
+		t.Error("Lookup(unknown) found, expected not found")
+	}
+}
+
+func BenchmarkIsEmail(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsEmail("first.last+tag@sub.example.co.uk")
+	}
+}
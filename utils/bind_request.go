@@ -1,11 +1,24 @@
 package utils
 
 import (
+	"encoding"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudment/utils-go/internal/parse"
+	"github.com/cloudment/utils-go/internal/structfields"
 )
 
 // BindRequest binds query parameters, form data, and JSON body to a struct.
@@ -19,8 +32,8 @@ import (
 // Usage:
 //
 //		When binding query parameters and form data, you can use struct tags to specify the field names.
-//		The `query` tag specifies the query parameter name, the `form` tag specifies the form field name
-//		and the `required` tag specifies if the field is required.
+//		The `query` tag specifies the query parameter name, the `form` tag specifies the form field name,
+//		the `header` tag specifies the header name and the `required` tag specifies if the field is required.
 //
 //		When binding JSON body, the struct tags are not required. The JSON body is automatically decoded into the struct.
 //	 Although specify for consistency.
@@ -40,50 +53,204 @@ import (
 //	 }
 //	}
 //
-// Note: This function only supports binding to string, int, uint, float, and bool fields.
-// It does not support nested structs or slices. It also does not support binding to unexported fields.
+// Note: This function only supports binding to string, int, uint, float, and bool fields, time.Time fields,
+// parsed as RFC3339 or with a `layout` tag, time.Duration and the other types parsed by the env package,
+// encoding.TextUnmarshaler fields, and slices, maps and pointers of them. It does not support binding to unexported fields.
+//
+// A pointer field, such as *int or *bool, stays nil when its value is absent and is set when it is sent,
+// so "not provided" can be told apart from a zero value, such as in a PATCH request. A required pointer field
+// accepts a zero value, such as "?count=0".
+//
+// A slice field takes every value of a repeated key, such as "tags=a&tags=b" or "tags[]=a&tags[]=b",
+// and a map field takes the keys under its own, such as "meta[env]=prod" or "meta.env=prod".
+//
+// A nested struct field is bound from the keys under its `query` and `form` tags, such as "address.city" or
+// "address[city]", and its `required` tags are checked too. A nil pointer to a struct is only allocated when such a
+// key is sent, so an optional struct stays nil. An embedded struct without tags is bound as if its fields were
+// declared directly. Slices and maps of structs can only be sent within a JSON body.
 //
 // JSON body is only decoded if the Content-Type header is "application/json",
 // it will still allow query parameters to be collected.
 //
 // If JSON data is intended for collection, query parameters may overwrite JSON values.
+//
+// The `validate` tag checks a bound field against rules such as `validate:"min=1,max=100"` or `validate:"email"`,
+// the same rules as the env package's envValidate tag. Every field is checked, and the broken rules are returned
+// together as a *ValidationError, which marshals to JSON for a 422 response. A missing `required` field is reported
+// within the same *ValidationError, as a violation of the "required" rule.
+// A value that is sent or defaulted is checked even when zero, such as "?limit=0", while an absent value or a nil
+// pointer is not, use the `required` tag for that. A zero value within a body cannot be told apart from an absent one,
+// so it is not checked.
+//
+// A destination or field implementing Binder binds itself from the request, and a field implementing ParamBinder
+// parses its own value, such as an enum or money type.
+//
+// The `default` tag sets a field whose value is not sent in the query, form, header or body, such as `default:"25"`,
+// or `default:"a,b"` for a slice. A body is decoded over the defaults, and the `required` tag is checked after them,
+// so a default satisfies `required`, and a value sent as zero, such as "?page=0" or {"active":false}, is kept.
+// A nil pointer to a struct takes the defaults of its fields once a query or form key under it is sent.
+//
+// An "application/xml" or "text/xml" body is decoded with encoding/xml, using the `xml` tags of the struct.
+// An "application/x-ndjson" body is a stream of JSON values, one per line, decoded into a slice, such as
+// *[]Event. A body decoded into a slice or map has no fields for query parameters to be bound to.
+//
+// A multipart/form-data body binds its text parts as form data, and its files to *multipart.FileHeader or
+// []*multipart.FileHeader fields with a `form` tag. Up to DefaultMaxMemory is held in memory,
+// use BindRequestWithOptions for another limit.
 func BindRequest[T any](r *http.Request, dest *T) error {
-	if r.Header.Get("Content-Type") == "application/json" {
-		err := decodeJSON(r, dest)
-		if err != nil {
-			return err
-		}
+	return BindRequestWithOptions(r, dest, BindOptions{})
+}
 
-		// Query params may still be present in the URL, so parse them
-	}
+// DefaultMaxMemory is the number of bytes of a multipart/form-data body held in memory by BindRequest,
+// the same as http.Request.FormFile.
+const DefaultMaxMemory = 32 << 20
 
-	if err := r.ParseForm(); err != nil {
-		return fmt.Errorf("failed to parse form: %w", err)
-	}
+// BindOptions customises BindRequestWithOptions.
+type BindOptions struct {
+	// MaxMemory is the number of bytes of a multipart/form-data body held in memory, defaulting to DefaultMaxMemory.
+	//
+	// Files beyond it are stored in temporary files, which http.Server removes once the handler returns.
+	MaxMemory int64
+}
 
-	destVal := reflect.ValueOf(dest).Elem()
-	destType := destVal.Type()
+// Binder is implemented by a destination, or a field, that binds itself from the whole request.
+//
+// BindRequest calls it instead of binding by reflection: for a destination, nothing else is bound, defaulted or
+// validated, and for a field, its tags are only used for `required` and `validate`.
+//
+// Example:
+//
+//	// Money is sent as the "amount" and "currency" query parameters.
+//	func (m *Money) BindRequest(r *http.Request) error {
+//	 cents, err := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+//	 m.Cents, m.Currency = cents, r.URL.Query().Get("currency")
+//	 return err
+//	}
+type Binder interface {
+	BindRequest(r *http.Request) error
+}
 
-	for i := 0; i < destType.NumField(); i++ {
-		field := destType.Field(i)
-		fieldVal := destVal.Field(i)
+// ParamBinder is implemented by a field that parses its own query, form or header value, such as an enum type.
+//
+// BindRequest calls it with the value instead of parsing it by reflection, before encoding.TextUnmarshaler.
+// It is called with each value of a slice of ParamBinders, and with the `default` tag of an absent value.
+//
+// Example:
+//
+//	func (s *Status) BindParam(value string) error {
+//	 switch value {
+//	 case "active", "archived":
+//	  *s = Status(value)
+//	  return nil
+//	 }
+//	 return fmt.Errorf("unknown status %q", value)
+//	}
+type ParamBinder interface {
+	BindParam(value string) error
+}
 
-		queryTag := field.Tag.Get("query")
-		formTag := field.Tag.Get("form")
-		required := field.Tag.Get("required") == "true"
+// BindRequestWithOptions binds query parameters, form data, and JSON body to a struct, as BindRequest does.
+//
+// Parameters:
+//   - r: The HTTP request to bind data from.
+//   - dest: A pointer to the struct to bind data to.
+//   - opts: The BindOptions, such as the memory limit of a multipart form.
+//
+// Returns: An error if the binding fails.
+//
+// Example:
+//
+//	type Upload struct {
+//	 Title  string                  `form:"title" required:"true"`
+//	 Avatar *multipart.FileHeader   `form:"avatar" required:"true"`
+//	 Photos []*multipart.FileHeader `form:"photos"`
+//	}
+//
+//	var req Upload
+//	err := BindRequestWithOptions(r, &req, BindOptions{MaxMemory: 8 << 20})
+//	file, err := req.Avatar.Open()
+func BindRequestWithOptions[T any](r *http.Request, dest *T, opts BindOptions) error {
+	if binder, ok := any(dest).(Binder); ok {
+		return binder.BindRequest(r)
+	}
 
-		if err := bindField(r, fieldVal, queryTag, formTag); err != nil {
+	// The body is decoded over the defaults, so a value sent as zero within it is kept.
+	defaulted := false
+	if ref := bindTarget(dest); ref.Kind() == reflect.Struct && hasBody(r) {
+		if err := applyDefaults(ref, ""); err != nil {
 			return err
 		}
+		defaulted = true
+	}
+
+	decoded, err := decodeBody(r, dest)
+	if err != nil {
+		return err
+	}
+
+	// Query params may still be present in the URL, so parse them
+	if err = parseForm(r, opts); err != nil {
+		return err
+	}
 
-		if required && fieldVal.IsZero() {
-			return fmt.Errorf("required field %s is missing", field.Name)
+	ref := bindTarget(dest)
+	if ref.Kind() != reflect.Struct {
+		if decoded && (ref.Kind() == reflect.Slice || ref.Kind() == reflect.Map) {
+			return nil
 		}
+		return errors.New("expected a struct or a pointer to a struct")
+	}
+
+	b := &binder{r: r, query: bindValues(r.URL.Query()), form: bindValues(r.Form), defaulted: defaulted}
+	if err = b.bindStruct(ref, bindScope{}); err != nil {
+		return err
 	}
 
+	if len(b.violations) > 0 {
+		return &ValidationError{Violations: b.violations}
+	}
 	return nil
 }
 
+// bindTarget returns the value a destination points to, through any non-nil pointers.
+func bindTarget(dest any) reflect.Value {
+	ref := reflect.ValueOf(dest)
+	for ref.Kind() == reflect.Pointer && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+	return ref
+}
+
+// hasBody reports whether the body of a request is decoded by decodeBody, by its Content-Type.
+func hasBody(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json", "application/xml", "text/xml", "application/x-ndjson":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeBody decodes the body of a request by its Content-Type, as JSON, XML or NDJSON.
+//
+// Returns: Whether the body was decoded, and an error if the decoding fails.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func decodeBody[T any](r *http.Request, dest *T) (bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return true, decodeJSON(r, dest)
+	case "application/xml", "text/xml":
+		return true, decodeXML(r, dest)
+	case "application/x-ndjson":
+		return true, decodeNDJSON(r, dest)
+	default:
+		return false, nil
+	}
+}
+
 // decodeJSON is a helper function for BindRequest that decodes JSON data into a struct.
 //
 // Returns: An error if the JSON decoding fails.
@@ -97,24 +264,653 @@ func decodeJSON[T any](r *http.Request, dest *T) error {
 	return nil
 }
 
-// bindField tries to set a field from query or form data.
+// decodeXML is a helper function for BindRequest that decodes XML data into a struct.
+//
+// Returns: An error if the XML decoding fails.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func decodeXML[T any](r *http.Request, dest *T) error {
+	decoder := xml.NewDecoder(r.Body)
+	if err := decoder.Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode xml: %w", err)
+	}
+	return nil
+}
+
+// decodeNDJSON is a helper function for BindRequest that decodes newline delimited JSON into a slice,
+// one element per value, replacing its elements.
+//
+// Returns: An error if dest is not a slice, or if a value cannot be decoded.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func decodeNDJSON[T any](r *http.Request, dest *T) error {
+	slice := reflect.ValueOf(dest).Elem()
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("an ndjson body must be decoded into a slice, got %s", slice.Type())
+	}
+
+	slice.SetLen(0)
+	decoder := json.NewDecoder(r.Body)
+	for {
+		elem := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(elem.Interface()); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode ndjson value %d: %w", slice.Len()+1, err)
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+}
+
+// parseForm parses the query and form data of a request, or its multipart form for multipart/form-data.
+//
+// Returns: An error if the form cannot be parsed.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func parseForm(r *http.Request, opts BindOptions) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := r.ParseForm(); err != nil {
+			return fmt.Errorf("failed to parse form: %w", err)
+		}
+		return nil
+	}
+
+	maxMemory := opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+
+	// The text parts are added to r.Form, so they are bound as any other form data.
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		return fmt.Errorf("failed to parse multipart form: %w", err)
+	}
+	return nil
+}
+
+// fileHeaderType is the type of a field holding an uploaded file.
+var fileHeaderType = reflect.TypeFor[*multipart.FileHeader]()
+
+// isFileField reports whether a field holds uploaded files, as a *multipart.FileHeader or []*multipart.FileHeader.
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+// bindFiles sets a file field from the files of a multipart form, with the first file for a single file field.
+//
+// Returns: An error if the field is not settable.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func bindFiles(r *http.Request, field reflect.Value, formTag string) error {
+	if formTag == "" || r.MultipartForm == nil || len(r.MultipartForm.File[formTag]) == 0 {
+		return nil
+	}
+
+	if !field.CanSet() {
+		return fmt.Errorf("field is not settable")
+	}
+
+	files := r.MultipartForm.File[formTag]
+	if field.Type() == fileHeaderType {
+		field.Set(reflect.ValueOf(files[0]))
+	} else {
+		field.Set(reflect.ValueOf(files))
+	}
+	return nil
+}
+
+// bindKeyReplacer turns the brackets of a key into dots, such as "address[city]" into "address.city".
+var bindKeyReplacer = strings.NewReplacer("][", ".", "[", ".", "]", "")
+
+// bindValues normalises the keys of query or form values, so bracketed and dotted keys are bound alike.
+// A trailing "[]", as in "tags[]=a&tags[]=b", is removed.
+//
+// Returns: The values, unchanged if no key has brackets.
+func bindValues(values url.Values) url.Values {
+	bracketed := false
+	for key := range values {
+		if strings.Contains(key, "[") {
+			bracketed = true
+			break
+		}
+	}
+	if !bracketed {
+		return values
+	}
+
+	normalised := make(url.Values, len(values))
+	for key, vals := range values {
+		key = bindKeyReplacer.Replace(strings.TrimSuffix(key, "[]"))
+		normalised[key] = append(normalised[key], vals...)
+	}
+	return normalised
+}
+
+// binder binds the values of a request to a struct, with the query and form keys normalised by bindValues.
+type binder struct {
+	r     *http.Request
+	query url.Values
+	form  url.Values
+	// defaulted is set when the defaults were set before the body was decoded, so they are not set again.
+	defaulted bool
+	// violations holds the broken rules of every field, returned as a *ValidationError once all are bound.
+	violations []FieldViolation
+}
+
+// bindScope is where the fields of a struct are bound from, the top level or a nested struct.
+type bindScope struct {
+	// query and form are the key prefixes of a nested struct, such as "address", empty at the top level.
+	query, form string
+	// noQuery and noForm are set when a nested struct has no query or form tag, so its fields are not bound from them.
+	noQuery, noForm bool
+	// path is the field path, such as "Address", used in errors.
+	path string
+}
+
+// queryKey returns the query key of a field within the scope, or an empty string if it is not bound from the query.
+func (s bindScope) queryKey(tag string) string {
+	return scopedKey(s.query, s.noQuery, tag)
+}
+
+// formKey returns the form key of a field within the scope, or an empty string if it is not bound from the form.
+func (s bindScope) formKey(tag string) string {
+	return scopedKey(s.form, s.noForm, tag)
+}
+
+// scopedKey joins a key prefix and a tag with a dot.
+func scopedKey(prefix string, disabled bool, tag string) string {
+	if tag == "" || disabled {
+		return ""
+	}
+	if prefix == "" {
+		return tag
+	}
+	return prefix + "." + tag
+}
+
+// nested returns the scope of a nested struct field.
+//
+// An embedded struct without tags is bound as if its fields were declared directly,
+// any other nested struct is bound from the keys under its `query` and `form` tags.
+func (s bindScope) nested(sf reflect.StructField, path string) bindScope {
+	queryTag, formTag := sf.Tag.Get("query"), sf.Tag.Get("form")
+	if sf.Anonymous && queryTag == "" && formTag == "" {
+		s.path = path
+		return s
+	}
+
+	query, form := s.queryKey(queryTag), s.formKey(formTag)
+	return bindScope{query: query, form: form, noQuery: query == "", noForm: form == "", path: path}
+}
+
+// bindStruct binds each field of a struct, descending into nested structs, then checks its required fields.
+//
+// A missing required field and the broken rules of a `validate` tag are added to the violations of the binder.
+//
+// Returns: An error if a field cannot be set.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func (b *binder) bindStruct(ref reflect.Value, scope bindScope) error {
+	for i, sf := range structfields.Fields(ref.Type()) {
+		field := ref.Field(i)
+		path := sf.Name
+		if scope.path != "" {
+			path = scope.path + "." + sf.Name
+		}
+
+		keys := bindKeys{query: scope.queryKey(sf.Tag.Get("query")), form: scope.formKey(sf.Tag.Get("form")), header: sf.Tag.Get("header")}
+
+		// present is set when a value is sent or defaulted, so a zero value, such as "?limit=0", is validated.
+		var present bool
+		var err error
+		switch {
+		case isBinder(sf.Type):
+			err = bindWithBinder(b.r, field)
+		case isFileField(sf.Type):
+			err = bindFiles(b.r, field, keys.form)
+		case isNestedStruct(sf.Type):
+			if isUnexportedEmbed(sf) {
+				continue
+			}
+			err = b.bindNested(field, scope.nested(sf, path))
+		default:
+			var found bool
+			layout := sf.Tag.Get("layout")
+			def, hasDefault := sf.Tag.Lookup("default")
+			found, err = b.bindField(field, keys, layout)
+			if hasDefault && err == nil && !found && !b.defaulted && field.IsZero() {
+				err = setDefault(field, def, layout, path)
+			}
+			present = found || hasDefault
+		}
+		if err != nil {
+			return err
+		}
+
+		if sf.Tag.Get("required") == "true" && field.IsZero() {
+			b.violations = append(b.violations, FieldViolation{
+				Field: violationField(sf, keys, path), Rule: "required", Message: "a value is required", Path: path,
+			})
+			continue
+		}
+
+		// A value set by the body cannot be told apart from an absent one when it is zero, so it is only validated when set.
+		if tag := sf.Tag.Get("validate"); tag != "" && (present || !field.IsZero()) {
+			violations, err := validateField(field, tag, violationField(sf, keys, path), path)
+			if err != nil {
+				return err
+			}
+			b.violations = append(b.violations, violations...)
+		}
+	}
+
+	return nil
+}
+
+// applyDefaults sets each field of a struct left zero to its `default` tag, descending into nested structs,
+// before a body is decoded over them.
+// A nil pointer to a struct is skipped, its defaults are set by bindNested once it is allocated.
+//
+// Returns: An error naming the field if a default cannot be set.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func applyDefaults(ref reflect.Value, path string) error {
+	for i, sf := range structfields.Fields(ref.Type()) {
+		field := ref.Field(i)
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		switch {
+		case isBinder(sf.Type), isFileField(sf.Type):
+			continue
+		case isNestedStruct(sf.Type):
+			if isUnexportedEmbed(sf) {
+				continue
+			}
+			if field.Kind() == reflect.Pointer {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if err := applyDefaults(field, fieldPath); err != nil {
+				return err
+			}
+		default:
+			if def, ok := sf.Tag.Lookup("default"); ok && field.IsZero() {
+				if err := setDefault(field, def, sf.Tag.Get("layout"), fieldPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// setDefault sets a field to its `default` tag, split by commas for a slice field such as `default:"a,b"`.
+//
+// Returns: An error naming the field if the default cannot be set.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func setDefault(field reflect.Value, def string, layout string, path string) error {
+	values := []string{def}
+	if t := field.Type(); t.Kind() == reflect.Slice && !isTextType(t) {
+		values = strings.Split(def, ",")
+	}
+
+	if err := setFieldValues(field, values, layout); err != nil {
+		return fmt.Errorf("invalid default of field %s: %w", path, err)
+	}
+	return nil
+}
+
+// isUnexportedEmbed reports whether a field is an unexported embedded pointer, such as *inner.
+// It cannot be allocated or bound through, so it is skipped as encoding/json skips it.
+func isUnexportedEmbed(sf reflect.StructField) bool {
+	return sf.Anonymous && !sf.IsExported() && sf.Type.Kind() == reflect.Pointer
+}
+
+// isNestedStruct reports whether a field is a struct, or a pointer to one, whose fields are bound.
+// A struct bound from a single value, such as time.Time, is not nested.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isTextType(t)
+}
+
+var (
+	// binderType is the type of Binder.
+	binderType = reflect.TypeFor[Binder]()
+	// paramBinderType is the type of ParamBinder.
+	paramBinderType = reflect.TypeFor[ParamBinder]()
+)
+
+// isBinder reports whether a field implements Binder, by its value or its pointer.
+func isBinder(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && (t.Implements(binderType) || reflect.PointerTo(t).Implements(binderType))
+}
+
+// bindWithBinder binds a field through its Binder, allocating a nil pointer first.
+// An unexported field is skipped, as it cannot be told whether the request holds a value for it.
+//
+// Returns: The error from the Binder.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func bindWithBinder(r *http.Request, field reflect.Value) error {
+	if !field.CanSet() {
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer && field.Type().Implements(binderType) {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return field.Interface().(Binder).BindRequest(r)
+	}
+
+	return field.Addr().Interface().(Binder).BindRequest(r)
+}
+
+// asParamBinder returns the ParamBinder of a field, or false if it does not implement one or is unexported.
+func asParamBinder(field reflect.Value) (ParamBinder, bool) {
+	if !field.CanAddr() || !field.Addr().CanInterface() {
+		return nil, false
+	}
+	pb, ok := field.Addr().Interface().(ParamBinder)
+	return pb, ok
+}
+
+// textUnmarshalerType is the type of encoding.TextUnmarshaler, which fields may implement to be bound from a value.
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// isTextType reports whether a type is parsed from a single value, by a ParamBinder or setBoundValue,
+// rather than by its kind.
+func isTextType(t reflect.Type) bool {
+	return t == timeType || parse.Types[t] != nil || reflect.PointerTo(t).Implements(textUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(paramBinderType)
+}
+
+// bindNested binds a nested struct, or a pointer to one.
+//
+// A nil pointer is only allocated when a key under its prefix is sent, so an optional struct stays nil,
+// along with the required fields within it.
+//
+// Returns: An error if the struct cannot be bound.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func (b *binder) bindNested(field reflect.Value, scope bindScope) error {
+	if field.Kind() != reflect.Pointer {
+		return b.bindStruct(field, scope)
+	}
+
+	if field.IsNil() {
+		if !b.hasStructKey(field.Type().Elem(), scope, nil) {
+			return nil
+		}
+		if !field.CanSet() {
+			return fmt.Errorf("field is not settable")
+		}
+		field.Set(reflect.New(field.Type().Elem()))
+		if b.defaulted {
+			if err := applyDefaults(field.Elem(), scope.path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.bindStruct(field.Elem(), scope)
+}
+
+// hasStructKey reports whether any key of a nested struct is sent, so a nil pointer to it is only allocated when needed.
+//
+// A struct with a key prefix, such as "address", has a key when any key is under the prefix. An embedded struct
+// without tags shares the prefix of its parent, so only the keys of its own fields are looked for.
+//
+// Parameters:
+//   - t: The type of the struct.
+//   - scope: The scope the fields of the struct are bound within.
+//   - embedding: The embedded struct types being looked through, so a struct embedding itself ends.
+func (b *binder) hasStructKey(t reflect.Type, scope bindScope, embedding []reflect.Type) bool {
+	if scope.query != "" || scope.form != "" {
+		return hasKeyPrefix(b.query, scope.query, scope.noQuery) || hasKeyPrefix(b.form, scope.form, scope.noForm)
+	}
+	if slices.Contains(embedding, t) {
+		return false
+	}
+	embedding = append(embedding, t)
+
+	for _, sf := range structfields.Fields(t) {
+		if (!sf.IsExported() && !sf.Anonymous) || isUnexportedEmbed(sf) {
+			continue
+		}
+
+		if isNestedStruct(sf.Type) {
+			nested := sf.Type
+			if nested.Kind() == reflect.Pointer {
+				nested = nested.Elem()
+			}
+			if b.hasStructKey(nested, scope.nested(sf, scope.path), embedding) {
+				return true
+			}
+			continue
+		}
+
+		if hasKey(b.query, scope.queryKey(sf.Tag.Get("query"))) || hasKey(b.form, scope.formKey(sf.Tag.Get("form"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKey reports whether a key is sent, either itself or as the prefix of map keys such as "meta.env".
+func hasKey(values url.Values, key string) bool {
+	if key == "" {
+		return false
+	}
+	if _, ok := values[key]; ok {
+		return true
+	}
+	return hasKeyPrefix(values, key, false)
+}
+
+// hasKeyPrefix reports whether any key is under the prefix of a nested struct.
+func hasKeyPrefix(values url.Values, prefix string, disabled bool) bool {
+	if disabled || prefix == "" {
+		return false
+	}
+
+	for key := range values {
+		if strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// bindKeys are the query, form and header keys of a field, empty if it is not bound from them.
+type bindKeys struct {
+	query, form, header string
+}
+
+// bindField tries to set a field from query, form or header data, in that order.
+//
+// A slice field takes every value of a repeated key, and a map field takes the keys under its own,
+// such as "meta[env]" or "meta.env" for the key "meta".
+//
+// Returns: Whether a value was found, and an error if the field cannot be set.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func (b *binder) bindField(field reflect.Value, keys bindKeys, layout string) (bool, error) {
+	kind := field.Kind()
+	if (kind == reflect.Slice || kind == reflect.Map) && isNestedStruct(field.Type().Elem()) {
+		// Slices and maps of structs can only be sent within a JSON body.
+		return false, nil
+	}
+
+	if kind == reflect.Map {
+		for _, source := range []struct {
+			values url.Values
+			key    string
+		}{{b.query, keys.query}, {b.form, keys.form}} {
+			if source.key == "" {
+				continue
+			}
+			if found, err := setMapValue(field, source.values, source.key, layout); found || err != nil {
+				return found, err
+			}
+		}
+		return false, nil
+	}
+
+	if keys.query != "" {
+		if vals := b.query[keys.query]; len(vals) > 0 && vals[0] != "" {
+			return true, setFieldValues(field, vals, layout)
+		}
+	}
+
+	if keys.form != "" {
+		if vals := b.form[keys.form]; len(vals) > 0 && vals[0] != "" {
+			return true, setFieldValues(field, vals, layout)
+		}
+	}
+
+	if keys.header != "" {
+		if vals := b.r.Header.Values(keys.header); len(vals) > 0 && vals[0] != "" {
+			return true, setFieldValues(field, vals, layout)
+		}
+	}
+
+	return false, nil
+}
+
+// setFieldValues sets a field from the values of a key, a slice field taking every value and any other field the first.
+// A pointer field is allocated and its element set. A ParamBinder field is given the first value.
 //
 // Returns: An error if the field cannot be set.
 //
 // Note: This function is not intended to be used directly, use BindRequest instead.
-func bindField(r *http.Request, fieldVal reflect.Value, queryTag string, formTag string) error {
-	if queryTag != "" {
-		if val := r.URL.Query().Get(queryTag); val != "" {
-			return setFieldValue(fieldVal, val)
+func setFieldValues(field reflect.Value, values []string, layout string) error {
+	if pb, ok := asParamBinder(field); ok {
+		if err := pb.BindParam(values[0]); err != nil {
+			return fmt.Errorf("failed to set field value: %w", err)
+		}
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		if !field.CanSet() {
+			return fmt.Errorf("field is not settable")
+		}
+
+		// The pointer is only allocated once a value is sent, so an absent value stays nil.
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValues(ptr.Elem(), values, layout); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.Kind() != reflect.Slice || isTextType(field.Type()) {
+		return setBoundValue(field, values[0], layout)
+	}
+
+	if !field.CanSet() {
+		return fmt.Errorf("field is not settable")
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i := range values {
+		if err := setFieldValues(slice.Index(i), values[i:i+1], layout); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setMapValue sets a map field from the keys under key, such as "meta.env" for the key "meta".
+// The map is replaced, as a single value replaces a field set by the JSON body.
+//
+// Returns: Whether any key was found, and an error if the map cannot be set.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func setMapValue(field reflect.Value, values url.Values, key string, layout string) (bool, error) {
+	var m reflect.Value
+	for name, vals := range values {
+		sub, ok := strings.CutPrefix(name, key+".")
+		if !ok || sub == "" || len(vals) == 0 {
+			continue
+		}
+
+		if !m.IsValid() {
+			if !field.CanSet() {
+				return true, fmt.Errorf("field is not settable")
+			}
+			if field.Type().Key().Kind() != reflect.String {
+				return true, fmt.Errorf("map keys must be strings, got %s", field.Type().Key())
+			}
+			m = reflect.MakeMap(field.Type())
+		}
+
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := setFieldValues(elem, vals, layout); err != nil {
+			return true, err
 		}
+		m.SetMapIndex(reflect.ValueOf(sub).Convert(field.Type().Key()), elem)
+	}
+
+	if !m.IsValid() {
+		return false, nil
+	}
+	field.Set(m)
+	return true, nil
+}
+
+// setBoundValue sets a field from a request value.
+//
+// A time.Time is parsed with the layout, RFC3339 by default, the types shared with the env package, such as
+// time.Duration and url.URL, are parsed as env parses them, and an encoding.TextUnmarshaler parses itself.
+// Any other field is set by setFieldValue.
+//
+// Returns: An error if the field cannot be set, or if the value cannot be parsed.
+//
+// Note: This function is not intended to be used directly, use BindRequest instead.
+func setBoundValue(field reflect.Value, value string, layout string) error {
+	t := field.Type()
+	if !isTextType(t) {
+		return setFieldValue(field, value)
 	}
 
-	if formTag != "" {
-		if val := r.FormValue(formTag); val != "" {
-			return setFieldValue(fieldVal, val)
+	if !field.CanSet() {
+		return fmt.Errorf("field is not settable")
+	}
+
+	var parsed interface{}
+	var err error
+	switch {
+	case t == timeType:
+		if layout == "" {
+			layout = time.RFC3339
 		}
+		parsed, err = time.Parse(layout, value)
+	case parse.Types[t] != nil:
+		parsed, err = parse.Types[t](value)
+	default:
+		err = field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
 	}
 
+	if err != nil {
+		return fmt.Errorf("failed to set field value: %w", err)
+	}
+	if parsed != nil {
+		field.Set(reflect.ValueOf(parsed))
+	}
 	return nil
 }
 
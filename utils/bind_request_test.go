@@ -3,11 +3,19 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type Request struct {
@@ -16,6 +24,7 @@ type Request struct {
 	Int        int     `query:"int" form:"int" json:"int"`
 	Float      float64 `query:"float" form:"float" json:"float"`
 	Bool       bool    `query:"bool" form:"bool" json:"bool"`
+	Tenant     string  `header:"X-Tenant"`
 	unexported string  `query:"unexported" form:"unexported"`
 }
 
@@ -115,6 +124,19 @@ func TestBindRequest(t *testing.T) {
 			}(),
 			expectError: true,
 		},
+		{
+			name: "Header",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/test?field1=value1", nil)
+				req.Header.Set("X-Tenant", "acme")
+				return req
+			}(),
+			expected: Request{
+				Field1: "value1",
+				Tenant: "acme",
+			},
+			expectError: false,
+		},
 		{
 			name:        "Unexported field",
 			request:     httptest.NewRequest(http.MethodGet, "/test?field1=value1&unexported=value", nil),
@@ -157,6 +179,1019 @@ func TestBindRequest(t *testing.T) {
 	}
 }
 
+type bindAddress struct {
+	Line1    string `query:"line1" form:"line1" required:"true"`
+	City     string `query:"city" form:"city"`
+	Postcode string `header:"X-Postcode"`
+}
+
+type bindPaging struct {
+	Page int `query:"page"`
+}
+
+type nestedRequest struct {
+	bindPaging
+	Tags     []string          `query:"tags" form:"tags"`
+	IDs      []int             `query:"ids"`
+	Langs    []string          `header:"Accept-Language"`
+	Meta     map[string]string `query:"meta" form:"meta"`
+	Limits   map[string]int    `query:"limits"`
+	Address  bindAddress       `query:"address" form:"address"`
+	Billing  *bindAddress      `query:"billing"`
+	Shipping *bindAddress      `form:"shipping"`
+	Items    []bindAddress     `query:"items"`
+	Untagged struct {
+		Line1 string `query:"line1"`
+	}
+}
+
+func TestBindRequest_Nested(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		form     string
+		header   http.Header
+		expected nestedRequest
+		err      string
+	}{
+		{
+			name:   "Dotted keys",
+			target: "/test?address.line1=1+Main+St&address.city=Leeds&page=2",
+			header: http.Header{"X-Postcode": {"LS1"}},
+			expected: nestedRequest{
+				bindPaging: bindPaging{Page: 2},
+				Address:    bindAddress{Line1: "1 Main St", City: "Leeds", Postcode: "LS1"},
+			},
+		},
+		{
+			name:     "Bracketed keys",
+			target:   "/test?address[line1]=1+Main+St&billing[line1]=2+High+St&billing[city]=York",
+			expected: nestedRequest{Address: bindAddress{Line1: "1 Main St"}, Billing: &bindAddress{Line1: "2 High St", City: "York"}},
+		},
+		{
+			name:     "Form keys",
+			target:   "/test",
+			form:     "address[line1]=1+Main+St&shipping.line1=3+Low+St&tags[]=a&tags[]=b&meta[env]=prod",
+			expected: nestedRequest{Address: bindAddress{Line1: "1 Main St"}, Shipping: &bindAddress{Line1: "3 Low St"}, Tags: []string{"a", "b"}, Meta: map[string]string{"env": "prod"}},
+		},
+		{
+			name:   "Repeated keys",
+			target: "/test?address.line1=x&tags=a&tags=b&ids=1&ids=2&ids=3",
+			header: http.Header{"Accept-Language": {"en", "fr"}},
+			expected: nestedRequest{
+				Address: bindAddress{Line1: "x"},
+				Tags:    []string{"a", "b"},
+				IDs:     []int{1, 2, 3},
+				Langs:   []string{"en", "fr"},
+			},
+		},
+		{
+			name:   "Maps",
+			target: "/test?address.line1=x&meta[env]=prod&meta.region=eu&limits[cpu]=2&meta=ignored",
+			expected: nestedRequest{
+				Address: bindAddress{Line1: "x"},
+				Meta:    map[string]string{"env": "prod", "region": "eu"},
+				Limits:  map[string]int{"cpu": 2},
+			},
+		},
+		{
+			name:     "Not bound from an untagged source",
+			target:   "/test?address.line1=x&untagged.line1=z&line1=w&items[0][line1]=v",
+			expected: nestedRequest{Address: bindAddress{Line1: "x"}},
+		},
+		{name: "Missing nested required field", target: "/test?address.city=Leeds", err: "address.line1: a value is required"},
+		{name: "Missing required field of a pointer", target: "/test?address.line1=x&billing.city=York", err: "billing.line1: a value is required"},
+		{name: "Invalid slice element", target: "/test?address.line1=x&ids=1&ids=a", err: "failed to set field value"},
+		{name: "Invalid map value", target: "/test?address.line1=x&limits[cpu]=a", err: "failed to set field value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.form != "" {
+				r = httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.form))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+
+			var got nestedRequest
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// bindInner is embedded by pointer, unexported and exported, as its fields are bound at the top level.
+type bindInner struct {
+	Q string `query:"q"`
+}
+
+type bindPage struct {
+	Page int `query:"page"`
+}
+
+type embeddedRequest struct {
+	*bindInner
+	*bindPage
+	Search string `query:"search"`
+}
+
+type exportedEmbedRequest struct {
+	*BindPage
+	Search string `query:"search"`
+}
+
+// BindPage is exported, so an embedded pointer to it can be allocated.
+type BindPage = bindPage
+
+func TestBindRequest_EmbeddedPointers(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/test?q=1&search=a", nil)
+
+	var unexported embeddedRequest
+	if err := BindRequest(r, &unexported); err != nil {
+		t.Fatalf("BindRequest() error = %v, expected unexported embedded pointers to be skipped", err)
+	}
+	if unexported.bindInner != nil || unexported.bindPage != nil || unexported.Search != "a" {
+		t.Errorf("BindRequest() = %+v, expected only Search to be bound", unexported)
+	}
+
+	// An exported embedded pointer is only allocated when a key of its own fields is sent.
+	var exported exportedEmbedRequest
+	if err := BindRequest(r, &exported); err != nil || exported.BindPage != nil {
+		t.Fatalf("BindRequest() = %+v, %v, expected BindPage to stay nil", exported, err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/test?page=2", nil)
+	if err := BindRequest(r, &exported); err != nil || exported.BindPage == nil || exported.Page != 2 {
+		t.Errorf("BindRequest() = %+v, %v, expected Page 2", exported, err)
+	}
+}
+
+func TestBindRequest_NestedErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/test?address.line1=x&ids[1]=2&meta[env]=prod&tags=a&nested.line1=x", nil)
+
+	var (
+		mapKeys struct {
+			IDs map[int]string `query:"ids"`
+		}
+		unexportedMap struct {
+			meta map[string]string `query:"meta"`
+		}
+		unexportedSlice struct {
+			tags []string `query:"tags"`
+		}
+		unexportedPointer struct {
+			nested *bindAddress `query:"nested"`
+		}
+		notStruct string
+	)
+
+	tests := []struct {
+		name string
+		bind func() error
+		err  string
+	}{
+		{"Map with other keys", func() error { return BindRequest(r, &mapKeys) }, "map keys must be strings"},
+		{"Unexported map", func() error { return BindRequest(r, &unexportedMap) }, "not settable"},
+		{"Unexported slice", func() error { return BindRequest(r, &unexportedSlice) }, "not settable"},
+		{"Unexported pointer", func() error { return BindRequest(r, &unexportedPointer) }, "not settable"},
+		{"Not a struct", func() error { return BindRequest(r, &notStruct) }, "expected a struct"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.bind(); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("BindRequest() error = %v, expected %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func BenchmarkBindRequest_Nested(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/test?address[line1]=1+Main+St&address[city]=Leeds&tags=a&tags=b&meta[env]=prod", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dest nestedRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// bindLevel is bound through encoding.TextUnmarshaler.
+type bindLevel int
+
+func (l *bindLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "error":
+		*l = 1
+	default:
+		return errors.New("unknown level " + string(text))
+	}
+	return nil
+}
+
+type textRequest struct {
+	Since   time.Time                `query:"since"`
+	Day     time.Time                `query:"day" layout:"2006-01-02"`
+	Timeout time.Duration            `query:"timeout" header:"X-Timeout"`
+	Level   bindLevel                `query:"level"`
+	Levels  []bindLevel              `query:"levels"`
+	Days    []time.Time              `query:"days" layout:"2006-01-02"`
+	IP      net.IP                   `query:"ip"`
+	Webhook url.URL                  `form:"webhook"`
+	Windows map[string]time.Duration `query:"windows"`
+}
+
+func TestBindRequest_TextTypes(t *testing.T) {
+	since := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		expected textRequest
+		err      string
+	}{
+		{
+			name:   "Parsed values",
+			target: "/test?since=2024-05-01T09:30:00Z&day=2024-05-01&timeout=1m30s&level=error&ip=10.0.0.1&webhook=https://example.com/hook",
+			expected: textRequest{
+				Since:   since,
+				Day:     day,
+				Timeout: 90 * time.Second,
+				Level:   1,
+				IP:      net.ParseIP("10.0.0.1"),
+				Webhook: url.URL{Scheme: "https", Host: "example.com", Path: "/hook"},
+			},
+		},
+		{
+			name:     "Header",
+			target:   "/test",
+			header:   http.Header{"X-Timeout": {"5s"}},
+			expected: textRequest{Timeout: 5 * time.Second},
+		},
+		{
+			name:     "Slices and maps",
+			target:   "/test?levels=debug&levels=error&days=2024-05-01&days=2024-05-02&windows[read]=1s",
+			expected: textRequest{Levels: []bindLevel{0, 1}, Days: []time.Time{day, day.AddDate(0, 0, 1)}, Windows: map[string]time.Duration{"read": time.Second}},
+		},
+		{name: "Invalid time", target: "/test?since=2024-05-01", err: "failed to set field value"},
+		{name: "Invalid layout", target: "/test?day=2024-05-01T09:30:00Z", err: "failed to set field value"},
+		{name: "Duration in days", target: "/test?timeout=1d", err: "use '24h' instead of '1d'"},
+		{name: "Invalid text", target: "/test?level=trace", err: "unknown level trace"},
+		{name: "Invalid IP", target: "/test?ip=10.0.0", err: "invalid IP address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+
+			var got textRequest
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_UnexportedTextType(t *testing.T) {
+	var dest struct {
+		since time.Time `query:"since"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/test?since=2024-05-01T09:30:00Z", nil)
+	if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "not settable") {
+		t.Errorf("BindRequest() error = %v, expected the field to not be settable", err)
+	}
+}
+
+func BenchmarkBindRequest_TextTypes(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/test?since=2024-05-01T09:30:00Z&timeout=1m30s&level=error", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dest textRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type patchRequest struct {
+	Name    *string         `query:"name" form:"name"`
+	Count   *int            `query:"count" required:"true"`
+	Active  *bool           `query:"active" header:"X-Active"`
+	Since   *time.Time      `query:"since"`
+	Timeout *time.Duration  `query:"timeout"`
+	Level   *bindLevel      `query:"level"`
+	IDs     []*int          `query:"ids"`
+	Tags    *[]string       `query:"tags"`
+	Limits  map[string]*int `query:"limits"`
+}
+
+func TestBindRequest_Pointers(t *testing.T) {
+	since := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		expected patchRequest
+		err      string
+	}{
+		{
+			name:     "Absent values",
+			target:   "/test?count=0",
+			expected: patchRequest{Count: Ptr(0)},
+		},
+		{
+			name:   "Zero values",
+			target: "/test?count=0&name=&active=false",
+			expected: patchRequest{
+				Count:  Ptr(0),
+				Active: Ptr(false),
+			},
+		},
+		{
+			name:   "Sent values",
+			target: "/test?count=2&name=a&since=2024-05-01T09:30:00Z&timeout=5s&level=error&ids=1&ids=2&tags=a&limits[cpu]=2",
+			header: http.Header{"X-Active": {"true"}},
+			expected: patchRequest{
+				Name:    Ptr("a"),
+				Count:   Ptr(2),
+				Active:  Ptr(true),
+				Since:   &since,
+				Timeout: Ptr(5 * time.Second),
+				Level:   Ptr(bindLevel(1)),
+				IDs:     []*int{Ptr(1), Ptr(2)},
+				Tags:    &[]string{"a"},
+				Limits:  map[string]*int{"cpu": Ptr(2)},
+			},
+		},
+		{name: "Missing required pointer", target: "/test?name=a", err: "count: a value is required"},
+		{name: "Invalid value", target: "/test?count=a", err: "failed to set field value"},
+		{name: "Invalid slice element", target: "/test?count=1&ids=1&ids=a", err: "failed to set field value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+
+			var got patchRequest
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_UnexportedPointer(t *testing.T) {
+	var dest struct {
+		count *int `query:"count"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/test?count=1", nil)
+	if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "not settable") {
+		t.Errorf("BindRequest() error = %v, expected the field to not be settable", err)
+	}
+}
+
+func BenchmarkBindRequest_Pointers(b *testing.B) {
+	r := httptest.NewRequest(http.MethodPatch, "/test?count=2&name=a&active=true", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dest patchRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type defaultRequest struct {
+	Page    int           `query:"page" default:"1"`
+	Limit   int           `query:"limit" form:"limit" json:"limit" default:"25" required:"true"`
+	Sort    *string       `query:"sort" default:"name"`
+	Fields  []string      `query:"fields" default:"id,name"`
+	Timeout time.Duration `header:"X-Timeout" default:"5s"`
+	Since   time.Time     `query:"since" layout:"2006-01-02" default:"2024-01-01"`
+	Search  string        `query:"search"`
+	Active  bool          `query:"active" json:"active" default:"true"`
+}
+
+func TestBindRequest_Default(t *testing.T) {
+	defaults := defaultRequest{
+		Page:    1,
+		Limit:   25,
+		Sort:    Ptr("name"),
+		Fields:  []string{"id", "name"},
+		Timeout: 5 * time.Second,
+		Since:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Active:  true,
+	}
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		expected func(d defaultRequest) defaultRequest
+	}{
+		{
+			name:     "Absent values",
+			request:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/test", nil) },
+			expected: func(d defaultRequest) defaultRequest { return d },
+		},
+		{
+			name: "Sent values",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/test?page=3&limit=10&sort=age&fields=id&since=2024-05-01&search=a", nil)
+				r.Header.Set("X-Timeout", "1s")
+				return r
+			},
+			expected: func(defaultRequest) defaultRequest {
+				return defaultRequest{
+					Page:    3,
+					Limit:   10,
+					Sort:    Ptr("age"),
+					Fields:  []string{"id"},
+					Timeout: time.Second,
+					Since:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+					Search:  "a",
+					Active:  true,
+				}
+			},
+		},
+		{
+			name: "JSON value",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"limit":50}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			},
+			expected: func(d defaultRequest) defaultRequest {
+				d.Limit = 50
+				return d
+			},
+		},
+		{
+			name: "Zero and empty values",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/test?page=0&active=false&limit=&sort=", nil)
+			},
+			expected: func(d defaultRequest) defaultRequest {
+				d.Page, d.Active = 0, false
+				return d
+			},
+		},
+		{
+			name: "JSON zero value",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"active":false}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			},
+			expected: func(d defaultRequest) defaultRequest {
+				d.Active = false
+				return d
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got defaultRequest
+			if err := BindRequest(tt.request(), &got); err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if expected := tt.expected(defaults); !reflect.DeepEqual(got, expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_InvalidDefault(t *testing.T) {
+	var dest struct {
+		Limit int `query:"limit" default:"many"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "invalid default of field Limit") {
+		t.Errorf("BindRequest() error = %v, expected an invalid default", err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/test?limit=5", nil)
+	if err := BindRequest(r, &dest); err != nil || dest.Limit != 5 {
+		t.Errorf("BindRequest() = %d, %v, expected a sent value to not use the default", dest.Limit, err)
+	}
+}
+
+// bindMoney binds itself from the "amount" and "currency" query parameters.
+type bindMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func (m *bindMoney) BindRequest(r *http.Request) error {
+	if r.URL.Query().Get("amount") == "" {
+		return nil
+	}
+
+	cents, err := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+	m.Cents, m.Currency = cents, r.URL.Query().Get("currency")
+	return err
+}
+
+// bindStatus parses its own value, as an enum.
+type bindStatus string
+
+func (s *bindStatus) BindParam(value string) error {
+	switch value {
+	case "active", "archived":
+		*s = bindStatus(value)
+		return nil
+	}
+	return errors.New("unknown status " + value)
+}
+
+// bindCode is a struct parsing its own value, rather than being bound as a nested struct.
+type bindCode struct {
+	Prefix, Number string
+}
+
+func (c *bindCode) BindParam(value string) error {
+	c.Prefix, c.Number, _ = strings.Cut(value, "-")
+	return nil
+}
+
+type customRequest struct {
+	Price    bindMoney `required:"true"`
+	Discount *bindMoney
+	Status   bindStatus   `query:"status" default:"active"`
+	Statuses []bindStatus `query:"statuses"`
+	Previous *bindStatus  `header:"X-Previous"`
+	Code     bindCode     `query:"code"`
+	Flags    bindFlags    `query:"flags"`
+	internal bindMoney
+}
+
+// bindFlags is a slice given the whole value, rather than one value per element.
+type bindFlags []string
+
+func (f *bindFlags) BindParam(value string) error {
+	*f = strings.Split(value, "|")
+	return nil
+}
+
+// bindSelf binds the whole request itself, so no fields are bound by reflection.
+type bindSelf struct {
+	Tenant string `query:"tenant" required:"true"`
+	Path   string
+}
+
+func (s *bindSelf) BindRequest(r *http.Request) error {
+	s.Path = r.URL.Path
+	return nil
+}
+
+func TestBindRequest_Binder(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		expected customRequest
+		err      string
+	}{
+		{
+			name:   "Custom binding",
+			target: "/test?amount=1250&currency=GBP&status=archived&statuses=active&statuses=archived&code=AB-12&flags=a|b",
+			header: http.Header{"X-Previous": {"active"}},
+			expected: customRequest{
+				Price:    bindMoney{Cents: 1250, Currency: "GBP"},
+				Discount: &bindMoney{Cents: 1250, Currency: "GBP"},
+				Status:   "archived",
+				Statuses: []bindStatus{"active", "archived"},
+				Previous: Ptr(bindStatus("active")),
+				Code:     bindCode{Prefix: "AB", Number: "12"},
+				Flags:    bindFlags{"a", "b"},
+			},
+		},
+		{
+			name:   "Default",
+			target: "/test?amount=1",
+			expected: customRequest{
+				Price:    bindMoney{Cents: 1},
+				Discount: &bindMoney{Cents: 1},
+				Status:   "active",
+			},
+		},
+		{name: "Missing required binder", target: "/test", err: "Price: a value is required"},
+		{name: "Binder error", target: "/test?amount=a", err: "invalid syntax"},
+		{name: "ParamBinder error", target: "/test?amount=1&status=deleted", err: "unknown status deleted"},
+		{name: "ParamBinder element error", target: "/test?amount=1&statuses=active&statuses=deleted", err: "unknown status deleted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+
+			var got customRequest
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_DestinationBinder(t *testing.T) {
+	var got bindSelf
+	if err := BindRequest(httptest.NewRequest(http.MethodGet, "/orders", nil), &got); err != nil {
+		t.Fatalf("BindRequest() error = %v", err)
+	}
+
+	if expected := (bindSelf{Path: "/orders"}); got != expected {
+		t.Errorf("BindRequest() = %+v, expected %+v", got, expected)
+	}
+}
+
+func BenchmarkBindRequest_Binder(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/test?amount=1250&currency=GBP&status=archived&statuses=active", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dest customRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type xmlRequest struct {
+	XMLName xml.Name `xml:"order"`
+	ID      string   `xml:"id,attr"`
+	Items   []string `xml:"item"`
+	Tenant  string   `query:"tenant" xml:"-"`
+}
+
+func TestBindRequest_XML(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    xmlRequest
+		err         string
+	}{
+		{
+			name:        "Application XML",
+			contentType: "application/xml",
+			body:        `<order id="1"><item>a</item><item>b</item></order>`,
+			expected:    xmlRequest{XMLName: xml.Name{Local: "order"}, ID: "1", Items: []string{"a", "b"}, Tenant: "acme"},
+		},
+		{
+			name:        "Text XML with a charset",
+			contentType: "text/xml; charset=utf-8",
+			body:        `<order id="2"></order>`,
+			expected:    xmlRequest{XMLName: xml.Name{Local: "order"}, ID: "2", Tenant: "acme"},
+		},
+		{name: "Invalid XML", contentType: "application/xml", body: `<order`, err: "failed to decode xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/test?tenant=acme", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			var got xmlRequest
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+type ndjsonEvent struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+func TestBindRequest_NDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		existing []ndjsonEvent
+		expected []ndjsonEvent
+		err      string
+	}{
+		{
+			name:     "Values",
+			body:     "{\"type\":\"click\",\"count\":1}\n\n{\"type\":\"view\",\"count\":2}\n",
+			expected: []ndjsonEvent{{Type: "click", Count: 1}, {Type: "view", Count: 2}},
+		},
+		{
+			name:     "Replaces existing elements",
+			body:     "{\"type\":\"view\"}",
+			existing: []ndjsonEvent{{Type: "old"}},
+			expected: []ndjsonEvent{{Type: "view"}},
+		},
+		{name: "Empty body", expected: []ndjsonEvent{}},
+		{name: "Invalid value", body: "{\"type\":\"click\"}\n{\"type\":", err: "failed to decode ndjson value 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/x-ndjson")
+
+			got := tt.existing
+			if got == nil {
+				got = []ndjsonEvent{}
+			}
+			err := BindRequest(r, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequest() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequest() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("BindRequest() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_NDJSONIntoStruct(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"field1":"a"}`))
+	r.Header.Set("Content-Type", "application/x-ndjson")
+
+	var dest Request
+	if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "must be decoded into a slice") {
+		t.Errorf("BindRequest() error = %v, expected a slice to be required", err)
+	}
+}
+
+func BenchmarkBindRequest_NDJSON(b *testing.B) {
+	body := strings.Repeat("{\"type\":\"click\",\"count\":1}\n", 100)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-ndjson")
+
+		var dest []ndjsonEvent
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type uploadRequest struct {
+	Title    string                  `form:"title" required:"true"`
+	Avatar   *multipart.FileHeader   `form:"avatar" required:"true"`
+	Photos   []*multipart.FileHeader `form:"photos"`
+	Untagged *multipart.FileHeader
+}
+
+// multipartRequest builds a multipart/form-data request from text fields and files, named by their field.
+func multipartRequest(t testing.TB, fields map[string]string, files map[string][]string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, contents := range files {
+		for i, content := range contents {
+			part, err := w.CreateFormFile(name, name+strconv.Itoa(i)+".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = io.WriteString(part, content); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestBindRequest_Multipart(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    map[string]string
+		files     map[string][]string
+		opts      BindOptions
+		avatar    string
+		photos    []string
+		err       string
+		malformed bool
+	}{
+		{
+			name:   "Text parts and a file",
+			fields: map[string]string{"title": "Holiday"},
+			files:  map[string][]string{"avatar": {"me"}},
+			avatar: "me",
+		},
+		{
+			name:   "Several files",
+			fields: map[string]string{"title": "Holiday"},
+			files:  map[string][]string{"avatar": {"first", "second"}, "photos": {"beach", "hills"}},
+			avatar: "first",
+			photos: []string{"beach", "hills"},
+		},
+		{
+			name:   "Files beyond the memory limit",
+			fields: map[string]string{"title": "Holiday"},
+			files:  map[string][]string{"avatar": {strings.Repeat("a", 1024)}},
+			opts:   BindOptions{MaxMemory: 1},
+			avatar: strings.Repeat("a", 1024),
+		},
+		{
+			name:   "Missing required file",
+			fields: map[string]string{"title": "Holiday"},
+			err:    "avatar: a value is required",
+		},
+		{
+			name:  "Missing required text part",
+			files: map[string][]string{"avatar": {"me"}},
+			err:   "title: a value is required",
+		},
+		{name: "Malformed body", malformed: true, err: "failed to parse multipart form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := multipartRequest(t, tt.fields, tt.files)
+			if tt.malformed {
+				r.Body = io.NopCloser(strings.NewReader("not multipart"))
+			}
+			defer func() {
+				if r.MultipartForm != nil {
+					_ = r.MultipartForm.RemoveAll()
+				}
+			}()
+
+			var got uploadRequest
+			err := BindRequestWithOptions(r, &got, tt.opts)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("BindRequestWithOptions() error = %v, expected %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindRequestWithOptions() error = %v", err)
+			}
+
+			if got.Title != tt.fields["title"] {
+				t.Errorf("BindRequestWithOptions() Title = %q, expected %q", got.Title, tt.fields["title"])
+			}
+			if content := readFileHeader(t, got.Avatar); content != tt.avatar {
+				t.Errorf("BindRequestWithOptions() Avatar = %q, expected %q", content, tt.avatar)
+			}
+			var photos []string
+			for _, photo := range got.Photos {
+				photos = append(photos, readFileHeader(t, photo))
+			}
+			if !reflect.DeepEqual(photos, tt.photos) {
+				t.Errorf("BindRequestWithOptions() Photos = %q, expected %q", photos, tt.photos)
+			}
+			if got.Untagged != nil {
+				t.Errorf("BindRequestWithOptions() Untagged = %v, expected nil", got.Untagged)
+			}
+		})
+	}
+}
+
+func TestBindRequest_MultipartUnexported(t *testing.T) {
+	var dest struct {
+		avatar *multipart.FileHeader `form:"avatar"`
+	}
+
+	r := multipartRequest(t, nil, map[string][]string{"avatar": {"me"}})
+	if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "not settable") {
+		t.Errorf("BindRequest() error = %v, expected the field to not be settable", err)
+	}
+}
+
+// readFileHeader reads an uploaded file.
+func readFileHeader(t *testing.T, fh *multipart.FileHeader) string {
+	t.Helper()
+
+	f, err := fh.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func BenchmarkBindRequest_Multipart(b *testing.B) {
+	fields := map[string]string{"title": "Holiday"}
+	files := map[string][]string{"avatar": {"me"}, "photos": {"beach", "hills"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		r := multipartRequest(b, fields, files)
+		b.StartTimer()
+
+		var dest uploadRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestSetFieldValue(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cloudment/utils-go/internal/validate"
+)
+
+// FieldViolation is a field of a request that breaks a rule of its `validate` tag.
+type FieldViolation struct {
+	// Field is the name of the field as sent, such as "limit" or "address.city".
+	Field string `json:"field"`
+	// Rule is the rule that was broken, such as "max=100".
+	Rule string `json:"rule"`
+	// Message describes why the value breaks the rule, such as "120 is greater than the maximum of 100".
+	Message string `json:"message"`
+	// Path is the path to the struct field, such as "Address.City". It is not sent to clients.
+	Path string `json:"-"`
+}
+
+// ValidationError is returned by BindRequest when fields break the rules of their `validate` tags.
+//
+// It holds every violation rather than the first, and marshals to JSON for a 422 Unprocessable Entity response.
+//
+// Example:
+//
+//	var verr *ValidationError
+//	if errors.As(err, &verr) {
+//	 w.Header().Set("Content-Type", "application/json")
+//	 w.WriteHeader(http.StatusUnprocessableEntity)
+//	 _ = json.NewEncoder(w).Encode(verr)
+//	}
+//
+// Writes: {"violations":[{"field":"limit","rule":"max=100","message":"120 is greater than the maximum of 100"}]}
+type ValidationError struct {
+	// Violations holds each broken rule, in field order.
+	Violations []FieldViolation `json:"violations"`
+}
+
+// Error returns each violation, such as "limit: 120 is greater than the maximum of 100; email: ...".
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Field + ": " + v.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validateField checks a bound field against the rules of its `validate` tag, such as "min=1,max=100,email".
+//
+// The rules are those of the env package's envValidate tag: min, max, len, oneof, regex and the formats
+// "email", "url", "uuid", "e164", "hostname" and "semver".
+//
+// Parameters:
+//   - v: The field, once it has been bound.
+//   - tag: The `validate` tag.
+//   - field: The name of the field as sent.
+//   - path: The path to the struct field.
+//
+// Returns: A FieldViolation for each broken rule, or an error if the tag is invalid.
+func validateField(v reflect.Value, tag, field, path string) ([]FieldViolation, error) {
+	rules, err := validate.ParseRules(tag, v.Type())
+	if err != nil {
+		return nil, fmt.Errorf("invalid validate tag of field %s: %w", path, err)
+	}
+
+	var violations []FieldViolation
+	for _, rule := range rules {
+		if msg := rule.Check(v); msg != "" {
+			violations = append(violations, FieldViolation{Field: field, Rule: rule.String(), Message: msg, Path: path})
+		}
+	}
+	return violations, nil
+}
+
+// violationField is the name of a field as sent, the first of its query, form and header keys or its JSON name,
+// falling back to the path of the struct field.
+func violationField(sf reflect.StructField, keys bindKeys, path string) string {
+	for _, key := range []string{keys.query, keys.form, keys.header, jsonFieldName(sf)} {
+		if key != "" {
+			return key
+		}
+	}
+	return path
+}
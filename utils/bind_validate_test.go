@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City     string `query:"city" validate:"min=2"`
+	Postcode string `query:"postcode"`
+}
+
+type validateRequest struct {
+	Limit   int             `query:"limit" default:"25" validate:"min=1,max=100"`
+	Email   string          `form:"email" json:"email" validate:"email"`
+	Sort    *string         `query:"sort" validate:"oneof=asc desc"`
+	Tags    []string        `query:"tags" validate:"max=2,min=2"`
+	Tenant  string          `header:"X-Tenant" validate:"uuid"`
+	Address validateAddress `query:"address"`
+	Note    string          `validate:"max=3"`
+	Offset  int             `query:"offset" validate:"max=-1"`
+}
+
+func TestBindRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		expected []FieldViolation
+	}{
+		{
+			name: "Valid",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/test?limit=10&sort=asc&tags=ab&tags=cd&address.city=Leeds", nil)
+				r.Header.Set("X-Tenant", "123e4567-e89b-12d3-a456-426614174000")
+				return r
+			},
+		},
+		{
+			name:    "Absent values",
+			request: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/test", nil) },
+		},
+		{
+			name:    "Zero values",
+			request: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/test?limit=0&offset=0", nil) },
+			expected: []FieldViolation{
+				{Field: "limit", Rule: "min=1", Message: "0 is less than the minimum of 1", Path: "Limit"},
+				{Field: "offset", Rule: "max=-1", Message: "0 is greater than the maximum of -1", Path: "Offset"},
+			},
+		},
+		{
+			name: "Every field",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/test?limit=120&sort=up&tags=a&address[city]=L", strings.NewReader(`{"email":"user"}`))
+				r.Header.Set("Content-Type", "application/json")
+				r.Header.Set("X-Tenant", "acme")
+				return r
+			},
+			expected: []FieldViolation{
+				{Field: "limit", Rule: "max=100", Message: "120 is greater than the maximum of 100", Path: "Limit"},
+				{Field: "email", Rule: "email", Message: `"user" is not a valid email address`, Path: "Email"},
+				{Field: "sort", Rule: "oneof=asc desc", Message: `"up" is not one of asc, desc`, Path: "Sort"},
+				{Field: "tags", Rule: "min=2", Message: "length 1 is less than the minimum of 2", Path: "Tags"},
+				{Field: "X-Tenant", Rule: "uuid", Message: `"acme" is not a valid UUID`, Path: "Tenant"},
+				{Field: "address.city", Rule: "min=2", Message: "length 1 is less than the minimum of 2", Path: "Address.City"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got validateRequest
+			err := BindRequest(tt.request(), &got)
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatalf("BindRequest() error = %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("BindRequest() error = %v, expected a *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Violations, tt.expected) {
+				t.Errorf("BindRequest() violations = %+v, expected %+v", verr.Violations, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRequest_ValidateRequired(t *testing.T) {
+	var dest struct {
+		Name  string `query:"name" required:"true" validate:"min=2"`
+		Limit int    `query:"limit" validate:"max=100"`
+		Email string `query:"email" validate:"email"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/test?limit=500&email=bad", nil)
+	var verr *ValidationError
+	if err := BindRequest(r, &dest); !errors.As(err, &verr) {
+		t.Fatalf("BindRequest() error = %v, expected a *ValidationError", err)
+	}
+
+	expected := []FieldViolation{
+		{Field: "name", Rule: "required", Message: "a value is required", Path: "Name"},
+		{Field: "limit", Rule: "max=100", Message: "500 is greater than the maximum of 100", Path: "Limit"},
+		{Field: "email", Rule: "email", Message: `"bad" is not a valid email address`, Path: "Email"},
+	}
+	if !reflect.DeepEqual(verr.Violations, expected) {
+		t.Errorf("BindRequest() violations = %+v, expected %+v", verr.Violations, expected)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Violations: []FieldViolation{
+		{Field: "limit", Rule: "max=100", Message: "120 is greater than the maximum of 100", Path: "Limit"},
+		{Field: "email", Rule: "email", Message: `"user" is not a valid email address`, Path: "Email"},
+	}}
+
+	expected := `limit: 120 is greater than the maximum of 100; email: "user" is not a valid email address`
+	if got := err.Error(); got != expected {
+		t.Errorf("Error() = %s, expected %s", got, expected)
+	}
+
+	out, _ := json.Marshal(err)
+	expected = `{"violations":[{"field":"limit","rule":"max=100","message":"120 is greater than the maximum of 100"},` +
+		`{"field":"email","rule":"email","message":"\"user\" is not a valid email address"}]}`
+	if string(out) != expected {
+		t.Errorf("json.Marshal() = %s, expected %s", out, expected)
+	}
+}
+
+func TestBindRequest_InvalidValidateTag(t *testing.T) {
+	var dest struct {
+		Name string `query:"name" validate:"min=a"`
+	}
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/test?name=a", nil)
+		if err := BindRequest(r, &dest); err == nil || !strings.Contains(err.Error(), "invalid validate tag of field Name") {
+			t.Errorf("BindRequest() error = %v, expected an invalid tag", err)
+		}
+	}
+}
+
+func BenchmarkBindRequest_Validate(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/test?limit=10&sort=asc&tags=ab&tags=cd&address.city=Leeds", nil)
+	r.Header.Set("X-Tenant", "123e4567-e89b-12d3-a456-426614174000")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dest validateRequest
+		if err := BindRequest(r, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
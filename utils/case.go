@@ -0,0 +1,77 @@
+package utils
+
+import "github.com/cloudment/utils-go/internal/strcase"
+
+// ToSnakeCase converts an identifier to snake_case.
+//
+// Words are split on separators, case changes and acronym boundaries.
+//
+// Parameters:
+//   - s: The identifier to convert, in any case.
+//
+// Returns: The snake_case identifier.
+//
+// Usage:
+//
+//	ToSnakeCase("HTTPServerURL") // -> "http_server_url"
+//	ToSnakeCase("userID")        // -> "user_id"
+func ToSnakeCase(s string) string {
+	return strcase.ToSnakeCase(s)
+}
+
+// ToKebabCase converts an identifier to kebab-case.
+//
+// Parameters:
+//   - s: The identifier to convert, in any case.
+//
+// Returns: The kebab-case identifier.
+//
+// Usage:
+//
+//	ToKebabCase("HTTPServerURL") // -> "http-server-url"
+func ToKebabCase(s string) string {
+	return strcase.ToKebabCase(s)
+}
+
+// ToScreamingSnake converts an identifier to SCREAMING_SNAKE_CASE, as used for environment variables.
+//
+// Parameters:
+//   - s: The identifier to convert, in any case.
+//
+// Returns: The SCREAMING_SNAKE_CASE identifier.
+//
+// Usage:
+//
+//	ToScreamingSnake("databaseURL") // -> "DATABASE_URL"
+func ToScreamingSnake(s string) string {
+	return strcase.ToScreamingSnake(s)
+}
+
+// ToCamelCase converts an identifier to camelCase, keeping known acronyms such as ID, URL and HTTP upper-case.
+//
+// Parameters:
+//   - s: The identifier to convert, in any case.
+//
+// Returns: The camelCase identifier.
+//
+// Usage:
+//
+//	ToCamelCase("user_id")         // -> "userID"
+//	ToCamelCase("http-server-url") // -> "httpServerURL"
+func ToCamelCase(s string) string {
+	return strcase.ToCamelCase(s)
+}
+
+// ToPascalCase converts an identifier to PascalCase, keeping known acronyms such as ID, URL and HTTP upper-case.
+//
+// Parameters:
+//   - s: The identifier to convert, in any case.
+//
+// Returns: The PascalCase identifier.
+//
+// Usage:
+//
+//	ToPascalCase("user_id") // -> "UserID"
+func ToPascalCase(s string) string {
+	return strcase.ToPascalCase(s)
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+// The conversions are tested thoroughly within internal/strcase, these ensure the wrappers are wired up.
+func TestCaseConversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(string) string
+		input    string
+		expected string
+	}{
+		{"ToSnakeCase", ToSnakeCase, "HTTPServerURL", "http_server_url"},
+		{"ToKebabCase", ToKebabCase, "HTTPServerURL", "http-server-url"},
+		{"ToScreamingSnake", ToScreamingSnake, "databaseURL", "DATABASE_URL"},
+		{"ToCamelCase", ToCamelCase, "user_id", "userID"},
+		{"ToPascalCase", ToPascalCase, "user_id", "UserID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.input); got != tt.expected {
+				t.Errorf("%s(%q) = %q, expected %q", tt.name, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that made the request.
+//
+// It checks X-Forwarded-For (the first, original client), then X-Real-IP, before falling back to RemoteAddr.
+//
+// Parameters:
+//   - r: The HTTP request.
+//
+// Returns: The client IP address, or an empty string if it cannot be determined.
+//
+// Usage:
+//
+//	ip := ClientIP(r) // -> "203.0.113.7"
+//
+// Note: The forwarding headers can be set by anyone, only rely on them when running behind a proxy
+// that overwrites them. Use RemoteIP when there is no trusted proxy.
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		// The header is a list "client, proxy1, proxy2", only the client is wanted.
+		if i := strings.IndexByte(forwarded, ','); i != -1 {
+			forwarded = forwarded[:i]
+		}
+		if ip := strings.TrimSpace(forwarded); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+
+	return RemoteIP(r)
+}
+
+// RemoteIP returns the IP address of the direct peer of the request, ignoring any forwarding headers.
+//
+// Parameters:
+//   - r: The HTTP request.
+//
+// Returns: The IP address taken from RemoteAddr, without the port.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RemoteAddr may not contain a port, such as when set manually.
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "RemoteAddr only",
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "RemoteAddr without port",
+			remoteAddr: "192.0.2.1",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "X-Forwarded-For with proxies",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.2"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "2001:db8::1"},
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "Invalid forwarded headers",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "not-an-ip", "X-Real-IP": "nope"},
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			if got := ClientIP(req); got != tt.expected {
+				t.Errorf("ClientIP() = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkClientIP(b *testing.B) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+
+	for i := 0; i < b.N; i++ {
+		ClientIP(req)
+	}
+}
@@ -0,0 +1,411 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressWriter is a compressing writer that can be reused through Reset, such as *gzip.Writer.
+type CompressWriter interface {
+	io.WriteCloser
+	// Reset discards any state and switches the output to w, allowing the writer to be pooled.
+	Reset(w io.Writer)
+	// Flush writes any pending compressed data.
+	Flush() error
+}
+
+// Encoder describes a content encoding that the Compress middleware can negotiate.
+//
+// This allows encodings outside the standard library, such as zstd, to be plugged in:
+//
+//	Encoder{Name: "zstd", NewWriter: func(w io.Writer) (CompressWriter, error) {
+//		return zstd.NewWriter(w)
+//	}}
+type Encoder struct {
+	// Name is the Content-Encoding token, such as "gzip" or "zstd".
+	Name string
+	// NewWriter creates a writer for the encoding, writers are pooled and reused through Reset.
+	NewWriter func(w io.Writer) (CompressWriter, error)
+}
+
+// CompressionOptions contains the options for the Compress middleware.
+type CompressionOptions struct {
+	// Level is the gzip compression level, from gzip.HuffmanOnly to gzip.BestCompression,
+	// defaults to gzip.DefaultCompression.
+	Level int
+	// MinSize is the minimum response size in bytes to compress, defaults to 1024.
+	//
+	// Small responses can become larger when compressed, and cost CPU for little gain.
+	MinSize int
+	// ContentTypes are the media types to compress, a trailing "/*" matches any subtype such as "text/*".
+	//
+	// Defaults to text, JSON, JavaScript, XML and SVG.
+	ContentTypes []string
+	// Encoders are additional encodings, preferred over gzip in the order given when the client accepts them.
+	Encoders []Encoder
+}
+
+// defaultCompressContentTypes are the media types compressed when CompressionOptions.ContentTypes is empty.
+var defaultCompressContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-ndjson",
+	"image/svg+xml",
+}
+
+// compressor is the prepared form of CompressionOptions, with a pool per encoding.
+type compressor struct {
+	minSize      int
+	contentTypes map[string]bool
+	typePrefixes []string
+	encoders     []Encoder
+	pools        map[string]*sync.Pool
+}
+
+// Compress returns a middleware that compresses responses based on the request's Accept-Encoding.
+//
+// Responses are only compressed when they reach the minimum size, have an allowed content type,
+// and are not already encoded.
+//
+// Parameters:
+//   - opts: The CompressionOptions to use.
+//
+// Returns: A function that wraps a handler with compression.
+//
+// Panics if Level is invalid, or an Encoder cannot create a writer, as it is a mistake within the options found on
+// startup rather than on the first compressed response.
+//
+// Example:
+//
+//	compress := Compress(CompressionOptions{MinSize: 512})
+//	http.ListenAndServe(":8080", compress(mux))
+func Compress(opts CompressionOptions) func(http.Handler) http.Handler {
+	c, err := newCompressor(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoder := c.negotiate(r.Header.Get("Accept-Encoding"))
+			if encoder == nil || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{ResponseWriter: w, c: c, encoder: encoder}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// newCompressor normalises the options, applying defaults.
+//
+// A writer is created for each encoder up front, so invalid options are reported here rather than disabling
+// compression on every response.
+//
+// Returns: The prepared options, or an error if a writer cannot be created, such as for an invalid gzip level.
+func newCompressor(opts CompressionOptions) (*compressor, error) {
+	c := &compressor{
+		minSize:      opts.MinSize,
+		contentTypes: make(map[string]bool),
+		pools:        make(map[string]*sync.Pool),
+	}
+
+	if c.minSize <= 0 {
+		c.minSize = 1024
+	}
+
+	types := opts.ContentTypes
+	if len(types) == 0 {
+		types = defaultCompressContentTypes
+	}
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if strings.HasSuffix(t, "/*") {
+			c.typePrefixes = append(c.typePrefixes, t[:len(t)-1])
+		} else {
+			c.contentTypes[t] = true
+		}
+	}
+
+	level := opts.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	c.encoders = append(c.encoders, opts.Encoders...)
+	c.encoders = append(c.encoders, Encoder{
+		Name: "gzip",
+		NewWriter: func(w io.Writer) (CompressWriter, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+	})
+
+	for _, e := range c.encoders {
+		first, err := e.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("compress: invalid %s encoder: %w", e.Name, err)
+		}
+
+		newWriter := e.NewWriter
+		c.pools[e.Name] = &sync.Pool{New: func() interface{} {
+			// The options were checked by the first writer, a later error is returned as a nil writer,
+			// which leaves the response uncompressed.
+			w, err := newWriter(nil)
+			if err != nil {
+				return nil
+			}
+			return w
+		}}
+		c.pools[e.Name].Put(first)
+	}
+
+	return c, nil
+}
+
+// negotiate picks the first encoder, in preference order, that the client accepts.
+//
+// Parameters:
+//   - acceptEncoding: The Accept-Encoding header of the request.
+//
+// Returns: The chosen Encoder, or nil if none are accepted.
+func (c *compressor) negotiate(acceptEncoding string) *Encoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	accepted := parseAcceptEncoding(acceptEncoding)
+	for i := range c.encoders {
+		q, ok := accepted[c.encoders[i].Name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return &c.encoders[i]
+		}
+	}
+
+	return nil
+}
+
+// parseAcceptEncoding parses an Accept-Encoding header into a map of encoding to quality.
+//
+// Parameters:
+//   - header: The Accept-Encoding header, such as "gzip;q=0.8, zstd".
+//
+// Returns: A map of the lower-cased encoding to its quality value.
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if key, val, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+				q = parsed
+			}
+		}
+
+		accepted[name] = q
+	}
+
+	return accepted
+}
+
+// isCompressible checks the content type against the allow-list.
+//
+// Parameters:
+//   - contentType: The Content-Type header, parameters such as charset are ignored.
+//
+// Returns: True if the content type may be compressed, false otherwise.
+func (c *compressor) isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if c.contentTypes[mediaType] {
+		return true
+	}
+
+	for _, prefix := range c.typePrefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressResponseWriter buffers the start of the response until it knows whether to compress it.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	c       *compressor
+	encoder *Encoder
+	writer  CompressWriter
+
+	status      int
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+}
+
+// WriteHeader records the status code, it is sent once the compression decision is made.
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+// Write buffers data until MinSize is reached, then writes through the chosen writer.
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if cw.decided {
+		return cw.write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.c.minSize {
+		return len(p), nil
+	}
+
+	if err := cw.decide(true); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush decides on compression with the data so far and flushes it to the client.
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		// Flushing indicates streaming, which benefits from compression regardless of size.
+		if err := cw.decide(len(cw.buf) > 0); err != nil {
+			return
+		}
+	}
+
+	if cw.writer != nil {
+		_ = cw.writer.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter.
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide chooses whether to compress, writes the headers and any buffered data.
+//
+// Parameters:
+//   - sizeReached: Whether enough data has been written to make compression worthwhile.
+//
+// Returns: An error if writing the buffered data fails.
+func (cw *compressResponseWriter) decide(sizeReached bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	compress := sizeReached &&
+		h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified &&
+		cw.c.isCompressible(h.Get("Content-Type"))
+
+	if compress {
+		cw.writer = cw.getWriter()
+	}
+
+	if cw.writer != nil {
+		h.Set("Content-Encoding", cw.encoder.Name)
+		h.Del("Content-Length")
+	}
+
+	cw.writeHeader()
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	_, err := cw.write(buf)
+	return err
+}
+
+// getWriter takes a writer from the pool and points it at the response.
+//
+// Returns: The writer, or nil if one could not be created.
+func (cw *compressResponseWriter) getWriter() CompressWriter {
+	w, _ := cw.c.pools[cw.encoder.Name].Get().(CompressWriter)
+	if w == nil {
+		return nil
+	}
+
+	w.Reset(cw.ResponseWriter)
+	return w
+}
+
+// writeHeader sends the recorded status code, once.
+func (cw *compressResponseWriter) writeHeader() {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// write writes through the compressing writer if in use, otherwise directly.
+func (cw *compressResponseWriter) write(p []byte) (int, error) {
+	if cw.writer != nil {
+		return cw.writer.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// close finishes the response, writing small responses uncompressed and returning the writer to its pool.
+func (cw *compressResponseWriter) close() {
+	if !cw.decided {
+		// The handler wrote less than MinSize, or nothing at all.
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		_ = cw.decide(false)
+	}
+
+	if cw.writer == nil {
+		return
+	}
+
+	_ = cw.writer.Close()
+	cw.writer.Reset(nil)
+	cw.c.pools[cw.encoder.Name].Put(cw.writer)
+	cw.writer = nil
+}
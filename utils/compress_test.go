@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// identityWriter is a CompressWriter that upper-cases data, to test custom encoders.
+type identityWriter struct {
+	w io.Writer
+}
+
+func (i *identityWriter) Write(p []byte) (int, error) {
+	return i.w.Write([]byte(strings.ToUpper(string(p))))
+}
+func (i *identityWriter) Close() error      { return nil }
+func (i *identityWriter) Flush() error      { return nil }
+func (i *identityWriter) Reset(w io.Writer) { i.w = w }
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+
+	upper := Encoder{Name: "upper", NewWriter: func(w io.Writer) (CompressWriter, error) {
+		return &identityWriter{w: w}, nil
+	}}
+
+	tests := []struct {
+		name             string
+		opts             CompressionOptions
+		method           string
+		acceptEncoding   string
+		contentType      string
+		contentEncoding  string
+		status           int
+		body             string
+		expectedEncoding string
+	}{
+		{
+			name:             "Compresses large text",
+			acceptEncoding:   "gzip, deflate",
+			contentType:      "text/plain; charset=utf-8",
+			body:             large,
+			expectedEncoding: "gzip",
+		},
+		{
+			name:             "Sniffs content type",
+			acceptEncoding:   "gzip",
+			body:             large,
+			expectedEncoding: "gzip",
+		},
+		{
+			name:             "Small response is not compressed",
+			acceptEncoding:   "gzip",
+			contentType:      "text/plain",
+			body:             "small",
+			expectedEncoding: "",
+		},
+		{
+			name:             "Client does not accept",
+			acceptEncoding:   "",
+			contentType:      "text/plain",
+			body:             large,
+			expectedEncoding: "",
+		},
+		{
+			name:             "Client rejects gzip with q=0",
+			acceptEncoding:   "gzip;q=0, br",
+			contentType:      "text/plain",
+			body:             large,
+			expectedEncoding: "",
+		},
+		{
+			name:             "Disallowed content type",
+			acceptEncoding:   "gzip",
+			contentType:      "image/png",
+			body:             large,
+			expectedEncoding: "",
+		},
+		{
+			name:             "Invalid content type",
+			acceptEncoding:   "gzip",
+			contentType:      "/",
+			body:             large,
+			expectedEncoding: "",
+		},
+		{
+			name:             "Already encoded",
+			acceptEncoding:   "gzip",
+			contentType:      "text/plain",
+			contentEncoding:  "br",
+			body:             large,
+			expectedEncoding: "br",
+		},
+		{
+			name:             "HEAD request",
+			method:           http.MethodHead,
+			acceptEncoding:   "gzip",
+			contentType:      "text/plain",
+			expectedEncoding: "",
+		},
+		{
+			name:             "Custom encoder preferred",
+			opts:             CompressionOptions{Encoders: []Encoder{upper}, MinSize: 10},
+			acceptEncoding:   "gzip, upper",
+			contentType:      "application/json",
+			body:             large,
+			expectedEncoding: "upper",
+		},
+		{
+			name:             "Wildcard accept",
+			opts:             CompressionOptions{ContentTypes: []string{"application/json"}},
+			acceptEncoding:   "*",
+			contentType:      "application/json",
+			body:             large,
+			expectedEncoding: "gzip",
+		},
+		{
+			name:             "Status is kept",
+			acceptEncoding:   "gzip",
+			contentType:      "text/plain",
+			status:           http.StatusCreated,
+			body:             large,
+			expectedEncoding: "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tt.contentEncoding)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// Write in chunks to exercise buffering.
+				for i := 0; i < len(tt.body); i += 100 {
+					_, _ = w.Write([]byte(tt.body[i:min(i+100, len(tt.body))]))
+				}
+			}))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.expectedEncoding {
+				t.Fatalf("Expected Content-Encoding %q, got %q", tt.expectedEncoding, got)
+			}
+			if tt.status != 0 && rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+
+			body := rec.Body.String()
+			switch tt.expectedEncoding {
+			case "gzip":
+				gr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("Failed to create gzip reader: %v", err)
+				}
+				decoded, _ := io.ReadAll(gr)
+				body = string(decoded)
+			case "upper":
+				body = strings.ToLower(body)
+			}
+
+			if body != tt.body {
+				t.Errorf("Expected body of length %d, got %d", len(tt.body), len(body))
+			}
+		})
+	}
+}
+
+func TestCompress_InvalidLevel(t *testing.T) {
+	defer func() {
+		if err, _ := recover().(error); err == nil || !strings.Contains(err.Error(), "invalid gzip encoder") {
+			t.Errorf("Compress() panic = %v, expected an invalid gzip encoder", err)
+		}
+	}()
+
+	Compress(CompressionOptions{Level: 100})
+}
+
+func TestCompress_Flush(t *testing.T) {
+	handler := Compress(CompressionOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected streamed response to be compressed")
+	}
+
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	decoded, _ := io.ReadAll(gr)
+	if string(decoded) != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("Unexpected body %q", decoded)
+	}
+}
+
+func TestCompress_EmptyResponse(t *testing.T) {
+	handler := Compress(CompressionOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+		t.Errorf("Expected an empty uncompressed response")
+	}
+
+	if _, ok := interface{}(&compressResponseWriter{ResponseWriter: rec}).(interface {
+		Unwrap() http.ResponseWriter
+	}); !ok {
+		t.Error("Expected compressResponseWriter to implement Unwrap")
+	}
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	got := parseAcceptEncoding("gzip;q=0.5, ZSTD , br;q=abc, ,identity;level=1")
+	expected := map[string]float64{"gzip": 0.5, "zstd": 1, "br": 1, "identity": 1}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseAcceptEncoding() = %v, expected %v", got, expected)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	body := []byte(strings.Repeat("hello world ", 200))
+	handler := Compress(CompressionOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(body)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
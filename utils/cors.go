@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions contains the options for the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	//
+	// An origin may be exact such as "https://example.com", a wildcard subdomain such as "https://*.example.com",
+	// or "*" to allow any origin.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed, defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed, "*" allows any header.
+	//
+	// Defaults to Accept, Content-Type and X-Requested-With. Header names are case-insensitive.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the browser is allowed to read.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent.
+	//
+	// It requires explicit or wildcard subdomain origins, as allowing "*" with credentials would let any site make
+	// credentialed requests and read the responses.
+	AllowCredentials bool
+	// MaxAge is how long the preflight response may be cached, 0 does not send the header.
+	MaxAge time.Duration
+}
+
+// cors is the prepared form of CORSOptions, normalised once rather than per request.
+type cors struct {
+	allowAllOrigins  bool
+	origins          map[string]bool
+	wildcardOrigins  [][2]string
+	methods          map[string]bool
+	methodsHeader    string
+	allowAllHeaders  bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// CORS returns a middleware that handles Cross-Origin Resource Sharing.
+//
+// Preflight requests are answered directly with 204 No Content, other requests have the CORS headers added
+// before being passed to the next handler.
+//
+// Parameters:
+//   - opts: The CORSOptions to use.
+//
+// Returns: A function that wraps a handler with CORS handling.
+//
+// Panics if AllowCredentials is set along with a "*" origin, as it is a mistake within the options found on startup.
+//
+// Example:
+//
+//	corsMiddleware := CORS(CORSOptions{
+//		AllowedOrigins:   []string{"https://example.com", "https://*.example.com"},
+//		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+//		AllowCredentials: true,
+//		MaxAge:           time.Hour,
+//	})
+//
+//	http.ListenAndServe(":8080", corsMiddleware(mux))
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	c, err := newCORS(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Responses differ per origin, so caches must key on it.
+			w.Header().Add("Vary", "Origin")
+
+			isPreflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if isPreflight {
+				c.handlePreflight(w, r, origin)
+				return
+			}
+
+			if c.isOriginAllowed(origin) {
+				c.setOriginHeaders(w, origin)
+				if c.exposedHeaders != "" {
+					w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// errCORSCredentialsAnyOrigin is returned by newCORS when credentials are allowed from any origin.
+var errCORSCredentialsAnyOrigin = errors.New(`cors: AllowCredentials cannot be used with the "*" origin, list the allowed origins`)
+
+// newCORS normalises the options, applying defaults.
+//
+// Returns: The prepared options, or an error if AllowCredentials is set along with a "*" origin.
+func newCORS(opts CORSOptions) (*cors, error) {
+	c := &cors{
+		origins:          make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowCredentials: opts.AllowCredentials,
+		exposedHeaders:   strings.Join(opts.ExposedHeaders, ", "),
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			c.allowAllOrigins = true
+		} else if i := strings.IndexByte(origin, '*'); i != -1 {
+			c.wildcardOrigins = append(c.wildcardOrigins, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			c.origins[origin] = true
+		}
+	}
+
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	if len(opts.AllowedMethods) > 0 {
+		methods = make([]string, len(opts.AllowedMethods))
+		for i, method := range opts.AllowedMethods {
+			methods[i] = strings.ToUpper(method)
+		}
+	}
+	for _, method := range methods {
+		c.methods[method] = true
+	}
+	c.methodsHeader = strings.Join(methods, ", ")
+
+	headers := opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"Accept", "Content-Type", "X-Requested-With"}
+	}
+	for _, header := range headers {
+		if header == "*" {
+			c.allowAllHeaders = true
+		}
+		c.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+
+	if c.allowAllOrigins && c.allowCredentials {
+		return nil, errCORSCredentialsAnyOrigin
+	}
+
+	return c, nil
+}
+
+// handlePreflight answers a preflight request.
+//
+// If the origin, method or any header is not allowed, no CORS headers are sent and the browser blocks the request.
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+
+	if !c.isOriginAllowed(origin) || !c.methods[method] || !c.areHeadersAllowed(requestedHeaders) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOriginHeaders(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", c.methodsHeader)
+	if requestedHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requestedHeaders)
+	}
+	if c.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setOriginHeaders sets the allowed origin and credentials headers.
+func (c *cors) setOriginHeaders(w http.ResponseWriter, origin string) {
+	if c.allowAllOrigins {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// isOriginAllowed checks the origin against the exact and wildcard origins.
+//
+// Parameters:
+//   - origin: The Origin header of the request.
+//
+// Returns: True if the origin is allowed, false otherwise.
+func (c *cors) isOriginAllowed(origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+
+	for _, w := range c.wildcardOrigins {
+		// The length check ensures "https://*.example.com" does not match "https://.example.com".
+		if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// areHeadersAllowed checks every header within the Access-Control-Request-Headers list.
+//
+// Parameters:
+//   - requested: The comma separated list of requested headers.
+//
+// Returns: True if all headers are allowed, false otherwise.
+func (c *cors) areHeadersAllowed(requested string) bool {
+	if c.allowAllHeaders || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !c.headers[header] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"get", "post", "delete"},
+		AllowedHeaders:   []string{"Content-Type", "authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+
+	tests := []struct {
+		name            string
+		opts            CORSOptions
+		method          string
+		headers         map[string]string
+		expectNext      bool
+		expectStatus    int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:            "No origin",
+			opts:            opts,
+			method:          http.MethodGet,
+			expectNext:      true,
+			expectStatus:    http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:         "Exact origin",
+			opts:         opts,
+			method:       http.MethodGet,
+			headers:      map[string]string{"Origin": "https://example.com"},
+			expectNext:   true,
+			expectStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:            "Wildcard subdomain origin",
+			opts:            opts,
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://api.example.org"},
+			expectNext:      true,
+			expectStatus:    http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "https://api.example.org"},
+		},
+		{
+			name:            "Wildcard does not match bare domain",
+			opts:            opts,
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://.example.org"},
+			expectNext:      true,
+			expectStatus:    http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:            "Disallowed origin",
+			opts:            opts,
+			method:          http.MethodGet,
+			headers:         map[string]string{"Origin": "https://evil.com"},
+			expectNext:      true,
+			expectStatus:    http.StatusOK,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "Preflight allowed",
+			opts:   opts,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "DELETE",
+				"Access-Control-Request-Headers": "content-type, Authorization",
+			},
+			expectNext:   false,
+			expectStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://example.com",
+				"Access-Control-Allow-Methods": "GET, POST, DELETE",
+				"Access-Control-Allow-Headers": "content-type, Authorization",
+				"Access-Control-Max-Age":       "3600",
+			},
+		},
+		{
+			name:   "Preflight disallowed method",
+			opts:   opts,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "PUT",
+			},
+			expectNext:      false,
+			expectStatus:    http.StatusNoContent,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "Preflight disallowed header",
+			opts:   opts,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			expectNext:      false,
+			expectStatus:    http.StatusNoContent,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "Any origin without credentials",
+			opts:   CORSOptions{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://anything.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			expectNext:   false,
+			expectStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Max-Age":           "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calledNext := false
+			handler := CORS(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calledNext = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if calledNext != tt.expectNext {
+				t.Errorf("Expected next called = %v, got %v", tt.expectNext, calledNext)
+			}
+			if rec.Code != tt.expectStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectStatus, rec.Code)
+			}
+			for k, v := range tt.expectedHeaders {
+				if got := rec.Header().Get(k); got != v {
+					t.Errorf("Expected header %s = %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCORS_CredentialsAnyOrigin(t *testing.T) {
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, errCORSCredentialsAnyOrigin) {
+			t.Errorf("CORS() panic = %v, expected %v", err, errCORSCredentialsAnyOrigin)
+		}
+	}()
+
+	CORS(CORSOptions{AllowedOrigins: []string{"https://example.com", "*"}, AllowCredentials: true})
+}
+
+func BenchmarkCORS(b *testing.B) {
+	handler := CORS(CORSOptions{AllowedOrigins: []string{"https://*.example.com"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://api.example.com")
+
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// csvColumn is a struct field bound to a CSV column by its `csv` tag.
+type csvColumn struct {
+	name  string
+	index int
+}
+
+// ReadCSV reads CSV data with a header row into a slice of structs, matching columns by the `csv` tag.
+//
+// Values are converted in the same way as BindRequest, so string, int, uint, float and bool fields are supported,
+// along with pointers to them. An empty cell leaves the field at its zero value, or nil for a pointer.
+//
+// Parameters:
+//   - r: The CSV data, the first row must be the header.
+//
+// Returns: The rows, or an error naming the line and column that failed.
+//
+// Example:
+//
+//	type User struct {
+//	 Name  string `csv:"name"`
+//	 Age   int    `csv:"age"`
+//	 Notes string // ignored, no csv tag
+//	}
+//
+//	users, err := ReadCSV[User](file)
+//
+// Note: Every tagged column must be within the header, extra columns within the data are ignored.
+func ReadCSV[T any](r io.Reader) ([]T, error) {
+	columns, err := csvColumns(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, newParseValueError("csv is missing the header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[name] = i
+	}
+
+	// recordIndex maps each column to its position within a record.
+	recordIndex := make([]int, len(columns))
+	for i, col := range columns {
+		pos, ok := positions[col.name]
+		if !ok {
+			return nil, newParseValueError(fmt.Sprintf("csv is missing the column %q", col.name))
+		}
+		recordIndex[i] = pos
+	}
+
+	var rows []T
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		var row T
+		rowVal := reflect.ValueOf(&row).Elem()
+		for i, col := range columns {
+			if err := setCSVValue(rowVal.Field(col.index), record[recordIndex[i]]); err != nil {
+				line, _ := reader.FieldPos(recordIndex[i])
+				return nil, fmt.Errorf("line %d, column %q: %w", line, col.name, err)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// WriteCSV writes a slice of structs as CSV with a header row, using the `csv` tags as column names.
+//
+// Parameters:
+//   - w: The writer to write the CSV data to.
+//   - rows: The rows to write.
+//
+// Returns: An error if a field cannot be formatted or writing fails.
+//
+// Example:
+//
+//	w.Header().Set("Content-Type", "text/csv")
+//	err := WriteCSV(w, users)
+func WriteCSV[T any](w io.Writer, rows []T) error {
+	columns, err := csvColumns(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.name
+	}
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	for _, row := range rows {
+		rowVal := reflect.ValueOf(row)
+		for i, col := range columns {
+			record[i], err = formatFieldValue(rowVal.Field(col.index))
+			if err != nil {
+				return fmt.Errorf("column %q: %w", col.name, err)
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvColumns finds the exported fields with a `csv` tag, in field order.
+//
+// Returns: The columns, or an error if the type is not a struct.
+func csvColumns(t reflect.Type) ([]csvColumn, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, newParseValueError(fmt.Sprintf("csv rows must be structs, got %s", t))
+	}
+
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("csv")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		columns = append(columns, csvColumn{name: name, index: i})
+	}
+
+	return columns, nil
+}
+
+// setCSVValue sets a field from a cell, leaving it zero when the cell is empty, so a pointer stays nil.
+//
+// Returns: An error if the cell cannot be converted to the field type.
+func setCSVValue(field reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	return setFieldValue(field, value)
+}
+
+// formatFieldValue formats a field value as a string, the inverse of setCSVValue.
+//
+// Returns: The formatted value, an empty string for a nil pointer, or an error if the field type is not supported.
+func formatFieldValue(field reflect.Value) (string, error) {
+	switch field.Kind() {
+	case reflect.Pointer:
+		if field.IsNil() {
+			return "", nil
+		}
+		return formatFieldValue(field.Elem())
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s", field.Type())
+	}
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type csvUser struct {
+	Name    string  `csv:"name"`
+	Age     int     `csv:"age"`
+	Balance float64 `csv:"balance"`
+	Active  bool    `csv:"active"`
+	Notes   string
+	Skipped string `csv:"-"`
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []csvUser
+		expectedErr string
+	}{
+		{
+			name:  "Valid",
+			input: "name,age,balance,active\nAlice,30,12.5,true\n\"Smith, Bob\",41,0,false\n",
+			expected: []csvUser{
+				{Name: "Alice", Age: 30, Balance: 12.5, Active: true},
+				{Name: "Smith, Bob", Age: 41},
+			},
+		},
+		{
+			name:     "Columns in another order with extras",
+			input:    "active,extra,balance,age,name\ntrue,x,1,2,Carol\n",
+			expected: []csvUser{{Name: "Carol", Age: 2, Balance: 1, Active: true}},
+		},
+		{
+			name:     "Header only",
+			input:    "name,age,balance,active\n",
+			expected: nil,
+		},
+		{
+			name:        "Empty",
+			input:       "",
+			expectedErr: "input error: csv is missing the header row",
+		},
+		{
+			name:        "Missing column",
+			input:       "name,age,balance\nAlice,30,1\n",
+			expectedErr: `input error: csv is missing the column "active"`,
+		},
+		{
+			name:        "Invalid value",
+			input:       "name,age,balance,active\nAlice,30,1,true\nBob,old,1,true\n",
+			expectedErr: `line 3, column "age": failed to set field value: strconv.ParseInt: parsing "old": invalid syntax`,
+		},
+		{
+			name:        "Malformed header",
+			input:       "name,\"age\nAlice",
+			expectedErr: "failed to read csv header",
+		},
+		{
+			name:        "Wrong number of fields",
+			input:       "name,age,balance,active\nAlice,30\n",
+			expectedErr: "failed to read csv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV[csvUser](strings.NewReader(tt.input))
+			if tt.expectedErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+					t.Fatalf("ReadCSV() error = %v, expected %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ReadCSV() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ReadCSV[string](strings.NewReader("a\n")); err == nil {
+		t.Errorf("ReadCSV[string]() expected an error")
+	}
+}
+
+type csvOptional struct {
+	Name   string   `csv:"name"`
+	Age    int      `csv:"age"`
+	Score  *float64 `csv:"score"`
+	Active *bool    `csv:"active"`
+}
+
+func TestReadCSV_EmptyCells(t *testing.T) {
+	got, err := ReadCSV[csvOptional](strings.NewReader("name,age,score,active\nAlice,,,\nBob,41,2.5,false\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+
+	score, active := 2.5, false
+	expected := []csvOptional{
+		{Name: "Alice"},
+		{Name: "Bob", Age: 41, Score: &score, Active: &active},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ReadCSV() = %+v, expected %+v", got, expected)
+	}
+
+	// A nil pointer is written as an empty cell, so it reads back as nil.
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, got); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	if out := buf.String(); out != "name,age,score,active\nAlice,0,,\nBob,41,2.5,false\n" {
+		t.Errorf("WriteCSV() = %q", out)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	users := []csvUser{
+		{Name: "Alice", Age: 30, Balance: 1000000.25, Active: true, Notes: "ignored"},
+		{Name: "Smith, Bob", Age: -1},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, users); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	expected := "name,age,balance,active\nAlice,30,1000000.25,true\n\"Smith, Bob\",-1,0,false\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("WriteCSV() = %q, expected %q", got, expected)
+	}
+
+	// Writing then reading gives back the tagged fields.
+	read, err := ReadCSV[csvUser](&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	users[0].Notes = ""
+	if !reflect.DeepEqual(read, users) {
+		t.Errorf("ReadCSV(WriteCSV()) = %+v, expected %+v", read, users)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("mocked error writing")
+}
+
+func TestWriteCSVErrors(t *testing.T) {
+	type unsupported struct {
+		Tags []string `csv:"tags"`
+	}
+	type allKinds struct {
+		Small float32 `csv:"small"`
+		Count uint8   `csv:"count"`
+	}
+
+	if err := WriteCSV(&bytes.Buffer{}, []unsupported{{Tags: []string{"a"}}}); err == nil {
+		t.Errorf("WriteCSV() with an unsupported field, expected an error")
+	}
+	if err := WriteCSV(&bytes.Buffer{}, []string{"a"}); err == nil {
+		t.Errorf("WriteCSV() with non-struct rows, expected an error")
+	}
+	if err := WriteCSV(failingWriter{}, []allKinds{{Small: 1.5, Count: 2}}); err == nil {
+		t.Errorf("WriteCSV() with a failing writer, expected an error")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []allKinds{{Small: 1.5, Count: 2}}); err != nil || buf.String() != "small,count\n1.5,2\n" {
+		t.Errorf("WriteCSV() = %q, %v, expected %q", buf.String(), err, "small,count\n1.5,2\n")
+	}
+}
+
+func BenchmarkReadCSV(b *testing.B) {
+	input := strings.Repeat("Alice,30,12.5,true\n", 100)
+	for i := 0; i < b.N; i++ {
+		_, _ = ReadCSV[csvUser](strings.NewReader("name,age,balance,active\n" + input))
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
@@ -72,6 +74,33 @@ func TestIgnoresEmptyFieldValues(t *testing.T) {
 	}
 }
 
+// boundQueryParams is bound by BindRequest from its query tags, and searched by GormSearchQuery with its where tags.
+type boundQueryParams struct {
+	Status string `query:"status" where:"status = ?"`
+	Rank   int    `query:"rank" where:"rank >= ?"`
+}
+
+func TestBindAndGeneratesQueryFromTheSameStruct(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?status=active&rank=3", nil)
+
+	var params boundQueryParams
+	if err := BindRequest(r, &params); err != nil {
+		t.Fatalf("BindRequest() error = %v", err)
+	}
+
+	expectedQuery := "(status = ? AND rank >= ?)"
+	expectedArgs := []interface{}{"active", 3}
+
+	query, args := GormSearchQuery(params)
+
+	if query != expectedQuery {
+		t.Errorf("expected query to be '%s', got '%s'", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args to be '%v', got '%v'", expectedArgs, args)
+	}
+}
+
 func BenchmarkGormSearchQuery(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		params := OptionalQueryParams{ID: "123", Array: "type1"}
@@ -0,0 +1,422 @@
+package utils
+
+import (
+	"html"
+	"strings"
+)
+
+// SanitizePolicy is the allow-list used by SanitizeHTML.
+type SanitizePolicy struct {
+	// AllowedTags maps a lower-case tag name to its allowed lower-case attribute names.
+	//
+	// Any tag not listed is removed, keeping its text content.
+	AllowedTags map[string][]string
+}
+
+// DefaultSanitizePolicy returns the policy used when SanitizeHTML is given an empty policy.
+//
+// Returns: A policy allowing basic formatting, lists, code blocks and links.
+func DefaultSanitizePolicy() SanitizePolicy {
+	return SanitizePolicy{
+		AllowedTags: map[string][]string{
+			"a":          {"href", "title"},
+			"b":          nil,
+			"blockquote": nil,
+			"br":         nil,
+			"code":       nil,
+			"em":         nil,
+			"i":          nil,
+			"li":         nil,
+			"ol":         nil,
+			"p":          nil,
+			"pre":        nil,
+			"strong":     nil,
+			"u":          nil,
+			"ul":         nil,
+		},
+	}
+}
+
+// rawTextTags are elements whose content is never shown as text, so it is dropped along with the tag.
+var rawTextTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"noscript": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// voidTags are elements without a closing tag.
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// urlAttributes are attributes containing URLs, only safe schemes are kept.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"cite":   true,
+	"action": true,
+}
+
+// EscapeHTML escapes the special characters <, >, &, ' and " so the string can be placed within HTML.
+//
+// Parameters:
+//   - s: The string to escape.
+//
+// Returns: The escaped string.
+//
+// Usage:
+//
+//	EscapeHTML(`<b>"hi"</b>`) // -> "&lt;b&gt;&#34;hi&#34;&lt;/b&gt;"
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// StripTags removes all HTML tags and comments, returning the plain text.
+//
+// The content of script and style elements is removed entirely and HTML entities are decoded.
+//
+// Parameters:
+//   - s: The HTML to strip.
+//
+// Returns: The plain text.
+//
+// Usage:
+//
+//	StripTags("<p>Fish &amp; <b>chips</b></p><script>alert(1)</script>") // -> "Fish & chips"
+//
+// Note: The result is plain text, it must be escaped again before being placed within HTML.
+func StripTags(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	tokenizeHTML(s, func(tok htmlToken) {
+		if tok.kind == htmlText {
+			b.WriteString(html.UnescapeString(tok.text))
+		}
+	})
+
+	return b.String()
+}
+
+// SanitizeHTML removes every tag and attribute not within the policy, for displaying user-generated HTML.
+//
+// Text is re-escaped, URL attributes only keep http, https, mailto and relative URLs,
+// and unclosed allowed tags are closed.
+//
+// Parameters:
+//   - s: The HTML to sanitize.
+//   - policy: The SanitizePolicy allow-list, an empty policy uses DefaultSanitizePolicy.
+//
+// Returns: The sanitized HTML.
+//
+// Usage:
+//
+//	SanitizeHTML(`<p onclick="x()">Hi <a href="javascript:x()">there</a><script>x()</script>`, SanitizePolicy{})
+//	// -> "<p>Hi <a>there</a></p>"
+func SanitizeHTML(s string, policy SanitizePolicy) string {
+	if len(policy.AllowedTags) == 0 {
+		policy = DefaultSanitizePolicy()
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	var open []string
+
+	tokenizeHTML(s, func(tok htmlToken) {
+		switch tok.kind {
+		case htmlText:
+			b.WriteString(html.EscapeString(html.UnescapeString(tok.text)))
+		case htmlStartTag:
+			allowedAttrs, ok := policy.AllowedTags[tok.name]
+			if !ok {
+				return
+			}
+
+			writeStartTag(&b, tok, allowedAttrs)
+			if !voidTags[tok.name] {
+				open = append(open, tok.name)
+			}
+		case htmlEndTag:
+			// Only close tags that are open, closing any unclosed tags nested within it.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	})
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+// writeStartTag writes a start tag with only the allowed attributes.
+func writeStartTag(b *strings.Builder, tok htmlToken, allowedAttrs []string) {
+	b.WriteByte('<')
+	b.WriteString(tok.name)
+
+	for _, attr := range tok.attrs {
+		if !containsString(allowedAttrs, attr[0]) {
+			continue
+		}
+
+		val := html.UnescapeString(attr[1])
+		if urlAttributes[attr[0]] && !isSafeURL(val) {
+			continue
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(attr[0])
+		b.WriteString(`="`)
+		b.WriteString(html.EscapeString(val))
+		b.WriteByte('"')
+	}
+
+	b.WriteByte('>')
+}
+
+// isSafeURL checks that a URL is relative or uses the http, https or mailto scheme.
+//
+// Parameters:
+//   - u: The unescaped URL.
+//
+// Returns: True if the URL is safe to link to, false otherwise.
+func isSafeURL(u string) bool {
+	// Browsers ignore whitespace and control characters within the scheme, such as "java\tscript:".
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, u)
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon == -1 {
+		return true
+	}
+
+	// A colon after a path, query or fragment separator is not a scheme, such as "/a:b".
+	if sep := strings.IndexAny(cleaned, "/?#"); sep != -1 && sep < colon {
+		return true
+	}
+
+	switch strings.ToLower(cleaned[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// containsString checks if the slice contains the string.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlTokenKind is the type of an htmlToken.
+type htmlTokenKind int
+
+const (
+	htmlText htmlTokenKind = iota
+	htmlStartTag
+	htmlEndTag
+)
+
+// htmlToken is a piece of HTML found by tokenizeHTML.
+type htmlToken struct {
+	kind htmlTokenKind
+	// text is the raw text for htmlText tokens.
+	text string
+	// name is the lower-case tag name for tag tokens.
+	name string
+	// attrs are the lower-case attribute names and raw values of a start tag.
+	attrs [][2]string
+}
+
+// tokenizeHTML is a lenient HTML tokenizer, it calls fn for each text, start tag and end tag.
+//
+// Comments, doctypes and processing instructions are skipped, as is the content of rawTextTags.
+// It is not a full HTML5 parser, but it never produces a tag that a browser would not also see as a tag.
+//
+// Parameters:
+//   - s: The HTML to tokenize.
+//   - fn: The function called for each token.
+func tokenizeHTML(s string, fn func(tok htmlToken)) {
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt == -1 {
+			fn(htmlToken{kind: htmlText, text: s})
+			return
+		}
+
+		if lt > 0 {
+			fn(htmlToken{kind: htmlText, text: s[:lt]})
+			s = s[lt:]
+		}
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s[4:], "-->")
+		case strings.HasPrefix(s, "<!") || strings.HasPrefix(s, "<?"):
+			s = skipPast(s[2:], ">")
+		case len(s) > 2 && s[1] == '/' && isASCIILetter(s[2]):
+			name, rest := readTagName(s[2:])
+			fn(htmlToken{kind: htmlEndTag, name: name})
+			s = skipPast(rest, ">")
+		case len(s) > 1 && isASCIILetter(s[1]):
+			var tok htmlToken
+			tok, s = readStartTag(s[1:])
+			fn(tok)
+
+			// Browsers ignore "/>" on non-void elements, so "<script/>" still starts a script.
+			if rawTextTags[tok.name] {
+				s = skipRawText(s, tok.name)
+			}
+		default:
+			// A lone '<' such as "1 < 2" is text.
+			fn(htmlToken{kind: htmlText, text: "<"})
+			s = s[1:]
+		}
+	}
+}
+
+// readStartTag reads a start tag after the '<', returning the token and the remaining input.
+func readStartTag(s string) (htmlToken, string) {
+	tok := htmlToken{kind: htmlStartTag}
+	tok.name, s = readTagName(s)
+
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f/")
+		if s == "" {
+			return tok, s
+		}
+
+		if s[0] == '>' {
+			return tok, s[1:]
+		}
+
+		var name string
+		name, s = readAttrName(s)
+		s = strings.TrimLeft(s, " \t\n\r\f")
+
+		var val string
+		if strings.HasPrefix(s, "=") {
+			val, s = readAttrValue(strings.TrimLeft(s[1:], " \t\n\r\f"))
+		}
+
+		if name != "" {
+			tok.attrs = append(tok.attrs, [2]string{name, val})
+		}
+	}
+}
+
+// readTagName reads a tag name, returning it lower-cased and the remaining input.
+func readTagName(s string) (string, string) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '/' && s[i] != '>' {
+		i++
+	}
+	return strings.ToLower(s[:i]), s[i:]
+}
+
+// readAttrName reads an attribute name, returning it lower-cased and the remaining input.
+func readAttrName(s string) (string, string) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '/' && s[i] != '>' && s[i] != '=' {
+		i++
+	}
+
+	// A name starting with '=' is still consumed, so the loop always progresses.
+	if i == 0 {
+		i = 1
+	}
+	return strings.ToLower(s[:i]), s[i:]
+}
+
+// readAttrValue reads a quoted or unquoted attribute value, returning it and the remaining input.
+func readAttrValue(s string) (string, string) {
+	if s == "" {
+		return "", s
+	}
+
+	if quote := s[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(s[1:], quote)
+		if end == -1 {
+			return s[1:], ""
+		}
+		return s[1 : end+1], s[end+2:]
+	}
+
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// skipRawText skips the content of a raw text element, up to and including its end tag.
+//
+// The end tag is matched within s itself, ignoring ASCII case only as browsers do. Lowercasing a copy would change
+// the length of characters such as the Kelvin sign or invalid UTF-8, so its offsets would not point into s.
+func skipRawText(s, name string) string {
+	tag := "</" + name
+	for i := 0; i+len(tag) <= len(s); i++ {
+		if s[i] == '<' && equalFoldASCII(s[i:i+len(tag)], tag) {
+			return skipPast(s[i:], ">")
+		}
+	}
+	return ""
+}
+
+// equalFoldASCII reports whether s and lower are equal ignoring ASCII case, lower being lowercase already.
+func equalFoldASCII(s, lower string) bool {
+	if len(s) != len(lower) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// skipPast returns the input after the first occurrence of sep, or an empty string if not found.
+func skipPast(s, sep string) string {
+	i := strings.Index(s, sep)
+	if i == -1 {
+		return ""
+	}
+	return s[i+len(sep):]
+}
+
+// isASCIILetter checks if the byte is an ASCII letter.
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isHTMLSpace checks if the byte is HTML whitespace.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeHTML(t *testing.T) {
+	got := EscapeHTML(`<b>"Tom" & 'Jerry'</b>`)
+	expected := "&lt;b&gt;&#34;Tom&#34; &amp; &#39;Jerry&#39;&lt;/b&gt;"
+	if got != expected {
+		t.Errorf("EscapeHTML() = %q, expected %q", got, expected)
+	}
+}
+
+func TestStripTags(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"<p>Fish &amp; <b>chips</b></p>", "Fish & chips"},
+		{"<script>alert(1)</script>safe", "safe"},
+		{"<SCRIPT type='text/javascript'>alert(1)</SCRIPT >safe", "safe"},
+		{"<style>p { color: red }</style>text", "text"},
+		{"a <!-- comment --> b", "a  b"},
+		{"<!DOCTYPE html><?xml version='1.0'?>doc", "doc"},
+		{"1 < 2 and 3 > 2", "1 < 2 and 3 > 2"},
+		{"<a href='x' title=\"y>z\">link</a>", "link"},
+		{"<script>never closed", ""},
+		{"<script>\u212a\u212a1>zz</script>safe", "safe"},
+		{"<script>\xff\xff\xff\xff\xff</script>a>b", "a>b"},
+		{"<script>\u212aa</scr\u212aipt>leak</script>safe", "safe"},
+		{"unterminated <!-- comment", "unterminated "},
+		{"<img src=x onerror=alert(1)//", ""},
+		{"</>", "</>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := StripTags(tt.input); got != tt.expected {
+				t.Errorf("StripTags(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		policy   SanitizePolicy
+		expected string
+	}{
+		{
+			name:     "Keeps allowed tags",
+			input:    "<p>Hello <b>world</b><br/></p>",
+			expected: "<p>Hello <b>world</b><br></p>",
+		},
+		{
+			name:     "Removes disallowed tags and attributes",
+			input:    `<p onclick="x()" class="c">Hi <span>there</span></p>`,
+			expected: "<p>Hi there</p>",
+		},
+		{
+			name:     "Removes script content",
+			input:    "<p>a<script>alert(1)</script>b</p>",
+			expected: "<p>ab</p>",
+		},
+		{
+			name:     "Removes script content after characters changing length when lowercased",
+			input:    "<p>a<script>" + strings.Repeat("\u212a", 20) + "x><b>leak</b></script>b</p>",
+			expected: "<p>ab</p>",
+		},
+		{
+			name:     "Keeps safe links",
+			input:    `<a href="https://example.com?a=1&amp;b=2" title='T "q"'>x</a>`,
+			expected: `<a href="https://example.com?a=1&amp;b=2" title="T &#34;q&#34;">x</a>`,
+		},
+		{
+			name:     "Keeps relative links",
+			input:    `<a href="/path:with:colons">x</a><a href=page.html>y</a>`,
+			expected: `<a href="/path:with:colons">x</a><a href="page.html">y</a>`,
+		},
+		{
+			name:     "Removes javascript links",
+			input:    `<a href="java&#x09;script:alert(1)">x</a><a href=" JavaScript:alert(1)">y</a>`,
+			expected: `<a>x</a><a>y</a>`,
+		},
+		{
+			name:     "Escapes text",
+			input:    "1 < 2 & <b>bold</b>",
+			expected: "1 &lt; 2 &amp; <b>bold</b>",
+		},
+		{
+			name:     "Closes unclosed tags",
+			input:    "<ul><li><b>one</ul>",
+			expected: "<ul><li><b>one</b></li></ul>",
+		},
+		{
+			name:     "Ignores unmatched end tags",
+			input:    "</b>text</p>",
+			expected: "text",
+		},
+		{
+			name:     "Closes tags at the end",
+			input:    "<p><em>open",
+			expected: "<p><em>open</em></p>",
+		},
+		{
+			name:     "Custom policy",
+			input:    `<p>x</p><img src="https://example.com/a.png" alt="a" onerror="x()"><img src="data:x">`,
+			policy:   SanitizePolicy{AllowedTags: map[string][]string{"img": {"src", "alt"}}},
+			expected: `x<img src="https://example.com/a.png" alt="a"><img>`,
+		},
+		{
+			name:     "Attribute without value and odd characters",
+			input:    `<p hidden ="x" = title=y>z</p>`,
+			expected: "<p>z</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input, tt.policy); got != tt.expected {
+				t.Errorf("SanitizeHTML(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadAttrValue(t *testing.T) {
+	if val, rest := readAttrValue(`"unterminated`); val != "unterminated" || rest != "" {
+		t.Errorf("Expected unterminated value to be read to the end, got %q, %q", val, rest)
+	}
+	if val, rest := readAttrValue(""); val != "" || rest != "" {
+		t.Errorf("Expected empty value, got %q, %q", val, rest)
+	}
+}
+
+func BenchmarkSanitizeHTML(b *testing.B) {
+	input := `<p onclick="x()">Hello <a href="https://example.com">world</a><script>alert(1)</script></p>`
+	for i := 0; i < b.N; i++ {
+		SanitizeHTML(input, SanitizePolicy{})
+	}
+}
+
+func BenchmarkStripTags(b *testing.B) {
+	input := `<p onclick="x()">Hello <a href="https://example.com">world</a><script>alert(1)</script></p>`
+	for i := 0; i < b.N; i++ {
+		StripTags(input)
+	}
+}
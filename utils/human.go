@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteUnits are the IEC binary units used by HumanBytes.
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// countUnits are the SI suffixes used by HumanCount.
+var countUnits = []string{"", "k", "M", "B", "T", "Q"}
+
+// durationUnits are the units used by HumanDuration, largest first.
+var durationUnits = []struct {
+	suffix string
+	size   time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"µs", time.Microsecond},
+	{"ns", time.Nanosecond},
+}
+
+// HumanBytes formats a number of bytes using binary units, with at most one decimal place.
+//
+// Parameters:
+//   - n: The number of bytes.
+//
+// Returns: The formatted size.
+//
+// Usage:
+//
+//	HumanBytes(512)     // -> "512 B"
+//	HumanBytes(1536)    // -> "1.5 KiB"
+//	HumanBytes(1 << 30) // -> "1 GiB"
+func HumanBytes(n int64) string {
+	return formatScaled(n, 1024, byteUnits, " ")
+}
+
+// HumanCount formats a count with a short suffix, with at most one decimal place, such as for follower counts.
+//
+// Parameters:
+//   - n: The count.
+//
+// Returns: The formatted count.
+//
+// Usage:
+//
+//	HumanCount(999)       // -> "999"
+//	HumanCount(1200)      // -> "1.2k"
+//	HumanCount(3_400_000) // -> "3.4M"
+func HumanCount(n int64) string {
+	return formatScaled(n, 1000, countUnits, "")
+}
+
+// HumanDuration formats a duration using its two largest units, dropping the second if it is zero.
+//
+// Parameters:
+//   - d: The duration.
+//
+// Returns: The formatted duration, "0s" for zero.
+//
+// Usage:
+//
+//	HumanDuration(2*time.Hour + 3*time.Minute + 4*time.Second) // -> "2h 3m"
+//	HumanDuration(26 * time.Hour)                              // -> "1d 2h"
+//	HumanDuration(1500 * time.Millisecond)                     // -> "1s 500ms"
+func HumanDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+
+	var b strings.Builder
+	// The magnitude is kept unsigned so that math.MinInt64 does not overflow when negated.
+	remaining := uint64(d)
+	if d < 0 {
+		b.WriteByte('-')
+		remaining = uint64(-(d + 1)) + 1
+	}
+
+	written := 0
+	for _, unit := range durationUnits {
+		size := uint64(unit.size)
+		if remaining < size {
+			if written > 0 {
+				// Units must be adjacent, so "2h 0m 5s" is shown as "2h" rather than "2h 5s".
+				break
+			}
+			continue
+		}
+
+		if written > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatUint(remaining/size, 10))
+		b.WriteString(unit.suffix)
+		remaining %= size
+
+		written++
+		if written == 2 || remaining == 0 {
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// formatScaled divides the value by the base until it fits the unit, rounding to one decimal place.
+//
+// Parameters:
+//   - n: The value to format.
+//   - base: The size of each unit relative to the previous one.
+//   - units: The unit suffixes, smallest first.
+//   - sep: The separator between the number and the unit.
+//
+// Returns: The formatted value, with a trailing ".0" removed.
+func formatScaled(n int64, base float64, units []string, sep string) string {
+	value := float64(n)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	i := 0
+	for value >= base && i < len(units)-1 {
+		value /= base
+		i++
+	}
+
+	formatted := strconv.FormatFloat(value, 'f', 1, 64)
+	// Rounding can reach the base, such as 999.96k, which reads better as the next unit.
+	if formatted == strconv.FormatFloat(base, 'f', 1, 64) && i < len(units)-1 {
+		i++
+		formatted = "1.0"
+	}
+	formatted = strings.TrimSuffix(formatted, ".0")
+
+	if units[i] == "" {
+		return sign + formatted
+	}
+	return sign + formatted + sep + units[i]
+}
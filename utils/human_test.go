@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1023, "1023 B"},
+		{1024, "1 KiB"},
+		{1536, "1.5 KiB"},
+		{1 << 30, "1 GiB"},
+		{1<<20 - 1, "1 MiB"},
+		{-2048, "-2 KiB"},
+		{math.MaxInt64, "8 EiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := HumanBytes(tt.input); got != tt.expected {
+				t.Errorf("HumanBytes(%d) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHumanCount(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1k"},
+		{1200, "1.2k"},
+		{999_960, "1M"},
+		{3_400_000, "3.4M"},
+		{2_000_000_000, "2B"},
+		{-1500, "-1.5k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := HumanCount(tt.input); got != tt.expected {
+				t.Errorf("HumanCount(%d) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{2*time.Hour + 3*time.Minute + 4*time.Second, "2h 3m"},
+		{2*time.Hour + 5*time.Second, "2h"},
+		{26 * time.Hour, "1d 2h"},
+		{1500 * time.Millisecond, "1s 500ms"},
+		{250 * time.Microsecond, "250µs"},
+		{42, "42ns"},
+		{-90 * time.Second, "-1m 30s"},
+		{math.MinInt64, "-106751d 23h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := HumanDuration(tt.input); got != tt.expected {
+				t.Errorf("HumanDuration(%d) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkHumanDuration(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		HumanDuration(2*time.Hour + 3*time.Minute)
+	}
+}
@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// JWTAlgorithm is a JWT signing algorithm, as used within the "alg" header.
+type JWTAlgorithm string
+
+const (
+	// JWTHS256 is HMAC with SHA-256, using a shared []byte secret.
+	JWTHS256 JWTAlgorithm = "HS256"
+	// JWTRS256 is RSASSA-PKCS1-v1_5 with SHA-256, using an *rsa.PrivateKey to sign and *rsa.PublicKey to verify.
+	JWTRS256 JWTAlgorithm = "RS256"
+	// JWTEdDSA is Ed25519, using an ed25519.PrivateKey to sign and ed25519.PublicKey to verify.
+	JWTEdDSA JWTAlgorithm = "EdDSA"
+)
+
+var (
+	// ErrJWTInvalidSignature is returned when the signature does not match, or the algorithm does not match the key.
+	ErrJWTInvalidSignature = errors.New("jwt: invalid signature")
+	// ErrJWTExpired is returned when the "exp" claim is in the past.
+	ErrJWTExpired = errors.New("jwt: token has expired")
+	// ErrJWTNotYetValid is returned when the "nbf" claim is in the future.
+	ErrJWTNotYetValid = errors.New("jwt: token is not yet valid")
+	// ErrJWTInvalidAudience is returned when the "aud" claim does not contain the expected audience.
+	ErrJWTInvalidAudience = errors.New("jwt: invalid audience")
+	// ErrJWTInvalidIssuer is returned when the "iss" claim does not match the expected issuer.
+	ErrJWTInvalidIssuer = errors.New("jwt: invalid issuer")
+)
+
+// RegisteredClaims are the standard JWT claims, embed them within your own claims struct.
+type RegisteredClaims struct {
+	Issuer    string         `json:"iss,omitempty"`
+	Subject   string         `json:"sub,omitempty"`
+	Audience  JWTAudience    `json:"aud,omitempty"`
+	ExpiresAt JWTNumericDate `json:"exp,omitempty"`
+	NotBefore JWTNumericDate `json:"nbf,omitempty"`
+	IssuedAt  JWTNumericDate `json:"iat,omitempty"`
+	ID        string         `json:"jti,omitempty"`
+}
+
+// JWTNumericDate is a time claim, such as "exp", in seconds since the Unix epoch.
+//
+// The JWT specification allows fractional seconds, such as 1700000000.5, which are truncated when decoded.
+type JWTNumericDate int64
+
+// UnmarshalJSON accepts an integer or fractional number of seconds, truncating the fraction.
+func (d *JWTNumericDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("jwt: numeric date must be a number: %w", err)
+	}
+
+	if i, err := n.Int64(); err == nil {
+		*d = JWTNumericDate(i)
+		return nil
+	}
+
+	f, err := n.Float64()
+	// Outside of this range the conversion to int64 is undefined.
+	if err != nil || f < math.MinInt64 || f >= math.MaxInt64 {
+		return fmt.Errorf("jwt: numeric date %s is out of range", n)
+	}
+	*d = JWTNumericDate(math.Trunc(f))
+	return nil
+}
+
+// Time returns the date as a time.Time.
+func (d JWTNumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0)
+}
+
+// JWTAudience is the "aud" claim, which may be a single string or an array of strings.
+type JWTAudience []string
+
+// MarshalJSON writes a single audience as a string, as most verifiers expect.
+func (a JWTAudience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON accepts either a string or an array of strings.
+func (a *JWTAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = JWTAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// JWTVerifyOptions contains the options for VerifyJWT.
+type JWTVerifyOptions struct {
+	// Audience, if set, must be within the "aud" claim.
+	Audience string
+	// Issuer, if set, must match the "iss" claim.
+	Issuer string
+	// ClockSkew is the tolerance applied to "exp" and "nbf", for clocks that differ between services.
+	ClockSkew time.Duration
+	// RequireExpiry rejects tokens without an "exp" claim.
+	RequireExpiry bool
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Alg JWTAlgorithm `json:"alg"`
+	Typ string       `json:"typ,omitempty"`
+}
+
+// SignJWT creates a signed JWT in the compact serialisation.
+//
+// Parameters:
+//   - claims: The claims, any value that marshals to a JSON object, such as a struct embedding RegisteredClaims.
+//   - key: A []byte secret for HS256, an *rsa.PrivateKey for RS256 or an ed25519.PrivateKey for EdDSA.
+//   - alg: The JWTAlgorithm to sign with.
+//
+// Returns: The token or an error if the claims cannot be marshalled or the key does not suit the algorithm.
+//
+// Example:
+//
+//	type Claims struct {
+//		RegisteredClaims
+//		Role string `json:"role"`
+//	}
+//
+//	token, err := SignJWT(Claims{
+//		RegisteredClaims: RegisteredClaims{Subject: "billing", Audience: JWTAudience{"api"}, ExpiresAt: JWTNumericDate(time.Now().Add(time.Minute).Unix())},
+//		Role:             "admin",
+//	}, secret, JWTHS256)
+func SignJWT(claims any, key any, alg JWTAlgorithm) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal jwt claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	signature, err := signJWT(signingInput, key, alg)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyJWT verifies a token's signature and registered claims, then unmarshals its claims.
+//
+// The algorithm is decided by the key type rather than trusted from the token, so a token cannot
+// choose a weaker algorithm, such as "none" or HS256 with an RSA public key as the secret.
+//
+// Parameters:
+//   - token: The token in the compact serialisation.
+//   - key: A []byte secret for HS256, an *rsa.PublicKey for RS256 or an ed25519.PublicKey for EdDSA.
+//   - opts: The JWTVerifyOptions to use.
+//   - claims: A pointer to unmarshal the claims into, or nil to only verify.
+//
+// Returns: An error if the token is malformed, the signature is invalid or a claim fails validation,
+// which can be checked with errors.Is against ErrJWTInvalidSignature, ErrJWTExpired and the others.
+//
+// Example:
+//
+//	var claims Claims
+//	err := VerifyJWT(token, secret, JWTVerifyOptions{Audience: "api", ClockSkew: 30 * time.Second}, &claims)
+func VerifyJWT(token string, key any, opts JWTVerifyOptions, claims any) error {
+	return verifyJWT(token, key, opts, claims, time.Now())
+}
+
+// verifyJWT verifies a token, validating the time based claims against now.
+func verifyJWT(token string, key any, opts JWTVerifyOptions, claims any, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return newParseValueError("jwt must have 3 parts")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return newParseValueError("invalid jwt header encoding")
+	}
+
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return newParseValueError("invalid jwt header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return newParseValueError("invalid jwt signature encoding")
+	}
+
+	if err := verifyJWTSignature(parts[0]+"."+parts[1], signature, key, header.Alg); err != nil {
+		return err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return newParseValueError("invalid jwt payload encoding")
+	}
+
+	var registered RegisteredClaims
+	if err := json.Unmarshal(payload, &registered); err != nil {
+		return newParseValueError("invalid jwt claims")
+	}
+
+	if err := validateRegisteredClaims(registered, opts, now); err != nil {
+		return err
+	}
+
+	if claims == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("could not unmarshal jwt claims: %w", err)
+	}
+
+	return nil
+}
+
+// validateRegisteredClaims checks the expiry, not before, audience and issuer claims.
+func validateRegisteredClaims(c RegisteredClaims, opts JWTVerifyOptions, now time.Time) error {
+	if c.ExpiresAt == 0 && opts.RequireExpiry {
+		return fmt.Errorf("%w: missing exp claim", ErrJWTExpired)
+	}
+
+	if c.ExpiresAt != 0 && !now.Before(c.ExpiresAt.Time().Add(opts.ClockSkew)) {
+		return ErrJWTExpired
+	}
+
+	if c.NotBefore != 0 && now.Add(opts.ClockSkew).Before(c.NotBefore.Time()) {
+		return ErrJWTNotYetValid
+	}
+
+	if opts.Audience != "" && !containsString(c.Audience, opts.Audience) {
+		return ErrJWTInvalidAudience
+	}
+
+	if opts.Issuer != "" && c.Issuer != opts.Issuer {
+		return ErrJWTInvalidIssuer
+	}
+
+	return nil
+}
+
+// signJWT signs the input with the key, checking that the key type suits the algorithm.
+func signJWT(signingInput string, key any, alg JWTAlgorithm) ([]byte, error) {
+	switch alg {
+	case JWTHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, newParseValueError("HS256 requires a non-empty []byte key")
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case JWTRS256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, newParseValueError("RS256 requires an *rsa.PrivateKey")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	case JWTEdDSA:
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok || len(privateKey) != ed25519.PrivateKeySize {
+			return nil, newParseValueError("EdDSA requires an ed25519.PrivateKey")
+		}
+		return ed25519.Sign(privateKey, []byte(signingInput)), nil
+	default:
+		return nil, newParseValueError(fmt.Sprintf("unsupported jwt algorithm: %s", alg))
+	}
+}
+
+// verifyJWTSignature checks the signature with the algorithm implied by the key type.
+func verifyJWTSignature(signingInput string, signature []byte, key any, alg JWTAlgorithm) error {
+	switch k := key.(type) {
+	case []byte:
+		if alg != JWTHS256 || len(k) == 0 {
+			return ErrJWTInvalidSignature
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrJWTInvalidSignature
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		if alg != JWTRS256 || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return ErrJWTInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if alg != JWTEdDSA || len(k) != ed25519.PublicKeySize || !ed25519.Verify(k, []byte(signingInput), signature) {
+			return ErrJWTInvalidSignature
+		}
+	default:
+		return newParseValueError(fmt.Sprintf("unsupported jwt verification key type: %T", key))
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testJWTClaims struct {
+	RegisteredClaims
+	Role string `json:"role"`
+}
+
+func TestSignAndVerifyJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+
+	tests := []struct {
+		name       string
+		alg        JWTAlgorithm
+		signKey    any
+		verifyKey  any
+		expectedOK bool
+	}{
+		{"HS256", JWTHS256, secret, secret, true},
+		{"HS256 wrong secret", JWTHS256, secret, []byte("other"), false},
+		{"RS256", JWTRS256, rsaKey, &rsaKey.PublicKey, true},
+		{"EdDSA", JWTEdDSA, edPrivate, edPublic, true},
+		{"EdDSA verified as HS256", JWTEdDSA, edPrivate, secret, false},
+		{"HS256 verified with RSA key", JWTHS256, secret, &rsaKey.PublicKey, false},
+		{"HS256 verified with Ed25519 key", JWTHS256, secret, edPublic, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testJWTClaims{
+				RegisteredClaims: RegisteredClaims{Subject: "billing", Audience: JWTAudience{"api"}},
+				Role:             "admin",
+			}
+
+			token, err := SignJWT(claims, tt.signKey, tt.alg)
+			if err != nil {
+				t.Fatalf("SignJWT() error = %v", err)
+			}
+
+			var got testJWTClaims
+			err = VerifyJWT(token, tt.verifyKey, JWTVerifyOptions{Audience: "api"}, &got)
+			if !tt.expectedOK {
+				if !errors.Is(err, ErrJWTInvalidSignature) {
+					t.Errorf("VerifyJWT() error = %v, expected %v", err, ErrJWTInvalidSignature)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("VerifyJWT() error = %v", err)
+			}
+			if got.Subject != "billing" || got.Role != "admin" || len(got.Audience) != 1 {
+				t.Errorf("VerifyJWT() claims = %+v, expected %+v", got, claims)
+			}
+		})
+	}
+}
+
+func TestSignJWTErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims any
+		key    any
+		alg    JWTAlgorithm
+	}{
+		{"Empty HS256 secret", RegisteredClaims{}, []byte{}, JWTHS256},
+		{"RS256 with secret", RegisteredClaims{}, []byte("secret"), JWTRS256},
+		{"EdDSA with secret", RegisteredClaims{}, []byte("secret"), JWTEdDSA},
+		{"Unsupported algorithm", RegisteredClaims{}, []byte("secret"), "none"},
+		{"Unmarshallable claims", make(chan int), []byte("secret"), JWTHS256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SignJWT(tt.claims, tt.key, tt.alg); err == nil {
+				t.Errorf("SignJWT() expected an error")
+			}
+		})
+	}
+}
+
+func TestVerifyJWTClaims(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	sign := func(c RegisteredClaims) string {
+		token, err := SignJWT(c, secret, JWTHS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		claims   RegisteredClaims
+		opts     JWTVerifyOptions
+		expected error
+	}{
+		{"Valid", RegisteredClaims{ExpiresAt: JWTNumericDate(now.Unix() + 60), NotBefore: JWTNumericDate(now.Unix() - 60)}, JWTVerifyOptions{}, nil},
+		{"Expired", RegisteredClaims{ExpiresAt: JWTNumericDate(now.Unix() - 10)}, JWTVerifyOptions{}, ErrJWTExpired},
+		{"Expired within skew", RegisteredClaims{ExpiresAt: JWTNumericDate(now.Unix() - 10)}, JWTVerifyOptions{ClockSkew: 30 * time.Second}, nil},
+		{"Missing required expiry", RegisteredClaims{}, JWTVerifyOptions{RequireExpiry: true}, ErrJWTExpired},
+		{"Not yet valid", RegisteredClaims{NotBefore: JWTNumericDate(now.Unix() + 10)}, JWTVerifyOptions{}, ErrJWTNotYetValid},
+		{"Not yet valid within skew", RegisteredClaims{NotBefore: JWTNumericDate(now.Unix() + 10)}, JWTVerifyOptions{ClockSkew: 30 * time.Second}, nil},
+		{"Audience within list", RegisteredClaims{Audience: JWTAudience{"web", "api"}}, JWTVerifyOptions{Audience: "api"}, nil},
+		{"Wrong audience", RegisteredClaims{Audience: JWTAudience{"web"}}, JWTVerifyOptions{Audience: "api"}, ErrJWTInvalidAudience},
+		{"Missing audience", RegisteredClaims{}, JWTVerifyOptions{Audience: "api"}, ErrJWTInvalidAudience},
+		{"Issuer", RegisteredClaims{Issuer: "auth"}, JWTVerifyOptions{Issuer: "auth"}, nil},
+		{"Wrong issuer", RegisteredClaims{Issuer: "other"}, JWTVerifyOptions{Issuer: "auth"}, ErrJWTInvalidIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyJWT(sign(tt.claims), secret, tt.opts, nil, now)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("verifyJWT() error = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTFractionalDates(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	token, err := SignJWT(map[string]any{"exp": 1_700_000_060.75, "nbf": 1_699_999_940.25, "role": "admin"}, secret, JWTHS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims struct {
+		RegisteredClaims
+		Role string `json:"role"`
+	}
+	if err := verifyJWT(token, secret, JWTVerifyOptions{}, &claims, now); err != nil {
+		t.Fatalf("verifyJWT() error = %v", err)
+	}
+	if claims.ExpiresAt != 1_700_000_060 || claims.NotBefore != 1_699_999_940 || claims.Role != "admin" {
+		t.Errorf("verifyJWT() claims = %+v, expected the dates truncated", claims)
+	}
+
+	expired, _ := SignJWT(map[string]any{"exp": 1_699_999_999.9}, secret, JWTHS256)
+	if err := verifyJWT(expired, secret, JWTVerifyOptions{}, nil, now); !errors.Is(err, ErrJWTExpired) {
+		t.Errorf("verifyJWT() error = %v, expected %v", err, ErrJWTExpired)
+	}
+}
+
+func TestJWTNumericDate_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected JWTNumericDate
+		err      bool
+	}{
+		{"Integer", "1700000000", 1_700_000_000, false},
+		{"Fraction", "1700000000.999", 1_700_000_000, false},
+		{"Exponent", "1.7e9", 1_700_000_000, false},
+		{"Negative fraction", "-1.5", -1, false},
+		{"Null", "null", 0, false},
+		{"Not a number", `"soon"`, 0, true},
+		{"Out of range", "1e30", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got JWTNumericDate
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.err {
+				t.Fatalf("UnmarshalJSON() error = %v, expected error %v", err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("UnmarshalJSON() = %d, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTMalformed(t *testing.T) {
+	secret := []byte("secret")
+	valid, _ := SignJWT(RegisteredClaims{}, secret, JWTHS256)
+	parts := strings.Split(valid, ".")
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	resign := func(header, payload string) string {
+		sig, _ := signJWT(header+"."+payload, secret, JWTHS256)
+		return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		key    any
+		claims any
+	}{
+		{"Too few parts", "a.b", secret, nil},
+		{"Invalid header encoding", "!." + parts[1] + "." + parts[2], secret, nil},
+		{"Invalid header JSON", encode("{") + "." + parts[1] + "." + parts[2], secret, nil},
+		{"Invalid signature encoding", parts[0] + "." + parts[1] + ".!", secret, nil},
+		{"Unsupported key", valid, "secret", nil},
+		{"Invalid payload encoding", resign(parts[0], "!"), secret, nil},
+		{"Invalid payload JSON", resign(parts[0], encode(`{"exp":"soon"}`)), secret, nil},
+		{"Invalid audience", resign(parts[0], encode(`{"aud":1}`)), secret, nil},
+		{"Claims do not match", resign(parts[0], encode(`{"role":1}`)), secret, &testJWTClaims{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyJWT(tt.token, tt.key, JWTVerifyOptions{}, tt.claims); err == nil {
+				t.Errorf("VerifyJWT() expected an error")
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyJWT(b *testing.B) {
+	secret := []byte("secret")
+	token, _ := SignJWT(RegisteredClaims{Subject: "billing"}, secret, JWTHS256)
+
+	for i := 0; i < b.N; i++ {
+		_ = VerifyJWT(token, secret, JWTVerifyOptions{}, nil)
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaskChar is the character used to hide masked characters.
+const MaskChar = '*'
+
+// MaskMiddle hides the middle of a string, keeping the given number of runes at the start and end.
+//
+// If keeping them would reveal the whole string, the entire string is masked instead.
+//
+// Parameters:
+//   - s: The string to mask.
+//   - keepStart: The number of runes to keep at the start.
+//   - keepEnd: The number of runes to keep at the end.
+//
+// Returns: The masked string, the same length in runes as s.
+//
+// Usage:
+//
+//	MaskMiddle("secret-token", 2, 3) // -> "se*******ken"
+//	MaskMiddle("abc", 2, 2)          // -> "***"
+func MaskMiddle(s string, keepStart, keepEnd int) string {
+	runes := []rune(s)
+
+	keepStart = max(keepStart, 0)
+	keepEnd = max(keepEnd, 0)
+
+	if keepStart+keepEnd >= len(runes) {
+		keepStart, keepEnd = 0, 0
+	}
+
+	for i := keepStart; i < len(runes)-keepEnd; i++ {
+		runes[i] = MaskChar
+	}
+
+	return string(runes)
+}
+
+// MaskEmail hides the local part of an email address, keeping its first character and the domain.
+//
+// Parameters:
+//   - email: The email address to mask.
+//
+// Returns: The masked email address. A value without an '@' is masked as a whole, keeping its first character.
+//
+// Usage:
+//
+//	MaskEmail("john.doe@example.com") // -> "j*******@example.com"
+func MaskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at == -1 {
+		return MaskMiddle(email, 1, 0)
+	}
+
+	return MaskMiddle(email[:at], 1, 0) + email[at:]
+}
+
+// MaskPhone hides all but the last 4 digits of a phone number, keeping any formatting and a leading '+'.
+//
+// Parameters:
+//   - phone: The phone number to mask.
+//
+// Returns: The masked phone number.
+//
+// Usage:
+//
+//	MaskPhone("+44 7700 900123") // -> "+** **** **0123"
+func MaskPhone(phone string) string {
+	return maskDigits(phone, 4)
+}
+
+// MaskCard hides all but the last 4 digits of a payment card number, keeping any spaces or dashes.
+//
+// Parameters:
+//   - card: The card number to mask.
+//
+// Returns: The masked card number.
+//
+// Usage:
+//
+//	MaskCard("4111 1111 1111 1111") // -> "**** **** **** 1111"
+func MaskCard(card string) string {
+	return maskDigits(card, 4)
+}
+
+// maskDigits masks every digit except the last keep digits, leaving other characters untouched.
+//
+// If there are not more than keep digits, every digit is masked.
+//
+// Parameters:
+//   - s: The string to mask.
+//   - keep: The number of trailing digits to keep.
+//
+// Returns: The masked string.
+func maskDigits(s string, keep int) string {
+	runes := []rune(s)
+
+	digits := 0
+	for _, r := range runes {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+
+	if digits <= keep {
+		keep = 0
+	}
+
+	toMask := digits - keep
+	for i, r := range runes {
+		if toMask == 0 {
+			break
+		}
+		if unicode.IsDigit(r) {
+			runes[i] = MaskChar
+			toMask--
+		}
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import "testing"
+
+func TestMaskMiddle(t *testing.T) {
+	tests := []struct {
+		input     string
+		keepStart int
+		keepEnd   int
+		expected  string
+	}{
+		{"secret-token", 2, 3, "se*******ken"},
+		{"abc", 2, 2, "***"},
+		{"abcdef", 0, 0, "******"},
+		{"abcdef", -1, 2, "****ef"},
+		{"pässwörd", 1, 1, "p******d"},
+		{"", 1, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := MaskMiddle(tt.input, tt.keepStart, tt.keepEnd); got != tt.expected {
+				t.Errorf("MaskMiddle(%q, %d, %d) = %q, expected %q", tt.input, tt.keepStart, tt.keepEnd, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"john.doe@example.com", "j*******@example.com"},
+		{"a@example.com", "*@example.com"},
+		{"weird@name@example.com", "w*********@example.com"},
+		{"not-an-email", "n***********"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := MaskEmail(tt.input); got != tt.expected {
+				t.Errorf("MaskEmail(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaskPhoneAndCard(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(string) string
+		input    string
+		expected string
+	}{
+		{"Phone with country code", MaskPhone, "+44 7700 900123", "+** **** **0123"},
+		{"Phone plain", MaskPhone, "07700900123", "*******0123"},
+		{"Phone too short", MaskPhone, "123", "***"},
+		{"Card with spaces", MaskCard, "4111 1111 1111 1111", "**** **** **** 1111"},
+		{"Card with dashes", MaskCard, "4111-1111-1111-1111", "****-****-****-1111"},
+		{"Card plain", MaskCard, "378282246310005", "***********0005"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.input); got != tt.expected {
+				t.Errorf("%s(%q) = %q, expected %q", tt.name, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkMaskEmail(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MaskEmail("john.doe@example.com")
+	}
+}
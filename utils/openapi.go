@@ -0,0 +1,496 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudment/utils-go/internal/structfields"
+)
+
+// SchemaObject is the OpenAPI 3 description of a request struct, the parameters and request body of an operation.
+//
+// It marshals to JSON, or YAML, in the shape expected within an OpenAPI operation object.
+type SchemaObject struct {
+	Parameters  []ParameterObject  `json:"parameters,omitempty"`
+	RequestBody *RequestBodyObject `json:"requestBody,omitempty"`
+}
+
+// ParameterObject is an OpenAPI parameter, read from the query or a header.
+type ParameterObject struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Style    string  `json:"style,omitempty"`
+	Explode  bool    `json:"explode,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBodyObject is an OpenAPI request body, keyed by media type.
+type RequestBodyObject struct {
+	Required bool                       `json:"required,omitempty"`
+	Content  map[string]MediaTypeObject `json:"content"`
+}
+
+// MediaTypeObject is the schema of a request body for one media type.
+type MediaTypeObject struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is an OpenAPI schema, describing a single value.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// openAPIFormats maps the validate tag formats to OpenAPI formats or patterns.
+var openAPIFormats = map[string]Schema{
+	"email":    {Format: "email"},
+	"url":      {Format: "uri"},
+	"uuid":     {Format: "uuid"},
+	"hostname": {Format: "hostname"},
+	"e164":     {Pattern: `^\+[1-9][0-9]{1,14}$`},
+	"semver":   {Pattern: `^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`},
+}
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+// OpenAPISchema describes a BindRequest struct as OpenAPI 3 parameters and a request body.
+//
+// The fields are walked as BindRequest binds them: fields with a `query` or `header` tag become parameters,
+// fields with a `json` tag become properties of the JSON request body and fields with a `form` tag become properties
+// of the form request body. A form with a *multipart.FileHeader field is described as multipart/form-data, its files
+// as binary strings.
+//
+// A nested struct is described by its dotted keys, such as the "address.city" query parameter, and an embedded struct
+// without tags by its own fields, within the parameters and the JSON body alike. A map query parameter is described as
+// a deepObject, such as "meta[env]=prod". A Binder field is only described within the JSON body, as it reads the
+// request itself.
+//
+// The `required:"true"` tag marks the field as required, the `default` tag sets its default,
+// and the `validate` tag adds formats such as "email" or "uuid", "min=" and "max=" limits and "oneof=" enums.
+//
+// Parameters:
+//   - v: The request struct, or a pointer to it.
+//
+// Returns: The SchemaObject, or an error if v is not a struct or contains a type OpenAPI cannot describe.
+//
+// Example:
+//
+//	type CreateUser struct {
+//	 TenantID string `header:"X-Tenant-ID" required:"true" validate:"uuid"`
+//	 Email    string `json:"email" required:"true" validate:"email"`
+//	 Age      int    `json:"age" validate:"min=18"`
+//	}
+//
+//	schema, err := OpenAPISchema(CreateUser{})
+//	out, _ := json.MarshalIndent(schema, "", "  ")
+//
+// Note: Path parameters are not bound by BindRequest, so they are left to the operation to describe.
+func OpenAPISchema(v interface{}) (*SchemaObject, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, newParseValueError(fmt.Sprintf("OpenAPISchema expects a struct, got %v", t))
+	}
+
+	o := &openAPIBuilder{
+		result:   &SchemaObject{},
+		jsonBody: &Schema{Type: "object", Properties: map[string]*Schema{}},
+		formBody: &Schema{Type: "object", Properties: map[string]*Schema{}},
+		formType: "application/x-www-form-urlencoded",
+		seen:     map[reflect.Type]bool{},
+	}
+	if err := o.describeStruct(t, bindScope{}, true); err != nil {
+		return nil, err
+	}
+
+	result := o.result
+	for _, body := range []struct {
+		mediaType string
+		schema    *Schema
+	}{
+		{"application/json", o.jsonBody},
+		{o.formType, o.formBody},
+	} {
+		if len(body.schema.Properties) == 0 {
+			continue
+		}
+		if result.RequestBody == nil {
+			result.RequestBody = &RequestBodyObject{Content: map[string]MediaTypeObject{}}
+		}
+		result.RequestBody.Required = result.RequestBody.Required || len(body.schema.Required) > 0
+		result.RequestBody.Content[body.mediaType] = MediaTypeObject{Schema: body.schema}
+	}
+
+	return result, nil
+}
+
+// openAPIBuilder collects the parameters and request bodies of a struct for OpenAPISchema.
+type openAPIBuilder struct {
+	result   *SchemaObject
+	jsonBody *Schema
+	formBody *Schema
+	// formType is the media type of the form body, multipart/form-data once a file is found as it cannot be sent otherwise.
+	formType string
+	// seen holds the struct types being described, to detect recursive types.
+	seen map[reflect.Type]bool
+}
+
+// describeStruct describes each field of a struct, descending into nested structs as bindStruct does.
+//
+// Parameters:
+//   - t: The struct type.
+//   - scope: The scope the fields are bound within, giving the keys of a nested struct such as "address.city".
+//   - inJSON: Whether the fields are properties of the JSON body, true at the top level and within the embedded
+//     structs encoding/json flattens into it.
+//
+// Returns: An error if a field has a type OpenAPI cannot describe.
+func (o *openAPIBuilder) describeStruct(t reflect.Type, scope bindScope, inJSON bool) error {
+	if o.seen[t] {
+		return fmt.Errorf("recursive type %s", t)
+	}
+	o.seen[t] = true
+	defer delete(o.seen, t)
+
+	for _, sf := range structfields.Fields(t) {
+		if (!sf.IsExported() && !sf.Anonymous) || isUnexportedEmbed(sf) {
+			continue
+		}
+
+		jsonName := ""
+		if inJSON {
+			jsonName = jsonFieldName(sf)
+		}
+
+		if isNestedStruct(sf.Type) && !isBinder(sf.Type) && !isFileField(sf.Type) {
+			// An embedded struct without a name is flattened into the body by encoding/json.
+			flatten := inJSON && sf.Anonymous && jsonName == "" && sf.Tag.Get("json") != "-"
+			if jsonName != "" {
+				if err := o.addJSONProperty(sf, jsonName); err != nil {
+					return err
+				}
+			}
+
+			nested := sf.Type
+			if nested.Kind() == reflect.Pointer {
+				nested = nested.Elem()
+			}
+			if err := o.describeStruct(nested, scope.nested(sf, sf.Name), flatten); err != nil {
+				return fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if jsonName != "" {
+			if err := o.addJSONProperty(sf, jsonName); err != nil {
+				return err
+			}
+		}
+
+		// A Binder reads the request itself, so its keys are unknown.
+		if isBinder(sf.Type) {
+			continue
+		}
+
+		keys := bindKeys{query: scope.queryKey(sf.Tag.Get("query")), form: scope.formKey(sf.Tag.Get("form")), header: sf.Tag.Get("header")}
+		if keys.query == "" && keys.form == "" && keys.header == "" {
+			continue
+		}
+
+		schema, err := fieldSchema(sf, o.seen)
+		if err != nil {
+			return err
+		}
+		required := sf.Tag.Get("required") == "true"
+
+		if keys.query != "" {
+			param := ParameterObject{Name: keys.query, In: "query", Required: required, Schema: schema}
+			// A map takes the keys under its own, such as "meta[env]=prod".
+			if t := sf.Type; t.Kind() == reflect.Map || (t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Map) {
+				param.Style, param.Explode = "deepObject", true
+			}
+			o.result.Parameters = append(o.result.Parameters, param)
+		}
+		if keys.header != "" {
+			o.result.Parameters = append(o.result.Parameters, ParameterObject{Name: keys.header, In: "header", Required: required, Schema: schema})
+		}
+		if keys.form != "" {
+			addProperty(o.formBody, keys.form, schema, required)
+			if isFileField(sf.Type) {
+				o.formType = "multipart/form-data"
+			}
+		}
+	}
+
+	return nil
+}
+
+// addJSONProperty adds a field to the JSON body.
+func (o *openAPIBuilder) addJSONProperty(sf reflect.StructField, name string) error {
+	schema, err := fieldSchema(sf, o.seen)
+	if err != nil {
+		return err
+	}
+	addProperty(o.jsonBody, name, schema, sf.Tag.Get("required") == "true")
+	return nil
+}
+
+// addProperty adds a property to an object schema.
+func addProperty(object *Schema, name string, schema *Schema, required bool) {
+	object.Properties[name] = schema
+	if required {
+		object.Required = append(object.Required, name)
+	}
+}
+
+// jsonFieldName returns the name from the `json` tag, or an empty string if the field has none or is skipped.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldSchema describes a struct field, applying its `default` and `validate` tags.
+//
+// Parameters:
+//   - field: The struct field.
+//   - seen: The struct types being described, to detect recursive types.
+//
+// Returns: The schema, or an error if the type cannot be described.
+func fieldSchema(field reflect.StructField, seen map[reflect.Type]bool) (*Schema, error) {
+	schema, err := typeSchema(field.Type, seen)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		schema.Default = defaultValue(field.Type, def)
+	}
+
+	if rules := field.Tag.Get("validate"); rules != "" {
+		applyValidateRules(schema, rules)
+	}
+
+	return schema, nil
+}
+
+// typeSchema describes a Go type.
+//
+// Parameters:
+//   - t: The type.
+//   - seen: The struct types being described, to detect recursive types.
+//
+// Returns: The schema, or an error if the type cannot be described.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case fileHeaderType.Elem():
+		return &Schema{Type: "string", Format: "binary"}, nil
+	}
+
+	// A struct parsed from a single value, such as url.URL or an enum, is bound as a string.
+	if t.Kind() == reflect.Struct && isTextType(t) {
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}, nil
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys must be strings, got %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return structSchema(t, seen)
+	case reflect.Interface:
+		// Any value is allowed, which an empty schema describes.
+		return &Schema{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structSchema describes a nested struct by its `json` field names, as it can only be sent within a JSON body.
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if err := addStructProperties(schema, t, seen); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// addStructProperties adds the fields of a struct to an object schema, flattening embedded structs without a name
+// as encoding/json does.
+func addStructProperties(schema *Schema, t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return fmt.Errorf("recursive type %s", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for _, field := range structfields.Fields(t) {
+		if (!field.IsExported() && !field.Anonymous) || isUnexportedEmbed(field) {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := jsonFieldName(field)
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && isNestedStruct(embedded) {
+			if err := addStructProperties(schema, embedded, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fs, err := fieldSchema(field, seen)
+		if err != nil {
+			return err
+		}
+		addProperty(schema, name, fs, field.Tag.Get("required") == "true")
+	}
+
+	return nil
+}
+
+// defaultValue converts a `default` tag to the field's type, so the schema shows 10 rather than "10".
+//
+// Values that cannot be converted are kept as strings.
+func defaultValue(t reflect.Type, def string) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if err := setFieldValue(v, def); err == nil {
+			return v.Interface()
+		}
+	}
+
+	return def
+}
+
+// applyValidateRules adds the constraints from a `validate` tag to the schema.
+//
+// Rules are comma separated, such as "email", "min=1,max=10" or "oneof=asc desc".
+// Rules OpenAPI cannot describe are ignored, as they are still enforced when binding.
+func applyValidateRules(schema *Schema, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		if format, ok := openAPIFormats[name]; ok {
+			schema.Format = format.Format
+			schema.Pattern = format.Pattern
+			continue
+		}
+
+		switch name {
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			applyLimit(schema, name == "min", n)
+		case "oneof":
+			for _, option := range strings.Fields(arg) {
+				schema.Enum = append(schema.Enum, option)
+			}
+		}
+	}
+}
+
+// applyLimit sets a minimum or maximum, which applies to the length of strings and arrays.
+func applyLimit(schema *Schema, isMin bool, n float64) {
+	length := int(n)
+
+	switch {
+	case schema.Type == "string" && isMin:
+		schema.MinLength = &length
+	case schema.Type == "string":
+		schema.MaxLength = &length
+	case schema.Type == "array" && isMin:
+		schema.MinItems = &length
+	case schema.Type == "array":
+		schema.MaxItems = &length
+	case isMin:
+		schema.Minimum = &n
+	default:
+		schema.Maximum = &n
+	}
+}
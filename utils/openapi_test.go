@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type openAPIAddress struct {
+	Line1    string `json:"line1" required:"true"`
+	Postcode string
+	Internal string `json:"-"`
+}
+
+type openAPIRequest struct {
+	TenantID string            `header:"X-Tenant-ID" required:"true"`
+	Sort     string            `query:"sort" default:"asc" validate:"oneof=asc desc"`
+	Limit    int               `query:"limit" default:"10" validate:"min=1,max=100"`
+	Email    string            `json:"email,omitempty" required:"true" validate:"email,min=3,max=254"`
+	Phone    string            `json:"phone" validate:"e164"`
+	Tags     []string          `json:"tags" validate:"min=1,max=5,unknown"`
+	Avatar   []byte            `json:"avatar"`
+	Labels   map[string]int32  `json:"labels"`
+	Address  *openAPIAddress   `json:"address"`
+	Created  time.Time         `json:"created"`
+	Timeout  time.Duration     `json:"timeout" default:"5s"`
+	Score    float32           `json:"score" validate:"min=0.5,max=abc"`
+	Ratio    float64           `json:"ratio"`
+	Count    uint              `json:"count"`
+	Small    int8              `json:"small"`
+	Active   bool              `json:"active" default:"true"`
+	Extra    any               `json:"extra"`
+	Name     string            `form:"name" required:"true"`
+	Skipped  string            `json:"-"`
+	Meta     map[string]string `json:"meta" validate:"semver,url,hostname"`
+	internal string
+}
+
+func TestOpenAPISchema(t *testing.T) {
+	schema, err := OpenAPISchema(&openAPIRequest{})
+	if err != nil {
+		t.Fatalf("OpenAPISchema() error = %v", err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	got := string(data)
+
+	expectedParts := []string{
+		`{"name":"X-Tenant-ID","in":"header","required":true,"schema":{"type":"string"}}`,
+		`{"name":"sort","in":"query","schema":{"type":"string","enum":["asc","desc"],"default":"asc"}}`,
+		`{"name":"limit","in":"query","schema":{"type":"integer","format":"int64","default":10,"minimum":1,"maximum":100}}`,
+		`"email":{"type":"string","format":"email","minLength":3,"maxLength":254}`,
+		`"phone":{"type":"string","pattern":"^\\+[1-9][0-9]{1,14}$"}`,
+		`"tags":{"type":"array","minItems":1,"maxItems":5,"items":{"type":"string"}}`,
+		`"avatar":{"type":"string","format":"byte"}`,
+		`"labels":{"type":"object","additionalProperties":{"type":"integer","format":"int32"}}`,
+		`"address":{"type":"object","properties":{"Postcode":{"type":"string"},"line1":{"type":"string"}},"required":["line1"]}`,
+		`"created":{"type":"string","format":"date-time"}`,
+		`"timeout":{"type":"integer","format":"int64","default":"5s"}`,
+		`"score":{"type":"number","format":"float","minimum":0.5}`,
+		`"ratio":{"type":"number","format":"double"}`,
+		`"count":{"type":"integer","minimum":0}`,
+		`"active":{"type":"boolean","default":true}`,
+		`"extra":{}`,
+		`"required":["email"]`,
+		`"application/x-www-form-urlencoded":{"schema":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}}`,
+		`"requestBody":{"required":true,`,
+	}
+
+	for _, part := range expectedParts {
+		if !strings.Contains(got, part) {
+			t.Errorf("OpenAPISchema() = %s\nexpected to contain %s", got, part)
+		}
+	}
+
+	if strings.Contains(got, "Skipped") || strings.Contains(got, "internal") || strings.Contains(got, "Internal") {
+		t.Errorf("OpenAPISchema() = %s, expected skipped and unexported fields to be left out", got)
+	}
+}
+
+func TestOpenAPISchemaParametersOnly(t *testing.T) {
+	type params struct {
+		Query string `query:"q"`
+	}
+
+	schema, err := OpenAPISchema(params{})
+	if err != nil {
+		t.Fatalf("OpenAPISchema() error = %v", err)
+	}
+	if schema.RequestBody != nil || len(schema.Parameters) != 1 {
+		t.Errorf("OpenAPISchema() = %+v, expected a single parameter without a body", schema)
+	}
+}
+
+func TestOpenAPISchemaMultipart(t *testing.T) {
+	schema, err := OpenAPISchema(uploadRequest{})
+	if err != nil {
+		t.Fatalf("OpenAPISchema() error = %v", err)
+	}
+
+	out, _ := json.Marshal(schema)
+	got := string(out)
+	for _, expected := range []string{
+		`"multipart/form-data":{"schema":{"type":"object","properties":{`,
+		`"avatar":{"type":"string","format":"binary"}`,
+		`"photos":{"type":"array","items":{"type":"string","format":"binary"}}`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("OpenAPISchema() = %s, expected it to contain %s", got, expected)
+		}
+	}
+	if strings.Contains(got, "x-www-form-urlencoded") {
+		t.Errorf("OpenAPISchema() = %s, expected a form with files to only be multipart/form-data", got)
+	}
+}
+
+type openAPIPage struct {
+	Page  int    `query:"page" default:"1"`
+	Token string `json:"token"`
+}
+
+type openAPIFilter struct {
+	City string            `query:"city" form:"city" required:"true"`
+	Tags []string          `query:"tags"`
+	Meta map[string]string `query:"meta"`
+}
+
+type openAPIOwner struct {
+	openAPIPage
+	Name string `json:"name"`
+}
+
+type openAPINestedRequest struct {
+	openAPIPage
+	ID     string        `path:"id"`
+	Filter openAPIFilter `query:"filter" form:"filter"`
+	Owner  *openAPIOwner `json:"owner"`
+	Since  *url.URL      `query:"since"`
+}
+
+func TestOpenAPISchemaNested(t *testing.T) {
+	schema, err := OpenAPISchema(openAPINestedRequest{})
+	if err != nil {
+		t.Fatalf("OpenAPISchema() error = %v", err)
+	}
+
+	out, _ := json.Marshal(schema)
+	got := string(out)
+	for _, expected := range []string{
+		`{"name":"page","in":"query","schema":{"type":"integer","format":"int64","default":1}}`,
+		`{"name":"filter.city","in":"query","required":true,"schema":{"type":"string"}}`,
+		`{"name":"filter.tags","in":"query","schema":{"type":"array","items":{"type":"string"}}}`,
+		`{"name":"filter.meta","in":"query","style":"deepObject","explode":true,"schema":{"type":"object","additionalProperties":{"type":"string"}}}`,
+		`{"name":"since","in":"query","schema":{"type":"string"}}`,
+		`"token":{"type":"string"}`,
+		`"owner":{"type":"object","properties":{"Page":{"type":"integer","format":"int64","default":1},"name":{"type":"string"},"token":{"type":"string"}}}`,
+		`"application/x-www-form-urlencoded":{"schema":{"type":"object","properties":{"filter.city":{"type":"string"}},"required":["filter.city"]}}`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("OpenAPISchema() = %s, expected it to contain %s", got, expected)
+		}
+	}
+	if strings.Contains(got, `"path"`) || strings.Contains(got, `"openAPIPage"`) {
+		t.Errorf("OpenAPISchema() = %s, expected no path parameter and the embedded struct to be flattened", got)
+	}
+}
+
+type openAPIRecursive struct {
+	Children []openAPIRecursive `json:"children"`
+}
+
+func TestOpenAPISchemaErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{"Nil", nil},
+		{"Not a struct", "string"},
+		{"Unsupported type", struct {
+			C chan int `json:"c"`
+		}{}},
+		{"Unsupported map key", struct {
+			M map[int]string `json:"m"`
+		}{}},
+		{"Unsupported map value", struct {
+			M map[string]func() `json:"m"`
+		}{}},
+		{"Unsupported slice element", struct {
+			S []complex64 `json:"s"`
+		}{}},
+		{"Unsupported nested field", struct {
+			N struct{ F func() } `json:"n"`
+		}{}},
+		{"Recursive", openAPIRecursive{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenAPISchema(tt.input); err == nil {
+				t.Errorf("OpenAPISchema() expected an error")
+			}
+		})
+	}
+}
+
+func BenchmarkOpenAPISchema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = OpenAPISchema(openAPIRequest{})
+	}
+}
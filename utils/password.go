@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashParams are the argon2id parameters used by HashPassword, they are encoded into the hash string.
+//
+// Zero values are replaced with those of DefaultHashParams.
+type HashParams struct {
+	// Memory is the memory cost in KiB.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the number of threads used.
+	Parallelism uint8
+	// SaltLength is the length of the random salt in bytes.
+	SaltLength uint32
+	// KeyLength is the length of the derived key in bytes.
+	KeyLength uint32
+}
+
+// DefaultHashParams returns the parameters used for any zero HashParams fields.
+//
+// Returns: 64 MiB of memory, 3 iterations, 2 threads, a 16 byte salt and a 32 byte key.
+func DefaultHashParams() HashParams {
+	return HashParams{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// withDefaults replaces zero fields with those of DefaultHashParams.
+func (p HashParams) withDefaults() HashParams {
+	d := DefaultHashParams()
+	if p.Memory == 0 {
+		p.Memory = d.Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = d.Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = d.Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = d.SaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = d.KeyLength
+	}
+	return p
+}
+
+// HashPassword hashes a password with argon2id and a random salt using the default rand.Reader.
+//
+// The result is in the PHC string format, so the parameters can be read back when verifying.
+//
+// Parameters:
+//   - password: The password to hash.
+//   - params: The HashParams to use, zero fields use DefaultHashParams.
+//
+// Returns: The encoded hash or an error if the salt could not be generated.
+//
+// Example:
+//
+//	hash, err := HashPassword("hunter2", HashParams{})
+//	fmt.Println(hash) // Output: "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>"
+func HashPassword(password string, params HashParams) (string, error) {
+	return hashPassword(password, params, rand.Reader)
+}
+
+// hashPassword hashes a password with argon2id using the provided reader for the salt.
+func hashPassword(password string, params HashParams, reader io.Reader) (string, error) {
+	params = params.withDefaults()
+
+	salt, err := generateRandomBytes(int(params.SaltLength), reader)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword checks a password against a hash from HashPassword.
+//
+// Hashes from bcrypt, starting with "$2a$", "$2b$" or "$2y$", are also accepted so existing
+// users can log in and have their password rehashed.
+//
+// Parameters:
+//   - password: The password to check.
+//   - encoded: The encoded hash.
+//
+// Returns: True if the password matches, or an error if the hash is malformed.
+//
+// Example:
+//
+//	ok, err := VerifyPassword("hunter2", hash)
+//	if err == nil && ok && NeedsRehash(hash, HashParams{}) {
+//		newHash, err := HashPassword("hunter2", HashParams{})
+//		// store newHash
+//	}
+func VerifyPassword(password, encoded string) (bool, error) {
+	if isBcryptHash(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, newParseValueError("invalid bcrypt hash: " + err.Error())
+		}
+		return true, nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+// NeedsRehash checks if a hash was created with different parameters, or with bcrypt.
+//
+// It is intended to be called after a successful VerifyPassword, while the plain password is available.
+//
+// Parameters:
+//   - encoded: The encoded hash.
+//   - params: The current HashParams, zero fields use DefaultHashParams.
+//
+// Returns: True if the password should be hashed again, including when the hash is malformed.
+func NeedsRehash(encoded string, params HashParams) bool {
+	current, _, _, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return true
+	}
+
+	return current != params.withDefaults()
+}
+
+// isBcryptHash checks for the bcrypt hash prefixes.
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2Hash parses an argon2id hash in the PHC string format.
+//
+// Parameters:
+//   - encoded: The encoded hash, such as "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>".
+//
+// Returns: The parameters, salt and key, or an error if the hash is malformed or uses another version.
+func decodeArgon2Hash(encoded string) (HashParams, []byte, []byte, error) {
+	var params HashParams
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, newParseValueError("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, newParseValueError("invalid argon2id hash version")
+	}
+	if version != argon2.Version {
+		return params, nil, nil, newParseValueError(fmt.Sprintf("unsupported argon2id version: %d", version))
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, newParseValueError("invalid argon2id hash parameters")
+	}
+	// argon2 panics on zero iterations or parallelism, so these are rejected rather than passed through.
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, newParseValueError("invalid argon2id hash parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, newParseValueError("invalid argon2id hash salt")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, newParseValueError("invalid argon2id hash key")
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHashParams keeps the tests fast, the defaults use 64 MiB per hash.
+var testHashParams = HashParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("hunter2", testHashParams)
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("HashPassword() = %q, expected the argon2id PHC prefix", hash)
+	}
+
+	other, _ := HashPassword("hunter2", testHashParams)
+	if hash == other {
+		t.Errorf("HashPassword() produced the same hash twice, expected a random salt")
+	}
+
+	_, err = hashPassword("hunter2", testHashParams, &errorReader{})
+	if err == nil {
+		t.Errorf("hashPassword() with a failing reader, expected an error")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, _ := HashPassword("hunter2", testHashParams)
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+
+	tests := []struct {
+		name        string
+		password    string
+		encoded     string
+		expected    bool
+		expectedErr bool
+	}{
+		{"Correct password", "hunter2", hash, true, false},
+		{"Wrong password", "hunter3", hash, false, false},
+		{"Bcrypt correct password", "hunter2", string(bcryptHash), true, false},
+		{"Bcrypt wrong password", "hunter3", string(bcryptHash), false, false},
+		{"Bcrypt malformed", "hunter2", "$2a$10$short", false, true},
+		{"Not a hash", "hunter2", "plain", false, true},
+		{"Other algorithm", "hunter2", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", false, true},
+		{"Invalid version", "hunter2", "$argon2id$v=x$m=1024,t=1,p=1$c2FsdA$a2V5", false, true},
+		{"Unsupported version", "hunter2", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", false, true},
+		{"Invalid parameters", "hunter2", "$argon2id$v=19$m=1024$c2FsdA$a2V5", false, true},
+		{"Zero parameters", "hunter2", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", false, true},
+		{"Invalid salt", "hunter2", "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", false, true},
+		{"Invalid key", "hunter2", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyPassword(tt.password, tt.encoded)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("VerifyPassword() error = %v, expected error %v", err, tt.expectedErr)
+			}
+			if got != tt.expected {
+				t.Errorf("VerifyPassword() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, _ := HashPassword("hunter2", testHashParams)
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+
+	tests := []struct {
+		name     string
+		encoded  string
+		params   HashParams
+		expected bool
+	}{
+		{"Same parameters", hash, testHashParams, false},
+		{"More iterations", hash, HashParams{Memory: 1024, Iterations: 2, Parallelism: 1}, true},
+		{"Defaults", hash, HashParams{}, true},
+		{"Bcrypt", string(bcryptHash), testHashParams, true},
+		{"Malformed", "plain", testHashParams, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRehash(tt.encoded, tt.params); got != tt.expected {
+				t.Errorf("NeedsRehash() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyPassword(b *testing.B) {
+	hash, _ := HashPassword("hunter2", testHashParams)
+	for i := 0; i < b.N; i++ {
+		_, _ = VerifyPassword("hunter2", hash)
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+// Ptr returns a pointer to a copy of the value, for setting optional pointer fields from literals.
+//
+// Parameters:
+//   - v: The value.
+//
+// Returns: A pointer to a copy of v.
+//
+// Usage:
+//
+//	update := UserUpdate{Name: Ptr("Alice"), Age: Ptr(30)}
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value the pointer points to, or the fallback if it is nil.
+//
+// Parameters:
+//   - p: The pointer.
+//   - fallback: The value to return if p is nil.
+//
+// Returns: The value of p, or fallback.
+//
+// Usage:
+//
+//	limit := Deref(req.Limit, 10)
+func Deref[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
+
+// IsZero checks if the value is the zero value of its type, without reflection.
+//
+// Parameters:
+//   - v: The value.
+//
+// Returns: True if v is the zero value, false otherwise.
+//
+// Usage:
+//
+//	IsZero("")  // -> true
+//	IsZero(0.5) // -> false
+func IsZero[T comparable](v T) bool {
+	var zero T
+	return v == zero
+}
@@ -0,0 +1,70 @@
+package utils
+
+import "testing"
+
+func TestPtr(t *testing.T) {
+	v := 42
+	p := Ptr(v)
+	if *p != 42 {
+		t.Errorf("Ptr() = %v, expected %v", *p, 42)
+	}
+
+	*p = 1
+	if v != 42 {
+		t.Errorf("Ptr() returned a pointer to the original value, expected a copy")
+	}
+}
+
+func TestDeref(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        *string
+		expected string
+	}{
+		{"Nil", nil, "fallback"},
+		{"Set", Ptr("value"), "value"},
+		{"Set to zero", Ptr(""), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Deref(tt.p, "fallback"); got != tt.expected {
+				t.Errorf("Deref() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	type point struct{ X, Y int }
+
+	tests := []struct {
+		name     string
+		got      bool
+		expected bool
+	}{
+		{"Empty string", IsZero(""), true},
+		{"String", IsZero("a"), false},
+		{"Zero int", IsZero(0), true},
+		{"Float", IsZero(0.5), false},
+		{"Zero struct", IsZero(point{}), true},
+		{"Struct", IsZero(point{X: 1}), false},
+		{"Nil pointer", IsZero[*int](nil), true},
+		{"Pointer to zero", IsZero(Ptr(0)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("IsZero() = %v, expected %v", tt.got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkDeref(b *testing.B) {
+	p := Ptr(10)
+	for i := 0; i < b.N; i++ {
+		Deref(p, 0)
+	}
+}
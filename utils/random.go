@@ -274,3 +274,45 @@ func generateRandomBytesGeneric[T Integer](n T, reader io.Reader) ([]byte, error
 	}
 	return b, nil
 }
+
+// GenerateJitter randomises a duration by up to the given factor in either direction using the default rand.Reader.
+//
+// A factor of 0.5 with a duration of 10s produces a duration between 5s and 15s.
+// This is commonly used to spread out retries, so many clients do not retry at the same moment.
+//
+// Parameters:
+//   - d: The duration to randomise.
+//   - factor: The randomisation factor, between 0 and 1.
+//
+// Returns: The randomised duration or an error if the generation fails.
+//
+// Example:
+//
+//	jittered, err := GenerateJitter(10*time.Second, 0.5)
+//	fmt.Println(jittered) // Output: 7.3s
+func GenerateJitter(d time.Duration, factor float64) (time.Duration, error) {
+	return generateJitter(d, factor, rand.Reader)
+}
+
+// generateJitter randomises a duration by up to the given factor using the provided reader.
+// It uses generateRandomDuration to pick a duration within the jittered range.
+//
+// Parameters:
+//   - d: The duration to randomise.
+//   - factor: The randomisation factor, between 0 and 1.
+//   - reader: The io.Reader to use for generating random numbers.
+//
+// Returns: The randomised duration or an error if the generation fails.
+func generateJitter(d time.Duration, factor float64, reader io.Reader) (time.Duration, error) {
+	if factor < 0 || factor > 1 {
+		return 0, newParseValueError("factor should be between 0 and 1")
+	}
+
+	delta := time.Duration(float64(d) * factor)
+	if d <= 0 || delta <= 0 {
+		return d, nil
+	}
+
+	// max is exclusive, adding 1 allows the upper bound to be picked.
+	return generateRandomDuration(int(d-delta), int(d+delta)+1, time.Nanosecond, reader)
+}
@@ -271,3 +271,34 @@ func BenchmarkGenerateRandomBytesWithGenericsInt32(b *testing.B) {
 		}
 	}
 }
+
+func TestGenerateJitter(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		d, err := GenerateJitter(10*time.Second, 0.5)
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if d < 5*time.Second || d > 15*time.Second {
+			t.Errorf("Expected duration between 5s and 15s, got %s", d)
+		}
+	}
+
+	d, err := GenerateJitter(time.Second, 0)
+	if err != nil || d != time.Second {
+		t.Errorf("Expected 1s without error, got %s, %v", d, err)
+	}
+
+	if _, err = GenerateJitter(time.Second, 1.5); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	if _, err = generateJitter(time.Second, 0.5, &errorReader{}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func BenchmarkGenerateJitter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = GenerateJitter(time.Second, 0.5)
+	}
+}
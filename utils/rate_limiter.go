@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket, it holds up to burst tokens and refills at rate tokens per second.
+//
+// Each allowed event takes a token, once empty events are denied until it has refilled.
+type TokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	lastUsed time.Time
+	now      func() time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket.
+//
+// Parameters:
+//   - rate: The number of tokens added per second.
+//   - burst: The maximum number of tokens held at once.
+//
+// Returns: The TokenBucket.
+//
+// Example:
+//
+//	bucket := NewTokenBucket(5, 10) // 5 requests per second, bursts of up to 10
+//	if !bucket.Allow() {
+//		// Too many requests
+//	}
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return newTokenBucket(rate, burst, time.Now)
+}
+
+// newTokenBucket creates a full TokenBucket using the provided clock.
+func newTokenBucket(rate float64, burst int, now func() time.Time) *TokenBucket {
+	t := now()
+	return &TokenBucket{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     t,
+		lastUsed: t,
+		now:      now,
+	}
+}
+
+// Allow reports whether a single event may happen now, taking a token if it can.
+//
+// Returns: True if the event is allowed, false otherwise.
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, taking n tokens if they can.
+//
+// Parameters:
+//   - n: The number of tokens to take.
+//
+// Returns: True if the events are allowed, false otherwise. No tokens are taken when false.
+func (b *TokenBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	if b.tokens < float64(n) {
+		return false
+	}
+
+	b.tokens -= float64(n)
+	return true
+}
+
+// RetryAfter returns how long until a single token is available.
+//
+// Returns: The duration to wait, 0 if a token is available now.
+func (b *TokenBucket) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	if b.tokens >= 1 || b.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// refill adds the tokens earned since the last refill, the caller must hold the lock.
+func (b *TokenBucket) refill() {
+	t := b.now()
+	elapsed := t.Sub(b.last).Seconds()
+
+	b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	b.last = t
+	b.lastUsed = t
+}
+
+// touch marks the bucket as used without taking a token.
+func (b *TokenBucket) touch() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastUsed = b.now()
+}
+
+// idleSince reports whether the bucket has not been used since the given time.
+func (b *TokenBucket) idleSince(t time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lastUsed.Before(t)
+}
+
+// DefaultRateLimiterMaxKeys is the number of per-key buckets a RateLimiter holds at most, unless set with SetMaxKeys.
+const DefaultRateLimiterMaxKeys = 100000
+
+// RateLimiter is a token bucket rate limiter with a global bucket and optional per-key buckets.
+//
+// Per-key buckets share the same rate and burst, they are evicted after not being used for the TTL.
+// At most DefaultRateLimiterMaxKeys buckets are held, or the number set with SetMaxKeys, so many distinct keys cannot
+// exhaust memory.
+type RateLimiter struct {
+	*TokenBucket
+
+	rate    float64
+	burst   int
+	ttl     time.Duration
+	maxKeys int
+
+	mu        sync.Mutex
+	buckets   map[string]*TokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter.
+//
+// Parameters:
+//   - rate: The number of events allowed per second.
+//   - burst: The maximum number of events allowed at once.
+//   - ttl: How long an unused per-key bucket is kept, 0 defaults to 10 minutes.
+//
+// Returns: The RateLimiter.
+//
+// Example:
+//
+//	limiter := NewRateLimiter(5, 10, time.Minute)
+//
+//	limiter.Allow()                  // Global limit
+//	limiter.PerKey(userID).Allow()   // Limit per user
+func NewRateLimiter(rate float64, burst int, ttl time.Duration) *RateLimiter {
+	return newRateLimiter(rate, burst, ttl, time.Now)
+}
+
+// newRateLimiter creates a RateLimiter using the provided clock.
+func newRateLimiter(rate float64, burst int, ttl time.Duration, now func() time.Time) *RateLimiter {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+
+	return &RateLimiter{
+		TokenBucket: newTokenBucket(rate, burst, now),
+		rate:        rate,
+		burst:       burst,
+		ttl:         ttl,
+		maxKeys:     DefaultRateLimiterMaxKeys,
+		buckets:     make(map[string]*TokenBucket),
+		lastSweep:   now(),
+		now:         now,
+	}
+}
+
+// SetMaxKeys sets the number of per-key buckets held at most.
+//
+// Parameters:
+//   - n: The maximum number of buckets, 0 or less defaults to DefaultRateLimiterMaxKeys.
+//
+// Note: Once the limit is reached, each new key evicts an arbitrary bucket, whose key starts again with a full bucket.
+// Expired buckets are still evicted after the TTL, so the limit is only reached by that many keys within the TTL.
+func (l *RateLimiter) SetMaxKeys(n int) {
+	if n <= 0 {
+		n = DefaultRateLimiterMaxKeys
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxKeys = n
+}
+
+// PerKey returns the bucket for the key, creating it if needed.
+//
+// Parameters:
+//   - key: The key to limit by, such as a user ID or IP address.
+//
+// Returns: The TokenBucket for the key.
+func (l *RateLimiter) PerKey(key string) *TokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictExpired()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		for len(l.buckets) >= l.maxKeys {
+			l.evictOne()
+		}
+
+		bucket = newTokenBucket(l.rate, l.burst, l.now)
+		l.buckets[key] = bucket
+		return bucket
+	}
+
+	bucket.touch()
+	return bucket
+}
+
+// Len returns the number of per-key buckets currently held.
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// evictExpired removes buckets unused for the TTL, the caller must hold the lock.
+//
+// To avoid scanning on every call, it runs at most once per TTL.
+func (l *RateLimiter) evictExpired() {
+	t := l.now()
+	if t.Sub(l.lastSweep) < l.ttl {
+		return
+	}
+
+	cutoff := t.Add(-l.ttl)
+	for key, bucket := range l.buckets {
+		if bucket.idleSince(cutoff) {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = t
+}
+
+// evictOne makes room for a new bucket once the limit is reached, the caller must hold the lock.
+//
+// The map is iterated in a random order, so an arbitrary bucket is removed without scanning every bucket for the
+// least recently used, which would slow each request down while many keys are being sent.
+func (l *RateLimiter) evictOne() {
+	for key := range l.buckets {
+		delete(l.buckets, key)
+		return
+	}
+}
+
+// Middleware limits requests per client, keyed by RemoteIP.
+//
+// Denied requests receive a 429 Too Many Requests response with a Retry-After header.
+//
+// Parameters:
+//   - next: The handler to call when the request is allowed.
+//
+// Returns: The wrapped handler.
+//
+// Example:
+//
+//	limiter := NewRateLimiter(5, 10, time.Minute)
+//	http.ListenAndServe(":8080", limiter.Middleware(mux))
+//
+// Note: The forwarding headers are not trusted, as a client connecting directly could send a new address with each
+// request and never be limited. Behind a proxy that overwrites X-Forwarded-For and X-Real-IP, every request comes from
+// the proxy, so key by the client with MiddlewareWithKey(ClientIP, next) instead.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return l.MiddlewareWithKey(RemoteIP, next)
+}
+
+// MiddlewareWithKey limits requests per key, the key is taken from the request with keyFunc.
+//
+// Parameters:
+//   - keyFunc: The function returning the key to limit by, such as RemoteIP.
+//   - next: The handler to call when the request is allowed.
+//
+// Returns: The wrapped handler.
+func (l *RateLimiter) MiddlewareWithKey(keyFunc func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := l.PerKey(keyFunc(r))
+
+		if !bucket.Allow() {
+			retryAfter := int(math.Ceil(bucket.RetryAfter().Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
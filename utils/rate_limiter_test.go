@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for testing time based behaviour.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	bucket := newTokenBucket(1, 2, clock.Now)
+
+	if !bucket.Allow() || !bucket.Allow() {
+		t.Fatal("Expected the first 2 events to be allowed")
+	}
+	if bucket.Allow() {
+		t.Error("Expected the third event to be denied")
+	}
+	if got := bucket.RetryAfter(); got != time.Second {
+		t.Errorf("Expected RetryAfter to be 1s, got %s", got)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if bucket.Allow() {
+		t.Error("Expected event to be denied after half a token")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Error("Expected event to be allowed after refill")
+	}
+
+	clock.Advance(time.Hour)
+	if bucket.AllowN(3) {
+		t.Error("Expected AllowN to never exceed burst")
+	}
+	if !bucket.AllowN(2) {
+		t.Error("Expected AllowN(2) to be allowed after refill")
+	}
+	if got := NewTokenBucket(1, 1).RetryAfter(); got != 0 {
+		t.Errorf("Expected RetryAfter to be 0 for a full bucket, got %s", got)
+	}
+}
+
+func TestRateLimiter_PerKey(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter(1, 1, time.Minute, clock.Now)
+
+	if !limiter.PerKey("a").Allow() {
+		t.Error("Expected key a to be allowed")
+	}
+	if limiter.PerKey("a").Allow() {
+		t.Error("Expected key a to be denied")
+	}
+	if !limiter.PerKey("b").Allow() {
+		t.Error("Expected key b to be allowed independently")
+	}
+	if !limiter.Allow() {
+		t.Error("Expected global limiter to be independent of keys")
+	}
+
+	clock.Advance(30 * time.Second)
+	limiter.PerKey("b")
+
+	clock.Advance(45 * time.Second)
+	limiter.PerKey("c")
+
+	if limiter.Len() != 2 {
+		t.Errorf("Expected key a to be evicted, got %d buckets", limiter.Len())
+	}
+}
+
+func TestRateLimiter_MaxKeys(t *testing.T) {
+	limiter := newRateLimiter(1, 1, time.Minute, newFakeClock().Now)
+	limiter.SetMaxKeys(2)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		limiter.PerKey(key)
+		if limiter.Len() > 2 {
+			t.Fatalf("Expected at most 2 buckets, got %d", limiter.Len())
+		}
+	}
+	if !limiter.PerKey("d").AllowN(0) || limiter.Len() != 2 {
+		t.Errorf("Expected the newest key to be kept, got %d buckets", limiter.Len())
+	}
+
+	limiter.SetMaxKeys(0)
+	if limiter.maxKeys != DefaultRateLimiterMaxKeys {
+		t.Errorf("Expected the default limit, got %d", limiter.maxKeys)
+	}
+}
+
+func TestNewRateLimiter_DefaultTTL(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, 0)
+	if limiter.ttl != 10*time.Minute {
+		t.Errorf("Expected default TTL of 10m, got %s", limiter.ttl)
+	}
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, time.Minute)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	forwarded := 0
+	serve := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		// A new forwarded address on every request must not reset the limit.
+		forwarded++
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", forwarded))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("192.0.2.1"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+
+	rec := serve("192.0.2.1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After of 1, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	if rec = serve("192.0.2.2"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a different client to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_MiddlewareWithKey(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, time.Minute)
+	handler := limiter.MiddlewareWithKey(ClientIP, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("192.0.2.1"); code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", code)
+	}
+	if code := serve("192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", code)
+	}
+	if code := serve("192.0.2.2"); code != http.StatusOK {
+		t.Errorf("Expected a different forwarded client to be allowed, got %d", code)
+	}
+}
+
+func BenchmarkRateLimiter_PerKey(b *testing.B) {
+	limiter := NewRateLimiter(1000, 1000, time.Minute)
+	for i := 0; i < b.N; i++ {
+		limiter.PerKey("key").Allow()
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to read and echo the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDLength is the length of generated request IDs.
+const requestIDLength = 32
+
+// maxRequestIDLength limits the length of incoming request IDs, to avoid logging arbitrarily large values.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key for the request ID, unexported to avoid collisions.
+type requestIDKey struct{}
+
+// RequestID is a middleware that ensures every request has a request ID.
+//
+// The ID is taken from the X-Request-ID header if present and valid, otherwise a secure random one is generated.
+// It is stored within the request context and echoed within the response header.
+//
+// Parameters:
+//   - next: The handler to call with the request ID in its context.
+//
+// Returns: The wrapped handler.
+//
+// Example:
+//
+//	http.ListenAndServe(":8080", RequestID(mux))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		log.Printf("request_id=%s", RequestIDFromContext(r.Context()))
+//	}
+func RequestID(next http.Handler) http.Handler {
+	return requestIDMiddleware(next, rand.Reader)
+}
+
+// requestIDMiddleware is RequestID using the provided reader to generate IDs.
+func requestIDMiddleware(next http.Handler, reader io.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+
+		if !isValidRequestID(id) {
+			var err error
+			if id, err = generateRandomString(requestIDLength, reader); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// ContextWithRequestID returns a copy of the context containing the request ID.
+//
+// This is useful for propagating a request ID into background jobs or outgoing calls.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - id: The request ID.
+//
+// Returns: The new context.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by RequestID.
+//
+// Parameters:
+//   - ctx: The context, usually r.Context().
+//
+// Returns: The request ID, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// isValidRequestID checks that the request ID is a reasonable length and only contains printable ASCII.
+//
+// This prevents header or log injection through a client provided ID.
+//
+// Parameters:
+//   - id: The request ID to check.
+//
+// Returns: True if the request ID is valid, false otherwise.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
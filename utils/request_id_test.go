@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		expectGen bool
+	}{
+		{name: "Generates when missing", header: "", expectGen: true},
+		{name: "Keeps valid ID", header: "abc-123", expectGen: false},
+		{name: "Replaces ID with spaces", header: "abc 123", expectGen: true},
+		{name: "Replaces ID that is too long", header: strings.Repeat("a", 129), expectGen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(RequestIDHeader)
+			if echoed != fromContext {
+				t.Errorf("Expected echoed ID %q to match context ID %q", echoed, fromContext)
+			}
+
+			if tt.expectGen && len(fromContext) != requestIDLength {
+				t.Errorf("Expected a generated ID of length %d, got %q", requestIDLength, fromContext)
+			}
+			if !tt.expectGen && fromContext != tt.header {
+				t.Errorf("Expected ID %q, got %q", tt.header, fromContext)
+			}
+		})
+	}
+}
+
+func TestRequestID_GenerationError(t *testing.T) {
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to be called")
+	}), &errorReader{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", rec.Code)
+	}
+}
+
+func TestRequestIDFromContext_Missing(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("Expected empty ID, got %q", id)
+	}
+}
+
+func BenchmarkRequestID(b *testing.B) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RetryPolicy describes how Retry should space out and limit attempts.
+//
+// Any zero value field falls back to the value within DefaultRetryPolicy,
+// this allows only the relevant fields to be set.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times fn is called, including the first call.
+	MaxAttempts int
+	// MaxElapsedTime is the maximum time spent retrying, measured from the first call.
+	//
+	// Once exceeded, the last error is returned even if attempts remain.
+	MaxElapsedTime time.Duration
+	// InitialInterval is the delay before the second attempt.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between attempts.
+	MaxInterval time.Duration
+	// Multiplier is applied to the delay after each failed attempt, such as 2 to double the delay.
+	Multiplier float64
+	// Jitter is the randomisation factor (between 0 and 1) applied to every delay, see GenerateJitter.
+	//
+	// Use a negative value to disable jitter.
+	Jitter float64
+	// RetryIf reports whether an error is retryable, if nil every error is retried.
+	//
+	// When it returns false, Retry stops and returns the error immediately.
+	RetryIf func(err error) bool
+}
+
+// DefaultRetryPolicy returns the policy used for any unset RetryPolicy fields.
+//
+// Returns: A policy of 5 attempts, starting at 100ms, doubling up to 10s with a 0.5 jitter
+// and without a maximum elapsed time.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     5,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+}
+
+// Retry calls fn until it succeeds, the policy is exhausted or the context is done.
+//
+// Delays grow exponentially from InitialInterval by Multiplier, capped by MaxInterval,
+// with jitter applied to each delay.
+//
+// Parameters:
+//   - ctx: The context, cancelling it stops any further attempts.
+//   - policy: The RetryPolicy to follow, zero value fields use DefaultRetryPolicy.
+//   - fn: The function to call, it receives ctx so that it can also respect cancellation.
+//
+// Returns: nil if fn succeeded, otherwise the last error returned by fn, or the context error.
+//
+// Example:
+//
+//	err := Retry(ctx, RetryPolicy{MaxAttempts: 3}, func(ctx context.Context) error {
+//		return client.Ping(ctx)
+//	})
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	return retry(ctx, policy, fn, rand.Reader)
+}
+
+// retry calls fn following the policy, using the provided reader for jitter.
+//
+// Parameters:
+//   - ctx: The context, cancelling it stops any further attempts.
+//   - policy: The RetryPolicy to follow.
+//   - fn: The function to call.
+//   - reader: The io.Reader to use for generating jitter.
+//
+// Returns: nil if fn succeeded, otherwise the last error returned by fn, or the context error.
+func retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error, reader io.Reader) error {
+	policy = policy.withDefaults()
+
+	start := time.Now()
+	interval := policy.InitialInterval
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		if policy.RetryIf != nil && !policy.RetryIf(err) {
+			return err
+		}
+
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := interval
+		if policy.Jitter > 0 {
+			var jitterErr error
+			if delay, jitterErr = generateJitter(interval, policy.Jitter, reader); jitterErr != nil {
+				return jitterErr
+			}
+		}
+
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			return fmt.Errorf("giving up after %s: %w", policy.MaxElapsedTime, err)
+		}
+
+		if ctxErr := sleepContext(ctx, delay); ctxErr != nil {
+			return ctxErr
+		}
+
+		interval = nextInterval(interval, policy)
+	}
+}
+
+// withDefaults fills in any zero value fields with DefaultRetryPolicy.
+//
+// Returns: The policy with defaults applied.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = def.InitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = def.MaxInterval
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = def.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = def.Jitter
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	return p
+}
+
+// nextInterval multiplies the interval, capping it at the policy's MaxInterval.
+//
+// Parameters:
+//   - interval: The current interval.
+//   - policy: The RetryPolicy containing the Multiplier and MaxInterval.
+//
+// Returns: The next interval.
+func nextInterval(interval time.Duration, policy RetryPolicy) time.Duration {
+	next := time.Duration(float64(interval) * policy.Multiplier)
+	// An overflow would produce a negative duration.
+	if next > policy.MaxInterval || next <= 0 {
+		return policy.MaxInterval
+	}
+	return next
+}
+
+// sleepContext waits for the duration or until the context is done, whichever is first.
+//
+// Parameters:
+//   - ctx: The context to wait on.
+//   - d: The duration to wait.
+//
+// Returns: The context error if it finished first, otherwise nil.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
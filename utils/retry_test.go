@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errRetryTest = errors.New("retry test error")
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "Succeeds first time",
+			policy:       RetryPolicy{InitialInterval: time.Millisecond},
+			failures:     0,
+			wantAttempts: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "Succeeds after failures",
+			policy:       RetryPolicy{InitialInterval: time.Millisecond},
+			failures:     2,
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "Exhausts attempts",
+			policy:       RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, Jitter: -1},
+			failures:     10,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name: "RetryIf stops on permanent error",
+			policy: RetryPolicy{
+				InitialInterval: time.Millisecond,
+				RetryIf: func(err error) bool {
+					return !errors.Is(err, errRetryTest)
+				},
+			},
+			failures:     10,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "Exceeds elapsed time",
+			policy:       RetryPolicy{InitialInterval: 50 * time.Millisecond, MaxElapsedTime: 10 * time.Millisecond},
+			failures:     10,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(context.Background(), tt.policy, func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errRetryTest
+				}
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errRetryTest) {
+				t.Errorf("Retry() error = %v, expected it to wrap %v", err, errRetryTest)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Retry() attempts = %d, expected %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetry_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := Retry(ctx, RetryPolicy{InitialInterval: time.Second}, func(ctx context.Context) error {
+		return errRetryTest
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRetry_JitterError(t *testing.T) {
+	err := retry(context.Background(), RetryPolicy{}, func(ctx context.Context) error {
+		return errRetryTest
+	}, &errorReader{})
+
+	if err == nil || errors.Is(err, errRetryTest) {
+		t.Errorf("Expected jitter error, got %v", err)
+	}
+}
+
+func TestRetryPolicy_WithDefaults(t *testing.T) {
+	p := RetryPolicy{Jitter: 2}.withDefaults()
+	def := DefaultRetryPolicy()
+
+	if p.MaxAttempts != def.MaxAttempts || p.InitialInterval != def.InitialInterval ||
+		p.MaxInterval != def.MaxInterval || p.Multiplier != def.Multiplier {
+		t.Errorf("Expected defaults to be applied, got %+v", p)
+	}
+	if p.Jitter != 1 {
+		t.Errorf("Expected Jitter to be capped at 1, got %v", p.Jitter)
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	policy := RetryPolicy{Multiplier: 2, MaxInterval: 3 * time.Second}
+
+	if got := nextInterval(time.Second, policy); got != 2*time.Second {
+		t.Errorf("Expected 2s, got %s", got)
+	}
+	if got := nextInterval(2*time.Second, policy); got != 3*time.Second {
+		t.Errorf("Expected 3s, got %s", got)
+	}
+}
+
+func BenchmarkRetry(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		_ = Retry(ctx, RetryPolicy{}, func(ctx context.Context) error {
+			return nil
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Paginator is a validated page and limit, as returned by BindSearchRequest.
+type Paginator struct {
+	// Page is the zero-based page number.
+	Page int
+	// Limit is the number of items per page.
+	Limit int
+}
+
+// Offset returns the number of items to skip, for use with OFFSET or db.Offset.
+func (p Paginator) Offset() int {
+	return p.Page * p.Limit
+}
+
+// BindSearchRequest binds a search params struct from the request, then builds the GORM query and pagination.
+//
+// The params are bound with BindRequest, so `required` tags are validated, and passed to GormSearchQuery.
+// The page and limit come from the "page" and "limit" query parameters and are corrected by ValidatePagination.
+//
+// Parameters:
+//   - r: The HTTP request to bind data from.
+//
+// Returns: The query and arguments from GormSearchQuery, the Paginator, or an error if binding fails.
+//
+// Usage:
+//
+//	The `where` tag holds the GORM condition, so the `query` and `form` tags hold the parameter name as they do
+//	for BindRequest. The `header` tag reads a header.
+//
+// Example:
+//
+//	type SearchParams struct {
+//	 Status   string `query:"status" where:"status = ?"`
+//	 TenantID string `header:"X-Tenant-ID" where:"tenant_id = ?" required:"true"`
+//	}
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//	 query, args, page, err := BindSearchRequest[SearchParams](r)
+//	 if err != nil {
+//	  http.Error(w, err.Error(), http.StatusBadRequest)
+//	  return
+//	 }
+//
+//	 db.Where(query, args...).Offset(page.Offset()).Limit(page.Limit).Find(&results)
+//	}
+func BindSearchRequest[P any](r *http.Request) (query string, args []interface{}, page Paginator, err error) {
+	var params P
+	if err = BindRequest(r, &params); err != nil {
+		return "", nil, Paginator{}, err
+	}
+
+	pageNum, err := queryInt(r, "page")
+	if err != nil {
+		return "", nil, Paginator{}, err
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return "", nil, Paginator{}, err
+	}
+
+	page.Page, page.Limit = ValidatePagination(pageNum, limit)
+	query, args = GormSearchQuery(params)
+
+	return query, args, page, nil
+}
+
+// queryInt reads an integer query parameter, returning 0 if it is not present.
+func queryInt(r *http.Request, name string) (int, error) {
+	val := r.URL.Query().Get(name)
+	if val == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, newParseValueError(fmt.Sprintf("%s must be an integer", name))
+	}
+
+	return n, nil
+}
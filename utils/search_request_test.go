@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type searchParams struct {
+	Status   string `query:"status" where:"status = ?"`
+	TenantID string `header:"X-Tenant-ID" where:"tenant_id = ?" required:"true"`
+	Owner    string `form:"owner" query:"owner_id = ?"`
+}
+
+func TestBindSearchRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		tenant        string
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedPage  Paginator
+		expectError   bool
+	}{
+		{
+			name:          "All parameters",
+			url:           "/items?status=active&page=2&limit=20",
+			tenant:        "acme",
+			expectedQuery: "(status = ? AND tenant_id = ?)",
+			expectedArgs:  []interface{}{"active", "acme"},
+			expectedPage:  Paginator{Page: 2, Limit: 20},
+		},
+		{
+			name:          "Condition within the query tag",
+			url:           "/items?owner=7",
+			tenant:        "acme",
+			expectedQuery: "(tenant_id = ? AND owner_id = ?)",
+			expectedArgs:  []interface{}{"acme", "7"},
+			expectedPage:  Paginator{Page: 0, Limit: 10},
+		},
+		{
+			name:          "Default pagination",
+			url:           "/items",
+			tenant:        "acme",
+			expectedQuery: "(tenant_id = ?)",
+			expectedArgs:  []interface{}{"acme"},
+			expectedPage:  Paginator{Page: 0, Limit: 10},
+		},
+		{
+			name:          "Corrected pagination",
+			url:           "/items?page=-1&limit=500",
+			tenant:        "acme",
+			expectedQuery: "(tenant_id = ?)",
+			expectedArgs:  []interface{}{"acme"},
+			expectedPage:  Paginator{Page: 0, Limit: 100},
+		},
+		{name: "Missing required header", url: "/items", expectError: true},
+		{name: "Invalid page", url: "/items?page=two", tenant: "acme", expectError: true},
+		{name: "Invalid limit", url: "/items?limit=ten", tenant: "acme", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.tenant != "" {
+				r.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+
+			query, args, page, err := BindSearchRequest[searchParams](r)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("BindSearchRequest() error = %v, expected error %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if query != tt.expectedQuery {
+				t.Errorf("BindSearchRequest() query = %q, expected %q", query, tt.expectedQuery)
+			}
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("BindSearchRequest() args = %v, expected %v", args, tt.expectedArgs)
+			}
+			if page != tt.expectedPage {
+				t.Errorf("BindSearchRequest() page = %+v, expected %+v", page, tt.expectedPage)
+			}
+		})
+	}
+}
+
+func TestPaginatorOffset(t *testing.T) {
+	if got := (Paginator{Page: 3, Limit: 25}).Offset(); got != 75 {
+		t.Errorf("Offset() = %d, expected %d", got, 75)
+	}
+}
+
+func BenchmarkBindSearchRequest(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/items?status=active&page=2&limit=20", nil)
+	r.Header.Set("X-Tenant-ID", "acme")
+
+	for i := 0; i < b.N; i++ {
+		_, _, _, _ = BindSearchRequest[searchParams](r)
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownOptions contains the options for RunServer.
+type ShutdownOptions struct {
+	// Timeout is the maximum time to wait for in-flight requests to finish, defaults to 30 seconds.
+	//
+	// The same timeout applies to the hooks, as they share the shutdown context.
+	Timeout time.Duration
+	// Signals are the signals that trigger a shutdown, defaults to SIGINT and SIGTERM.
+	Signals []os.Signal
+	// Hooks are called in order after the server has stopped, such as closing database connections.
+	//
+	// Every hook is called even if a previous hook fails, all errors are returned together.
+	Hooks []func(ctx context.Context) error
+	// Listener is used instead of listening on srv.Addr when set.
+	Listener net.Listener
+}
+
+// RunServer starts the server and blocks until it fails, ctx is done, or a shutdown signal is received.
+//
+// Once stopped, it waits for in-flight requests to finish, then runs the cleanup hooks in order.
+// The hooks also run when the server fails to start or serve.
+//
+// Parameters:
+//   - ctx: The context, cancelling it shuts the server down the same way a signal would.
+//   - srv: The server to run.
+//   - opts: The ShutdownOptions, zero values use the defaults.
+//
+// Returns: An error if the server failed to start or serve, or if the shutdown or a hook failed.
+//
+// Example:
+//
+//	srv := &http.Server{Addr: ":8080", Handler: mux}
+//	err := RunServer(context.Background(), srv, ShutdownOptions{
+//		Timeout: 10 * time.Second,
+//		Hooks: []func(ctx context.Context) error{
+//			func(ctx context.Context) error { return db.Close() },
+//		},
+//	})
+//
+// Note: Errors from serving, the shutdown and the hooks are joined with errors.Join.
+func RunServer(ctx context.Context, srv *http.Server, opts ShutdownOptions) error {
+	opts = opts.withDefaults()
+
+	ctx, stop := signal.NotifyContext(ctx, opts.Signals...)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- serve(srv, opts.Listener)
+	}()
+
+	var errs []error
+	select {
+	case err := <-serveErr:
+		// The hooks still run, so resources opened before the server are closed when it fails to start.
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("server failed: %w", err))
+		}
+	case <-ctx.Done():
+	}
+
+	// The parent context is already done, a fresh one is needed to give requests time to drain.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("server shutdown failed: %w", err))
+	}
+
+	for i, hook := range opts.Hooks {
+		if err := hook(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %d failed: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serve serves on the listener if provided, otherwise it listens on srv.Addr.
+//
+// TLS is used when the server has a TLSConfig with certificates.
+func serve(srv *http.Server, l net.Listener) error {
+	useTLS := srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 || srv.TLSConfig.GetCertificate != nil)
+
+	switch {
+	case l != nil && useTLS:
+		return srv.ServeTLS(l, "", "")
+	case l != nil:
+		return srv.Serve(l)
+	case useTLS:
+		return srv.ListenAndServeTLS("", "")
+	default:
+		return srv.ListenAndServe()
+	}
+}
+
+// withDefaults fills in any zero value fields.
+//
+// Returns: The options with defaults applied.
+func (o ShutdownOptions) withDefaults() ShutdownOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+	if len(o.Signals) == 0 {
+		o.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return o
+}
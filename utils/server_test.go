@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+
+	var order []int
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- RunServer(ctx, srv, ShutdownOptions{
+			Listener: listener,
+			Timeout:  time.Second,
+			Hooks: []func(ctx context.Context) error{
+				func(ctx context.Context) error { order = append(order, 1); return nil },
+				func(ctx context.Context) error { order = append(order, 2); return nil },
+			},
+		})
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+
+	cancel()
+
+	if err = <-done; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(order, []int{1, 2}) {
+		t.Errorf("Expected hooks to run in order, got %v", order)
+	}
+}
+
+func TestRunServer_HookErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = RunServer(ctx, &http.Server{}, ShutdownOptions{
+		Listener: listener,
+		Hooks: []func(ctx context.Context) error{
+			func(ctx context.Context) error { return errFirst },
+			func(ctx context.Context) error { return errSecond },
+		},
+	})
+
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("Expected both hook errors, got %v", err)
+	}
+}
+
+func TestRunServer_ListenError(t *testing.T) {
+	errHook := errors.New("hook")
+	ran := false
+
+	err := RunServer(context.Background(), &http.Server{Addr: "invalid-address"}, ShutdownOptions{
+		Hooks: []func(ctx context.Context) error{
+			func(ctx context.Context) error { ran = true; return errHook },
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "server failed") {
+		t.Errorf("Expected the serve error, got %v", err)
+	}
+	if !ran || !errors.Is(err, errHook) {
+		t.Errorf("Expected the hooks to run and their error to be joined, got %v", err)
+	}
+}
+
+func TestShutdownOptions_WithDefaults(t *testing.T) {
+	opts := ShutdownOptions{}.withDefaults()
+	if opts.Timeout != 30*time.Second {
+		t.Errorf("Expected 30s timeout, got %s", opts.Timeout)
+	}
+	if len(opts.Signals) != 2 {
+		t.Errorf("Expected 2 signals, got %d", len(opts.Signals))
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis is appended by TruncateWithEllipsis when a string is shortened.
+const Ellipsis = "…"
+
+// transliterations maps accented and special Latin characters to ASCII.
+//
+// Without golang.org/x/text there is no Unicode normalisation, so common characters are listed instead.
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'ĵ': "j",
+	'ķ': "k",
+	'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'œ': "oe",
+	'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s",
+	'ß': "ss",
+	'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t",
+	'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ŵ': "w",
+	'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Slugify converts a string into a lower-case URL slug.
+//
+// Accented Latin characters are transliterated to ASCII, letters and digits from other scripts are kept,
+// and everything else is collapsed into a single hyphen.
+//
+// Parameters:
+//   - s: The string to convert.
+//
+// Returns: The slug, without leading or trailing hyphens.
+//
+// Usage:
+//
+//	Slugify("  Crème Brûlée: A How-To!  ") // -> "creme-brulee-a-how-to"
+//	Slugify("Straße & Søn")                // -> "strasse-son"
+func Slugify(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	pendingSeparator := false
+	for _, r := range s {
+		r = unicode.ToLower(r)
+
+		var part string
+		if t, ok := transliterations[r]; ok {
+			part = t
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			part = string(r)
+		} else if unicode.Is(unicode.Mn, r) {
+			// Combining marks, such as a separate accent, are dropped rather than treated as separators.
+			continue
+		} else {
+			pendingSeparator = true
+			continue
+		}
+
+		if pendingSeparator && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingSeparator = false
+		b.WriteString(part)
+	}
+
+	return b.String()
+}
+
+// TruncateWithEllipsis shortens a string to at most maxLen runes, including the ellipsis.
+//
+// It never cuts through a multibyte character, and prefers to cut at a word boundary
+// when one is close to the limit, such as for preview text.
+//
+// Parameters:
+//   - s: The string to truncate.
+//   - maxLen: The maximum length in runes.
+//
+// Returns: The original string if it fits, otherwise the shortened string ending with Ellipsis.
+//
+// Usage:
+//
+//	TruncateWithEllipsis("The quick brown fox", 12) // -> "The quick…"
+//	TruncateWithEllipsis("héllo", 10)              // -> "héllo"
+func TruncateWithEllipsis(s string, maxLen int) string {
+	if maxLen <= 0 {
+		return ""
+	}
+
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+
+	// Find the byte offset of the last rune that fits alongside the ellipsis.
+	keep := maxLen - 1
+	cut := 0
+	for i := 0; i < keep; i++ {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+	}
+
+	truncated := s[:cut]
+
+	// Only cut at a word boundary if it keeps most of the text, otherwise a long word would leave little.
+	if next, _ := utf8.DecodeRuneInString(s[cut:]); !unicode.IsSpace(next) {
+		if i := strings.LastIndexFunc(truncated, unicode.IsSpace); i > 0 && utf8.RuneCountInString(truncated[:i]) >= keep*2/3 {
+			truncated = truncated[:i]
+		}
+	}
+
+	truncated = strings.TrimRightFunc(truncated, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+
+	return truncated + Ellipsis
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Crème Brûlée: A How-To!  ", "creme-brulee-a-how-to"},
+		{"Straße & Søn", "strasse-son"},
+		{"Ærøskøbing Œuvre Þór", "aeroskobing-oeuvre-thor"},
+		{"multiple---separators___here", "multiple-separators-here"},
+		{"Привет Мир", "привет-мир"},
+		{"école", "ecole"},
+		{"Version 2.0", "version-2-0"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Slugify(tt.input); got != tt.expected {
+				t.Errorf("Slugify(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTruncateWithEllipsis(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"The quick brown fox", 12, "The quick…"},
+		{"The quick brown fox", 19, "The quick brown fox"},
+		{"The quick brown fox", 10, "The quick…"},
+		{"Supercalifragilistic", 8, "Superca…"},
+		{"Hello, world", 7, "Hello…"},
+		{"héllo wörld ünïcode", 9, "héllo…"},
+		{"日本語のテキストです", 5, "日本語の…"},
+		{"abc", 1, "…"},
+		{"abc", 0, ""},
+		{"", 5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := TruncateWithEllipsis(tt.input, tt.maxLen)
+			if got != tt.expected {
+				t.Errorf("TruncateWithEllipsis(%q, %d) = %q, expected %q", tt.input, tt.maxLen, got, tt.expected)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateWithEllipsis(%q, %d) produced invalid UTF-8", tt.input, tt.maxLen)
+			}
+		})
+	}
+}
+
+func BenchmarkSlugify(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Slugify("  Crème Brûlée: A How-To!  ")
+	}
+}
+
+func BenchmarkTruncateWithEllipsis(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TruncateWithEllipsis("The quick brown fox jumps over the lazy dog", 20)
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ISO8601Layout is the layout used by FormatISO8601, UTC with fixed millisecond precision.
+const ISO8601Layout = "2006-01-02T15:04:05.000Z07:00"
+
+// DefaultTimeLayouts are the layouts tried by ParseInLocation when none are given, most specific first.
+var DefaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.ANSIC,
+}
+
+// ParseInLocation parses a time by trying each layout in order, returning it in UTC.
+//
+// Values with an offset, such as "2024-01-02T15:04:05+01:00", keep their offset,
+// otherwise the value is read as a wall clock time within loc.
+//
+// Parameters:
+//   - value: The time to parse, surrounding whitespace is ignored.
+//   - layouts: The layouts to try, nil uses DefaultTimeLayouts.
+//   - loc: The location for values without an offset, nil uses UTC.
+//
+// Returns: The time in UTC, or an error if no layout matches.
+//
+// Usage:
+//
+//	london, _ := time.LoadLocation("Europe/London")
+//	t, err := ParseInLocation("2024-07-01 09:30", nil, london) // -> 2024-07-01 08:30:00 UTC
+func ParseInLocation(value string, layouts []string, loc *time.Location) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, newParseValueError(fmt.Sprintf("could not parse time %q with any of the %d layouts", value, len(layouts)))
+}
+
+// FormatISO8601 formats a time as ISO 8601 in UTC with millisecond precision, such as "2024-07-01T08:30:00.000Z".
+//
+// The fixed width keeps values sortable as strings, unlike time.RFC3339Nano which trims trailing zeros.
+//
+// Parameters:
+//   - t: The time to format.
+//
+// Returns: The formatted time.
+func FormatISO8601(t time.Time) string {
+	return t.UTC().Format(ISO8601Layout)
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInLocation(t *testing.T) {
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name        string
+		value       string
+		layouts     []string
+		loc         *time.Location
+		expected    time.Time
+		expectedErr bool
+	}{
+		{"RFC3339 keeps offset", "2024-07-01T09:30:00+02:00", nil, london, time.Date(2024, 7, 1, 7, 30, 0, 0, time.UTC), false},
+		{"RFC3339 nano", "2024-07-01T09:30:00.123456Z", nil, nil, time.Date(2024, 7, 1, 9, 30, 0, 123456000, time.UTC), false},
+		{"Wall clock in location", "2024-07-01 09:30", nil, london, time.Date(2024, 7, 1, 8, 30, 0, 0, time.UTC), false},
+		{"Wall clock without location", "2024-07-01T09:30:15", nil, nil, time.Date(2024, 7, 1, 9, 30, 15, 0, time.UTC), false},
+		{"Date only", " 2024-01-15 ", nil, london, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), false},
+		{"RFC1123", "Mon, 01 Jul 2024 09:30:00 GMT", nil, nil, time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC), false},
+		{"Custom layout", "01/07/2024", []string{"02/01/2006"}, nil, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), false},
+		{"No layout matches", "yesterday", nil, nil, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInLocation(tt.value, tt.layouts, tt.loc)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("ParseInLocation() error = %v, expected error %v", err, tt.expectedErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("ParseInLocation() = %v, expected %v", got, tt.expected)
+			}
+			if !tt.expectedErr && got.Location() != time.UTC {
+				t.Errorf("ParseInLocation() location = %v, expected UTC", got.Location())
+			}
+		})
+	}
+}
+
+func TestFormatISO8601(t *testing.T) {
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected string
+	}{
+		{"UTC", time.Date(2024, 7, 1, 8, 30, 0, 0, time.UTC), "2024-07-01T08:30:00.000Z"},
+		{"Converted to UTC", time.Date(2024, 7, 1, 9, 30, 0, 0, london), "2024-07-01T08:30:00.000Z"},
+		{"Milliseconds", time.Date(2024, 7, 1, 8, 30, 0, 123456789, time.UTC), "2024-07-01T08:30:00.123Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatISO8601(tt.t); got != tt.expected {
+				t.Errorf("FormatISO8601() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkParseInLocation(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseInLocation("2024-07-01", nil, nil)
+	}
+}
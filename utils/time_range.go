@@ -0,0 +1,183 @@
+package utils
+
+import "time"
+
+// TimeRange is a half-open range of time, including Start and excluding End.
+//
+// Half-open ranges can be placed next to each other without overlapping, such as consecutive days.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TimeStep is a step used by SplitRange.
+//
+// Months and Days are calendar steps, so a day is 23 or 25 hours across a DST change,
+// while Duration is an exact amount of time.
+type TimeStep struct {
+	Months   int
+	Days     int
+	Duration time.Duration
+}
+
+// Duration returns the length of the range, or 0 if End is before Start.
+func (r TimeRange) Duration() time.Duration {
+	if r.End.Before(r.Start) {
+		return 0
+	}
+	return r.End.Sub(r.Start)
+}
+
+// Contains checks if the time is within the range, including Start and excluding End.
+//
+// Parameters:
+//   - t: The time to check.
+//
+// Returns: True if Start <= t < End, false otherwise.
+func (r TimeRange) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// Overlaps checks if two ranges share any time, ranges that only touch do not overlap.
+//
+// Parameters:
+//   - other: The range to compare against.
+//
+// Returns: True if the ranges overlap, false otherwise.
+//
+// Usage:
+//
+//	morning := TimeRange{Start: nine, End: twelve}
+//	afternoon := TimeRange{Start: twelve, End: five}
+//	morning.Overlaps(afternoon) // -> false
+func (r TimeRange) Overlaps(other TimeRange) bool {
+	return r.Start.Before(other.End) && other.Start.Before(r.End)
+}
+
+// Clamp limits the time to within the range.
+//
+// Parameters:
+//   - t: The time to clamp.
+//
+// Returns: Start if t is before it, End if t is after it, otherwise t.
+func (r TimeRange) Clamp(t time.Time) time.Time {
+	if t.Before(r.Start) {
+		return r.Start
+	}
+	if t.After(r.End) {
+		return r.End
+	}
+	return t
+}
+
+// StartOfDay returns midnight at the start of the day, in the given location.
+//
+// The day is decided in loc rather than t's own location, so a UTC timestamp can be bucketed by a user's day.
+//
+// Parameters:
+//   - t: The time.
+//   - loc: The location the day is in, nil uses t's location.
+//
+// Returns: The start of the day, in loc.
+//
+// Usage:
+//
+//	london, _ := time.LoadLocation("Europe/London")
+//	StartOfDay(time.Date(2024, 7, 1, 23, 30, 0, 0, time.UTC), london) // -> 2024-07-02 00:00 BST
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = inLocation(t, loc)
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns midnight at the start of the ISO week, Monday, in the given location.
+//
+// Parameters:
+//   - t: The time.
+//   - loc: The location the week is in, nil uses t's location.
+//
+// Returns: The start of the week, in loc.
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	t = inLocation(t, loc)
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.Date()
+	// time.Date normalises a negative day into the previous month.
+	return time.Date(y, m, d-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfMonth returns midnight on the first day of the month, in the given location.
+//
+// Parameters:
+//   - t: The time.
+//   - loc: The location the month is in, nil uses t's location.
+//
+// Returns: The start of the month, in loc.
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = inLocation(t, loc)
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// SplitRange splits a range into consecutive buckets of the given step, such as for a daily report.
+//
+// Each boundary is calculated from Start rather than from the previous boundary, so monthly steps starting on the
+// 31st do not drift. Calendar steps are taken in Start's location, the last bucket is cut short at End.
+//
+// Parameters:
+//   - r: The range to split.
+//   - step: The TimeStep of each bucket, it must move forward.
+//
+// Returns: The buckets, or an error if the step does not move forward.
+//
+// Usage:
+//
+//	days, err := SplitRange(TimeRange{Start: StartOfDay(from, loc), End: to}, TimeStep{Days: 1})
+func SplitRange(r TimeRange, step TimeStep) ([]TimeRange, error) {
+	if !step.add(r.Start, 1).After(r.Start) {
+		return nil, newParseValueError("step must be positive")
+	}
+
+	var buckets []TimeRange
+	start := r.Start
+	for i := 1; start.Before(r.End); i++ {
+		end := step.add(r.Start, i)
+		if end.After(r.End) {
+			end = r.End
+		}
+
+		buckets = append(buckets, TimeRange{Start: start, End: end})
+		start = end
+	}
+
+	return buckets, nil
+}
+
+// add moves the time forward by n steps.
+func (s TimeStep) add(t time.Time, n int) time.Time {
+	if s.Months != 0 || s.Days != 0 {
+		t = addCalendar(t, s.Months*n, s.Days*n)
+	}
+	return t.Add(s.Duration * time.Duration(n))
+}
+
+// addCalendar adds months and days, clamping to the end of the month rather than overflowing,
+// so 31 January plus one month is 29 February rather than 2 March.
+func addCalendar(t time.Time, months, days int) time.Time {
+	y, m, d := t.Date()
+	hour, minute, sec := t.Clock()
+
+	if months != 0 {
+		lastDay := time.Date(y, m+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		d = min(d, lastDay)
+	}
+
+	return time.Date(y, m+time.Month(months), d+days, hour, minute, sec, t.Nanosecond(), t.Location())
+}
+
+// inLocation converts the time to the location, if one is given.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return t
+	}
+	return t.In(loc)
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t testing.TB, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("time.LoadLocation(%q) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestTimeRange(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h-9) * time.Hour) }
+	r := TimeRange{Start: at(9), End: at(12)}
+
+	containsTests := []struct {
+		name     string
+		t        time.Time
+		expected bool
+	}{
+		{"Start", at(9), true},
+		{"Middle", at(10), true},
+		{"End", at(12), false},
+		{"Before", at(8), false},
+	}
+	for _, tt := range containsTests {
+		t.Run("Contains "+tt.name, func(t *testing.T) {
+			if got := r.Contains(tt.t); got != tt.expected {
+				t.Errorf("Contains() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	overlapTests := []struct {
+		name     string
+		other    TimeRange
+		expected bool
+	}{
+		{"Touching after", TimeRange{Start: at(12), End: at(14)}, false},
+		{"Touching before", TimeRange{Start: at(7), End: at(9)}, false},
+		{"Overlapping", TimeRange{Start: at(11), End: at(14)}, true},
+		{"Within", TimeRange{Start: at(10), End: at(11)}, true},
+		{"Surrounding", TimeRange{Start: at(8), End: at(13)}, true},
+	}
+	for _, tt := range overlapTests {
+		t.Run("Overlaps "+tt.name, func(t *testing.T) {
+			if got := r.Overlaps(tt.other); got != tt.expected {
+				t.Errorf("Overlaps() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	clampTests := []struct {
+		name     string
+		t        time.Time
+		expected time.Time
+	}{
+		{"Before", at(8), at(9)},
+		{"Within", at(10), at(10)},
+		{"After", at(13), at(12)},
+	}
+	for _, tt := range clampTests {
+		t.Run("Clamp "+tt.name, func(t *testing.T) {
+			if got := r.Clamp(tt.t); !got.Equal(tt.expected) {
+				t.Errorf("Clamp() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	if got := r.Duration(); got != 3*time.Hour {
+		t.Errorf("Duration() = %v, expected %v", got, 3*time.Hour)
+	}
+	if got := (TimeRange{Start: at(12), End: at(9)}).Duration(); got != 0 {
+		t.Errorf("Duration() of a reversed range = %v, expected 0", got)
+	}
+}
+
+func TestStartOf(t *testing.T) {
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name     string
+		fn       func(time.Time, *time.Location) time.Time
+		t        time.Time
+		loc      *time.Location
+		expected time.Time
+	}{
+		{"Day in another location", StartOfDay, time.Date(2024, 7, 1, 23, 30, 0, 0, time.UTC), london, time.Date(2024, 7, 2, 0, 0, 0, 0, london)},
+		{"Day without location", StartOfDay, time.Date(2024, 7, 1, 23, 30, 0, 0, time.UTC), nil, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"Day of DST change", StartOfDay, time.Date(2024, 3, 31, 12, 0, 0, 0, london), london, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"Week on Sunday", StartOfWeek, time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC), nil, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{"Week on Monday", StartOfWeek, time.Date(2024, 2, 26, 12, 0, 0, 0, time.UTC), nil, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{"Month", StartOfMonth, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), london, time.Date(2024, 2, 1, 0, 0, 0, 0, london)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.t, tt.loc); !got.Equal(tt.expected) {
+				t.Errorf("StartOf() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitRange(t *testing.T) {
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name          string
+		r             TimeRange
+		step          TimeStep
+		expectedCount int
+		expectedLast  TimeRange
+	}{
+		{
+			name:          "Days across DST",
+			r:             TimeRange{Start: time.Date(2024, 3, 30, 0, 0, 0, 0, london), End: time.Date(2024, 4, 1, 0, 0, 0, 0, london)},
+			step:          TimeStep{Days: 1},
+			expectedCount: 2,
+			expectedLast:  TimeRange{Start: time.Date(2024, 3, 31, 0, 0, 0, 0, london), End: time.Date(2024, 4, 1, 0, 0, 0, 0, london)},
+		},
+		{
+			name:          "Months from the 31st",
+			r:             TimeRange{Start: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+			step:          TimeStep{Months: 1},
+			expectedCount: 3,
+			expectedLast:  TimeRange{Start: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:          "Uneven hours",
+			r:             TimeRange{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)},
+			step:          TimeStep{Duration: 2 * time.Hour},
+			expectedCount: 3,
+			expectedLast:  TimeRange{Start: time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitRange(tt.r, tt.step)
+			if err != nil {
+				t.Fatalf("SplitRange() error = %v", err)
+			}
+			if len(got) != tt.expectedCount {
+				t.Fatalf("SplitRange() returned %d buckets, expected %d", len(got), tt.expectedCount)
+			}
+
+			last := got[len(got)-1]
+			if !last.Start.Equal(tt.expectedLast.Start) || !last.End.Equal(tt.expectedLast.End) {
+				t.Errorf("SplitRange() last bucket = %v, expected %v", last, tt.expectedLast)
+			}
+
+			for i := 1; i < len(got); i++ {
+				if !got[i].Start.Equal(got[i-1].End) {
+					t.Errorf("SplitRange() bucket %d does not follow the previous bucket", i)
+				}
+			}
+		})
+	}
+
+	// The first day of the DST change is 23 hours long.
+	days, _ := SplitRange(tests[0].r, TimeStep{Days: 1})
+	if got := days[1].Duration(); got != 23*time.Hour {
+		t.Errorf("SplitRange() DST day = %v, expected %v", got, 23*time.Hour)
+	}
+
+	if _, err := SplitRange(tests[0].r, TimeStep{}); err == nil {
+		t.Errorf("SplitRange() with a zero step, expected an error")
+	}
+	if _, err := SplitRange(tests[0].r, TimeStep{Days: -1}); err == nil {
+		t.Errorf("SplitRange() with a negative step, expected an error")
+	}
+}
+
+func BenchmarkSplitRange(b *testing.B) {
+	r := TimeRange{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for i := 0; i < b.N; i++ {
+		_, _ = SplitRange(r, TimeStep{Days: 1})
+	}
+}
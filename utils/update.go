@@ -1,8 +1,6 @@
 package utils
 
-import (
-	"reflect"
-)
+import "reflect"
 
 // UpdateStruct maps the fields of a new struct to the fields of an existing struct.
 //
@@ -33,28 +31,23 @@ import (
 //
 // Note: This function is generic and can be used with any struct type.
 func UpdateStruct[t interface{}, t2 interface{}](current *t, newStruct *t2) {
-	currentValue := reflect.ValueOf(current).Elem()
 	updatesValue := reflect.ValueOf(newStruct).Elem()
 
-	currentType := currentValue.Type()
-
-	for i := 0; i < currentValue.NumField(); i++ {
-		currentField := currentValue.Field(i)
-		currentFieldInfo := currentType.Field(i)
-		currentFieldName := currentFieldInfo.Name
-
+	// Only the top level fields are updated, nested structs are replaced as a whole.
+	_ = WalkStruct(current, func(f WalkField) error {
 		// Check if the field has the update tag `update:"true"`
-		if currentFieldInfo.Tag.Get("update") != "true" {
-			continue
+		if f.Tag.Get("update") != "true" {
+			return SkipField
 		}
 
 		// Find the corresponding field in newStruct
-		updatesField := updatesValue.FieldByName(currentFieldName)
+		updatesField := updatesValue.FieldByName(f.Name)
 
-		if !updatesField.IsValid() || currentField.Type() != updatesField.Type() || updatesField.IsZero() {
-			continue
+		if !updatesField.IsValid() || f.Value.Type() != updatesField.Type() || updatesField.IsZero() {
+			return SkipField
 		}
 
-		currentField.Set(updatesField)
-	}
+		f.Value.Set(updatesField)
+		return SkipField
+	})
 }
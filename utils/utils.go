@@ -81,6 +81,138 @@ func GetOperatingSystemFromUserAgent(userAgent string) string {
 	}
 }
 
+// windowsVersions maps the Windows NT version within a user agent to the marketing name.
+//
+// Windows 11 still reports NT 10.0, so the two cannot be told apart from the user agent alone.
+var windowsVersions = map[string]string{
+	"10.0": "Windows 10/11",
+	"6.3":  "Windows 8.1",
+	"6.2":  "Windows 8",
+	"6.1":  "Windows 7",
+	"6.0":  "Windows Vista",
+	"5.2":  "Windows XP",
+	"5.1":  "Windows XP",
+}
+
+// GetOSVersion returns the operating system and its major version from the user agent string.
+//
+// Parameters:
+//   - userAgent: The user agent string.
+//
+// Returns: The operating system and version, the operating system alone if the version is not present,
+// or "Unknown".
+//
+// Usage:
+//
+//	GetOSVersion("Mozilla/5.0 (Windows NT 10.0; Win64; x64) ...")              // -> "Windows 10/11"
+//	GetOSVersion("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) ...")           // -> "macOS 14"
+//	GetOSVersion("Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) ...") // -> "iOS 17"
+//	GetOSVersion("Mozilla/5.0 (Linux; Android 14; Pixel 8) ...")               // -> "Android 14"
+//
+// Note: Safari reports macOS 10.15 on every later version, so that version may be newer than reported.
+func GetOSVersion(userAgent string) string {
+	// The order matches GetOperatingSystemFromUserAgent, as iOS user agents also contain "Mac OS X".
+	if strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") {
+		name := "iOS"
+		if strings.Contains(userAgent, "iPad") {
+			name = "iPadOS"
+		}
+		return withMajorVersion(name, userAgentVersion(userAgent, " OS "), false)
+	} else if strings.Contains(userAgent, "Android") {
+		return withMajorVersion("Android", userAgentVersion(userAgent, "Android "), false)
+	} else if strings.Contains(userAgent, "Windows") {
+		if name, ok := windowsVersions[userAgentVersion(userAgent, "Windows NT ")]; ok {
+			return name
+		}
+		return "Windows"
+	} else if strings.Contains(userAgent, "CrOS") {
+		return "ChromeOS"
+	} else if strings.Contains(userAgent, "Mac") {
+		return withMajorVersion("macOS", userAgentVersion(userAgent, "Mac OS X "), true)
+	} else if strings.Contains(userAgent, "Linux") {
+		return "Linux"
+	} else {
+		return "Unknown"
+	}
+}
+
+// GetArchitecture returns the CPU architecture from the user agent string.
+//
+// Parameters:
+//   - userAgent: The user agent string.
+//
+// Returns: "x86_64", "x86", "arm64", "arm", or "Unknown" if the user agent does not say.
+//
+// Usage:
+//
+//	GetArchitecture("Mozilla/5.0 (Windows NT 10.0; Win64; x64) ...") // -> "x86_64"
+//	GetArchitecture("Mozilla/5.0 (X11; Linux aarch64) ...")          // -> "arm64"
+//
+// Note: Macs report "Intel" even on Apple silicon, so they are "Unknown" rather than x86_64.
+// iPhones and iPads are always arm64.
+func GetArchitecture(userAgent string) string {
+	if strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") {
+		return "arm64"
+	} else if strings.Contains(userAgent, "Macintosh") {
+		return "Unknown"
+	} else if strings.Contains(userAgent, "aarch64") || strings.Contains(userAgent, "arm64") || strings.Contains(userAgent, "ARM64") {
+		return "arm64"
+	} else if strings.Contains(userAgent, "x86_64") || strings.Contains(userAgent, "x64") ||
+		strings.Contains(userAgent, "amd64") || strings.Contains(userAgent, "WOW64") {
+		return "x86_64"
+	} else if strings.Contains(userAgent, "armv") || strings.Contains(userAgent, "ARM") {
+		return "arm"
+	} else if strings.Contains(userAgent, "i686") || strings.Contains(userAgent, "i386") || strings.Contains(userAgent, "x86") {
+		return "x86"
+	} else {
+		return "Unknown"
+	}
+}
+
+// userAgentVersion reads the version number after the marker, with underscores replaced by dots.
+//
+// Parameters:
+//   - userAgent: The user agent string.
+//   - marker: The text before the version, such as "Android ".
+//
+// Returns: The version, such as "14.2", or an empty string if not found.
+func userAgentVersion(userAgent, marker string) string {
+	i := strings.Index(userAgent, marker)
+	if i == -1 {
+		return ""
+	}
+
+	rest := userAgent[i+len(marker):]
+	end := 0
+	for end < len(rest) && (rest[end] == '.' || rest[end] == '_' || (rest[end] >= '0' && rest[end] <= '9')) {
+		end++
+	}
+
+	return strings.ReplaceAll(strings.Trim(rest[:end], "._"), "_", ".")
+}
+
+// withMajorVersion appends the major version to the name, if there is one.
+//
+// Parameters:
+//   - name: The operating system name.
+//   - version: The full version, such as "14.2.1".
+//   - legacyMinor: Whether a major version of 10 includes the minor version, as for macOS 10.15.
+//
+// Returns: The name with the major version, such as "macOS 14".
+func withMajorVersion(name, version string, legacyMinor bool) string {
+	if version == "" {
+		return name
+	}
+
+	parts := strings.SplitN(version, ".", 3)
+	major := parts[0]
+	if legacyMinor && major == "10" && len(parts) > 1 {
+		major += "." + parts[1]
+	}
+
+	return name + " " + major
+}
+
 // IsEqual compares two interfaces and returns true if they are equal.
 //
 // Mainly used for testing.
@@ -132,6 +132,70 @@ func BenchmarkGetOperatingSystemFromUserAgent(b *testing.B) {
 	}
 }
 
+func TestGetOSVersion(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/117.0", "Windows 10/11"},
+		{"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0 Safari/537.36", "Windows 7"},
+		{"Mozilla/5.0 (Windows Phone 10.0; Android 6.0.1) Edge/15.0", "Android 6"},
+		{"Mozilla/5.0 (Windows 98; Win 9x 4.90)", "Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15", "macOS 14"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "macOS 10.15"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0", "macOS 10.15"},
+		{"Mozilla/5.0 (Macintosh) AppleWebKit/605.1.15", "macOS"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", "iOS 17"},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", "iPadOS 16"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36", "Android 14"},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "ChromeOS"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0", "Linux"},
+		{"Broken", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := GetOSVersion(tt.userAgent); got != tt.expected {
+				t.Errorf("GetOSVersion(%q) = %q, expected %q", tt.userAgent, got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkGetOSVersion(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		GetOSVersion(userAgents.Desktop[0])
+	}
+}
+
+func TestGetArchitecture(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		expected  string
+	}{
+		{"Windows x64", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/117.0", "x86_64"},
+		{"Windows WOW64", "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36", "x86_64"},
+		{"Windows ARM64", "Mozilla/5.0 (Windows NT 10.0; ARM64) AppleWebKit/537.36", "arm64"},
+		{"Linux x86_64", "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0", "x86_64"},
+		{"Linux aarch64", "Mozilla/5.0 (X11; Linux aarch64) AppleWebKit/537.36", "arm64"},
+		{"Linux i686", "Mozilla/5.0 (X11; Linux i686; rv:109.0) Gecko/20100101 Firefox/115.0", "x86"},
+		{"Android armv7", "Mozilla/5.0 (Linux; Android 9; armv7l) AppleWebKit/537.36", "arm"},
+		{"Android without architecture", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36", "Unknown"},
+		{"iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15", "arm64"},
+		{"Mac", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15", "Unknown"},
+		{"Broken", "Broken", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetArchitecture(tt.userAgent); got != tt.expected {
+				t.Errorf("GetArchitecture(%q) = %q, expected %q", tt.userAgent, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestIsEqual(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,118 @@
+package utils
+
+import "github.com/cloudment/utils-go/internal/validate"
+
+// IsEmail checks for a bare email address such as "user@example.com", without a display name.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is a valid email address, false otherwise.
+//
+// Usage:
+//
+//	IsEmail("user@example.com")        // -> true
+//	IsEmail("User <user@example.com>") // -> false
+func IsEmail(s string) bool {
+	return validate.IsEmail(s)
+}
+
+// IsURL checks for an absolute URL with a scheme and host.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is an absolute URL, false otherwise.
+//
+// Usage:
+//
+//	IsURL("https://example.com/path") // -> true
+//	IsURL("/path")                    // -> false
+func IsURL(s string) bool {
+	return validate.IsURL(s)
+}
+
+// IsUUID checks for a UUID in its canonical 8-4-4-4-12 hexadecimal form.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is a UUID, false otherwise.
+//
+// Usage:
+//
+//	IsUUID("123e4567-e89b-12d3-a456-426614174000") // -> true
+func IsUUID(s string) bool {
+	return validate.IsUUID(s)
+}
+
+// IsE164Phone checks for an E.164 phone number, a '+' followed by up to 15 digits without separators.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is an E.164 phone number, false otherwise.
+//
+// Usage:
+//
+//	IsE164Phone("+447700900123")   // -> true
+//	IsE164Phone("+44 7700 900123") // -> false
+func IsE164Phone(s string) bool {
+	return validate.IsE164Phone(s)
+}
+
+// IsHostname checks for an RFC 1123 hostname.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is a valid hostname, false otherwise.
+//
+// Usage:
+//
+//	IsHostname("api.example.com") // -> true
+//	IsHostname("-example.com")    // -> false
+func IsHostname(s string) bool {
+	return validate.IsHostname(s)
+}
+
+// IsSemver checks for a Semantic Versioning 2.0.0 version.
+//
+// Parameters:
+//   - s: The value to check.
+//
+// Returns: True if the value is a semantic version, false otherwise.
+//
+// Usage:
+//
+//	IsSemver("1.0.0-rc.1+build.5") // -> true
+//	IsSemver("v1.2.3")             // -> false, the "v" prefix is not part of the specification
+func IsSemver(s string) bool {
+	return validate.IsSemver(s)
+}
+
+// ValidateFormat checks a value against a named format, returning a consistent error message.
+//
+// This is the same check used by validation tags, so errors read the same wherever they come from.
+//
+// Parameters:
+//   - format: The format name, one of "email", "url", "uuid", "e164", "hostname" or "semver".
+//   - value: The value to check.
+//
+// Returns: A ParseValueError if the value is invalid or the format is unknown, otherwise nil.
+//
+// Usage:
+//
+//	err := ValidateFormat("email", "abc") // -> input error: "abc" is not a valid email address
+func ValidateFormat(format, value string) error {
+	f, ok := validate.Lookup(format)
+	if !ok {
+		return newParseValueError("unknown format: " + format)
+	}
+
+	if !f.Check(value) {
+		return newParseValueError(validate.Message(f, value))
+	}
+
+	return nil
+}
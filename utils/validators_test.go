@@ -0,0 +1,60 @@
+package utils
+
+import "testing"
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(string) bool
+		input    string
+		expected bool
+	}{
+		{"IsEmail", IsEmail, "user@example.com", true},
+		{"IsEmail", IsEmail, "user", false},
+		{"IsURL", IsURL, "https://example.com", true},
+		{"IsURL", IsURL, "example.com", false},
+		{"IsUUID", IsUUID, "123e4567-e89b-12d3-a456-426614174000", true},
+		{"IsUUID", IsUUID, "123e4567", false},
+		{"IsE164Phone", IsE164Phone, "+447700900123", true},
+		{"IsE164Phone", IsE164Phone, "07700900123", false},
+		{"IsHostname", IsHostname, "api.example.com", true},
+		{"IsHostname", IsHostname, "api_example", false},
+		{"IsSemver", IsSemver, "1.2.3", true},
+		{"IsSemver", IsSemver, "1.2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.input); got != tt.expected {
+				t.Errorf("%s(%q) = %v, expected %v", tt.name, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		value    string
+		expected string
+	}{
+		{"Valid", "email", "user@example.com", ""},
+		{"Invalid", "email", "abc", `input error: "abc" is not a valid email address`},
+		{"Invalid semver", "semver", "v1", `input error: "v1" is not a valid semantic version`},
+		{"Unknown format", "colour", "red", "input error: unknown format: colour"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFormat(tt.format, tt.value)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.expected {
+				t.Errorf("ValidateFormat(%q, %q) = %q, expected %q", tt.format, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
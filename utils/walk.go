@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/cloudment/utils-go/internal/structfields"
+)
+
+// WalkField is a struct field visited by WalkStruct.
+//
+// It embeds reflect.StructField, so Name, Type and Tag are available directly.
+type WalkField struct {
+	reflect.StructField
+	// Value is the field's value, settable if WalkStruct was given a pointer and the field is exported.
+	Value reflect.Value
+	// Path is the field's location from the root, such as "Database.Replicas[1].Host".
+	Path string
+	// Depth is the number of structs above the field, 0 for fields of the root struct.
+	Depth int
+}
+
+// FieldVisitor is called by WalkStruct for each field.
+//
+// Returning SkipField does not descend into the field, any other error stops the walk.
+type FieldVisitor func(f WalkField) error
+
+// SkipField can be returned by a FieldVisitor to not descend into the field's struct, pointer or slice.
+var SkipField = errors.New("skip field")
+
+// WalkStruct calls fn for each field of the struct, depth first and in declaration order.
+//
+// After fn returns, exported fields holding a struct, a non-nil pointer to a struct, or a slice or array of structs
+// are descended into. The field is read again after fn, so fn may initialise a nil pointer to have it walked.
+// Unexported fields are visited, so fn can report them, but never descended into.
+// A pointer to a struct already being walked, such as a linked list pointing back to its head, is visited but not
+// descended into again, so a cycle ends the walk of that branch. A struct reached through several pointers is walked
+// once for each.
+//
+// This is the traversal used by UpdateStruct, so new tag-driven features can walk structs the same way.
+// Struct fields are cached per type, and the cache is shared by BindRequest, GormSearchQuery and the env package.
+//
+// Parameters:
+//   - v: A struct, or a pointer to a struct. A pointer is needed for the fields to be settable.
+//   - fn: The FieldVisitor called for each field.
+//
+// Returns: The first error returned by fn other than SkipField, or an error if v is not a struct.
+//
+// Example:
+//
+//	err := WalkStruct(&cfg, func(f WalkField) error {
+//		if f.Tag.Get("secret") == "true" {
+//			fmt.Println(f.Path, "is a secret")
+//		}
+//		return nil
+//	})
+func WalkStruct(v interface{}, fn FieldVisitor) error {
+	w := &walker{fn: fn, visiting: make(map[visitKey]bool)}
+
+	ref := reflect.ValueOf(v)
+	for ref.Kind() == reflect.Pointer && !ref.IsNil() {
+		w.visiting[visitKey{ptr: ref.Pointer(), typ: ref.Type()}] = true
+		ref = ref.Elem()
+	}
+
+	if ref.Kind() != reflect.Struct {
+		return errors.New("expected a struct or a pointer to a struct")
+	}
+
+	return w.walkStruct(ref, "", 0)
+}
+
+// visitKey identifies a pointer, with its type as a struct and its first field share an address.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walker holds the state of a walk.
+type walker struct {
+	fn FieldVisitor
+	// visiting holds the pointers on the path from the root to the current field, to detect cycles.
+	visiting map[visitKey]bool
+}
+
+// walkStruct visits the fields of a struct value.
+func (w *walker) walkStruct(ref reflect.Value, path string, depth int) error {
+	for i, sf := range structfields.Fields(ref.Type()) {
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		f := WalkField{StructField: sf, Value: ref.Field(i), Path: fieldPath, Depth: depth}
+		if err := w.fn(f); err != nil {
+			if errors.Is(err, SkipField) {
+				continue
+			}
+			return err
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if err := w.walkValue(f.Value, fieldPath, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkValue descends into a struct, pointer to a struct, or slice or array of structs.
+// A pointer already on the path from the root is not descended into, ending a cycle.
+func (w *walker) walkValue(v reflect.Value, path string, depth int) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		key := visitKey{ptr: v.Pointer(), typ: v.Type()}
+		if w.visiting[key] {
+			return nil
+		}
+		w.visiting[key] = true
+		defer delete(w.visiting, key)
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return w.walkStruct(v, path, depth)
+	case reflect.Slice, reflect.Array:
+		if !isStructElem(v.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walkValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", depth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isStructElem checks if a slice element type is a struct or pointer to a struct.
+func isStructElem(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalkStruct(t *testing.T) {
+	type inner struct {
+		Secret string `secret:"true"`
+	}
+	type outer struct {
+		Name  string
+		Inner inner
+		Skip  inner
+	}
+
+	var paths []string
+	err := WalkStruct(&outer{}, func(f WalkField) error {
+		paths = append(paths, f.Path)
+		if f.Name == "Skip" {
+			return SkipField
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkStruct() error = %v", err)
+	}
+
+	expected := "Name,Inner,Inner.Secret,Skip"
+	if got := strings.Join(paths, ","); got != expected {
+		t.Errorf("WalkStruct() paths = %s, expected %s", got, expected)
+	}
+
+	if err := WalkStruct(1, func(WalkField) error { return nil }); err == nil {
+		t.Errorf("WalkStruct() with a non-struct, expected an error")
+	}
+}
+
+type walkAddress struct {
+	Host string
+	Port int
+}
+
+type walkConfig struct {
+	Name     string
+	Primary  walkAddress
+	Replicas []walkAddress
+	Backups  []*walkAddress
+	Fallback *walkAddress
+	Lazy     *walkAddress
+	Tags     []string
+	hidden   walkAddress
+}
+
+func TestWalkStruct_Paths(t *testing.T) {
+	cfg := walkConfig{
+		Replicas: []walkAddress{{}, {}},
+		Backups:  []*walkAddress{{}, nil},
+		Fallback: &walkAddress{},
+	}
+
+	var paths []string
+	err := WalkStruct(&cfg, func(f WalkField) error {
+		paths = append(paths, f.Path)
+		if f.Name == "Lazy" {
+			// Initialising a nil pointer within the visitor has it walked.
+			f.Value.Set(reflect.ValueOf(&walkAddress{}))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkStruct() error = %v", err)
+	}
+
+	expected := []string{
+		"Name",
+		"Primary", "Primary.Host", "Primary.Port",
+		"Replicas", "Replicas[0].Host", "Replicas[0].Port", "Replicas[1].Host", "Replicas[1].Port",
+		"Backups", "Backups[0].Host", "Backups[0].Port",
+		"Fallback", "Fallback.Host", "Fallback.Port",
+		"Lazy", "Lazy.Host", "Lazy.Port",
+		"Tags",
+		"hidden",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("WalkStruct() paths = %v, expected %v", paths, expected)
+	}
+}
+
+func TestWalkStruct_SkipAndDepth(t *testing.T) {
+	var visited []string
+	err := WalkStruct(walkConfig{}, func(f WalkField) error {
+		visited = append(visited, f.Path)
+		if f.Depth != 0 {
+			t.Errorf("WalkStruct() %s depth = %d, expected 0", f.Path, f.Depth)
+		}
+		return SkipField
+	})
+	if err != nil {
+		t.Fatalf("WalkStruct() error = %v", err)
+	}
+	if len(visited) != 8 {
+		t.Errorf("WalkStruct() visited %v, expected only the top level fields", visited)
+	}
+
+	depths := map[string]int{}
+	_ = WalkStruct(walkConfig{}, func(f WalkField) error {
+		depths[f.Path] = f.Depth
+		return nil
+	})
+	if depths["Primary.Host"] != 1 {
+		t.Errorf("WalkStruct() Primary.Host depth = %d, expected 1", depths["Primary.Host"])
+	}
+}
+
+type walkNode struct {
+	Name string
+	Next *walkNode
+}
+
+func TestWalkStruct_Cycle(t *testing.T) {
+	head := &walkNode{Name: "head"}
+	tail := &walkNode{Name: "tail", Next: head}
+	head.Next = tail
+	shared := &walkAddress{}
+
+	var paths []string
+	err := WalkStruct(head, func(f WalkField) error {
+		paths = append(paths, f.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkStruct() error = %v", err)
+	}
+
+	expected := []string{"Name", "Next", "Next.Name", "Next.Next"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("WalkStruct() paths = %v, expected %v", paths, expected)
+	}
+
+	// A struct reached through several pointers, without a cycle, is walked for each.
+	paths = nil
+	_ = WalkStruct(walkConfig{Fallback: shared, Lazy: shared}, func(f WalkField) error {
+		paths = append(paths, f.Path)
+		return nil
+	})
+	if len(paths) != 14 || paths[7] != "Fallback.Host" || paths[10] != "Lazy.Host" {
+		t.Errorf("WalkStruct() paths = %v, expected the shared struct to be walked twice", paths)
+	}
+}
+
+func TestWalkStruct_Errors(t *testing.T) {
+	expectedErr := errors.New("stop")
+
+	tests := []struct {
+		name  string
+		input any
+		fn    FieldVisitor
+	}{
+		{"Not a struct", "string", func(WalkField) error { return nil }},
+		{"Nil pointer", (*walkConfig)(nil), func(WalkField) error { return nil }},
+		{"Visitor error", walkConfig{}, func(WalkField) error { return expectedErr }},
+		{"Nested visitor error", walkConfig{}, func(f WalkField) error {
+			if f.Path == "Primary.Port" {
+				return expectedErr
+			}
+			return nil
+		}},
+		{"Slice visitor error", walkConfig{Replicas: []walkAddress{{}}}, func(f WalkField) error {
+			if f.Path == "Replicas[0].Host" {
+				return expectedErr
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := WalkStruct(tt.input, tt.fn); err == nil {
+				t.Errorf("WalkStruct() expected an error")
+			}
+		})
+	}
+}
+
+func BenchmarkWalkStruct(b *testing.B) {
+	cfg := walkConfig{Replicas: []walkAddress{{}, {}}}
+	fn := func(WalkField) error { return nil }
+
+	for i := 0; i < b.N; i++ {
+		_ = WalkStruct(&cfg, fn)
+	}
+}